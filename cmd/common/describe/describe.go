@@ -0,0 +1,233 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package describe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+// OutputModeJSONSchema prints only the JSON Schema of the gadget's elements.
+const OutputModeJSONSchema = "jsonschema"
+
+// SchemaFunc returns the schema of the elements (events or entries) printed by
+// a gadget.
+type SchemaFunc func() *columns.Schema
+
+// Gadget contains what can't be found in the command of a gadget.
+type Gadget struct {
+	// Schema is nil for the gadgets that don't use the columns library.
+	Schema SchemaFunc
+
+	// OutputModes are the output modes supported by the gadget, when it
+	// doesn't support all the ones of its output flag.
+	OutputModes []string
+}
+
+// ParameterDescription describes a flag accepted by a gadget.
+type ParameterDescription struct {
+	Name        string `json:"name"`
+	Shorthand   string `json:"shorthand,omitempty"`
+	Type        string `json:"type"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// GadgetDescription is what the describe command prints about a gadget.
+type GadgetDescription struct {
+	Category    string                 `json:"category"`
+	Gadget      string                 `json:"gadget"`
+	Description string                 `json:"description,omitempty"`
+	OutputModes []string               `json:"outputModes"`
+	Parameters  []ParameterDescription `json:"parameters"`
+	Columns     []columns.ColumnSchema `json:"columns,omitempty"`
+	JSONSchema  map[string]any         `json:"jsonSchema,omitempty"`
+}
+
+// NewDescribeCmd returns the describe command. gadgets maps
+// "<category>/<gadget>" to the schema of the elements printed by that gadget
+// and its output modes; gadgets that don't use the columns library are
+// described without columns.
+func NewDescribeCmd(gadgets map[string]Gadget) *cobra.Command {
+	var outputMode string
+
+	cmd := &cobra.Command{
+		Use:   "describe <category> <gadget>",
+		Short: "Describe the columns, output modes and parameters of a gadget",
+		Example: `  # List the columns printed by the exec gadget
+  describe trace exec
+
+  # Get the description in JSON, e.g. to be used by scripts or UIs
+  describe trace exec -o json`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputMode {
+			case commonutils.OutputModeColumns, commonutils.OutputModeJSON, OutputModeJSONSchema:
+			default:
+				return commonutils.WrapInErrOutputModeNotSupported(outputMode)
+			}
+
+			gadgetCmd, err := findGadgetCmd(cmd.Root(), args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			desc := &GadgetDescription{
+				Category:    gadgetCmd.Parent().Name(),
+				Gadget:      gadgetCmd.Name(),
+				Description: gadgetCmd.Short,
+				OutputModes: getOutputModes(gadgetCmd),
+				Parameters:  getParameters(gadgetCmd),
+			}
+
+			gadget := gadgets[desc.Category+"/"+desc.Gadget]
+			if gadget.OutputModes != nil {
+				desc.OutputModes = gadget.OutputModes
+			}
+			if gadget.Schema != nil {
+				schema := gadget.Schema()
+				desc.Columns = schema.Columns
+				desc.JSONSchema = schema.JSONSchema()
+			}
+
+			switch outputMode {
+			case commonutils.OutputModeJSON:
+				return printJSON(cmd.OutOrStdout(), desc)
+			case OutputModeJSONSchema:
+				if desc.JSONSchema == nil {
+					return fmt.Errorf("gadget %q of category %q does not provide a schema", desc.Gadget, desc.Category)
+				}
+				return printJSON(cmd.OutOrStdout(), desc.JSONSchema)
+			}
+
+			printDescription(cmd.OutOrStdout(), desc)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(
+		&outputMode,
+		"output",
+		"o",
+		commonutils.OutputModeColumns,
+		fmt.Sprintf("Output format (%s).", strings.Join([]string{commonutils.OutputModeColumns, commonutils.OutputModeJSON, OutputModeJSONSchema}, ", ")),
+	)
+
+	return cmd
+}
+
+// findGadgetCmd returns the command implementing the given gadget, it has to
+// be a direct child of the category command.
+func findGadgetCmd(rootCmd *cobra.Command, category, gadget string) (*cobra.Command, error) {
+	gadgetCmd, remainingArgs, err := rootCmd.Find([]string{category, gadget})
+	if err != nil || len(remainingArgs) != 0 ||
+		!gadgetCmd.HasParent() || !gadgetCmd.Parent().HasParent() || gadgetCmd.Parent().Parent() != rootCmd {
+		return nil, commonutils.WrapInErrInvalidArg(category+" "+gadget, fmt.Errorf("gadget not found"))
+	}
+
+	return gadgetCmd, nil
+}
+
+// getOutputModes returns the output modes accepted by the output flag of the
+// gadget, none if it doesn't have one.
+func getOutputModes(gadgetCmd *cobra.Command) []string {
+	flag := gadgetCmd.Flag("output")
+	if flag == nil || flag.Annotations[commonutils.OutputModesAnnotation] == nil {
+		return []string{}
+	}
+	return flag.Annotations[commonutils.OutputModesAnnotation]
+}
+
+func getParameters(gadgetCmd *cobra.Command) []ParameterDescription {
+	params := []ParameterDescription{}
+
+	gadgetCmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Name == "help" {
+			return
+		}
+
+		params = append(params, ParameterDescription{
+			Name:        flag.Name,
+			Shorthand:   flag.Shorthand,
+			Type:        flag.Value.Type(),
+			Default:     flag.DefValue,
+			Description: flag.Usage,
+		})
+	})
+
+	return params
+}
+
+func printJSON(w io.Writer, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return commonutils.WrapInErrMarshalOutput(err)
+	}
+
+	fmt.Fprintf(w, "%s\n", b)
+	return nil
+}
+
+func printDescription(w io.Writer, desc *GadgetDescription) {
+	fmt.Fprintf(w, "Gadget:       %s %s\n", desc.Category, desc.Gadget)
+	fmt.Fprintf(w, "Description:  %s\n", desc.Description)
+	fmt.Fprintf(w, "Output modes: %s\n", strings.Join(desc.OutputModes, ", "))
+
+	if len(desc.Columns) != 0 {
+		fmt.Fprintf(w, "\nColumns:\n")
+
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tJSON\tTYPE\tDEFAULT\tFILTERABLE\tUNIT\tDESCRIPTION")
+		for _, c := range desc.Columns {
+			unit := string(c.Unit)
+			if c.Kind != columns.SemanticKindNone {
+				unit = string(c.Kind)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%t\t%t\t%s\t%s\n",
+				c.Name, c.JSONName, c.Type, c.Visible, c.Filterable, unit, c.Description)
+		}
+		tw.Flush()
+	}
+
+	if len(desc.Parameters) != 0 {
+		fmt.Fprintf(w, "\nParameters:\n")
+
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, p := range desc.Parameters {
+			name := "    --" + p.Name
+			if p.Shorthand != "" {
+				name = "-" + p.Shorthand + ", --" + p.Name
+			}
+			def := ""
+			switch p.Default {
+			case "", "[]", "false", "0":
+			default:
+				def = fmt.Sprintf(" (default %s)", p.Default)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s%s\n", name, p.Type, p.Description, def)
+		}
+		tw.Flush()
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package describe
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+type testEvent struct {
+	Pid  uint32 `json:"pid,omitempty" column:"pid"`
+	Comm string `json:"comm,omitempty" column:"comm"`
+}
+
+func newTestRootCmd() *cobra.Command {
+	rootCmd := &cobra.Command{Use: "gadget"}
+
+	gadgetCmd := &cobra.Command{
+		Use:   "exec",
+		Short: "Trace new processes",
+		Run:   func(*cobra.Command, []string) {},
+	}
+	gadgetCmd.Flags().IntP("timeout", "t", 0, "Number of seconds")
+
+	histogramCmd := &cobra.Command{
+		Use:   "block-io",
+		Short: "Analyze block I/O performance",
+		Run:   func(*cobra.Command, []string) {},
+	}
+
+	categoryCmd := &cobra.Command{Use: "trace"}
+	categoryCmd.PersistentFlags().StringP("output", "o", commonutils.OutputModeColumns, "Output format")
+	categoryCmd.PersistentFlags().SetAnnotation("output", commonutils.OutputModesAnnotation, commonutils.SupportedOutputModes)
+	categoryCmd.AddCommand(gadgetCmd, histogramCmd)
+
+	rootCmd.AddCommand(
+		categoryCmd,
+		NewDescribeCmd(map[string]Gadget{
+			"trace/exec": {Schema: func() *columns.Schema {
+				return columns.MustCreateColumns[testEvent]().GetColumnMap().Schema()
+			}},
+			"trace/block-io": {OutputModes: []string{commonutils.OutputModeJSON}},
+		}),
+	)
+
+	return rootCmd
+}
+
+func TestDescribe(t *testing.T) {
+	rootCmd := newTestRootCmd()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"describe", "trace", "exec", "-o", "json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("describing gadget: %s", err)
+	}
+
+	var desc GadgetDescription
+	if err := json.Unmarshal(out.Bytes(), &desc); err != nil {
+		t.Fatalf("unmarshalling %q: %s", out.String(), err)
+	}
+
+	if desc.Category != "trace" || desc.Gadget != "exec" || desc.Description != "Trace new processes" {
+		t.Fatalf("unexpected gadget description: %+v", desc)
+	}
+	if len(desc.Columns) != 2 || desc.Columns[0].Name != "pid" || desc.Columns[1].Name != "comm" {
+		t.Fatalf("unexpected columns: %+v", desc.Columns)
+	}
+	if len(desc.Parameters) != 1 || desc.Parameters[0].Name != "timeout" || desc.Parameters[0].Shorthand != "t" {
+		t.Fatalf("unexpected parameters: %+v", desc.Parameters)
+	}
+	if desc.JSONSchema == nil {
+		t.Fatalf("expected JSON Schema")
+	}
+	if !reflect.DeepEqual(desc.OutputModes, commonutils.SupportedOutputModes) {
+		t.Fatalf("unexpected output modes: %v", desc.OutputModes)
+	}
+}
+
+func TestDescribeOutputModes(t *testing.T) {
+	rootCmd := newTestRootCmd()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"describe", "trace", "block-io", "-o", "json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("describing gadget: %s", err)
+	}
+
+	var desc GadgetDescription
+	if err := json.Unmarshal(out.Bytes(), &desc); err != nil {
+		t.Fatalf("unmarshalling %q: %s", out.String(), err)
+	}
+
+	if !reflect.DeepEqual(desc.OutputModes, []string{commonutils.OutputModeJSON}) {
+		t.Fatalf("unexpected output modes: %v", desc.OutputModes)
+	}
+	if desc.Columns != nil || desc.JSONSchema != nil {
+		t.Fatalf("unexpected schema: %+v", desc)
+	}
+}
+
+func TestDescribeErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"describe", "trace", "unknown"},
+		{"describe", "unknown", "exec"},
+		{"describe", "trace"},
+		{"describe", "trace", "exec", "-o", "yaml"},
+	} {
+		rootCmd := newTestRootCmd()
+		rootCmd.SetOut(&bytes.Buffer{})
+		rootCmd.SetErr(&bytes.Buffer{})
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
@@ -98,6 +98,18 @@ func (g *SnapshotGadgetPrinter[Event]) PrintEvents(allEvents []Event) error {
 		}
 
 		w.Flush()
+	case commonutils.OutputModeGoTemplate:
+		fallthrough
+	case commonutils.OutputModeJSONPath:
+		for _, e := range allEvents {
+			baseEvent := e.GetBaseEvent()
+			if baseEvent.Type != eventtypes.NORMAL {
+				commonutils.ManageSpecialEvent(baseEvent, outputConfig.Verbose)
+				continue
+			}
+
+			fmt.Println(outputConfig.TransformIntoTemplate(&e))
+		}
 	default:
 		return commonutils.WrapInErrOutputModeNotSupported(outputConfig.OutputMode)
 	}
@@ -16,7 +16,9 @@ package snapshot
 
 import (
 	"fmt"
+	"net"
 	"sort"
+	"strconv"
 	"strings"
 
 	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
@@ -44,8 +46,14 @@ func newSocketParser(outputConfig *commonutils.OutputConfig, flags *SocketFlags,
 		"protocol",
 		"local",
 		"remote",
+		"remotekind",
+		"remotename",
 		"status",
 		"inode",
+		"netns",
+		"pid",
+		"comm",
+		"uid",
 	}
 
 	if len(outputConfig.CustomColumns) == 0 {
@@ -56,7 +64,7 @@ func newSocketParser(outputConfig *commonutils.OutputConfig, flags *SocketFlags,
 	}
 
 	if outputConfig.OutputMode == commonutils.OutputModeColumns && flags.Extended {
-		outputConfig.CustomColumns = append(outputConfig.CustomColumns, "inode")
+		outputConfig.CustomColumns = append(outputConfig.CustomColumns, "inode", "netns", "pid", "comm", "uid")
 	}
 
 	return &SocketParser{
@@ -90,13 +98,30 @@ func (s *SocketParser) TransformToColumns(e *types.Event) string {
 		case "protocol":
 			sb.WriteString(fmt.Sprintf("%s", e.Protocol))
 		case "local":
-			sb.WriteString(fmt.Sprintf("%s:%d", e.LocalAddress, e.LocalPort))
+			sb.WriteString(formatSocketAddress(e.Protocol, e.LocalAddress, e.LocalPort))
 		case "remote":
-			sb.WriteString(fmt.Sprintf("%s:%d", e.RemoteAddress, e.RemotePort))
+			sb.WriteString(formatSocketAddress(e.Protocol, e.RemoteAddress, e.RemotePort))
+		case "remotekind":
+			sb.WriteString(e.RemoteKind)
+		case "remotename":
+			sb.WriteString(e.RemoteName)
 		case "status":
 			sb.WriteString(fmt.Sprintf("%s", e.Status))
 		case "inode":
 			sb.WriteString(fmt.Sprintf("%d", e.InodeNumber))
+		case "netns":
+			sb.WriteString(fmt.Sprintf("%d", e.Netns))
+		case "pid":
+			// The owner of the socket isn't always known
+			if e.Pid != 0 {
+				sb.WriteString(fmt.Sprintf("%d", e.Pid))
+			}
+		case "comm":
+			sb.WriteString(fmt.Sprintf("%s", e.Comm))
+		case "uid":
+			if e.UID != nil {
+				sb.WriteString(fmt.Sprintf("%d", *e.UID))
+			}
 		default:
 			continue
 		}
@@ -106,6 +131,15 @@ func (s *SocketParser) TransformToColumns(e *types.Event) string {
 	return sb.String()
 }
 
+// formatSocketAddress returns the address of a socket as shown to the user:
+// the path for UNIX sockets and the address and port pair for the others.
+func formatSocketAddress(protocol, address string, port uint16) string {
+	if protocol == "UNIX" {
+		return address
+	}
+	return net.JoinHostPort(address, strconv.FormatUint(uint64(port), 10))
+}
+
 func (s *SocketParser) SortEvents(allSockets *[]types.Event) {
 	sort.Slice(*allSockets, func(i, j int) bool {
 		si, sj := (*allSockets)[i], (*allSockets)[j]
@@ -148,7 +182,7 @@ func GetSocketDefaultColumns() []string {
 func NewSocketCmd(runCmd func(*cobra.Command, []string) error, flags *SocketFlags) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "socket",
-		Short: "Gather information about TCP and UDP sockets",
+		Short: "Gather information about TCP, UDP, UNIX and raw sockets",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			var err error
 			if flags.ParsedProtocol, err = types.ParseProtocol(flags.Protocol); err != nil {
@@ -164,20 +198,21 @@ func NewSocketCmd(runCmd func(*cobra.Command, []string) error, flags *SocketFlag
 	for protocol := range types.ProtocolsMap {
 		protocols = append(protocols, protocol)
 	}
+	sort.Strings(protocols)
 
 	cmd.PersistentFlags().StringVarP(
 		&flags.Protocol,
 		"proto",
 		"",
 		"all",
-		fmt.Sprintf("Show only sockets using this protocol (%s)", strings.Join(protocols, ", ")),
+		fmt.Sprintf("Show only sockets using this protocol (%s), all being TCP and UDP", strings.Join(protocols, ", ")),
 	)
 	cmd.PersistentFlags().BoolVarP(
 		&flags.Extended,
 		"extend",
 		"e",
 		false,
-		"Display other/more information (like socket inode)",
+		"Display other/more information (like socket inode, network namespace and owner process)",
 	)
 
 	return cmd
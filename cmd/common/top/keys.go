@@ -0,0 +1,131 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package top
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyEnter
+	keyEsc
+	keyBackspace
+	keyCtrlC
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPgUp
+	keyPgDown
+	keyHome
+	keyEnd
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// escapeSequences maps the escape sequences sent by terminals (without the
+// leading ESC) to the keys they represent.
+var escapeSequences = map[string]keyCode{
+	"[A":  keyUp,
+	"[B":  keyDown,
+	"[C":  keyRight,
+	"[D":  keyLeft,
+	"OA":  keyUp,
+	"OB":  keyDown,
+	"OC":  keyRight,
+	"OD":  keyLeft,
+	"[5~": keyPgUp,
+	"[6~": keyPgDown,
+	"[H":  keyHome,
+	"[F":  keyEnd,
+	"OH":  keyHome,
+	"OF":  keyEnd,
+	"[1~": keyHome,
+	"[4~": keyEnd,
+	"[7~": keyHome,
+	"[8~": keyEnd,
+}
+
+// parseKeys returns the keys contained in buf, as read from a terminal in raw
+// mode. Unknown escape sequences are ignored.
+func parseKeys(buf []byte) []key {
+	var keys []key
+
+	for len(buf) > 0 {
+		switch buf[0] {
+		case 0x1b:
+			if len(buf) == 1 {
+				keys = append(keys, key{code: keyEsc})
+				buf = buf[1:]
+				continue
+			}
+
+			// Escape sequences end with a letter or with "~"
+			end := 1
+			for end < len(buf) && end < 8 {
+				c := buf[end]
+				end++
+				if c == '~' || (end > 2 && (c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z')) {
+					break
+				}
+			}
+			if code, ok := escapeSequences[string(buf[1:end])]; ok {
+				keys = append(keys, key{code: code})
+			}
+			buf = buf[end:]
+		case '\r', '\n':
+			keys = append(keys, key{code: keyEnter})
+			buf = buf[1:]
+		case 0x7f, 0x08:
+			keys = append(keys, key{code: keyBackspace})
+			buf = buf[1:]
+		case 0x03:
+			keys = append(keys, key{code: keyCtrlC})
+			buf = buf[1:]
+		default:
+			r, size := utf8.DecodeRune(buf)
+			if r >= 0x20 {
+				keys = append(keys, key{code: keyRune, r: r})
+			}
+			buf = buf[size:]
+		}
+	}
+
+	return keys
+}
+
+// readKeys reads the keys pressed by the user from r and sends them to keys
+// until r is closed.
+func readKeys(r io.Reader, keys chan<- key) {
+	defer close(keys)
+
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
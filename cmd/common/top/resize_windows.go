@@ -0,0 +1,48 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package top
+
+import (
+	"time"
+)
+
+// resizeCheckInterval is how often the size of the terminal is checked, as
+// Windows doesn't signal when it changes
+const resizeCheckInterval = 250 * time.Millisecond
+
+// notifyResize returns a channel that receives a value each time the size of
+// the terminal has to be checked and a function to stop the notifications.
+func notifyResize() (<-chan struct{}, func()) {
+	c := make(chan struct{}, 1)
+	ticker := time.NewTicker(resizeCheckInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case c <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return c, func() {
+		ticker.Stop()
+		close(done)
+	}
+}
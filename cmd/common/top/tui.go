@@ -0,0 +1,563 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package top provides an interactive terminal user interface to watch the
+// stats of the top gadgets.
+package top
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/filter"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/textcolumns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/sort"
+)
+
+const (
+	enterAltScreen = "\033[?1049h"
+	exitAltScreen  = "\033[?1049l"
+	hideCursor     = "\033[?25l"
+	showCursor     = "\033[?25h"
+	cursorHome     = "\033[H"
+	clearLine      = "\033[K"
+	clearBelow     = "\033[J"
+	styleReverse   = "\033[7m"
+	styleBold      = "\033[1m"
+	styleReset     = "\033[0m"
+
+	helpText = "q quit  ↑↓ scroll  ←→ sort column  r reverse  / filter  c clear  enter drill down  backspace go up  p pause"
+)
+
+// Config describes what the TUI shows.
+type Config[T any] struct {
+	// Title is shown at the top of the screen, e.g. the name of the gadget
+	Title string
+
+	// Columns are the columns that can be shown, sorted and filtered
+	Columns columns.ColumnMap[T]
+
+	// ShownColumns lists the columns to show or nil to show the visible ones
+	ShownColumns []string
+
+	// SortBy is the initial list of columns to sort by, see sort.SortEntries
+	SortBy []string
+
+	// DrillDown lists the levels to drill into when pressing enter on a row.
+	// Each level filters the entries by the values that the selected row has
+	// in the given columns, e.g. {{"namespace", "pod"}, {"container"}}.
+	DrillDown [][]string
+}
+
+type inputMode int
+
+const (
+	inputModeNormal inputMode = iota
+	inputModeFilter
+)
+
+// TUI is an interactive full-screen view of the stats of a top gadget. The
+// stats can be scrolled, sorted by any of the shown columns and filtered.
+type TUI[T any] struct {
+	mu sync.Mutex
+
+	config       *Config[T]
+	formatter    *textcolumns.TextColumnsFormatter[T]
+	shownColumns []string
+
+	entries []*T // last stats received
+	pending []*T // stats received while paused
+	view    []*T // entries after applying filters and sorting
+
+	sortBy       []string
+	filters      []string   // filters typed by the user
+	drillFilters [][]string // filters added by drilling down, one slice per level
+	paused       bool
+
+	selected int // index of the selected row in view
+	offset   int // index of the first row shown
+	rows     int // number of rows that fit on the screen
+
+	mode    inputMode
+	input   string
+	message string // error or information shown in the last line
+
+	redraw chan struct{}
+
+	// restoreTerm puts the terminal back in the state it was before Run
+	restoreTerm func()
+}
+
+// New creates a TUI; call Run to start it and Update to give it new stats.
+func New[T any](config *Config[T]) *TUI[T] {
+	shownColumns := columns.ToLowerStrings(config.ShownColumns)
+	if len(shownColumns) == 0 {
+		shownColumns = nil
+		for _, col := range config.Columns.GetOrderedColumns() {
+			if col.Visible {
+				shownColumns = append(shownColumns, strings.ToLower(col.Name))
+			}
+		}
+	}
+
+	return &TUI[T]{
+		config: config,
+		formatter: textcolumns.NewFormatter(
+			config.Columns,
+			textcolumns.WithDefaultColumns(shownColumns),
+		),
+		shownColumns: shownColumns,
+		sortBy:       append([]string{}, config.SortBy...),
+		redraw:       make(chan struct{}, 1),
+	}
+}
+
+// Update replaces the stats shown. While paused, they are kept until the TUI
+// is resumed.
+func (t *TUI[T]) Update(entries []*T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused {
+		t.pending = entries
+		return
+	}
+
+	t.entries = entries
+	t.updateView()
+	t.requestRedraw()
+}
+
+// SetMessage shows msg in the last line of the screen until the next key is
+// pressed.
+func (t *TUI[T]) SetMessage(msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.message = msg
+	t.requestRedraw()
+}
+
+func (t *TUI[T]) requestRedraw() {
+	select {
+	case t.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// activeFilters returns the filters typed by the user and the ones added
+// when drilling down.
+func (t *TUI[T]) activeFilters() []string {
+	filters := append([]string{}, t.filters...)
+	for _, level := range t.drillFilters {
+		filters = append(filters, level...)
+	}
+	return filters
+}
+
+// updateView filters and sorts the entries and keeps the selection within
+// bounds. It must be called with t.mu held.
+func (t *TUI[T]) updateView() {
+	view := append([]*T{}, t.entries...)
+
+	if filters := t.activeFilters(); len(filters) > 0 {
+		var err error
+		view, err = filter.FilterEntries(t.config.Columns, view, filters)
+		if err != nil {
+			// Filters are validated before being added, so this shouldn't
+			// happen. Show all the entries rather than an empty view.
+			t.message = fmt.Sprintf("invalid filter: %s", err)
+			view = append([]*T{}, t.entries...)
+		}
+	}
+
+	sort.SortEntries(t.config.Columns, view, t.sortBy)
+
+	t.view = view
+	t.clampSelection()
+}
+
+func (t *TUI[T]) clampSelection() {
+	if t.selected >= len(t.view) {
+		t.selected = len(t.view) - 1
+	}
+	if t.selected < 0 {
+		t.selected = 0
+	}
+
+	rows := t.rows
+	if rows < 1 {
+		rows = 1
+	}
+	if t.selected < t.offset {
+		t.offset = t.selected
+	}
+	if t.selected >= t.offset+rows {
+		t.offset = t.selected - rows + 1
+	}
+	if t.offset > len(t.view)-rows {
+		t.offset = len(t.view) - rows
+	}
+	if t.offset < 0 {
+		t.offset = 0
+	}
+}
+
+// primarySort returns the column the entries are sorted by in the first
+// place and whether it's sorted in descending order.
+func (t *TUI[T]) primarySort() (string, bool) {
+	if len(t.sortBy) == 0 {
+		return "", false
+	}
+	return strings.TrimPrefix(t.sortBy[0], "-"), strings.HasPrefix(t.sortBy[0], "-")
+}
+
+// setPrimarySort sorts the entries by the given column first, keeping the
+// remaining sort columns as tie-breakers.
+func (t *TUI[T]) setPrimarySort(column string, descending bool) {
+	sortBy := []string{column}
+	if descending {
+		sortBy[0] = "-" + column
+	}
+	for _, s := range t.sortBy {
+		if strings.TrimPrefix(s, "-") != column {
+			sortBy = append(sortBy, s)
+		}
+	}
+	t.sortBy = sortBy
+}
+
+// moveSortColumn sorts by the shown column delta positions away from the
+// current one.
+func (t *TUI[T]) moveSortColumn(delta int) {
+	if len(t.shownColumns) == 0 {
+		return
+	}
+
+	current, descending := t.primarySort()
+	idx := -1
+	for i, name := range t.shownColumns {
+		if name == current {
+			idx = i
+			break
+		}
+	}
+
+	if idx == -1 {
+		idx = 0
+	} else {
+		idx = (idx + delta + len(t.shownColumns)) % len(t.shownColumns)
+	}
+
+	t.setPrimarySort(t.shownColumns[idx], descending)
+}
+
+// drillDown filters the entries by the values of the selected row in the
+// columns of the next drill down level.
+func (t *TUI[T]) drillDown() {
+	if len(t.drillFilters) >= len(t.config.DrillDown) {
+		t.message = "can't drill down any further"
+		return
+	}
+	if len(t.view) == 0 {
+		return
+	}
+
+	entry := t.view[t.selected]
+
+	var filters []string
+	for _, name := range t.config.DrillDown[len(t.drillFilters)] {
+		col, ok := t.config.Columns.GetColumn(name)
+		if !ok {
+			continue
+		}
+		f := fmt.Sprintf("%s:%v", name, col.Get(entry).Interface())
+		// Values like "~(" can't be used as a filter
+		if _, err := filter.GetFilterFromString(t.config.Columns, f); err != nil {
+			t.message = fmt.Sprintf("can't drill down: %s", err)
+			return
+		}
+		filters = append(filters, f)
+	}
+
+	t.drillFilters = append(t.drillFilters, filters)
+	t.selected = 0
+	t.offset = 0
+}
+
+func (t *TUI[T]) addFilter(f string) error {
+	if f == "" {
+		return nil
+	}
+	if _, err := filter.GetFilterFromString(t.config.Columns, f); err != nil {
+		return err
+	}
+	t.filters = append(t.filters, f)
+	t.selected = 0
+	t.offset = 0
+	return nil
+}
+
+// handleKey updates the state of the TUI according to the key pressed and
+// returns false if the user wants to quit.
+func (t *TUI[T]) handleKey(k key) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if k.code == keyCtrlC {
+		return false
+	}
+
+	t.message = ""
+
+	if t.mode == inputModeFilter {
+		switch k.code {
+		case keyEnter:
+			if err := t.addFilter(strings.TrimSpace(t.input)); err != nil {
+				t.message = fmt.Sprintf("invalid filter: %s", err)
+			}
+			t.mode = inputModeNormal
+			t.input = ""
+		case keyEsc:
+			t.mode = inputModeNormal
+			t.input = ""
+		case keyBackspace:
+			if r := []rune(t.input); len(r) > 0 {
+				t.input = string(r[:len(r)-1])
+			}
+		case keyRune:
+			t.input += string(k.r)
+		}
+		t.updateView()
+		return true
+	}
+
+	switch k.code {
+	case keyUp:
+		t.selected--
+	case keyDown:
+		t.selected++
+	case keyPgUp:
+		t.selected -= t.rows
+	case keyPgDown:
+		t.selected += t.rows
+	case keyHome:
+		t.selected = 0
+	case keyEnd:
+		t.selected = len(t.view) - 1
+	case keyLeft:
+		t.moveSortColumn(-1)
+	case keyRight:
+		t.moveSortColumn(1)
+	case keyEnter:
+		t.drillDown()
+	case keyBackspace:
+		if len(t.drillFilters) > 0 {
+			t.drillFilters = t.drillFilters[:len(t.drillFilters)-1]
+		}
+	case keyRune:
+		switch k.r {
+		case 'q':
+			return false
+		case 'k':
+			t.selected--
+		case 'j':
+			t.selected++
+		case 'g':
+			t.selected = 0
+		case 'G':
+			t.selected = len(t.view) - 1
+		case '<':
+			t.moveSortColumn(-1)
+		case '>':
+			t.moveSortColumn(1)
+		case 'r':
+			if column, descending := t.primarySort(); column != "" {
+				t.setPrimarySort(column, !descending)
+			}
+		case '/':
+			t.mode = inputModeFilter
+		case 'c':
+			t.filters = nil
+			t.drillFilters = nil
+		case 'p', ' ':
+			t.paused = !t.paused
+			if !t.paused && t.pending != nil {
+				t.entries = t.pending
+				t.pending = nil
+			}
+		}
+	}
+
+	t.updateView()
+	return true
+}
+
+// fit cuts or pads s to be exactly width characters long.
+func fit(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width])
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
+// render returns the content of a screen of the given size.
+func (t *TUI[T]) render(width, height int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if width < 1 || height < 4 {
+		return []string{fit(t.config.Title, width)}
+	}
+
+	t.formatter.RecalculateWidths(width, false)
+
+	// Title, header and status line take three lines
+	t.rows = height - 3
+	t.clampSelection()
+
+	status := []string{t.config.Title}
+	if column, descending := t.primarySort(); column != "" {
+		order := "asc"
+		if descending {
+			order = "desc"
+		}
+		status = append(status, fmt.Sprintf("sort: %s (%s)", column, order))
+	}
+	if filters := t.activeFilters(); len(filters) > 0 {
+		status = append(status, "filter: "+strings.Join(filters, ", "))
+	}
+	status = append(status, fmt.Sprintf("%d/%d rows", len(t.view), len(t.entries)))
+	if t.paused {
+		status = append(status, "PAUSED")
+	}
+
+	lines := make([]string, 0, height)
+	lines = append(lines, fit(strings.Join(status, " | "), width))
+	lines = append(lines, styleBold+fit(t.formatter.FormatHeader(), width)+styleReset)
+
+	for i := t.offset; i < t.offset+t.rows; i++ {
+		if i >= len(t.view) {
+			lines = append(lines, "")
+			continue
+		}
+		line := fit(t.formatter.FormatEntry(t.view[i]), width)
+		if i == t.selected {
+			line = styleReverse + line + styleReset
+		}
+		lines = append(lines, line)
+	}
+
+	switch {
+	case t.mode == inputModeFilter:
+		lines = append(lines, fit("filter (e.g. comm:nginx): "+t.input, width))
+	case t.message != "":
+		lines = append(lines, fit(t.message, width))
+	default:
+		lines = append(lines, fit(helpText, width))
+	}
+
+	return lines
+}
+
+func (t *TUI[T]) draw(width, height int) {
+	var sb strings.Builder
+	sb.WriteString(cursorHome)
+	for i, line := range t.render(width, height) {
+		if i > 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(line)
+		sb.WriteString(clearLine)
+	}
+	sb.WriteString(clearBelow)
+	fmt.Print(sb.String())
+}
+
+// Run shows the TUI until the user quits. It requires stdin and stdout to be
+// terminals.
+func (t *TUI[T]) Run() error {
+	inFd := int(os.Stdin.Fd())
+	outFd := int(os.Stdout.Fd())
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return errors.New("the interactive mode requires a terminal")
+	}
+
+	oldState, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("setting terminal in raw mode: %w", err)
+	}
+
+	t.mu.Lock()
+	t.restoreTerm = func() {
+		fmt.Print(showCursor + exitAltScreen)
+		term.Restore(inFd, oldState)
+	}
+	t.mu.Unlock()
+	defer t.Restore()
+
+	fmt.Print(enterAltScreen + hideCursor)
+
+	keys := make(chan key)
+	go readKeys(os.Stdin, keys)
+
+	resized, stopResize := notifyResize()
+	defer stopResize()
+
+	width, height := 0, 0
+	forceDraw := true
+	for {
+		w, h, err := term.GetSize(outFd)
+		if err != nil {
+			return fmt.Errorf("getting terminal size: %w", err)
+		}
+		if forceDraw || w != width || h != height {
+			width, height = w, h
+			t.draw(width, height)
+		}
+
+		forceDraw = true
+		select {
+		case k, ok := <-keys:
+			if !ok || !t.handleKey(k) {
+				return nil
+			}
+		case <-t.redraw:
+		case <-resized:
+			// Only redraw if the size actually changed
+			forceDraw = false
+		}
+	}
+}
+
+// Restore puts the terminal back in the state it was before Run. It's safe to
+// call it several times and from another goroutine, e.g. before exiting
+// because of a signal.
+func (t *TUI[T]) Restore() {
+	t.mu.Lock()
+	restore := t.restoreTerm
+	t.restoreTerm = nil
+	t.mu.Unlock()
+
+	if restore != nil {
+		restore()
+	}
+}
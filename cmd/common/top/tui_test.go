@@ -0,0 +1,197 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package top
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+type testStats struct {
+	Namespace string `column:"namespace"`
+	Pod       string `column:"pod"`
+	Comm      string `column:"comm"`
+	Reads     uint64 `column:"reads"`
+}
+
+func newTestTUI() *TUI[testStats] {
+	tui := New(&Config[testStats]{
+		Title:     "top test",
+		Columns:   columns.MustCreateColumns[testStats]().GetColumnMap(),
+		SortBy:    []string{"-reads"},
+		DrillDown: [][]string{{"namespace", "pod"}},
+	})
+	tui.Update([]*testStats{
+		{Namespace: "default", Pod: "web", Comm: "nginx", Reads: 10},
+		{Namespace: "default", Pod: "db", Comm: "mysqld", Reads: 30},
+		{Namespace: "kube-system", Pod: "dns", Comm: "coredns", Reads: 20},
+	})
+	return tui
+}
+
+func comms(tui *TUI[testStats]) []string {
+	var res []string
+	for _, e := range tui.view {
+		res = append(res, e.Comm)
+	}
+	return res
+}
+
+func pressKeys(tui *TUI[testStats], input string) {
+	for _, k := range parseKeys([]byte(input)) {
+		tui.handleKey(k)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("q\x1b[A\x1b[6~\x1bOC\r\x7f\x03é\x1b"))
+	expected := []key{
+		{code: keyRune, r: 'q'},
+		{code: keyUp},
+		{code: keyPgDown},
+		{code: keyRight},
+		{code: keyEnter},
+		{code: keyBackspace},
+		{code: keyCtrlC},
+		{code: keyRune, r: 'é'},
+		{code: keyEsc},
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("%v != %v", keys, expected)
+	}
+}
+
+func TestTUISort(t *testing.T) {
+	tui := newTestTUI()
+
+	if got := comms(tui); !reflect.DeepEqual(got, []string{"mysqld", "coredns", "nginx"}) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+
+	// Reverse the order
+	pressKeys(tui, "r")
+	if got := comms(tui); !reflect.DeepEqual(got, []string{"nginx", "coredns", "mysqld"}) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+
+	// Sort by the previous column (comm), keeping the ascending order
+	pressKeys(tui, "<")
+	if !reflect.DeepEqual(tui.sortBy, []string{"comm", "reads"}) {
+		t.Fatalf("unexpected sort: %v", tui.sortBy)
+	}
+	if got := comms(tui); !reflect.DeepEqual(got, []string{"coredns", "mysqld", "nginx"}) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+}
+
+func TestTUIFilterAndDrillDown(t *testing.T) {
+	tui := newTestTUI()
+
+	pressKeys(tui, "/namespace:default\r")
+	if got := comms(tui); !reflect.DeepEqual(got, []string{"mysqld", "nginx"}) {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+
+	pressKeys(tui, "/foo:bar\r")
+	if !strings.Contains(tui.message, "invalid filter") {
+		t.Fatalf("expected error message, got %q", tui.message)
+	}
+
+	// Drill down into the pod of the second row
+	pressKeys(tui, "j\r")
+	if got := comms(tui); !reflect.DeepEqual(got, []string{"nginx"}) {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+
+	pressKeys(tui, "\r")
+	if tui.message == "" {
+		t.Fatalf("expected message when drilling down too far")
+	}
+
+	// Go up and clear the filters
+	pressKeys(tui, "\x7f")
+	if got := comms(tui); !reflect.DeepEqual(got, []string{"mysqld", "nginx"}) {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+	pressKeys(tui, "c")
+	if len(tui.view) != 3 {
+		t.Fatalf("unexpected entries: %v", comms(tui))
+	}
+}
+
+func TestTUIDrillDownInvalidFilter(t *testing.T) {
+	tui := newTestTUI()
+	tui.Update([]*testStats{{Namespace: "default", Pod: "~(", Comm: "cat"}})
+
+	pressKeys(tui, "\r")
+	if !strings.Contains(tui.message, "can't drill down") {
+		t.Fatalf("expected error message, got %q", tui.message)
+	}
+	if len(tui.drillFilters) != 0 {
+		t.Fatalf("unexpected drill down filters: %v", tui.drillFilters)
+	}
+	if got := comms(tui); !reflect.DeepEqual(got, []string{"cat"}) {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+}
+
+func TestTUIPause(t *testing.T) {
+	tui := newTestTUI()
+
+	pressKeys(tui, "p")
+	tui.Update([]*testStats{{Comm: "cat"}})
+	if len(tui.view) != 3 {
+		t.Fatalf("view changed while paused: %v", comms(tui))
+	}
+
+	pressKeys(tui, "p")
+	if got := comms(tui); !reflect.DeepEqual(got, []string{"cat"}) {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+}
+
+func TestTUIRender(t *testing.T) {
+	tui := newTestTUI()
+
+	lines := tui.render(60, 5)
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], "sort: reads (desc)") || !strings.Contains(lines[0], "3/3 rows") {
+		t.Fatalf("unexpected status line: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "NAMESPACE") {
+		t.Fatalf("unexpected header: %q", lines[1])
+	}
+	// Only two rows fit: the first one is selected
+	if !strings.HasPrefix(lines[2], styleReverse) || !strings.Contains(lines[2], "mysqld") {
+		t.Fatalf("unexpected first row: %q", lines[2])
+	}
+
+	// Scrolling down moves the view
+	pressKeys(tui, "jj")
+	lines = tui.render(60, 5)
+	if !strings.Contains(lines[2], "coredns") || !strings.Contains(lines[3], "nginx") {
+		t.Fatalf("unexpected rows: %q", lines[2:4])
+	}
+	for _, line := range lines {
+		if len([]rune(strings.NewReplacer(styleBold, "", styleReverse, "", styleReset, "").Replace(line))) != 60 {
+			t.Fatalf("line doesn't fit the screen width: %q", line)
+		}
+	}
+}
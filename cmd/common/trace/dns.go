@@ -16,12 +16,36 @@ package trace
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
 )
 
-func NewDNSCmd(runCmd func(*cobra.Command, []string) error) *cobra.Command {
-	return &cobra.Command{
+type DNSFlags struct {
+	CaptureResponses bool
+}
+
+func NewDNSCmd(runCmd func(*cobra.Command, []string) error, flags *DNSFlags) *cobra.Command {
+	cmd := &cobra.Command{
 		Use:   "dns",
 		Short: "Trace DNS requests",
 		RunE:  runCmd,
 	}
+
+	cmd.Flags().BoolVar(
+		&flags.CaptureResponses, "responses", false,
+		"Also trace the responses, with the addresses of their answers",
+	)
+
+	return cmd
+}
+
+// GetDNSColumns returns the columns of the dns gadget. The qr column is only
+// shown by default when the responses are traced.
+func GetDNSColumns(flags *DNSFlags) *columns.Columns[types.Event] {
+	cols := types.GetColumns()
+	if col, ok := cols.GetColumn("qr"); ok && flags.CaptureResponses {
+		col.Visible = true
+	}
+	return cols
 }
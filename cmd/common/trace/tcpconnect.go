@@ -15,13 +15,58 @@
 package trace
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/cobra"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpconnect/types"
 )
 
-func NewTcpconnectCmd(runCmd func(*cobra.Command, []string) error) *cobra.Command {
-	return &cobra.Command{
+type TcpconnectFlags struct {
+	CalculateLatency bool
+	MinLatency       time.Duration
+}
+
+func NewTcpconnectCmd(runCmd func(*cobra.Command, []string) error, flags *TcpconnectFlags) *cobra.Command {
+	cmd := &cobra.Command{
 		Use:   "tcpconnect",
 		Short: "Trace connect system calls",
-		RunE:  runCmd,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if flags.MinLatency < 0 {
+				return commonutils.WrapInErrInvalidArg("--latency-min",
+					fmt.Errorf("%s is a negative duration", flags.MinLatency))
+			}
+
+			if flags.MinLatency != 0 {
+				flags.CalculateLatency = true
+			}
+
+			return nil
+		},
+		RunE: runCmd,
+	}
+
+	cmd.Flags().BoolVar(
+		&flags.CalculateLatency, "latency", false,
+		"Report the connections once they are established, together with the time elapsed since the connect call",
+	)
+	cmd.Flags().DurationVar(
+		&flags.MinLatency, "latency-min", 0,
+		"Only report the connections whose latency is greater than this value, e.g. 10ms. It implies --latency",
+	)
+
+	return cmd
+}
+
+// GetTcpconnectColumns returns the columns of the tcpconnect gadget. The
+// latency column is only shown by default when it's calculated.
+func GetTcpconnectColumns(flags *TcpconnectFlags) *columns.Columns[types.Event] {
+	cols := types.GetColumns()
+	if col, ok := cols.GetColumn("latency"); ok && flags.CalculateLatency {
+		col.Visible = true
 	}
+	return cols
 }
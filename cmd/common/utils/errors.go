@@ -118,3 +118,9 @@ func WrapInErrUnmarshalOutput(err error, output string) error {
 func WrapInErrMarshalOutput(err error) error {
 	return fmt.Errorf("failed to marshal output: %w", err)
 }
+
+// Templates
+
+func WrapInErrExecuteTemplate(err error) error {
+	return fmt.Errorf("failed to execute template: %w", err)
+}
@@ -17,6 +17,7 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -26,9 +27,26 @@ const (
 	OutputModeColumns       = "columns"
 	OutputModeJSON          = "json"
 	OutputModeCustomColumns = "custom-columns"
+
+	// Template output modes are evaluated per event for trace gadgets and per
+	// entry for snapshot and top gadgets.
+	OutputModeGoTemplate     = "go-template"
+	OutputModeGoTemplateFile = "go-template-file"
+	OutputModeJSONPath       = "jsonpath"
 )
 
-var SupportedOutputModes = []string{OutputModeColumns, OutputModeJSON, OutputModeCustomColumns}
+// OutputModesAnnotation is the annotation of the output flag listing the
+// output modes it accepts.
+const OutputModesAnnotation = "outputModes"
+
+var SupportedOutputModes = []string{
+	OutputModeColumns,
+	OutputModeJSON,
+	OutputModeCustomColumns,
+	OutputModeGoTemplate,
+	OutputModeGoTemplateFile,
+	OutputModeJSONPath,
+}
 
 // OutputConfig contains the flags that describes how to print the gadget's output
 type OutputConfig struct {
@@ -38,8 +56,28 @@ type OutputConfig struct {
 	// List of columns to print (only meaningful when OutputMode is "columns=...")
 	CustomColumns []string
 
+	// Template is the go-template or JSONPath expression used to print each
+	// element (only meaningful when OutputMode is "go-template" or "jsonpath")
+	Template string
+
 	// Verbose prints additional information
 	Verbose bool
+
+	templatePrinter templatePrinter
+}
+
+// IsColumnsOutputMode returns true if the output is printed in columns, i.e.
+// when a header has to be printed.
+func (config *OutputConfig) IsColumnsOutputMode() bool {
+	return config.OutputMode == OutputModeColumns ||
+		config.OutputMode == OutputModeCustomColumns
+}
+
+// IsTemplateOutputMode returns true if each element is printed using a
+// go-template or a JSONPath expression.
+func (config *OutputConfig) IsTemplateOutputMode() bool {
+	return config.OutputMode == OutputModeGoTemplate ||
+		config.OutputMode == OutputModeJSONPath
 }
 
 func (config *OutputConfig) ParseOutputConfig() error {
@@ -47,6 +85,10 @@ func (config *OutputConfig) ParseOutputConfig() error {
 		log.StandardLogger().SetLevel(log.DebugLevel)
 	}
 
+	// The template output modes take their template after "=", compare
+	// only the name before it.
+	mode, _, _ := strings.Cut(config.OutputMode, "=")
+
 	switch {
 	case config.OutputMode == OutputModeColumns:
 		fallthrough
@@ -70,6 +112,41 @@ func (config *OutputConfig) ParseOutputConfig() error {
 		config.CustomColumns = cols
 		config.OutputMode = OutputModeCustomColumns
 		return nil
+	case mode == OutputModeGoTemplateFile:
+		parts := strings.SplitN(config.OutputMode, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return WrapInErrInvalidArg(OutputModeGoTemplateFile,
+				errors.New("expects the path of a file containing a go-template"))
+		}
+
+		b, err := os.ReadFile(parts[1])
+		if err != nil {
+			return WrapInErrInvalidArg(OutputModeGoTemplateFile, err)
+		}
+
+		config.Template = string(b)
+		config.OutputMode = OutputModeGoTemplate
+		return config.parseTemplate(OutputModeGoTemplateFile)
+	case mode == OutputModeGoTemplate:
+		parts := strings.SplitN(config.OutputMode, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return WrapInErrInvalidArg(OutputModeGoTemplate,
+				errors.New("expects a go-template"))
+		}
+
+		config.Template = parts[1]
+		config.OutputMode = OutputModeGoTemplate
+		return config.parseTemplate(OutputModeGoTemplate)
+	case mode == OutputModeJSONPath:
+		parts := strings.SplitN(config.OutputMode, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return WrapInErrInvalidArg(OutputModeJSONPath,
+				errors.New("expects a JSONPath expression"))
+		}
+
+		config.Template = parts[1]
+		config.OutputMode = OutputModeJSONPath
+		return config.parseTemplate(OutputModeJSONPath)
 	default:
 		return WrapInErrInvalidArg("--output / -o",
 			fmt.Errorf("%q is not a valid output format", config.OutputMode))
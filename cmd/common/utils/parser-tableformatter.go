@@ -47,6 +47,17 @@ type GadgetParser[T any] struct {
 	colsMap   columns.ColumnMap[T]
 }
 
+// getColumnMap returns the columns to be used for the given metadata tag. If
+// no tag is provided, we use only the columns with no specific tag. In other
+// words, the gadget-specific columns. Otherwise, we also include the columns
+// with the requested tag.
+func getColumnMap[T any](cols *columns.Columns[T], metadataTag string) columns.ColumnMap[T] {
+	if metadataTag == "" {
+		return cols.GetColumnMap(columns.WithNoTags())
+	}
+	return cols.GetColumnMap(columns.Or(columns.WithTag(metadataTag), columns.WithNoTags()))
+}
+
 func NewGadgetParser[T any](outputConfig *OutputConfig, cols *columns.Columns[T], options ...Option) (*GadgetParser[T], error) {
 	var opts GadgetParserOptions
 
@@ -54,15 +65,7 @@ func NewGadgetParser[T any](outputConfig *OutputConfig, cols *columns.Columns[T]
 		o(&opts)
 	}
 
-	// If no tag is provided, we use only the columns with no specific tag. In
-	// other words, the gadget-specific columns. Otherwise, we also include the
-	// columns with the requested tag.
-	var colsMap columns.ColumnMap[T]
-	if opts.metadataTag == "" {
-		colsMap = cols.GetColumnMap(columns.WithNoTags())
-	} else {
-		colsMap = cols.GetColumnMap(columns.Or(columns.WithTag(opts.metadataTag), columns.WithNoTags()))
-	}
+	colsMap := getColumnMap(cols, opts.metadataTag)
 
 	var formatter *textcolumns.TextColumnsFormatter[T]
 	if len(outputConfig.CustomColumns) != 0 {
@@ -93,6 +96,17 @@ func NewGadgetParserWithRuntimeInfo[T any](outputConfig *OutputConfig, columns *
 	return NewGadgetParser(outputConfig, columns, WithMetadataTag(ContainerRuntimeTag))
 }
 
+// NewGadgetSchema returns the schema of the columns that a GadgetParser
+// created with the same metadata tag would handle.
+func NewGadgetSchema[T any](cols *columns.Columns[T], metadataTag string) *columns.Schema {
+	return getColumnMap(cols, metadataTag).Schema()
+}
+
+// GetColumnMap returns the columns handled by the parser.
+func (p *GadgetParser[T]) GetColumnMap() columns.ColumnMap[T] {
+	return p.colsMap
+}
+
 func (p *GadgetParser[T]) BuildColumnsHeader() string {
 	return p.formatter.FormatHeader()
 }
@@ -112,6 +112,10 @@ func (p *BaseParser[E]) Transform(element *E, toColumns func(*E) string) string
 		fallthrough
 	case OutputModeCustomColumns:
 		return toColumns(element)
+	case OutputModeGoTemplate:
+		fallthrough
+	case OutputModeJSONPath:
+		return p.OutputConfig.TransformIntoTemplate(element)
 	}
 
 	return ""
@@ -0,0 +1,218 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// templatePrinter prints an element using a user-provided template. The
+// element is converted to its JSON representation before executing the
+// template, so fields have to be referred using their JSON names, as done by
+// kubectl.
+type templatePrinter interface {
+	execute(w io.Writer, data any) error
+}
+
+type goTemplatePrinter struct {
+	tmpl *template.Template
+}
+
+func (p *goTemplatePrinter) execute(w io.Writer, data any) error {
+	return p.tmpl.Execute(w, data)
+}
+
+type jsonPathPrinter struct {
+	jp *jsonpath.JSONPath
+}
+
+func (p *jsonPathPrinter) execute(w io.Writer, data any) error {
+	return p.jp.Execute(w, data)
+}
+
+// commonDataField returns the value of a field of the eventtypes.CommonData
+// structure from the JSON representation of an element.
+func commonDataField(element any, field string) string {
+	m, ok := element.(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	value, _ := m[field].(string)
+	return value
+}
+
+// templateFuncs are the helper functions available in go-templates.
+var templateFuncs = template.FuncMap{
+	// json returns the JSON representation of a value, e.g. {{json .}}.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+	// node, namespace, pod and container return the Kubernetes metadata of
+	// an element, e.g. {{pod .}}.
+	"node": func(element any) string {
+		return commonDataField(element, "node")
+	},
+	"namespace": func(element any) string {
+		return commonDataField(element, "namespace")
+	},
+	"pod": func(element any) string {
+		return commonDataField(element, "pod")
+	},
+	"container": func(element any) string {
+		return commonDataField(element, "container")
+	},
+	// podRef returns "namespace/pod", or an empty string for host-level
+	// elements.
+	"podRef": func(element any) string {
+		pod := commonDataField(element, "pod")
+		if pod == "" {
+			return ""
+		}
+		return commonDataField(element, "namespace") + "/" + pod
+	},
+	// containerRef returns "namespace/pod/container", or just the container
+	// name when there is no pod information (e.g. local-gadget).
+	"containerRef": func(element any) string {
+		container := commonDataField(element, "container")
+		pod := commonDataField(element, "pod")
+		if pod == "" {
+			return container
+		}
+		return commonDataField(element, "namespace") + "/" + pod + "/" + container
+	},
+}
+
+func newGoTemplatePrinter(text string) (templatePrinter, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &goTemplatePrinter{tmpl: tmpl}, nil
+}
+
+var jsonRegexp = regexp.MustCompile(`^\{\.?([^{}]+)\}$|^\.?([^{}]+)$`)
+
+// relaxedJSONPathExpression allows the user to omit the curly braces and the
+// leading dot, e.g. "pod" or ".pod" are interpreted as "{.pod}".
+func relaxedJSONPathExpression(pathExpression string) string {
+	if len(pathExpression) == 0 {
+		return pathExpression
+	}
+
+	submatches := jsonRegexp.FindStringSubmatch(pathExpression)
+	if submatches == nil {
+		// Complex expressions like "{.pod}{'\t'}{.comm}" are passed as they
+		// are.
+		return pathExpression
+	}
+
+	var fieldSpec string
+	if len(submatches[1]) != 0 {
+		fieldSpec = submatches[1]
+	} else {
+		fieldSpec = submatches[2]
+	}
+
+	return fmt.Sprintf("{.%s}", fieldSpec)
+}
+
+func newJSONPathPrinter(text string) (templatePrinter, error) {
+	expr := relaxedJSONPathExpression(text)
+
+	// Fields with empty values are omitted from the JSON representation of
+	// most elements, don't fail when they are missing.
+	jp := jsonpath.New("output").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, err
+	}
+
+	return &jsonPathPrinter{jp: jp}, nil
+}
+
+// parseTemplate validates and compiles the template configured in
+// config.Template according to config.OutputMode.
+func (config *OutputConfig) parseTemplate(arg string) error {
+	var err error
+
+	switch config.OutputMode {
+	case OutputModeGoTemplate:
+		config.templatePrinter, err = newGoTemplatePrinter(config.Template)
+	case OutputModeJSONPath:
+		config.templatePrinter, err = newJSONPathPrinter(config.Template)
+	default:
+		err = fmt.Errorf("%q is not a template output mode", config.OutputMode)
+	}
+
+	if err != nil {
+		return WrapInErrInvalidArg(arg, err)
+	}
+
+	return nil
+}
+
+// TransformIntoTemplate executes the go-template or JSONPath expression
+// configured through ParseOutputConfig on a single element (an event for trace
+// gadgets or an entry for snapshot and top gadgets).
+func (config *OutputConfig) TransformIntoTemplate(element any) string {
+	if config.templatePrinter == nil {
+		fmt.Fprint(os.Stderr, WrapInErrOutputModeNotSupported(config.OutputMode))
+		return ""
+	}
+
+	// Use the JSON representation of the element so that templates refer to
+	// fields with the same names used in the JSON output mode.
+	b, err := json.Marshal(element)
+	if err != nil {
+		fmt.Fprint(os.Stderr, fmt.Sprint(WrapInErrMarshalOutput(err)))
+		return ""
+	}
+
+	// Keep the numbers as they are, decoding them as float64 would print
+	// large integers like mount namespace IDs or timestamps in exponent
+	// notation.
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		fmt.Fprint(os.Stderr, fmt.Sprint(WrapInErrUnmarshalOutput(err, string(b))))
+		return ""
+	}
+
+	var out bytes.Buffer
+	if err := config.templatePrinter.execute(&out, data); err != nil {
+		fmt.Fprint(os.Stderr, fmt.Sprint(WrapInErrExecuteTemplate(err)))
+		return ""
+	}
+
+	// Each element is printed in its own line, avoid an empty line when the
+	// template already ends with a newline.
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type MyEvent struct {
+	eventtypes.Event
+
+	Pid       uint32   `json:"pid,omitempty"`
+	Comm      string   `json:"comm,omitempty"`
+	Args      []string `json:"args,omitempty"`
+	MountNsID uint64   `json:"mntns,omitempty"`
+}
+
+var event = &MyEvent{
+	Event: eventtypes.Event{
+		CommonData: eventtypes.CommonData{
+			Node:      "node1",
+			Namespace: "default",
+			Pod:       "mypod",
+			Container: "mycontainer",
+		},
+		Type: eventtypes.NORMAL,
+	},
+	Pid:       1234,
+	Comm:      "cat",
+	Args:      []string{"/bin/cat", "/etc/passwd"},
+	MountNsID: 4026531840,
+}
+
+func TestTemplateOutputModes(t *testing.T) {
+	templateFile := filepath.Join(t.TempDir(), "template.tmpl")
+	if err := os.WriteFile(templateFile, []byte("{{.comm}}:{{.pid}}\n"), 0o644); err != nil {
+		t.Fatalf("writing template file: %s", err)
+	}
+
+	table := []struct {
+		description    string
+		outputMode     string
+		element        any
+		expectedMode   string
+		expectedError  bool
+		expectedOutput string
+	}{
+		{
+			description:    "go-template with fields",
+			outputMode:     "go-template={{.pod}} {{.comm}} {{.pid}}",
+			element:        event,
+			expectedMode:   OutputModeGoTemplate,
+			expectedOutput: "mypod cat 1234",
+		},
+		{
+			description:    "go-template with helpers",
+			outputMode:     "go-template={{podRef .}} {{containerRef .}} {{json .args}}",
+			element:        event,
+			expectedMode:   OutputModeGoTemplate,
+			expectedOutput: `default/mypod default/mypod/mycontainer ["/bin/cat","/etc/passwd"]`,
+		},
+		{
+			description:    "go-template with host-level element",
+			outputMode:     "go-template=[{{podRef .}}]",
+			element:        &MyEvent{Pid: 1},
+			expectedMode:   OutputModeGoTemplate,
+			expectedOutput: "[]",
+		},
+		{
+			description:    "go-template with large integer",
+			outputMode:     "go-template={{.mntns}}",
+			element:        event,
+			expectedMode:   OutputModeGoTemplate,
+			expectedOutput: "4026531840",
+		},
+		{
+			description:    "go-template-file",
+			outputMode:     "go-template-file=" + templateFile,
+			element:        event,
+			expectedMode:   OutputModeGoTemplate,
+			expectedOutput: "cat:1234",
+		},
+		{
+			description:    "jsonpath relaxed expression",
+			outputMode:     "jsonpath=.comm",
+			element:        event,
+			expectedMode:   OutputModeJSONPath,
+			expectedOutput: "cat",
+		},
+		{
+			description:    "jsonpath complex expression",
+			outputMode:     "jsonpath={.namespace}/{.pod}:{.args[1]}",
+			element:        event,
+			expectedMode:   OutputModeJSONPath,
+			expectedOutput: "default/mypod:/etc/passwd",
+		},
+		{
+			description:    "jsonpath missing field",
+			outputMode:     "jsonpath={.pod}",
+			element:        &MyEvent{Pid: 1},
+			expectedMode:   OutputModeJSONPath,
+			expectedOutput: "",
+		},
+		{
+			description:    "jsonpath large integer",
+			outputMode:     "jsonpath=.mntns",
+			element:        event,
+			expectedMode:   OutputModeJSONPath,
+			expectedOutput: "4026531840",
+		},
+		{
+			description:   "unknown template mode",
+			outputMode:    "go-templates={{.pod}}",
+			expectedError: true,
+		},
+		{
+			description:   "empty go-template",
+			outputMode:    "go-template=",
+			expectedError: true,
+		},
+		{
+			description:   "invalid go-template",
+			outputMode:    "go-template={{.pod",
+			expectedError: true,
+		},
+		{
+			description:   "missing go-template-file",
+			outputMode:    "go-template-file=" + filepath.Join(t.TempDir(), "missing"),
+			expectedError: true,
+		},
+		{
+			description:   "invalid jsonpath",
+			outputMode:    "jsonpath={.pod",
+			expectedError: true,
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			config := &OutputConfig{OutputMode: entry.outputMode}
+
+			err := config.ParseOutputConfig()
+			if entry.expectedError {
+				if err == nil {
+					t.Fatalf("expected error parsing %q", entry.outputMode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsing %q: %s", entry.outputMode, err)
+			}
+
+			if config.OutputMode != entry.expectedMode {
+				t.Fatalf("%q != %q", config.OutputMode, entry.expectedMode)
+			}
+
+			if !config.IsTemplateOutputMode() || config.IsColumnsOutputMode() {
+				t.Fatalf("%q is not considered a template output mode", config.OutputMode)
+			}
+
+			output := config.TransformIntoTemplate(entry.element)
+			if output != entry.expectedOutput {
+				t.Fatalf("%q != %q", output, entry.expectedOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advise
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/capabilities/advisor"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+	capabilitiesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/k8sutil"
+)
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Generate security contexts based on the capabilities used by containers",
+}
+
+var capabilitiesMonitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Monitor the capability checks",
+	RunE:  runCapabilitiesMonitor,
+}
+
+var capabilitiesReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report security contexts",
+	Long: `Report the security context of each container, per workload. It drops all
+the capabilities and only adds the ones that were granted to the container.
+Privileged containers are made unprivileged, as dropping capabilities has no
+effect on them, and the ones that didn't use any capability are flagged.`,
+	RunE: runCapabilitiesReport,
+}
+
+var capabilitiesReportFormat string
+
+func init() {
+	AdviseCmd.AddCommand(capabilitiesCmd)
+	utils.AddCommonFlags(capabilitiesCmd, &params)
+
+	capabilitiesCmd.AddCommand(capabilitiesMonitorCmd)
+	capabilitiesMonitorCmd.PersistentFlags().StringVarP(&outputFileName, "output", "", "-", "File name output")
+
+	capabilitiesCmd.AddCommand(capabilitiesReportCmd)
+	capabilitiesReportCmd.PersistentFlags().StringVarP(&inputFileName, "input", "", "", "File with recorded capability checks")
+	capabilitiesReportCmd.PersistentFlags().StringVarP(&outputFileName, "output", "", "-", "File name output")
+	capabilitiesReportCmd.PersistentFlags().StringVarP(&capabilitiesReportFormat, "format", "", "yaml",
+		"Output format: yaml for the security contexts or patch for kubectl commands patching the workloads")
+}
+
+func runCapabilitiesMonitor(cmd *cobra.Command, args []string) error {
+	config := &utils.TraceConfig{
+		GadgetName:       "capabilities",
+		Operation:        gadgetv1alpha1.OperationStart,
+		TraceOutputMode:  gadgetv1alpha1.TraceOutputModeStream,
+		TraceOutputState: gadgetv1alpha1.TraceStateStarted,
+		CommonFlags:      &params,
+		Parameters: map[string]string{
+			// All the checks are needed to know whether a capability was
+			// granted at least once.
+			capabilitiesTypes.AuditOnlyParam: strconv.FormatBool(false),
+			capabilitiesTypes.UniqueParam:    strconv.FormatBool(false),
+		},
+	}
+	return recordEvents(config)
+}
+
+// podWorkload returns the kind and name of the workload of a pod, i.e. its
+// highest controller.
+func podWorkload(client kubernetes.Interface, pod *corev1.Pod) (string, string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod", pod.Name
+	}
+
+	// Pods of Deployments and CronJobs are owned by a ReplicaSet and a Job,
+	// respectively.
+	switch owner.Kind {
+	case "ReplicaSet":
+		rs, err := client.AppsV1().ReplicaSets(pod.Namespace).Get(context.TODO(), owner.Name, metav1.GetOptions{})
+		if err != nil {
+			break
+		}
+		if rsOwner := metav1.GetControllerOf(rs); rsOwner != nil && rsOwner.Kind == "Deployment" {
+			return rsOwner.Kind, rsOwner.Name
+		}
+	case "Job":
+		job, err := client.BatchV1().Jobs(pod.Namespace).Get(context.TODO(), owner.Name, metav1.GetOptions{})
+		if err != nil {
+			break
+		}
+		if jobOwner := metav1.GetControllerOf(job); jobOwner != nil && jobOwner.Kind == "CronJob" {
+			return jobOwner.Kind, jobOwner.Name
+		}
+	}
+
+	return owner.Kind, owner.Name
+}
+
+// forEachContainer calls f with the name, the security context and the volume
+// mounts of the init, regular and ephemeral containers of a pod.
+func forEachContainer(pod *corev1.Pod, f func(name string, sc *corev1.SecurityContext, mounts []corev1.VolumeMount)) {
+	for _, c := range pod.Spec.InitContainers {
+		f(c.Name, c.SecurityContext, c.VolumeMounts)
+	}
+	for _, c := range pod.Spec.Containers {
+		f(c.Name, c.SecurityContext, c.VolumeMounts)
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		f(c.Name, c.SecurityContext, c.VolumeMounts)
+	}
+}
+
+// privilegedContainers returns the names of the containers of a pod running
+// privileged.
+func privilegedContainers(pod *corev1.Pod) map[string]bool {
+	privileged := map[string]bool{}
+	forEachContainer(pod, func(name string, sc *corev1.SecurityContext, _ []corev1.VolumeMount) {
+		if sc != nil && sc.Privileged != nil {
+			privileged[name] = *sc.Privileged
+		}
+	})
+	return privileged
+}
+
+// getPodFunc returns a function looking up the workload of the pods, their
+// privileged containers and their volume mounts in the cluster.
+func getPodFunc(client kubernetes.Interface) func(namespace, name string) *workload.Pod {
+	return func(namespace, name string) *workload.Pod {
+		pod, err := client.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			// The pod doesn't exist anymore, it's its own workload
+			return nil
+		}
+
+		p := &workload.Pod{
+			Privileged: privilegedContainers(pod),
+			MountPaths: map[string][]string{},
+		}
+		p.OwnerKind, p.OwnerName = podWorkload(client, pod)
+		forEachContainer(pod, func(name string, _ *corev1.SecurityContext, mounts []corev1.VolumeMount) {
+			for _, m := range mounts {
+				p.MountPaths[name] = append(p.MountPaths[name], m.MountPath)
+			}
+		})
+		return p
+	}
+}
+
+func runCapabilitiesReport(cmd *cobra.Command, args []string) error {
+	if inputFileName == "" {
+		return commonutils.WrapInErrMissingArgs("--input")
+	}
+
+	var format func(*advisor.CapabilitiesAdvisor) string
+	switch capabilitiesReportFormat {
+	case "yaml":
+		format = (*advisor.CapabilitiesAdvisor).FormatRecommendations
+	case "patch":
+		format = (*advisor.CapabilitiesAdvisor).FormatPatches
+	default:
+		return commonutils.WrapInErrInvalidArg("--format",
+			fmt.Errorf("%q is not valid, it should be yaml or patch", capabilitiesReportFormat))
+	}
+
+	adv := advisor.NewAdvisor()
+	err := adv.LoadFile(inputFileName)
+	if err != nil {
+		return err
+	}
+
+	client, err := k8sutil.NewClientsetFromConfigFlags(utils.KubernetesConfigFlags)
+	if err != nil {
+		return commonutils.WrapInErrSetupK8sClient(err)
+	}
+	adv.GetPod = getPodFunc(client)
+
+	adv.GenerateRecommendations()
+
+	w, closure, err := newWriter(outputFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q: %w", outputFileName, err)
+	}
+	defer closure()
+
+	_, err = w.Write([]byte(format(adv)))
+	if err != nil {
+		return fmt.Errorf("failed to write file %q: %w", outputFileName, err)
+	}
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush file %q: %w", outputFileName, err)
+	}
+
+	return nil
+}
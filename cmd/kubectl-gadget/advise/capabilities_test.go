@@ -0,0 +1,77 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advise
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+)
+
+func TestGetPodFunc(t *testing.T) {
+	privileged := true
+	unprivileged := false
+
+	client := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{
+				Name:            "setup",
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "data", MountPath: "/data"}},
+			}},
+			Containers: []corev1.Container{{
+				Name:            "nginx",
+				SecurityContext: &corev1.SecurityContext{Privileged: &unprivileged},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "data", MountPath: "/usr/share/nginx/html"}},
+			}},
+			EphemeralContainers: []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name:            "debugger",
+					SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+					VolumeMounts:    []corev1.VolumeMount{{Name: "data", MountPath: "/debug"}},
+				},
+			}},
+		},
+	})
+
+	expected := &workload.Pod{
+		OwnerKind: "Pod",
+		OwnerName: "web",
+		Privileged: map[string]bool{
+			"setup":    true,
+			"nginx":    false,
+			"debugger": true,
+		},
+		MountPaths: map[string][]string{
+			"setup":    {"/data"},
+			"nginx":    {"/usr/share/nginx/html"},
+			"debugger": {"/debug"},
+		},
+	}
+
+	getPod := getPodFunc(client)
+	if diff := cmp.Diff(expected, getPod("demo", "web")); diff != "" {
+		t.Fatalf("Unexpected pod (-want +got):\n%s", diff)
+	}
+	if pod := getPod("demo", "gone"); pod != nil {
+		t.Fatalf("Expected nil for an unknown pod, got %+v", pod)
+	}
+}
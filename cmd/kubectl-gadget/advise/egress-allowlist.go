@@ -0,0 +1,148 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advise
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/egressallowlist/advisor"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/k8sutil"
+)
+
+var egressAllowlistCmd = &cobra.Command{
+	Use:   "egress-allowlist",
+	Short: "Generate the list of external hosts contacted by the workloads",
+}
+
+var egressAllowlistMonitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Monitor the DNS queries, TLS server names and TCP connections",
+	Long: `Monitor the DNS queries and responses, the server names of the TLS handshakes
+and the TCP connections of the pods, until the command is interrupted.`,
+	RunE:         runEgressAllowlistMonitor,
+	SilenceUsage: true,
+}
+
+var egressAllowlistReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report the external hosts and ports contacted by each workload",
+	Long: `Report, per workload, the hosts and ports outside of the cluster that were
+contacted. The host names come from the server name of the TLS handshakes, or
+from the DNS responses matching the addresses connected to. The connections to
+addresses that don't match any name are reported as unresolved.`,
+	RunE:         runEgressAllowlistReport,
+	SilenceUsage: true,
+}
+
+var egressAllowlistReportFormat string
+
+func init() {
+	AdviseCmd.AddCommand(egressAllowlistCmd)
+	utils.AddCommonFlags(egressAllowlistCmd, &params)
+
+	egressAllowlistCmd.AddCommand(egressAllowlistMonitorCmd)
+	egressAllowlistMonitorCmd.PersistentFlags().StringVarP(&outputFileName, "output", "", "-", "File name output")
+
+	egressAllowlistCmd.AddCommand(egressAllowlistReportCmd)
+	egressAllowlistReportCmd.PersistentFlags().StringVarP(&inputFileName, "input", "", "", "File with recorded activity")
+	egressAllowlistReportCmd.PersistentFlags().StringVarP(&outputFileName, "output", "", "-", "File name output")
+	egressAllowlistReportCmd.PersistentFlags().StringVarP(&egressAllowlistReportFormat, "format", "", "yaml",
+		"Output format: yaml for the destinations with their evidence or list for host:port lines")
+}
+
+func runEgressAllowlistMonitor(cmd *cobra.Command, args []string) error {
+	w, closure, err := newWriter(outputFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q: %w", outputFileName, err)
+	}
+	defer closure()
+
+	streams := []*recordedStream{
+		{gadget: advisor.GadgetDNS, config: &utils.TraceConfig{
+			GadgetName: "dns",
+			Parameters: map[string]string{
+				"responses": "true",
+			},
+		}},
+		{gadget: advisor.GadgetSNI, config: &utils.TraceConfig{GadgetName: "snisnoop"}},
+		{gadget: advisor.GadgetTCPConnect, config: &utils.TraceConfig{GadgetName: "tcpconnect"}},
+	}
+	deleteStreams, err := createStreams(streams)
+	defer deleteStreams()
+	if err != nil {
+		return err
+	}
+
+	if err := recordStreams(streams, newRecorder(w)); err != nil {
+		return err
+	}
+	if outputFileName != "-" {
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func runEgressAllowlistReport(cmd *cobra.Command, args []string) error {
+	if inputFileName == "" {
+		return commonutils.WrapInErrMissingArgs("--input")
+	}
+
+	var format func(*advisor.EgressAllowlistAdvisor) string
+	switch egressAllowlistReportFormat {
+	case "yaml":
+		format = (*advisor.EgressAllowlistAdvisor).FormatAllowlists
+	case "list":
+		format = (*advisor.EgressAllowlistAdvisor).FormatList
+	default:
+		return commonutils.WrapInErrInvalidArg("--format",
+			fmt.Errorf("%q is not valid, it should be yaml or list", egressAllowlistReportFormat))
+	}
+
+	adv := advisor.NewAdvisor()
+	err := adv.LoadFile(inputFileName)
+	if err != nil {
+		return err
+	}
+
+	client, err := k8sutil.NewClientsetFromConfigFlags(utils.KubernetesConfigFlags)
+	if err != nil {
+		return commonutils.WrapInErrSetupK8sClient(err)
+	}
+	adv.GetPod = getPodFunc(client)
+
+	adv.GenerateAllowlists()
+
+	w, closure, err := newWriter(outputFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q: %w", outputFileName, err)
+	}
+	defer closure()
+
+	_, err = w.Write([]byte(format(adv)))
+	if err != nil {
+		return fmt.Errorf("failed to write file %q: %w", outputFileName, err)
+	}
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush file %q: %w", outputFileName, err)
+	}
+
+	return nil
+}
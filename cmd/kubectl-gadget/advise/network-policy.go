@@ -16,16 +16,21 @@ package advise
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 
 	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
 	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/networkpolicy/advisor"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/k8sutil"
 )
 
 var networkPolicyCmd = &cobra.Command{
@@ -42,12 +47,32 @@ var networkPolicyMonitorCmd = &cobra.Command{
 var networkPolicyReportCmd = &cobra.Command{
 	Use:   "report",
 	Short: "Report network policies",
-	RunE:  runNetworkPolicyReport,
+	Long: `Report the network policies allowing the recorded network activity.
+
+With --validate, the recorded network activity is instead evaluated against the
+existing network policies, loaded from the cluster or from the files given with
+--policies. The policies are loaded from all the namespaces with
+--all-namespaces, otherwise from the namespace given with --namespace and the
+ones of the recorded pods. The labels of the namespaces, used by the namespace
+selectors, are read from the cluster when it's reachable, even with --policies,
+otherwise only their kubernetes.io/metadata.name label is known. It reports the
+flows they would block, the policies that don't apply to any recorded traffic
+and the pods not selected by any policy.
+
+The policies are generated as Kubernetes NetworkPolicy by default. With
+--format, they can instead be generated for Cilium, using the DNS queries given
+with --dns-input to allow the connections by domain name, or for Calico.`,
+	RunE: runNetworkPolicyReport,
 }
 
 var (
 	inputFileName  string
 	outputFileName string
+
+	networkPolicyValidate     bool
+	networkPolicyFiles        []string
+	networkPolicyFormat       string
+	networkPolicyDNSInputFile string
 )
 
 func init() {
@@ -60,6 +85,14 @@ func init() {
 	networkPolicyCmd.AddCommand(networkPolicyReportCmd)
 	networkPolicyReportCmd.PersistentFlags().StringVarP(&inputFileName, "input", "", "", "File with recorded network activity")
 	networkPolicyReportCmd.PersistentFlags().StringVarP(&outputFileName, "output", "", "-", "File name output")
+	networkPolicyReportCmd.PersistentFlags().BoolVarP(&networkPolicyValidate, "validate", "", false,
+		"Validate the existing network policies against the recorded network activity")
+	networkPolicyReportCmd.PersistentFlags().StringSliceVarP(&networkPolicyFiles, "policies", "", []string{},
+		"Files with the network policies to validate, instead of the ones in the cluster")
+	networkPolicyReportCmd.PersistentFlags().StringVarP(&networkPolicyFormat, "format", "", "kubernetes",
+		"Format of the generated policies: kubernetes, cilium or calico")
+	networkPolicyReportCmd.PersistentFlags().StringVarP(&networkPolicyDNSInputFile, "dns-input", "", "",
+		"File with the DNS queries recorded with \"kubectl gadget trace dns -o json\", used by the cilium format")
 }
 
 func newWriter(file string) (*bufio.Writer, func(), error) {
@@ -81,12 +114,6 @@ func newWriter(file string) (*bufio.Writer, func(), error) {
 }
 
 func runNetworkPolicyMonitor(cmd *cobra.Command, args []string) error {
-	w, closure, err := newWriter(outputFileName)
-	if err != nil {
-		return fmt.Errorf("failed to create file %q: %w", outputFileName, err)
-	}
-	defer closure()
-
 	config := &utils.TraceConfig{
 		GadgetName:       "network-graph",
 		Operation:        gadgetv1alpha1.OperationStart,
@@ -94,6 +121,18 @@ func runNetworkPolicyMonitor(cmd *cobra.Command, args []string) error {
 		TraceOutputState: gadgetv1alpha1.TraceStateStarted,
 		CommonFlags:      &params,
 	}
+	return recordEvents(config)
+}
+
+// recordEvents runs the trace and writes the events it streams into the
+// output file.
+func recordEvents(config *utils.TraceConfig) error {
+	w, closure, err := newWriter(outputFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q: %w", outputFileName, err)
+	}
+	defer closure()
+
 	count := 0
 	transform := func(line string) string {
 		line = strings.Replace(line, "\r", "\n", -1)
@@ -119,13 +158,47 @@ func runNetworkPolicyReport(cmd *cobra.Command, args []string) error {
 		return commonutils.WrapInErrMissingArgs("--input")
 	}
 
+	var format func(*advisor.NetworkPolicyAdvisor) string
+	switch networkPolicyFormat {
+	case "kubernetes":
+		format = (*advisor.NetworkPolicyAdvisor).FormatPolicies
+	case "cilium":
+		format = (*advisor.NetworkPolicyAdvisor).FormatCiliumPolicies
+	case "calico":
+		format = (*advisor.NetworkPolicyAdvisor).FormatCalicoPolicies
+	default:
+		return commonutils.WrapInErrInvalidArg("--format",
+			fmt.Errorf("%q is not valid, it should be kubernetes, cilium or calico", networkPolicyFormat))
+	}
+
 	adv := advisor.NewAdvisor()
 	err := adv.LoadFile(inputFileName)
 	if err != nil {
 		return err
 	}
 
-	adv.GeneratePolicies()
+	if networkPolicyValidate {
+		if networkPolicyFormat != "kubernetes" {
+			return commonutils.WrapInErrArgsNotSupported("--format with --validate")
+		}
+		err = loadExistingPolicies(adv)
+		if err != nil {
+			return err
+		}
+		adv.ValidatePolicies()
+		format = (*advisor.NetworkPolicyAdvisor).FormatValidation
+	} else {
+		if len(networkPolicyFiles) != 0 {
+			return commonutils.WrapInErrArgsNotSupported("--policies without --validate")
+		}
+		if networkPolicyDNSInputFile != "" {
+			err = adv.LoadDNSFile(networkPolicyDNSInputFile)
+			if err != nil {
+				return err
+			}
+		}
+		adv.GeneratePolicies()
+	}
 
 	w, closure, err := newWriter(outputFileName)
 	if err != nil {
@@ -133,7 +206,7 @@ func runNetworkPolicyReport(cmd *cobra.Command, args []string) error {
 	}
 	defer closure()
 
-	_, err = w.Write([]byte(adv.FormatPolicies()))
+	_, err = w.Write([]byte(format(adv)))
 	if err != nil {
 		return fmt.Errorf("failed to write file %q: %w", outputFileName, err)
 	}
@@ -144,3 +217,84 @@ func runNetworkPolicyReport(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// loadExistingPolicies loads the network policies to validate from the files
+// given with --policies or, if there are none, from the cluster: from all the
+// namespaces with --all-namespaces, otherwise from the selected namespace and
+// the ones of the recorded pods.
+func loadExistingPolicies(adv *advisor.NetworkPolicyAdvisor) error {
+	if len(networkPolicyFiles) != 0 {
+		for _, file := range networkPolicyFiles {
+			err := adv.LoadPoliciesFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to load network policies from %q: %w", file, err)
+			}
+		}
+
+		// The namespace selectors still need the labels of the
+		// namespaces. Without a cluster, only the
+		// kubernetes.io/metadata.name label is known.
+		if client, err := k8sutil.NewClientsetFromConfigFlags(utils.KubernetesConfigFlags); err == nil {
+			adv.GetNamespaceLabels = getNamespaceLabelsFunc(client)
+		}
+		return nil
+	}
+
+	client, err := k8sutil.NewClientsetFromConfigFlags(utils.KubernetesConfigFlags)
+	if err != nil {
+		return commonutils.WrapInErrSetupK8sClient(err)
+	}
+
+	if params.AllNamespaces {
+		list, err := client.NetworkingV1().NetworkPolicies(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list network policies: %w", err)
+		}
+		adv.ExistingPolicies = append(adv.ExistingPolicies, list.Items...)
+		adv.GetNamespaceLabels = getNamespaceLabelsFunc(client)
+		return nil
+	}
+
+	namespaces := map[string]struct{}{
+		params.Namespace: {},
+	}
+	for _, e := range adv.Events {
+		if e.Namespace != "" {
+			namespaces[e.Namespace] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(namespaces))
+	for ns := range namespaces {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+
+	for _, ns := range names {
+		list, err := client.NetworkingV1().NetworkPolicies(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list network policies in namespace %q: %w", ns, err)
+		}
+		adv.ExistingPolicies = append(adv.ExistingPolicies, list.Items...)
+	}
+
+	adv.GetNamespaceLabels = getNamespaceLabelsFunc(client)
+	return nil
+}
+
+// getNamespaceLabelsFunc returns a function looking up the labels of the
+// namespaces in the cluster.
+func getNamespaceLabelsFunc(client kubernetes.Interface) func(namespace string) map[string]string {
+	cache := map[string]map[string]string{}
+	return func(namespace string) map[string]string {
+		if l, ok := cache[namespace]; ok {
+			return l
+		}
+		var l map[string]string
+		ns, err := client.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+		if err == nil {
+			l = ns.Labels
+		}
+		cache[namespace] = l
+		return l
+	}
+}
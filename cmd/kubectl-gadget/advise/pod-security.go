@@ -0,0 +1,327 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advise
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/podsecurity/advisor"
+	capabilitiesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/k8sutil"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var podSecurityCmd = &cobra.Command{
+	Use:   "pod-security",
+	Short: "Generate hardened security contexts based on the activity of containers",
+}
+
+var podSecurityMonitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Monitor the processes, file accesses, capabilities and syscalls",
+	Long: `Monitor the processes, file accesses and capability checks of the containers,
+until the command is interrupted. The syscalls of the containers running when
+the command starts are recorded too, except with --all-namespaces.`,
+	RunE:         runPodSecurityMonitor,
+	SilenceUsage: true,
+}
+
+var podSecurityReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report hardened security contexts",
+	Long: `Report the hardened security context of each container, per workload, with the
+evidence behind each recommendation:
+* capabilities: all dropped but the ones that were granted.
+* runAsNonRoot and allowPrivilegeEscalation: when no process ran as root.
+* readOnlyRootFilesystem: with an emptyDir volume on the directories written,
+  only when no image content was read in these directories.
+* seccompProfile: RuntimeDefault when it allows all the syscalls used.`,
+	RunE:         runPodSecurityReport,
+	SilenceUsage: true,
+}
+
+var podSecurityReportFormat string
+
+func init() {
+	AdviseCmd.AddCommand(podSecurityCmd)
+	utils.AddCommonFlags(podSecurityCmd, &params)
+
+	podSecurityCmd.AddCommand(podSecurityMonitorCmd)
+	podSecurityMonitorCmd.PersistentFlags().StringVarP(&outputFileName, "output", "", "-", "File name output")
+
+	podSecurityCmd.AddCommand(podSecurityReportCmd)
+	podSecurityReportCmd.PersistentFlags().StringVarP(&inputFileName, "input", "", "", "File with recorded activity")
+	podSecurityReportCmd.PersistentFlags().StringVarP(&outputFileName, "output", "", "-", "File name output")
+	podSecurityReportCmd.PersistentFlags().StringVarP(&podSecurityReportFormat, "format", "", "yaml",
+		"Output format: yaml for the security contexts or patch for kubectl commands patching the workloads")
+}
+
+// seccompTrace records the syscalls of a container.
+type seccompTrace struct {
+	traceID string
+	event   eventtypes.Event
+}
+
+// startSeccompTraces starts a seccomp trace for each container running and
+// matching the filters. The seccomp gadget can only generate a profile for a
+// given container, so it isn't done for all the namespaces.
+func startSeccompTraces(client kubernetes.Interface) ([]*seccompTrace, error) {
+	if params.AllNamespaces {
+		fmt.Fprintln(os.Stderr, "The syscalls aren't recorded with --all-namespaces")
+		return nil, nil
+	}
+
+	opts := metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(params.Labels).String(),
+	}
+	if params.Node != "" {
+		opts.FieldSelector = "spec.nodeName=" + params.Node
+	}
+	pods, err := client.CoreV1().Pods(params.Namespace).List(context.TODO(), opts)
+	if err != nil {
+		return nil, commonutils.WrapInErrListPods(err)
+	}
+
+	traces := []*seccompTrace{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || (params.Podname != "" && pod.Name != params.Podname) {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			if params.Containername != "" && c.Name != params.Containername {
+				continue
+			}
+
+			flags := utils.CommonFlags{
+				Node:          pod.Spec.NodeName,
+				Namespace:     pod.Namespace,
+				Podname:       pod.Name,
+				Containername: c.Name,
+			}
+			config := &utils.TraceConfig{
+				GadgetName:        "seccomp",
+				Operation:         gadgetv1alpha1.OperationStart,
+				TraceOutputMode:   gadgetv1alpha1.TraceOutputModeStatus,
+				TraceInitialState: gadgetv1alpha1.TraceStateStarted,
+				CommonFlags:       &flags,
+			}
+			traceID, err := utils.CreateTrace(config)
+			if err != nil {
+				deleteSeccompTraces(traces)
+				return nil, err
+			}
+
+			traces = append(traces, &seccompTrace{
+				traceID: traceID,
+				event: eventtypes.Event{
+					Type: eventtypes.NORMAL,
+					CommonData: eventtypes.CommonData{
+						Node:      pod.Spec.NodeName,
+						Namespace: pod.Namespace,
+						Pod:       pod.Name,
+						Container: c.Name,
+					},
+				},
+			})
+		}
+	}
+
+	return traces, nil
+}
+
+func deleteSeccompTraces(traces []*seccompTrace) {
+	for _, t := range traces {
+		utils.DeleteTrace(t.traceID)
+	}
+}
+
+// generateSeccompEvent returns the syscalls recorded by a seccomp trace.
+func generateSeccompEvent(t *seccompTrace) (*advisor.SeccompEvent, error) {
+	err := utils.SetTraceOperation(t.traceID, string(gadgetv1alpha1.OperationGenerate))
+	if err != nil {
+		return nil, commonutils.WrapInErrGenGadgetOutput(err)
+	}
+
+	// Stop the trace so its Status.State becomes Stopped once the profile
+	// is generated.
+	err = utils.SetTraceOperation(t.traceID, string(gadgetv1alpha1.OperationStop))
+	if err != nil {
+		return nil, commonutils.WrapInErrStopGadget(err)
+	}
+
+	event := &advisor.SeccompEvent{Event: t.event}
+	callback := func(traceOutputMode string, results []string) error {
+		syscalls := map[string]struct{}{}
+		for _, r := range results {
+			// The container wasn't found, e.g. it terminated
+			if r == "" {
+				continue
+			}
+			profile := &specs.LinuxSeccomp{}
+			if err := json.Unmarshal([]byte(r), profile); err != nil {
+				return commonutils.WrapInErrUnmarshalOutput(err, r)
+			}
+			for _, s := range profile.Syscalls {
+				if s.Action != specs.ActAllow {
+					continue
+				}
+				for _, name := range s.Names {
+					syscalls[name] = struct{}{}
+				}
+			}
+		}
+		for name := range syscalls {
+			event.Syscalls = append(event.Syscalls, name)
+		}
+		sort.Strings(event.Syscalls)
+		return nil
+	}
+
+	err = utils.PrintTraceOutputFromStatus(t.traceID, string(gadgetv1alpha1.TraceStateStopped), callback)
+	if err != nil {
+		return nil, commonutils.WrapInErrGetGadgetOutput(err)
+	}
+	if len(event.Syscalls) == 0 {
+		return nil, nil
+	}
+
+	return event, nil
+}
+
+func runPodSecurityMonitor(cmd *cobra.Command, args []string) error {
+	client, err := k8sutil.NewClientsetFromConfigFlags(utils.KubernetesConfigFlags)
+	if err != nil {
+		return commonutils.WrapInErrSetupK8sClient(err)
+	}
+
+	w, closure, err := newWriter(outputFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q: %w", outputFileName, err)
+	}
+	defer closure()
+
+	write := newRecorder(w)
+
+	streams := []*recordedStream{
+		{gadget: advisor.GadgetExec, config: &utils.TraceConfig{GadgetName: "execsnoop"}},
+		{gadget: advisor.GadgetOpen, config: &utils.TraceConfig{GadgetName: "opensnoop"}},
+		{gadget: advisor.GadgetCapabilities, config: &utils.TraceConfig{
+			GadgetName: "capabilities",
+			Parameters: map[string]string{
+				// All the checks are needed to know whether a
+				// capability was granted at least once.
+				capabilitiesTypes.AuditOnlyParam: strconv.FormatBool(false),
+				capabilitiesTypes.UniqueParam:    strconv.FormatBool(false),
+			},
+		}},
+	}
+	deleteStreams, err := createStreams(streams)
+	defer deleteStreams()
+	if err != nil {
+		return err
+	}
+
+	seccompTraces, err := startSeccompTraces(client)
+	if err != nil {
+		return commonutils.WrapInErrRunGadget(err)
+	}
+	defer deleteSeccompTraces(seccompTraces)
+
+	if err := recordStreams(streams, write); err != nil {
+		return err
+	}
+
+	for _, t := range seccompTraces {
+		event, err := generateSeccompEvent(t)
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			continue
+		}
+		b, err := json.Marshal(event)
+		if err != nil {
+			return commonutils.WrapInErrMarshalOutput(err)
+		}
+		write(advisor.GadgetSeccomp, b)
+	}
+	if outputFileName != "-" {
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func runPodSecurityReport(cmd *cobra.Command, args []string) error {
+	if inputFileName == "" {
+		return commonutils.WrapInErrMissingArgs("--input")
+	}
+
+	var format func(*advisor.PodSecurityAdvisor) string
+	switch podSecurityReportFormat {
+	case "yaml":
+		format = (*advisor.PodSecurityAdvisor).FormatRecommendations
+	case "patch":
+		format = (*advisor.PodSecurityAdvisor).FormatPatches
+	default:
+		return commonutils.WrapInErrInvalidArg("--format",
+			fmt.Errorf("%q is not valid, it should be yaml or patch", podSecurityReportFormat))
+	}
+
+	adv := advisor.NewAdvisor()
+	err := adv.LoadFile(inputFileName)
+	if err != nil {
+		return err
+	}
+
+	client, err := k8sutil.NewClientsetFromConfigFlags(utils.KubernetesConfigFlags)
+	if err != nil {
+		return commonutils.WrapInErrSetupK8sClient(err)
+	}
+	adv.GetPod = getPodFunc(client)
+
+	adv.GenerateRecommendations()
+
+	w, closure, err := newWriter(outputFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create file %q: %w", outputFileName, err)
+	}
+	defer closure()
+
+	_, err = w.Write([]byte(format(adv)))
+	if err != nil {
+		return fmt.Errorf("failed to write file %q: %w", outputFileName, err)
+	}
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush file %q: %w", outputFileName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advise
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+)
+
+// recordedStream is a trace whose events are recorded by a monitor command.
+type recordedStream struct {
+	gadget  string
+	traceID string
+	config  *utils.TraceConfig
+}
+
+// newRecorder returns a function writing the events of the gadgets as records
+// to w. It can be called concurrently.
+func newRecorder(w *bufio.Writer) func(gadget string, event []byte) {
+	var mu sync.Mutex
+	count := 0
+	return func(gadget string, event []byte) {
+		b, err := json.Marshal(workload.Record{Gadget: gadget, Event: event})
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Write(append(b, '\n'))
+		w.Flush()
+		count++
+		if outputFileName != "-" {
+			fmt.Printf("\033[2K\rRecording %d events into file %q...", count, outputFileName)
+		}
+	}
+}
+
+// createStreams creates the traces of the streams. The returned function
+// deletes them, it has to be called even when an error is returned.
+func createStreams(streams []*recordedStream) (func(), error) {
+	cleanup := func() {
+		for _, s := range streams {
+			if s.traceID != "" {
+				utils.DeleteTrace(s.traceID)
+			}
+		}
+	}
+
+	for _, s := range streams {
+		s.config.Operation = gadgetv1alpha1.OperationStart
+		s.config.TraceOutputMode = gadgetv1alpha1.TraceOutputModeStream
+		s.config.TraceOutputState = gadgetv1alpha1.TraceStateStarted
+		s.config.CommonFlags = &params
+
+		traceID, err := utils.CreateTrace(s.config)
+		if err != nil {
+			return cleanup, commonutils.WrapInErrRunGadget(err)
+		}
+		s.traceID = traceID
+	}
+
+	return cleanup, nil
+}
+
+// recordStreams records the events of the streams until the command is
+// interrupted or all the streams end.
+func recordStreams(streams []*recordedStream, write func(gadget string, event []byte)) error {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+
+	if outputFileName != "-" {
+		fmt.Printf("\033[2K\rRecording events into file %q...", outputFileName)
+	}
+
+	stop := make(chan struct{})
+	errs := make(chan error, len(streams))
+	var wg sync.WaitGroup
+	for _, s := range streams {
+		wg.Add(1)
+		go func(s *recordedStream) {
+			defer wg.Done()
+			callback := func(line string, node string) {
+				// Ignore the lines that aren't events, e.g. errors
+				if json.Valid([]byte(line)) {
+					write(s.gadget, []byte(line))
+				}
+			}
+			if err := utils.StreamTraceCallbackUntil(s.traceID, s.config, callback, stop); err != nil {
+				errs <- err
+			}
+		}(s)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-sig:
+	case <-done:
+	}
+	// Let a second signal terminate the command while the caller finishes
+	// its work, e.g. collects the syscalls.
+	signal.Stop(sig)
+	close(stop)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return commonutils.WrapInErrRunGadget(err)
+	default:
+	}
+
+	return nil
+}
@@ -16,15 +16,21 @@ package advise
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/spf13/cobra"
 
 	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
 	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -51,6 +57,13 @@ var seccompAdvisorStopCmd = &cobra.Command{
 	SilenceUsage: true,
 }
 
+var seccompAdvisorDiffCmd = &cobra.Command{
+	Use:          "diff <trace-id>",
+	Short:        "Compare the recorded syscalls with an existing seccomp profile",
+	RunE:         runSeccompAdvisorDiff,
+	SilenceUsage: true,
+}
+
 var seccompAdvisorListCmd = &cobra.Command{
 	Use:          "list",
 	Short:        "List existing seccomp traces",
@@ -61,6 +74,10 @@ var seccompAdvisorListCmd = &cobra.Command{
 var (
 	outputMode    string
 	profilePrefix string
+	aggregate     bool
+	syscallArgs   string
+	diffProfile   string
+	diffFile      string
 )
 
 func init() {
@@ -76,8 +93,21 @@ func init() {
 	seccompAdvisorStartCmd.PersistentFlags().StringVar(&profilePrefix,
 		"profile-prefix", "",
 		"Name prefix of the seccomp profile to be created when using --output-mode=seccomp-profile.\nNamespace can be specified by using namespace/profile-prefix.")
+	seccompAdvisorStartCmd.PersistentFlags().BoolVar(&aggregate,
+		"aggregate", false,
+		"Merge the syscalls of all the replicas of a workload, on all the nodes, in one seccomp profile per container name.\nRequires --output-mode=seccomp-profile.")
+	seccompAdvisorStartCmd.PersistentFlags().StringVar(&syscallArgs,
+		"syscall-args", "",
+		"Record the values of the given syscall arguments and restrict them in the profile, e.g. socket:0:1,clone:0.\nUse \"default\" for the arguments of clone, ioctl, personality, prctl, setns, socket, socketpair and unshare.")
 
 	seccompAdvisorCmd.AddCommand(seccompAdvisorStopCmd)
+	seccompAdvisorCmd.AddCommand(seccompAdvisorDiffCmd)
+	seccompAdvisorDiffCmd.PersistentFlags().StringVar(&diffProfile,
+		"profile", "",
+		"Name of the SeccompProfile to compare with. Namespace can be specified by using namespace/name.")
+	seccompAdvisorDiffCmd.PersistentFlags().StringVar(&diffFile,
+		"file", "",
+		"Path of an OCI seccomp profile, in JSON, to compare with.")
 	seccompAdvisorCmd.AddCommand(seccompAdvisorListCmd)
 }
 
@@ -95,7 +125,7 @@ func outputModeToTraceOutputMode(outputMode string) (gadgetv1alpha1.TraceOutputM
 // runSeccompAdvisorStart starts monitoring of syscalls for the given
 // parameters.
 func runSeccompAdvisorStart(cmd *cobra.Command, args []string) error {
-	if params.Podname == "" {
+	if params.Podname == "" && !aggregate {
 		return commonutils.WrapInErrMissingArgs("--podname")
 	}
 
@@ -104,10 +134,25 @@ func runSeccompAdvisorStart(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if aggregate && traceOutputMode != gadgetv1alpha1.TraceOutputModeExternalResource {
+		return errors.New("you can only use --aggregate with --output-mode seccomp-profile")
+	}
+
+	if aggregate && syscallArgs != "" {
+		return errors.New("you can't use --syscall-args with --aggregate")
+	}
+
 	if traceOutputMode != gadgetv1alpha1.TraceOutputModeExternalResource && profilePrefix != "" {
 		return errors.New("you can only use --profile-prefix with --output seccomp-profile")
 	}
 
+	parameters := map[string]string{
+		"aggregate": strconv.FormatBool(aggregate),
+	}
+	if syscallArgs != "" {
+		parameters["syscall-args"] = syscallArgs
+	}
+
 	config := &utils.TraceConfig{
 		GadgetName:        "seccomp",
 		Operation:         gadgetv1alpha1.OperationStart,
@@ -115,6 +160,7 @@ func runSeccompAdvisorStart(cmd *cobra.Command, args []string) error {
 		TraceOutput:       profilePrefix,
 		TraceInitialState: gadgetv1alpha1.TraceStateStarted,
 		CommonFlags:       &params,
+		Parameters:        parameters,
 	}
 
 	traceID, err := utils.CreateTrace(config)
@@ -232,6 +278,114 @@ func runSeccompAdvisorStop(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runSeccompAdvisorDiff compares the syscalls recorded by a running trace with
+// a seccomp profile.
+func runSeccompAdvisorDiff(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return commonutils.WrapInErrMissingArgs("<trace-id>")
+	}
+
+	traceID := args[0]
+
+	if (diffProfile == "") == (diffFile == "") {
+		return errors.New("you need to use one of --profile or --file")
+	}
+
+	// Both parameters are always set to override the ones of a previous
+	// diff.
+	parameters := map[string]string{
+		"diff-id":           strconv.FormatInt(time.Now().UnixNano(), 10),
+		"diff-profile":      "",
+		"diff-profile-json": "",
+	}
+
+	if diffProfile != "" {
+		if !strings.Contains(diffProfile, "/") {
+			parameters["diff-profile"] = params.Namespace + "/" + diffProfile
+		} else {
+			parameters["diff-profile"] = diffProfile
+		}
+	} else {
+		profile, err := os.ReadFile(diffFile)
+		if err != nil {
+			return commonutils.WrapInErrInvalidArg("--file", err)
+		}
+		if err := json.Unmarshal(profile, &specs.LinuxSeccomp{}); err != nil {
+			return commonutils.WrapInErrInvalidArg("--file", err)
+		}
+		parameters["diff-profile-json"] = string(profile)
+	}
+
+	err := utils.SetTraceOperationWithParameters(traceID, string(gadgetv1alpha1.OperationDiff), parameters)
+	if err != nil {
+		return commonutils.WrapInErrGenGadgetOutput(err)
+	}
+
+	// Wait for the output of this diff, not the one of a previous operation.
+	condition := func(trace *gadgetv1alpha1.Trace) bool {
+		var diff types.ProfileDiff
+		if err := json.Unmarshal([]byte(trace.Status.Output), &diff); err != nil {
+			return false
+		}
+		return diff.ID == parameters["diff-id"]
+	}
+
+	callback := func(traceOutputMode string, results []string) error {
+		diffs := []*types.ProfileDiff{}
+		for _, r := range results {
+			diff := &types.ProfileDiff{}
+			if err := json.Unmarshal([]byte(r), diff); err != nil {
+				return commonutils.WrapInErrUnmarshalOutput(err, r)
+			}
+			// The container isn't on this node
+			if diff.Container == "" {
+				continue
+			}
+			if diff.Profile == "" {
+				diff.Profile = diffFile
+			}
+			diffs = append(diffs, diff)
+		}
+
+		if len(diffs) == 0 {
+			return errors.New("the traced container wasn't found")
+		}
+
+		if params.OutputMode == commonutils.OutputModeJSON {
+			for _, diff := range diffs {
+				b, err := json.Marshal(diff)
+				if err != nil {
+					return commonutils.WrapInErrMarshalOutput(err)
+				}
+				fmt.Println(string(b))
+			}
+			return nil
+		}
+
+		for _, diff := range diffs {
+			fmt.Printf("Container %s/%s/%s on node %s compared with %s\n",
+				diff.Namespace, diff.Pod, diff.Container, diff.Node, diff.Profile)
+			fmt.Printf("Used but not allowed (%d):\n", len(diff.UsedNotAllowed))
+			for _, name := range diff.UsedNotAllowed {
+				fmt.Printf("  %s\n", name)
+			}
+			fmt.Printf("Allowed but not used (%d):\n", len(diff.AllowedNotUsed))
+			for _, name := range diff.AllowedNotUsed {
+				fmt.Printf("  %s\n", name)
+			}
+		}
+
+		return nil
+	}
+
+	err = utils.PrintTraceOutputFromStatusCondition(traceID, condition, callback)
+	if err != nil {
+		return commonutils.WrapInErrGetGadgetOutput(err)
+	}
+
+	return nil
+}
+
 // runSeccompAdvisorList lists already running traces which config was given as
 // parameter.
 func runSeccompAdvisorList(cmd *cobra.Command, args []string) error {
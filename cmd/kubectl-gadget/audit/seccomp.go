@@ -40,7 +40,7 @@ func newSeccompCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			parser := commonaudit.NewSeccompK8sParser(&commonFlags.OutputConfig)
 
-			if commonFlags.OutputMode != commonutils.OutputModeJSON {
+			if commonFlags.IsColumnsOutputMode() {
 				fmt.Println(parser.BuildColumnsHeader())
 			}
 
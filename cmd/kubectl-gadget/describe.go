@@ -0,0 +1,127 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/describe"
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	biotopTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/block-io/types"
+	ebpftopTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/ebpf/types"
+	filetopTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/file/types"
+	tcptopTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/tcp/types"
+	udptopTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/udp/types"
+	bindTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/bind/types"
+	capabilitiesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	dnsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	dropsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/types"
+	execTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	fsslowerTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/fsslower/types"
+	mountTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/mount/types"
+	oomkillTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/oomkill/types"
+	openTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	packetsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/types"
+	signalTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/signal/types"
+	tcpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcp/types"
+	tcpconnectTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpconnect/types"
+	tcplifeTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcplife/types"
+	tcpretransTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpretrans/types"
+)
+
+// histogramOutputModes are the output modes of the gadgets printing
+// histograms, they can't be printed with templates.
+var histogramOutputModes = []string{
+	commonutils.OutputModeColumns,
+	commonutils.OutputModeJSON,
+}
+
+// describedGadgets contains the schemas of the gadgets using the columns
+// library, using the same columns as NewGadgetParserWithK8sInfo, and the
+// output modes of the gadgets not supporting all the common ones.
+var describedGadgets = map[string]describe.Gadget{
+	// The monitor commands of the advisors record the events as they are
+	// and their report commands have their own formats.
+	"advise/capabilities":        {OutputModes: []string{}},
+	"advise/egress-allowlist":    {OutputModes: []string{}},
+	"advise/network-policy":      {OutputModes: []string{}},
+	"advise/pod-security":        {OutputModes: []string{}},
+	"advise/seccomp-profile":     {OutputModes: []string{commonutils.OutputModeColumns, commonutils.OutputModeJSON}},
+	"profile/block-io":           {OutputModes: histogramOutputModes},
+	"profile/tcpconnect-latency": {OutputModes: histogramOutputModes},
+	"top/block-io": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(biotopTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"top/ebpf": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(ebpftopTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"top/file": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(filetopTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"top/tcp": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcptopTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"top/udp": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(udptopTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/bind": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(bindTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/capabilities": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(capabilitiesTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/dns": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(dnsTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/drops": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(dropsTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/exec": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(execTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/fsslower": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(fsslowerTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/mount": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(mountTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/oomkill": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(oomkillTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/open": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(openTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/packets": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(packetsTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/signal": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(signalTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/tcp": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcpTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/tcpconnect": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcpconnectTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/tcplife": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcplifeTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+	"trace/tcpretrans": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcpretransTypes.GetColumns(), commonutils.KubernetesTag)
+	}},
+}
+
+func init() {
+	rootCmd.AddCommand(describe.NewDescribeCmd(describedGadgets))
+}
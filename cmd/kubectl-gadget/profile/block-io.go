@@ -50,6 +50,12 @@ func newBlockIOCmd() *cobra.Command {
 				return commonutils.WrapInErrMissingArgs("--node")
 			}
 
+			// The histogram is printed as a whole, templates are not
+			// supported.
+			if commonFlags.IsTemplateOutputMode() {
+				return commonutils.WrapInErrOutputModeNotSupported(commonFlags.OutputMode)
+			}
+
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -69,7 +69,7 @@ func (g *ProfileGadget) Run() error {
 		}()
 	}
 
-	if g.commonFlags.OutputMode != commonutils.OutputModeJSON {
+	if g.commonFlags.IsColumnsOutputMode() {
 		if g.commonFlags.Timeout != 0 {
 			fmt.Printf(g.inProgressMsg + "...")
 		} else {
@@ -79,7 +79,7 @@ func (g *ProfileGadget) Run() error {
 
 	<-c
 
-	if g.commonFlags.OutputMode != commonutils.OutputModeJSON {
+	if g.commonFlags.IsColumnsOutputMode() {
 		// Trick to have ^C on the same line than above message, so the gadget
 		// output begins on a "clean" line.
 		fmt.Println()
@@ -107,6 +107,7 @@ func NewProfileCmd() *cobra.Command {
 
 	cmd.AddCommand(newBlockIOCmd())
 	cmd.AddCommand(newCPUCmd())
+	cmd.AddCommand(newTCPConnectLatencyCmd())
 
 	return cmd
 }
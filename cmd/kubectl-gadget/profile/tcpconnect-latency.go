@@ -0,0 +1,135 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	biolatencytypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/block-io/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/tcpconnect-latency/types"
+)
+
+type TCPConnectLatencyParser struct {
+	outputConfig *commonutils.OutputConfig
+}
+
+func newTCPConnectLatencyCmd() *cobra.Command {
+	var commonFlags utils.CommonFlags
+
+	cmd := &cobra.Command{
+		Use:          "tcpconnect-latency",
+		Short:        "Analyze the latency of TCP connections through distributions per destination and per pod",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// The histograms are printed as a whole, templates are not
+			// supported.
+			if commonFlags.IsTemplateOutputMode() {
+				return commonutils.WrapInErrOutputModeNotSupported(commonFlags.OutputMode)
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tcpconnectLatencyGadget := &ProfileGadget{
+				gadgetName:    "tcpconnlat",
+				commonFlags:   &commonFlags,
+				inProgressMsg: "Tracing TCP connections",
+				parser: &TCPConnectLatencyParser{
+					outputConfig: &commonFlags.OutputConfig,
+				},
+			}
+
+			return tcpconnectLatencyGadget.Run()
+		},
+	}
+
+	utils.AddCommonFlags(cmd, &commonFlags)
+
+	return cmd
+}
+
+// mergeData adds the counts of the src histogram to the dst one.
+func mergeData(dst, src []biolatencytypes.Data) []biolatencytypes.Data {
+	for i, data := range src {
+		if i < len(dst) {
+			dst[i].Count += data.Count
+		} else {
+			dst = append(dst, data)
+		}
+	}
+	return dst
+}
+
+func (p *TCPConnectLatencyParser) DisplayResultsCallback(traceOutputMode string, results []string) error {
+	if p.outputConfig.OutputMode == commonutils.OutputModeJSON {
+		for _, r := range results {
+			fmt.Println(r)
+		}
+		return nil
+	}
+
+	// The destinations can be reached from several nodes, merge their
+	// histograms. Pods are only on a node.
+	destinations := map[string][]biolatencytypes.Data{}
+	pods := map[string][]biolatencytypes.Data{}
+	valType := ""
+
+	for _, r := range results {
+		var report types.Report
+		if err := json.Unmarshal([]byte(r), &report); err != nil {
+			return commonutils.WrapInErrUnmarshalOutput(err, r)
+		}
+
+		valType = report.ValType
+		for _, h := range report.Destinations {
+			key := net.JoinHostPort(h.Daddr, strconv.Itoa(int(h.Dport)))
+			destinations[key] = mergeData(destinations[key], h.Data)
+		}
+		for _, h := range report.Pods {
+			key := h.Namespace + "/" + h.Pod
+			pods[key] = mergeData(pods[key], h.Data)
+		}
+	}
+
+	printHistograms := func(title string, histograms map[string][]biolatencytypes.Data) {
+		keys := make([]string, 0, len(histograms))
+		for key := range histograms {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Printf("\n%s %s\n", title, key)
+			fmt.Print(reportToString(biolatencytypes.Report{
+				ValType: valType,
+				Data:    histograms[key],
+			}))
+		}
+	}
+
+	printHistograms("Destination", destinations)
+	printHistograms("Pod", pods)
+
+	return nil
+}
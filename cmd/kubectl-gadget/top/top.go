@@ -15,18 +15,68 @@
 package top
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
-
-	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	commontop "github.com/inspektor-gadget/inspektor-gadget/cmd/common/top"
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 )
 
 type CommonTopFlags struct {
 	OutputInterval int
 	MaxRows        int
 	SortBy         string
+	Filters        []string
+	Interactive    bool
+
+	ParsedSortBy []string
+}
+
+// TopGadget represents a gadget belonging to the top category.
+type TopGadget[Stats any] struct {
+	sync.Mutex
+
+	name           string
+	commonTopFlags *CommonTopFlags
+	commonFlags    *utils.CommonFlags
+	params         map[string]string
+	parser         *commonutils.GadgetParser[Stats]
+
+	// setNode, if set, is used to fill in the node the stats were received
+	// from, for gadgets that don't do it on their own.
+	setNode func(stats *Stats, node string)
+
+	// drillDown lists the columns used to drill down into the stats in the
+	// interactive mode, see commontop.Config.
+	drillDown [][]string
+	tui       *commontop.TUI[Stats]
+
+	nodeStats map[string][]*Stats
+}
+
+// interactiveMaxRows is the number of rows requested to each node in the
+// interactive mode when --max-rows isn't given, as the user can scroll.
+const interactiveMaxRows = 1000
+
+// podDrillDown allows drilling down into pods and then containers.
+var podDrillDown = [][]string{{"namespace", "pod"}, {"container"}}
+
+// topEvent is the information generated by the top gadgets each interval.
+type topEvent[Stats any] struct {
+	Error string   `json:"error,omitempty"`
+	Stats []*Stats `json:"stats,omitempty"`
 }
 
 func NewTopCmd() *cobra.Command {
@@ -39,6 +89,7 @@ func NewTopCmd() *cobra.Command {
 	cmd.AddCommand(newEbpfCmd())
 	cmd.AddCommand(newFileCmd())
 	cmd.AddCommand(newTCPCmd())
+	cmd.AddCommand(newUDPCmd())
 
 	return cmd
 }
@@ -48,10 +99,290 @@ func addCommonTopFlags(
 	commonTopFlags *CommonTopFlags,
 	commonFlags *utils.CommonFlags,
 	defaultMaxRows int,
-	sortBySlice []string,
+	defaultSortBy []string,
 ) {
 	command.Flags().IntVarP(&commonTopFlags.MaxRows, "max-rows", "r", defaultMaxRows, "Maximum rows to print")
-	command.Flags().StringVarP(&commonTopFlags.SortBy, "sort", "", sortBySlice[0], fmt.Sprintf("Sort column (%s)", strings.Join(sortBySlice, ", ")))
+	command.Flags().StringVarP(&commonTopFlags.SortBy, "sort", "", strings.Join(defaultSortBy, gadgettop.SortBySeparator),
+		"Comma-separated list of columns to sort by, prefix a column with - to sort in descending order")
+	command.Flags().StringArrayVarP(&commonTopFlags.Filters, "filter", "", []string{},
+		"Show only the stats matching the given filter, e.g. comm:nginx (can be repeated)")
+	command.Flags().BoolVarP(&commonTopFlags.Interactive, "interactive", "", false,
+		"Show the stats in an interactive terminal UI")
 
 	utils.AddCommonFlags(command, commonFlags)
 }
+
+// parseCommonTopFlags parses the interval given as argument and validates the
+// sort and filter flags against the columns of the gadget.
+func parseCommonTopFlags[Stats any](
+	cmd *cobra.Command,
+	commonTopFlags *CommonTopFlags,
+	commonFlags *utils.CommonFlags,
+	args []string,
+	defaultInterval int,
+	cols columns.ColumnMap[Stats],
+	sortByAliases map[string][]string,
+) error {
+	var err error
+
+	if len(args) == 1 {
+		commonTopFlags.OutputInterval, err = strconv.Atoi(args[0])
+		if err != nil {
+			return commonutils.WrapInErrInvalidArg("<interval>",
+				fmt.Errorf("%q is not a valid value", args[0]))
+		}
+	} else {
+		commonTopFlags.OutputInterval = defaultInterval
+	}
+
+	commonTopFlags.ParsedSortBy, err = gadgettop.ParseSortBy(cols, commonTopFlags.SortBy, sortByAliases)
+	if err != nil {
+		return commonutils.WrapInErrInvalidArg("--sort", err)
+	}
+	// Send the columns a deprecated key stands for to the gadget
+	commonTopFlags.SortBy = strings.Join(commonTopFlags.ParsedSortBy, gadgettop.SortBySeparator)
+
+	for _, f := range commonTopFlags.Filters {
+		if strings.Contains(f, gadgettop.FiltersSeparator) {
+			return commonutils.WrapInErrInvalidArg("--filter",
+				fmt.Errorf("%q can't contain %q, use --filter multiple times instead", f, gadgettop.FiltersSeparator))
+		}
+		if _, err := gadgettop.ParseFilters(cols, f); err != nil {
+			return commonutils.WrapInErrInvalidArg("--filter", err)
+		}
+	}
+
+	if commonTopFlags.Interactive {
+		if !commonFlags.IsColumnsOutputMode() {
+			return commonutils.WrapInErrInvalidArg("--interactive",
+				fmt.Errorf("only supported with the columns and custom-columns output modes"))
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			return commonutils.WrapInErrInvalidArg("--interactive",
+				fmt.Errorf("stdin and stdout must be terminals"))
+		}
+		if !cmd.Flags().Changed("max-rows") {
+			commonTopFlags.MaxRows = interactiveMaxRows
+		}
+	}
+
+	return nil
+}
+
+// filtersParam returns the value of the filter parameter of the top gadgets.
+func (f *CommonTopFlags) filtersParam() string {
+	return strings.Join(f.Filters, gadgettop.FiltersSeparator)
+}
+
+// Run runs a TopGadget and periodically prints the stats received from all
+// the nodes.
+func (g *TopGadget[Stats]) Run() error {
+	g.nodeStats = make(map[string][]*Stats)
+
+	config := &utils.TraceConfig{
+		GadgetName:       g.name,
+		Operation:        gadgetv1alpha1.OperationStart,
+		TraceOutputMode:  gadgetv1alpha1.TraceOutputModeStream,
+		TraceOutputState: gadgetv1alpha1.TraceStateStarted,
+		CommonFlags:      g.commonFlags,
+		Parameters:       g.params,
+	}
+
+	if g.commonTopFlags.Interactive {
+		return g.runInteractive(config)
+	}
+
+	// when params.Timeout == interval it means the user
+	// only wants to run for a given amount of time and print
+	// that result.
+	singleShot := g.commonFlags.Timeout == g.commonTopFlags.OutputInterval
+
+	// start print loop if this is not a "single shot" operation
+	if singleShot {
+		g.PrintHeader()
+	} else {
+		g.StartPrintLoop()
+	}
+
+	if err := utils.RunTraceStreamCallback(config, g.Callback); err != nil {
+		return commonutils.WrapInErrRunGadget(err)
+	}
+
+	if singleShot {
+		g.PrintStats()
+	}
+
+	return nil
+}
+
+// runInteractive runs the gadget showing the stats in a terminal UI until the
+// user quits it.
+func (g *TopGadget[Stats]) runInteractive(config *utils.TraceConfig) error {
+	g.tui = commontop.New(&commontop.Config[Stats]{
+		Title:        fmt.Sprintf("kubectl gadget top %s", strings.TrimSuffix(g.name, "top")),
+		Columns:      g.parser.GetColumnMap(),
+		ShownColumns: g.commonFlags.CustomColumns,
+		SortBy:       g.commonTopFlags.ParsedSortBy,
+		DrillDown:    g.drillDown,
+	})
+
+	// Don't leave the terminal in raw mode if a signal makes us exit
+	utils.SetSignalExitHook(g.tui.Restore)
+	defer utils.SetSignalExitHook(nil)
+
+	stop := make(chan struct{})
+	tuiErr := make(chan error, 1)
+	go func() {
+		tuiErr <- g.tui.Run()
+		close(stop)
+	}()
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(g.commonTopFlags.OutputInterval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				g.tui.Update(g.mergeStats())
+			}
+		}
+	}()
+
+	err := utils.RunTraceStreamCallbackUntil(config, g.Callback, stop)
+
+	// Keep showing the last stats if the gadget stopped on its own
+	select {
+	case <-stop:
+	default:
+		if err != nil {
+			g.tui.SetMessage(fmt.Sprintf("Error: %s, press q to quit", err))
+		} else {
+			g.tui.SetMessage("The gadget stopped, press q to quit")
+		}
+		<-stop
+	}
+
+	if err := <-tuiErr; err != nil {
+		return err
+	}
+	if err != nil {
+		return commonutils.WrapInErrRunGadget(err)
+	}
+
+	return nil
+}
+
+func (g *TopGadget[Stats]) Callback(line string, node string) {
+	g.Lock()
+	defer g.Unlock()
+
+	var event topEvent[Stats]
+
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		g.printError(fmt.Sprintf("Error: %s", commonutils.WrapInErrUnmarshalOutput(err, line)))
+		return
+	}
+
+	if event.Error != "" {
+		g.printError(fmt.Sprintf("Error: failed on node %q: %s", node, event.Error))
+		return
+	}
+
+	g.nodeStats[node] = event.Stats
+}
+
+// printError prints msg to stderr or, in the interactive mode, shows it in the
+// terminal UI to avoid messing up the screen.
+func (g *TopGadget[Stats]) printError(msg string) {
+	if g.tui != nil {
+		g.tui.SetMessage(msg)
+		return
+	}
+	fmt.Fprint(os.Stderr, msg)
+}
+
+func (g *TopGadget[Stats]) StartPrintLoop() {
+	go func() {
+		ticker := time.NewTicker(time.Duration(g.commonTopFlags.OutputInterval) * time.Second)
+		g.PrintHeader()
+		for {
+			_ = <-ticker.C
+			g.PrintHeader()
+			g.PrintStats()
+		}
+	}()
+}
+
+func (g *TopGadget[Stats]) PrintHeader() {
+	if !g.commonFlags.IsColumnsOutputMode() {
+		return
+	}
+
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		utils.ClearScreen()
+	} else {
+		fmt.Println("")
+	}
+
+	fmt.Println(g.parser.BuildColumnsHeader())
+}
+
+// mergeStats returns the stats received from all the nodes since the last
+// call.
+func (g *TopGadget[Stats]) mergeStats() []*Stats {
+	g.Lock()
+	defer g.Unlock()
+
+	stats := []*Stats{}
+	for node, stat := range g.nodeStats {
+		if g.setNode != nil {
+			for _, s := range stat {
+				g.setNode(s, node)
+			}
+		}
+		stats = append(stats, stat...)
+	}
+	g.nodeStats = make(map[string][]*Stats)
+
+	return stats
+}
+
+func (g *TopGadget[Stats]) PrintStats() {
+	stats := g.mergeStats()
+
+	// Each node already sent its stats filtered and sorted, but they need to
+	// be sorted again once merged.
+	g.parser.Sort(stats, g.commonTopFlags.ParsedSortBy)
+
+	for idx, stat := range stats {
+		if idx == g.commonTopFlags.MaxRows {
+			break
+		}
+		fmt.Println(g.transformStats(stat))
+	}
+}
+
+func (g *TopGadget[Stats]) transformStats(stats *Stats) string {
+	switch g.commonFlags.OutputMode {
+	case commonutils.OutputModeJSON:
+		b, err := json.Marshal(stats)
+		if err != nil {
+			fmt.Fprint(os.Stderr, fmt.Sprint(commonutils.WrapInErrMarshalOutput(err)))
+			return ""
+		}
+
+		return string(b)
+	case commonutils.OutputModeColumns:
+		fallthrough
+	case commonutils.OutputModeCustomColumns:
+		return g.parser.TransformIntoColumns(stats)
+	case commonutils.OutputModeGoTemplate:
+		fallthrough
+	case commonutils.OutputModeJSONPath:
+		return g.commonFlags.TransformIntoTemplate(stats)
+	}
+
+	return ""
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package top
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/udp/types"
+)
+
+func newUDPCmd() *cobra.Command {
+	var commonTopFlags CommonTopFlags
+	var commonFlags utils.CommonFlags
+	var filteredPid uint
+	var family uint
+
+	cols := types.GetColumns()
+
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("udp [interval=%d]", types.IntervalDefault),
+		Short: "Periodically report UDP activity",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parser, err := commonutils.NewGadgetParserWithK8sInfo(&commonFlags.OutputConfig, cols)
+			if err != nil {
+				return commonutils.WrapInErrParserCreate(err)
+			}
+
+			parameters := map[string]string{
+				types.IntervalParam: strconv.Itoa(commonTopFlags.OutputInterval),
+				types.MaxRowsParam:  strconv.Itoa(commonTopFlags.MaxRows),
+				types.SortByParam:   commonTopFlags.SortBy,
+				types.FilterParam:   commonTopFlags.filtersParam(),
+			}
+
+			if family != 0 {
+				parameters[types.FamilyParam] = strconv.FormatUint(uint64(family), 10)
+			}
+
+			if filteredPid != 0 {
+				parameters[types.PidParam] = strconv.FormatUint(uint64(filteredPid), 10)
+			}
+
+			gadget := &TopGadget[types.Stats]{
+				name:           "udptop",
+				commonTopFlags: &commonTopFlags,
+				commonFlags:    &commonFlags,
+				params:         parameters,
+				parser:         parser,
+				drillDown:      podDrillDown,
+			}
+
+			return gadget.Run()
+		},
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return parseCommonTopFlags(cmd, &commonTopFlags, &commonFlags, args, types.IntervalDefault, cols.GetColumnMap(), nil)
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	addCommonTopFlags(cmd, &commonTopFlags, &commonFlags, types.MaxRowsDefault, types.SortByDefault)
+
+	cmd.PersistentFlags().UintVarP(
+		&filteredPid,
+		"pid",
+		"",
+		0,
+		"Show only UDP events generated by this particular PID",
+	)
+	cmd.PersistentFlags().UintVarP(
+		&family,
+		"family",
+		"f",
+		0,
+		"Show only UDP events for this IP version: either 4 or 6 (by default all will be printed)",
+	)
+
+	return cmd
+}
@@ -15,6 +15,8 @@
 package trace
 
 import (
+	"strconv"
+
 	"github.com/spf13/cobra"
 
 	commontrace "github.com/inspektor-gadget/inspektor-gadget/cmd/common/trace"
@@ -25,9 +27,13 @@ import (
 
 func newDNSCmd() *cobra.Command {
 	var commonFlags utils.CommonFlags
+	var flags commontrace.DNSFlags
 
 	runCmd := func(cmd *cobra.Command, args []string) error {
-		parser, err := commonutils.NewGadgetParserWithK8sInfo(&commonFlags.OutputConfig, dnsTypes.GetColumns())
+		parser, err := commonutils.NewGadgetParserWithK8sInfo(
+			&commonFlags.OutputConfig,
+			commontrace.GetDNSColumns(&flags),
+		)
 		if err != nil {
 			return commonutils.WrapInErrParserCreate(err)
 		}
@@ -36,12 +42,15 @@ func newDNSCmd() *cobra.Command {
 			name:        "dns",
 			commonFlags: &commonFlags,
 			parser:      parser,
+			params: map[string]string{
+				"responses": strconv.FormatBool(flags.CaptureResponses),
+			},
 		}
 
 		return execGadget.Run()
 	}
 
-	cmd := commontrace.NewDNSCmd(runCmd)
+	cmd := commontrace.NewDNSCmd(runCmd, &flags)
 
 	utils.AddCommonFlags(cmd, &commonFlags)
 
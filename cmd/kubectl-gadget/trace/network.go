@@ -75,6 +75,8 @@ func NewNetworkParser(outputConfig *commonutils.OutputConfig) commontrace.TraceP
 		"proto":     -6,
 		"port":      -7,
 		"remote":    -30,
+		"packets":   -8,
+		"bytes":     -10,
 	}
 
 	return &NetworkParser{
@@ -118,6 +120,10 @@ func (p *NetworkParser) TransformIntoColumns(event *types.Event) string {
 			sb.WriteString(fmt.Sprintf("%*d", p.ColumnsWidth[col], event.Port))
 		case "remote":
 			sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], remote))
+		case "packets":
+			sb.WriteString(fmt.Sprintf("%*d", p.ColumnsWidth[col], event.Packets))
+		case "bytes":
+			sb.WriteString(fmt.Sprintf("%*d", p.ColumnsWidth[col], event.Bytes))
 		default:
 			continue
 		}
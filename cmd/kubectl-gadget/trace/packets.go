@@ -0,0 +1,221 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/filter"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/pcapng"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type packetsFlags struct {
+	filter  string
+	snapLen uint32
+	write   string
+}
+
+func newPacketsCmd() *cobra.Command {
+	var commonFlags utils.CommonFlags
+	var flags packetsFlags
+
+	cmd := &cobra.Command{
+		Use:   "packets [filter expression]",
+		Short: "Capture the packets of pods",
+		Long: `Capture the packets of pods.
+
+The filter expression supports a subset of the tcpdump syntax: the host, net,
+port and portrange primitives with the ip, ip6, src and dst qualifiers, the
+ip, ip6, arp, tcp, udp, sctp, icmp and icmp6 protocols, greater, less and the
+and, or and not operators.
+
+With --write, the packets are written in the pcapng format, which can be
+opened in Wireshark or tcpdump. Pass "-" to write them to the standard output:
+
+  $ kubectl gadget trace packets -p mypod -w - | wireshark -k -i -`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if flags.filter != "" {
+					return commonutils.WrapInErrInvalidArg("filter",
+						fmt.Errorf("it's given both as argument and with --filter"))
+				}
+				flags.filter = strings.Join(args, " ")
+			}
+
+			if err := filter.Validate(flags.filter); err != nil {
+				return commonutils.WrapInErrInvalidArg("--filter", err)
+			}
+			if flags.snapLen == 0 {
+				return commonutils.WrapInErrInvalidArg("--snaplen",
+					fmt.Errorf("it must be greater than 0"))
+			}
+
+			params := map[string]string{
+				types.FilterParam:  flags.filter,
+				types.SnapLenParam: strconv.FormatUint(uint64(flags.snapLen), 10),
+			}
+
+			if flags.write != "" {
+				return runPacketsWrite(&commonFlags, params, &flags)
+			}
+
+			parser, err := commonutils.NewGadgetParserWithK8sInfo(&commonFlags.OutputConfig, types.GetColumns())
+			if err != nil {
+				return commonutils.WrapInErrParserCreate(err)
+			}
+
+			packetsGadget := &TraceGadget[types.Event]{
+				name:        "packets",
+				commonFlags: &commonFlags,
+				parser:      parser,
+				params:      params,
+			}
+
+			return packetsGadget.Run()
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&flags.filter,
+		"filter",
+		"",
+		"",
+		"Capture only the packets matching this filter expression",
+	)
+	cmd.PersistentFlags().Uint32VarP(
+		&flags.snapLen,
+		"snaplen",
+		"s",
+		types.SnapLenDefault,
+		"Number of bytes captured per packet",
+	)
+	cmd.PersistentFlags().StringVarP(
+		&flags.write,
+		"write",
+		"w",
+		"",
+		`Write the packets in the pcapng format to this file, or to the standard output with "-"`,
+	)
+
+	utils.AddCommonFlags(cmd, &commonFlags)
+
+	return cmd
+}
+
+// runPacketsWrite writes the packets captured on all nodes to a single pcapng
+// file. Each interface of each pod gets its own interface description block,
+// whose description tells where the packets come from.
+func runPacketsWrite(commonFlags *utils.CommonFlags, params map[string]string, flags *packetsFlags) error {
+	var out io.Writer = os.Stdout
+	if flags.write != "-" {
+		f, err := os.Create(flags.write)
+		if err != nil {
+			return commonutils.WrapInErrGenGadgetOutput(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w, err := pcapng.NewWriter(out, "Inspektor Gadget")
+	if err != nil {
+		return commonutils.WrapInErrGenGadgetOutput(err)
+	}
+
+	var mu sync.Mutex
+	interfaces := make(map[string]int)
+
+	callback := func(line string, node string) {
+		var e types.Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", commonutils.WrapInErrUnmarshalOutput(err, line))
+			return
+		}
+
+		if e.Type != eventtypes.NORMAL {
+			commonutils.ManageSpecialEvent(e.Event, commonFlags.Verbose)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		ifaceKey := fmt.Sprintf("%s/%s/%s/%s", e.Node, e.Namespace, e.Pod, e.Interface)
+		id, ok := interfaces[ifaceKey]
+		if !ok {
+			description := fmt.Sprintf("node %s, pod %s/%s", e.Node, e.Namespace, e.Pod)
+			if e.Container != "" {
+				description += fmt.Sprintf(", container %s", e.Container)
+			}
+
+			id, err = w.AddInterface(pcapng.Interface{
+				Name:        e.Interface,
+				Description: description,
+				LinkType:    pcapng.LinkTypeEthernet,
+				SnapLen:     flags.snapLen,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", commonutils.WrapInErrGenGadgetOutput(err))
+				return
+			}
+			interfaces[ifaceKey] = id
+		}
+
+		direction := pcapng.DirectionInbound
+		if e.PktType == "OUTGOING" {
+			direction = pcapng.DirectionOutbound
+		}
+
+		err := w.WritePacket(pcapng.Packet{
+			Interface: id,
+			Timestamp: time.Unix(0, e.Timestamp),
+			Direction: direction,
+			Data:      e.Data,
+			Length:    int(e.Len),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", commonutils.WrapInErrGenGadgetOutput(err))
+		}
+	}
+
+	config := &utils.TraceConfig{
+		GadgetName:       "packets",
+		Operation:        gadgetv1alpha1.OperationStart,
+		TraceOutputMode:  gadgetv1alpha1.TraceOutputModeStream,
+		TraceOutputState: gadgetv1alpha1.TraceStateStarted,
+		CommonFlags:      commonFlags,
+		Parameters:       params,
+	}
+
+	if err := utils.RunTraceStreamCallback(config, callback); err != nil {
+		return commonutils.WrapInErrRunGadget(err)
+	}
+
+	return nil
+}
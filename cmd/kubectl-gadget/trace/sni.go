@@ -40,6 +40,9 @@ func newSNICmd() *cobra.Command {
 				"namespace",
 				"pod",
 				"name",
+				"tls",
+				"daddr",
+				"dport",
 			},
 		},
 	}
@@ -65,10 +68,18 @@ func newSNICmd() *cobra.Command {
 
 func NewSNIParser(outputConfig *commonutils.OutputConfig) commontrace.TraceParser[types.Event] {
 	columnsWidth := map[string]int{
-		"node":      -16,
-		"namespace": -16,
-		"pod":       -30,
-		"name":      -24,
+		"node":       -16,
+		"namespace":  -16,
+		"pod":        -30,
+		"name":       -24,
+		"tls":        -7,
+		"alpn":       -16,
+		"ciphers":    -7,
+		"ip":         -2,
+		"daddr":      -16,
+		"dport":      -7,
+		"remotekind": -10,
+		"remotename": -24,
 	}
 
 	return &SNIParser{
@@ -89,6 +100,22 @@ func (p *SNIParser) TransformIntoColumns(event *types.Event) string {
 			sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], event.Pod))
 		case "name":
 			sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], event.Name))
+		case "tls":
+			sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], event.Version))
+		case "alpn":
+			sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], strings.Join(event.ALPN, ",")))
+		case "ciphers":
+			sb.WriteString(fmt.Sprintf("%*d", p.ColumnsWidth[col], event.CipherCount))
+		case "ip":
+			sb.WriteString(fmt.Sprintf("%*d", p.ColumnsWidth[col], event.IPVersion))
+		case "daddr":
+			sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], event.Daddr))
+		case "dport":
+			sb.WriteString(fmt.Sprintf("%*d", p.ColumnsWidth[col], event.Dport))
+		case "remotekind":
+			sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], event.RemoteKind))
+		case "remotename":
+			sb.WriteString(fmt.Sprintf("%*s", p.ColumnsWidth[col], event.RemoteName))
 		default:
 			continue
 		}
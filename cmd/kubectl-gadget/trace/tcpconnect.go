@@ -15,6 +15,8 @@
 package trace
 
 import (
+	"strconv"
+
 	"github.com/spf13/cobra"
 
 	commontrace "github.com/inspektor-gadget/inspektor-gadget/cmd/common/trace"
@@ -25,11 +27,12 @@ import (
 
 func newTcpconnectCmd() *cobra.Command {
 	var commonFlags utils.CommonFlags
+	var flags commontrace.TcpconnectFlags
 
 	runCmd := func(*cobra.Command, []string) error {
 		parser, err := commonutils.NewGadgetParserWithK8sInfo(
 			&commonFlags.OutputConfig,
-			tcpconnectTypes.GetColumns(),
+			commontrace.GetTcpconnectColumns(&flags),
 		)
 		if err != nil {
 			return commonutils.WrapInErrParserCreate(err)
@@ -39,12 +42,16 @@ func newTcpconnectCmd() *cobra.Command {
 			name:        "tcpconnect",
 			commonFlags: &commonFlags,
 			parser:      parser,
+			params: map[string]string{
+				"latency":     strconv.FormatBool(flags.CalculateLatency),
+				"latency-min": flags.MinLatency.String(),
+			},
 		}
 
 		return tcpconnectGadget.Run()
 	}
 
-	cmd := commontrace.NewTcpconnectCmd(runCmd)
+	cmd := commontrace.NewTcpconnectCmd(runCmd, &flags)
 
 	utils.AddCommonFlags(cmd, &commonFlags)
 
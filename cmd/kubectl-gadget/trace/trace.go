@@ -49,12 +49,7 @@ func (g *TraceGadget[Event]) Run() error {
 	}
 
 	// Print header
-	switch g.commonFlags.OutputMode {
-	case commonutils.OutputModeJSON:
-		// Nothing to print
-	case commonutils.OutputModeColumns:
-		fallthrough
-	case commonutils.OutputModeCustomColumns:
+	if g.commonFlags.IsColumnsOutputMode() {
 		fmt.Println(g.parser.BuildColumnsHeader())
 	}
 
@@ -85,6 +80,10 @@ func (g *TraceGadget[Event]) Run() error {
 			fallthrough
 		case commonutils.OutputModeCustomColumns:
 			return g.parser.TransformIntoColumns(&e)
+		case commonutils.OutputModeGoTemplate:
+			fallthrough
+		case commonutils.OutputModeJSONPath:
+			return g.commonFlags.TransformIntoTemplate(&e)
 		default:
 			fmt.Fprint(os.Stderr, commonutils.WrapInErrOutputModeNotSupported(g.commonFlags.OutputMode))
 			return ""
@@ -104,16 +103,20 @@ func NewTraceCmd() *cobra.Command {
 	traceCmd.AddCommand(newBindCmd())
 	traceCmd.AddCommand(newCapabilitiesCmd())
 	traceCmd.AddCommand(newDNSCmd())
+	traceCmd.AddCommand(newDropsCmd())
 	traceCmd.AddCommand(newExecCmd())
 	traceCmd.AddCommand(newFsSlowerCmd())
 	traceCmd.AddCommand(newMountCmd())
 	traceCmd.AddCommand(newNetworkCmd())
 	traceCmd.AddCommand(newOOMKillCmd())
 	traceCmd.AddCommand(newOpenCmd())
+	traceCmd.AddCommand(newPacketsCmd())
 	traceCmd.AddCommand(newSignalCmd())
 	traceCmd.AddCommand(newSNICmd())
 	traceCmd.AddCommand(newTCPCmd())
 	traceCmd.AddCommand(newTcpconnectCmd())
+	traceCmd.AddCommand(newTcplifeCmd())
+	traceCmd.AddCommand(newTcpretransCmd())
 
 	return traceCmd
 }
@@ -190,6 +190,7 @@ func AddCommonFlags(command *cobra.Command, params *CommonFlags) {
 		commonutils.OutputModeColumns,
 		fmt.Sprintf("Output format (%s).", strings.Join(commonutils.SupportedOutputModes, ", ")),
 	)
+	command.PersistentFlags().SetAnnotation("output", commonutils.OutputModesAnnotation, commonutils.SupportedOutputModes)
 
 	command.PersistentFlags().BoolVarP(
 		&params.Verbose,
@@ -24,6 +24,7 @@ import (
 	"os/signal"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"text/tabwriter"
@@ -287,8 +288,9 @@ func createTraces(trace *gadgetv1alpha1.Trace) error {
 }
 
 // updateTraceOperation updates operation for an already existing trace using
-// Kubernetes REST API.
-func updateTraceOperation(trace *gadgetv1alpha1.Trace, operation string) error {
+// Kubernetes REST API. The given parameters, if any, are merged with the
+// existing ones.
+func updateTraceOperation(trace *gadgetv1alpha1.Trace, operation string, parameters map[string]string) error {
 	traceClient, err := getTraceClient()
 	if err != nil {
 		return err
@@ -302,8 +304,12 @@ func updateTraceOperation(trace *gadgetv1alpha1.Trace, operation string) error {
 	type ObjectMeta struct {
 		Annotations Annotations `json:"annotations"`
 	}
+	type Spec struct {
+		Parameters map[string]string `json:"parameters,omitempty"`
+	}
 	type JSONMergePatch struct {
 		ObjectMeta ObjectMeta `json:"metadata"`
+		Spec       *Spec      `json:"spec,omitempty"`
 	}
 	patch := JSONMergePatch{
 		ObjectMeta: ObjectMeta{
@@ -312,6 +318,9 @@ func updateTraceOperation(trace *gadgetv1alpha1.Trace, operation string) error {
 			},
 		},
 	}
+	if len(parameters) != 0 {
+		patch.Spec = &Spec{Parameters: parameters}
+	}
 
 	patchBytes, err := json.Marshal(patch)
 	if err != nil {
@@ -443,6 +452,12 @@ func getTraceListFromID(traceID string) (*gadgetv1alpha1.TraceList, error) {
 // SetTraceOperation sets the operation of an existing trace.
 // If trace does not exist an error is returned.
 func SetTraceOperation(traceID string, operation string) error {
+	return SetTraceOperationWithParameters(traceID, operation, nil)
+}
+
+// SetTraceOperationWithParameters is like SetTraceOperation but also sets
+// some parameters of the trace, for the operation to use them.
+func SetTraceOperationWithParameters(traceID string, operation string, parameters map[string]string) error {
 	// We have to wait for the previous operation to start before changing the
 	// trace operation.
 	// The trace controller deletes the GADGET_OPERATION field from Annotations
@@ -457,7 +472,7 @@ func SetTraceOperation(traceID string, operation string) error {
 	}
 
 	for _, trace := range traces.Items {
-		localError := updateTraceOperation(&trace, operation)
+		localError := updateTraceOperation(&trace, operation, parameters)
 		if localError != nil {
 			err = fmt.Errorf("%w\nError updating trace operation for %q: %s", err, traceID, localError)
 		}
@@ -696,6 +711,31 @@ func waitForNoOperation(traceID string) (*gadgetv1alpha1.TraceList, error) {
 
 var sigIntReceivedNumber = 0
 
+var (
+	signalExitHookMu sync.Mutex
+	signalExitHook   func()
+)
+
+// SetSignalExitHook sets a function that is called before exiting because of
+// a signal, e.g. to restore the terminal. Passing nil removes it.
+func SetSignalExitHook(hook func()) {
+	signalExitHookMu.Lock()
+	defer signalExitHookMu.Unlock()
+
+	signalExitHook = hook
+}
+
+func runSignalExitHook() {
+	signalExitHookMu.Lock()
+	hook := signalExitHook
+	signalExitHook = nil
+	signalExitHookMu.Unlock()
+
+	if hook != nil {
+		hook()
+	}
+}
+
 // sigHandler installs a handler for all signals which cause termination as
 // their default behavior.
 // On reception of this signal, the given trace will be deleted.
@@ -707,6 +747,8 @@ func sigHandler(traceID *string, printTerminationMessage bool) {
 	go func() {
 		sig := <-c
 
+		runSignalExitHook()
+
 		// This code is here in case DeleteTrace() hangs.
 		// In this case, we install again this handler and if SIGINT is received
 		// another time (thus getting it twice) we exit the whole program without
@@ -747,7 +789,7 @@ func PrintTraceOutputFromStream(traceID string, expectedState string, params *Co
 		return err
 	}
 
-	return genericStreams(params, traces, nil, transformLine)
+	return genericStreams(params, traces, nil, transformLine, nil)
 }
 
 // PrintTraceOutputFromStatus is used to print trace output using function
@@ -763,6 +805,29 @@ func PrintTraceOutputFromStatus(
 		return err
 	}
 
+	return printTraceOutput(traces, customResultsDisplay)
+}
+
+// PrintTraceOutputFromStatusCondition is like PrintTraceOutputFromStatus but
+// waits for the traces to satisfy conditionFunction instead of being in a
+// given state.
+func PrintTraceOutputFromStatusCondition(
+	traceID string,
+	conditionFunction func(*gadgetv1alpha1.Trace) bool,
+	customResultsDisplay func(traceOutputMode string, results []string) error,
+) error {
+	traces, err := waitForCondition(traceID, conditionFunction)
+	if err != nil {
+		return err
+	}
+
+	return printTraceOutput(traces, customResultsDisplay)
+}
+
+func printTraceOutput(
+	traces *gadgetv1alpha1.TraceList,
+	customResultsDisplay func(traceOutputMode string, results []string) error,
+) error {
 	results := make([]string, len(traces.Items))
 	traceOutputMode := string(gadgetv1alpha1.TraceOutputModeStatus)
 	for i, trace := range traces.Items {
@@ -905,7 +970,7 @@ func PrintAllTraces(config *TraceConfig) error {
 func RunTraceAndPrintStream(config *TraceConfig, transformLine func(string) string) error {
 	var traceID string
 
-	sigHandler(&traceID, config.CommonFlags.OutputMode != commonutils.OutputModeJSON)
+	sigHandler(&traceID, config.CommonFlags.IsColumnsOutputMode())
 
 	if config.TraceOutputMode != gadgetv1alpha1.TraceOutputModeStream {
 		return errors.New("TraceOutputMode must be Stream. Otherwise, call RunTraceAndPrintStatusOutput")
@@ -924,6 +989,16 @@ func RunTraceAndPrintStream(config *TraceConfig, transformLine func(string) stri
 // RunTraceStreamCallback creates a stream trace and calls callback each
 // time one of the tracers produces a new line on any of the nodes.
 func RunTraceStreamCallback(config *TraceConfig, callback func(line string, node string)) error {
+	return RunTraceStreamCallbackUntil(config, callback, nil)
+}
+
+// RunTraceStreamCallbackUntil is like RunTraceStreamCallback but it also
+// stops and deletes the trace once stop is closed.
+func RunTraceStreamCallbackUntil(
+	config *TraceConfig,
+	callback func(line string, node string),
+	stop <-chan struct{},
+) error {
 	var traceID string
 
 	sigHandler(&traceID, false)
@@ -939,12 +1014,25 @@ func RunTraceStreamCallback(config *TraceConfig, callback func(line string, node
 
 	defer DeleteTrace(traceID)
 
+	return StreamTraceCallbackUntil(traceID, config, callback, stop)
+}
+
+// StreamTraceCallbackUntil calls callback each time one of the tracers of an
+// already created trace produces a new line, until stop is closed. Contrary to
+// RunTraceStreamCallbackUntil, it doesn't handle the signals nor delete the
+// trace, which allows the caller to stream several traces at once.
+func StreamTraceCallbackUntil(
+	traceID string,
+	config *TraceConfig,
+	callback func(line string, node string),
+	stop <-chan struct{},
+) error {
 	traces, err := waitForTraceState(traceID, string(config.TraceOutputState))
 	if err != nil {
 		return err
 	}
 
-	return genericStreams(config.CommonFlags, traces, callback, nil)
+	return genericStreams(config.CommonFlags, traces, callback, nil, stop)
 }
 
 // RunTraceAndPrintStatusOutput creates a trace, prints its output and deletes
@@ -980,6 +1068,7 @@ func genericStreams(
 	results *gadgetv1alpha1.TraceList,
 	callback func(line string, node string),
 	transform func(line string) string,
+	stop <-chan struct{},
 ) error {
 	completion := make(chan string)
 
@@ -989,8 +1078,8 @@ func genericStreams(
 	}
 
 	verbose := false
-	// verbose only when not json is used
-	if params.Verbose && params.OutputMode != commonutils.OutputModeJSON {
+	// verbose only when the output is printed in columns
+	if params.Verbose && params.IsColumnsOutputMode() {
 		verbose = true
 	}
 
@@ -1043,6 +1132,8 @@ func genericStreams(
 			}
 		case <-exit:
 			return nil
+		case <-stop:
+			return nil
 		}
 	}
 }
@@ -0,0 +1,168 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advise
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/local-gadget/utils"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/advise/seccomp"
+	seccomptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+	localgadgetmanager "github.com/inspektor-gadget/inspektor-gadget/pkg/local-gadget-manager"
+)
+
+func newSeccompProfileCmd() *cobra.Command {
+	var commonFlags utils.CommonFlags
+	var outputFile string
+
+	cmd := &cobra.Command{
+		Use:   "seccomp-profile",
+		Short: "Generate a seccomp profile based on the syscalls of a container",
+		Long: `Record the syscalls of the container given by --containername until it
+terminates or the command is interrupted, then generate a seccomp profile
+allowing them. The profile uses the OCI format understood by Docker, containerd
+and runc. The container can be started after the command to record all its
+syscalls. Only the containers of Docker, containerd and CRI-O are supported,
+not the ones of Podman.`,
+		RunE: func(*cobra.Command, []string) error {
+			if commonFlags.Containername == "" {
+				return commonutils.WrapInErrMissingArgs("--containername")
+			}
+
+			return runSeccompProfile(&commonFlags, outputFile)
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&outputFile,
+		"output-file", "",
+		"Write the seccomp profile in this file instead of printing it")
+
+	utils.AddCommonFlags(cmd, &commonFlags)
+
+	return cmd
+}
+
+func runSeccompProfile(commonFlags *utils.CommonFlags, outputFile string) error {
+	localGadgetManager, err := localgadgetmanager.NewManager(commonFlags.RuntimeConfigs)
+	if err != nil {
+		return commonutils.WrapInErrManagerInit(err)
+	}
+	defer localGadgetManager.Close()
+
+	tracer, err := seccomptracer.NewTracer()
+	if err != nil {
+		return commonutils.WrapInErrGadgetTracerCreateAndRun(err)
+	}
+	defer tracer.Close()
+
+	containerSelector := containercollection.ContainerSelector{
+		Name: commonFlags.Containername,
+	}
+
+	// mntns is the mount namespace of the recorded container. Only the first
+	// container matching the selector is recorded.
+	var mu sync.Mutex
+	var mntns uint64
+	terminated := make(chan []byte, 1)
+
+	record := func(container *containercollection.Container, running bool) {
+		if mntns != 0 {
+			return
+		}
+		mntns = container.Mntns
+		if running {
+			fmt.Fprintf(os.Stderr, "Warning: container %s is already running, the syscalls it made "+
+				"before are not recorded and could be missing from the profile\n", container.Name)
+		}
+		fmt.Fprintf(os.Stderr, "Recording the syscalls of container %s, stop with Ctrl-C\n", container.Name)
+	}
+
+	key := "local-gadget/advise/seccomp-profile"
+	containers := localGadgetManager.Subscribe(key, containerSelector, func(event containercollection.PubSubEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch event.Type {
+		case containercollection.EventTypeAddContainer:
+			record(event.Container, false)
+		case containercollection.EventTypeRemoveContainer:
+			if event.Container.Mntns != mntns {
+				return
+			}
+			// The syscalls need to be read before the tracer is closed.
+			select {
+			case terminated <- tracer.Peek(mntns):
+			default:
+			}
+		}
+	})
+	defer localGadgetManager.Unsubscribe(key)
+
+	mu.Lock()
+	if len(containers) > 1 {
+		mu.Unlock()
+		return commonutils.WrapInErrInvalidArg("--containername",
+			fmt.Errorf("%d containers are named %q", len(containers), commonFlags.Containername))
+	}
+	for _, container := range containers {
+		record(container, true)
+	}
+	if mntns == 0 {
+		fmt.Fprintf(os.Stderr, "Waiting for container %s to start\n", commonFlags.Containername)
+	}
+	mu.Unlock()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	var b []byte
+	select {
+	case b = <-terminated:
+	case <-stop:
+		mu.Lock()
+		defer mu.Unlock()
+		if mntns == 0 {
+			return fmt.Errorf("container %q didn't start", commonFlags.Containername)
+		}
+		b = tracer.Peek(mntns)
+	}
+
+	profile, err := json.MarshalIndent(seccomp.SyscallArrToLinuxSeccomp(b, nil), "", "  ")
+	if err != nil {
+		return commonutils.WrapInErrMarshalOutput(err)
+	}
+
+	if outputFile == "" {
+		fmt.Println(string(profile))
+		return nil
+	}
+
+	if err := os.WriteFile(outputFile, append(profile, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing seccomp profile: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Seccomp profile written to %s\n", outputFile)
+
+	return nil
+}
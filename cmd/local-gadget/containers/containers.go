@@ -62,6 +62,12 @@ func NewListContainersCmd() *cobra.Command {
 				fallthrough
 			case commonutils.OutputModeCustomColumns:
 				fmt.Println(parser.TransformIntoTable(containers))
+			case commonutils.OutputModeGoTemplate:
+				fallthrough
+			case commonutils.OutputModeJSONPath:
+				for _, c := range containers {
+					fmt.Println(commonFlags.TransformIntoTemplate(c))
+				}
 			default:
 				return commonutils.WrapInErrOutputModeNotSupported(commonFlags.OutputMode)
 			}
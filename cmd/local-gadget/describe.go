@@ -0,0 +1,71 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/describe"
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	bindTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/bind/types"
+	capabilitiesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	dropsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/types"
+	execTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	oomkillTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/oomkill/types"
+	tcpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcp/types"
+	tcpconnectTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpconnect/types"
+	tcplifeTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcplife/types"
+	tcpretransTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpretrans/types"
+)
+
+// describedGadgets contains the schemas of the gadgets using the columns
+// library, using the same columns as NewGadgetParserWithRuntimeInfo, and the
+// output modes of the gadgets not supporting all the common ones.
+var describedGadgets = map[string]describe.Gadget{
+	// The profile is always written in JSON
+	"advise/seccomp-profile": {OutputModes: []string{commonutils.OutputModeJSON}},
+	"trace/bind": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(bindTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+	"trace/capabilities": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(capabilitiesTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+	"trace/drops": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(dropsTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+	"trace/exec": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(execTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+	"trace/oomkill": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(oomkillTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+	"trace/tcp": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcpTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+	"trace/tcpconnect": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcpconnectTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+	"trace/tcplife": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcplifeTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+	"trace/tcpretrans": {Schema: func() *columns.Schema {
+		return commonutils.NewGadgetSchema(tcpretransTypes.GetColumns(), commonutils.ContainerRuntimeTag)
+	}},
+}
+
+func newDescribeCmd() *cobra.Command {
+	return describe.NewDescribeCmd(describedGadgets)
+}
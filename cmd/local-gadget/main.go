@@ -19,6 +19,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/local-gadget/advise"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/local-gadget/containers"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/local-gadget/interactive"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/local-gadget/snapshot"
@@ -35,7 +36,9 @@ func main() {
 		interactive.NewInteractiveCmd(),
 		containers.NewListContainersCmd(),
 		snapshot.NewSnapshotCmd(),
+		advise.NewAdviseCmd(),
 		trace.NewTraceCmd(),
+		newDescribeCmd(),
 		newVersionCmd(),
 	)
 
@@ -0,0 +1,57 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"github.com/spf13/cobra"
+
+	commontrace "github.com/inspektor-gadget/inspektor-gadget/cmd/common/trace"
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/local-gadget/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	dropsTracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/tracer"
+	dropsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/types"
+)
+
+func newDropsCmd() *cobra.Command {
+	var commonFlags utils.CommonFlags
+
+	runCmd := func(*cobra.Command, []string) error {
+		parser, err := commonutils.NewGadgetParserWithRuntimeInfo(
+			&commonFlags.OutputConfig,
+			dropsTypes.GetColumns(),
+		)
+		if err != nil {
+			return commonutils.WrapInErrParserCreate(err)
+		}
+
+		dropsGadget := &TraceGadget[dropsTypes.Event]{
+			commonFlags: &commonFlags,
+			parser:      parser,
+			createAndRunNetNsTracer: func(enricher gadgets.NetNsEnricher, eventCallback func(dropsTypes.Event)) (trace.NetNsTracer, error) {
+				return dropsTracer.NewTracer(&dropsTracer.Config{FilterByNetNs: true}, enricher, eventCallback)
+			},
+		}
+
+		return dropsGadget.Run()
+	}
+
+	cmd := commontrace.NewDropsCmd(runCmd)
+
+	utils.AddCommonFlags(cmd, &commonFlags)
+
+	return cmd
+}
@@ -29,11 +29,12 @@ import (
 
 func newTcpconnectCmd() *cobra.Command {
 	var commonFlags utils.CommonFlags
+	var flags commontrace.TcpconnectFlags
 
 	runCmd := func(*cobra.Command, []string) error {
 		parser, err := commonutils.NewGadgetParserWithRuntimeInfo(
 			&commonFlags.OutputConfig,
-			tcpconnectTypes.GetColumns(),
+			commontrace.GetTcpconnectColumns(&flags),
 		)
 		if err != nil {
 			return commonutils.WrapInErrParserCreate(err)
@@ -43,14 +44,19 @@ func newTcpconnectCmd() *cobra.Command {
 			commonFlags: &commonFlags,
 			parser:      parser,
 			createAndRunTracer: func(mountnsmap *ebpf.Map, enricher gadgets.DataEnricher, eventCallback func(tcpconnectTypes.Event)) (trace.Tracer, error) {
-				return tcpconnectTracer.NewTracer(&tcpconnectTracer.Config{MountnsMap: mountnsmap}, enricher, eventCallback)
+				config := &tcpconnectTracer.Config{
+					MountnsMap:       mountnsmap,
+					CalculateLatency: flags.CalculateLatency,
+					MinLatency:       flags.MinLatency,
+				}
+				return tcpconnectTracer.NewTracer(config, enricher, eventCallback)
 			},
 		}
 
 		return tcpconnectGadget.Run()
 	}
 
-	cmd := commontrace.NewTcpconnectCmd(runCmd)
+	cmd := commontrace.NewTcpconnectCmd(runCmd, &flags)
 
 	utils.AddCommonFlags(cmd, &commonFlags)
 
@@ -28,6 +28,7 @@ import (
 	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/local-gadget/utils"
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	localgadgetmanager "github.com/inspektor-gadget/inspektor-gadget/pkg/local-gadget-manager"
@@ -39,6 +40,10 @@ type TraceGadget[Event commontrace.TraceEvent] struct {
 	commonFlags        *utils.CommonFlags
 	parser             commontrace.TraceParser[Event]
 	createAndRunTracer func(*ebpf.Map, gadgets.DataEnricher, func(Event)) (trace.Tracer, error)
+
+	// createAndRunNetNsTracer is used instead of createAndRunTracer by the
+	// gadgets that filter the events by network namespace.
+	createAndRunNetNsTracer func(gadgets.NetNsEnricher, func(Event)) (trace.NetNsTracer, error)
 }
 
 // Run runs a TraceGadget and prints the output after parsing it using the
@@ -63,7 +68,7 @@ func (g *TraceGadget[Event]) Run() error {
 	}
 	defer localGadgetManager.RemoveMountNsMap()
 
-	if g.commonFlags.OutputMode != commonutils.OutputModeJSON {
+	if g.commonFlags.IsColumnsOutputMode() {
 		fmt.Println(g.parser.BuildColumnsHeader())
 	}
 
@@ -88,16 +93,32 @@ func (g *TraceGadget[Event]) Run() error {
 			fallthrough
 		case commonutils.OutputModeCustomColumns:
 			fmt.Println(g.parser.TransformIntoColumns(&event))
+		case commonutils.OutputModeGoTemplate:
+			fallthrough
+		case commonutils.OutputModeJSONPath:
+			fmt.Println(g.commonFlags.TransformIntoTemplate(&event))
 		default:
 			fmt.Fprint(os.Stderr, commonutils.WrapInErrOutputModeNotSupported(g.commonFlags.OutputMode))
 		}
 	}
 
-	gadgetTracer, err := g.createAndRunTracer(mountnsmap, &localGadgetManager.ContainerCollection, eventCallback)
-	if err != nil {
-		return commonutils.WrapInErrGadgetTracerCreateAndRun(err)
+	if g.createAndRunNetNsTracer != nil {
+		gadgetTracer, err := g.createAndRunNetNsTracer(&localGadgetManager.ContainerCollection, eventCallback)
+		if err != nil {
+			return commonutils.WrapInErrGadgetTracerCreateAndRun(err)
+		}
+		defer gadgetTracer.Stop()
+
+		key := "local-gadget/trace"
+		g.attachNetNsTracer(gadgetTracer, key, localGadgetManager, containerSelector)
+		defer localGadgetManager.Unsubscribe(key)
+	} else {
+		gadgetTracer, err := g.createAndRunTracer(mountnsmap, &localGadgetManager.ContainerCollection, eventCallback)
+		if err != nil {
+			return commonutils.WrapInErrGadgetTracerCreateAndRun(err)
+		}
+		defer gadgetTracer.Stop()
 	}
-	defer gadgetTracer.Stop()
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
@@ -106,15 +127,62 @@ func (g *TraceGadget[Event]) Run() error {
 	return nil
 }
 
+// attachNetNsTracer attaches the tracer to the network namespace of the
+// containers matching the selector, including the ones created afterwards. The
+// host network namespace is skipped.
+func (g *TraceGadget[Event]) attachNetNsTracer(
+	tracer trace.NetNsTracer,
+	key string,
+	localGadgetManager *localgadgetmanager.LocalGadgetManager,
+	containerSelector containercollection.ContainerSelector,
+) {
+	netnsHost, _ := containerutils.GetNetNs(os.Getpid())
+
+	attach := func(container *containercollection.Container) {
+		if container.Netns == netnsHost {
+			return
+		}
+		if err := tracer.AttachNetNs(container.Netns); err != nil {
+			msg := fmt.Sprintf("failed to attach tracer to container %s: %s", container.Name, err)
+			commonutils.ManageSpecialEvent(eventtypes.Err(msg), g.commonFlags.Verbose)
+		}
+	}
+
+	detach := func(container *containercollection.Container) {
+		if container.Netns == netnsHost {
+			return
+		}
+		if err := tracer.DetachNetNs(container.Netns); err != nil {
+			msg := fmt.Sprintf("failed to detach tracer from container %s: %s", container.Name, err)
+			commonutils.ManageSpecialEvent(eventtypes.Err(msg), g.commonFlags.Verbose)
+		}
+	}
+
+	containers := localGadgetManager.Subscribe(key, containerSelector, func(event containercollection.PubSubEvent) {
+		switch event.Type {
+		case containercollection.EventTypeAddContainer:
+			attach(event.Container)
+		case containercollection.EventTypeRemoveContainer:
+			detach(event.Container)
+		}
+	})
+	for _, container := range containers {
+		attach(container)
+	}
+}
+
 func NewTraceCmd() *cobra.Command {
 	traceCmd := commontrace.NewCommonTraceCmd()
 
 	traceCmd.AddCommand(newBindCmd())
 	traceCmd.AddCommand(newCapabilitiesCmd())
+	traceCmd.AddCommand(newDropsCmd())
 	traceCmd.AddCommand(newExecCmd())
 	traceCmd.AddCommand(newOOMKillCmd())
 	traceCmd.AddCommand(newTCPCmd())
 	traceCmd.AddCommand(newTcpconnectCmd())
+	traceCmd.AddCommand(newTcplifeCmd())
+	traceCmd.AddCommand(newTcpretransCmd())
 
 	return traceCmd
 }
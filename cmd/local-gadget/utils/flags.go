@@ -23,6 +23,7 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/containerd"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/crio"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/docker"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/podman"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -48,6 +49,9 @@ type CommonFlags struct {
 	// CrioSocketPath is the CRI-O CRI Unix socket path.
 	CrioSocketPath string
 
+	// PodmanSocketPath is the Podman libpod API Unix socket path.
+	PodmanSocketPath string
+
 	// RuntimeConfigs contains the list of the container runtimes to be used
 	// with their specific socket path.
 	RuntimeConfigs []*containerutils.RuntimeConfig
@@ -70,6 +74,8 @@ func AddCommonFlags(command *cobra.Command, commonFlags *CommonFlags) {
 				socketPath = commonFlags.ContainerdSocketPath
 			case crio.Name:
 				socketPath = commonFlags.CrioSocketPath
+			case podman.Name:
+				socketPath = commonFlags.PodmanSocketPath
 			default:
 				return commonutils.WrapInErrInvalidArg("--runtime / -r",
 					fmt.Errorf("runtime %q is not supported", p))
@@ -114,6 +120,7 @@ func AddCommonFlags(command *cobra.Command, commonFlags *CommonFlags) {
 		commonutils.OutputModeColumns,
 		fmt.Sprintf("Output format (%s).", strings.Join(commonutils.SupportedOutputModes, ", ")),
 	)
+	command.PersistentFlags().SetAnnotation("output", commonutils.OutputModesAnnotation, commonutils.SupportedOutputModes)
 
 	command.PersistentFlags().StringVarP(
 		&commonFlags.Containername,
@@ -151,4 +158,11 @@ func AddCommonFlags(command *cobra.Command, commonFlags *CommonFlags) {
 		crio.DefaultSocketPath,
 		"CRI-O CRI Unix socket path",
 	)
+
+	command.PersistentFlags().StringVarP(
+		&commonFlags.PodmanSocketPath,
+		"podman-socketpath", "",
+		podman.DefaultSocketPath,
+		"Podman libpod API Unix socket path",
+	)
 }
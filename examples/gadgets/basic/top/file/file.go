@@ -62,8 +62,8 @@ func main() {
 	tracerConfig := &tracer.Config{
 		Interval: interval * time.Second,
 		MaxRows:  maxRows,
-		// Sort results by number of write operations
-		SortBy: types.WRITES,
+		// Sort results by number of write operations, in descending order
+		SortBy: []string{"-writes"},
 	}
 
 	tracer, err := tracer.NewTracer(tracerConfig, nil, callback)
@@ -41,8 +41,8 @@ func main() {
 			event.QType, event.DNSName)
 	}
 
-	// Create tracer. In this case no parameters are passed.
-	tracer, err := tracer.NewTracer()
+	// Create tracer. In this case only the queries are traced.
+	tracer, err := tracer.NewTracer(&tracer.Config{})
 	if err != nil {
 		fmt.Printf("error creating tracer: %s\n", err)
 		return
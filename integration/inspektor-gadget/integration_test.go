@@ -30,14 +30,18 @@ import (
 	bindTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/bind/types"
 	capabilitiesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
 	dnsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	dropsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/types"
 	execTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
 	fsslowerType "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/fsslower/types"
 	mountTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/mount/types"
 	oomkillTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/oomkill/types"
 	openTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	packetsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/types"
 	signalTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/signal/types"
 	tcpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcp/types"
 	tcpconnectTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpconnect/types"
+	tcplifeTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcplife/types"
+	tcpretransTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpretrans/types"
 )
 
 const (
@@ -493,6 +497,51 @@ func TestDns(t *testing.T) {
 	RunCommands(commands, t)
 }
 
+func TestDrops(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-drops")
+
+	t.Parallel()
+
+	dropsCmd := &Command{
+		Name:         "StartDropsGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace drops -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &dropsTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				IPVersion: 4,
+				Proto:     "udp",
+				Saddr:     "127.0.0.1",
+				Daddr:     "127.0.0.1",
+				Dport:     9999,
+			}
+
+			normalize := func(e *dropsTypes.Event) {
+				e.Node = ""
+				e.Sport = 0
+				e.NetNsID = 0
+				e.SrcKind = ""
+				e.DstKind = ""
+
+				// Drop reasons are only available since Linux 5.17.
+				e.Reason = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		dropsCmd,
+		BusyboxPodRepeatCommand(ns, "echo foo | nc -u -w 1 127.0.0.1 9999"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
 func TestEbpftop(t *testing.T) {
 	if *k8sDistro == K8sDistroAKSUbuntu && *k8sArch == "amd64" {
 		t.Skip("Skip running top ebpf gadget on AKS Ubuntu amd64: see issue #931")
@@ -537,19 +586,25 @@ func TestExecsnoop(t *testing.T) {
 		ExpectedOutputFn: func(output string) error {
 			expectedEntries := []*execTypes.Event{
 				{
-					Event: BuildBaseEvent(ns),
-					Comm:  "sh",
-					Args:  shArgs,
+					Event:    BuildBaseEvent(ns),
+					Comm:     "sh",
+					Args:     shArgs,
+					ExePath:  "/bin/sh",
+					ExeLayer: execTypes.ExeLayerLower,
 				},
 				{
-					Event: BuildBaseEvent(ns),
-					Comm:  "date",
-					Args:  dateArgs,
+					Event:    BuildBaseEvent(ns),
+					Comm:     "date",
+					Args:     dateArgs,
+					ExePath:  "/bin/date",
+					ExeLayer: execTypes.ExeLayerLower,
 				},
 				{
-					Event: BuildBaseEvent(ns),
-					Comm:  "sleep",
-					Args:  sleepArgs,
+					Event:    BuildBaseEvent(ns),
+					Comm:     "sleep",
+					Args:     sleepArgs,
+					ExePath:  "/bin/sleep",
+					ExeLayer: execTypes.ExeLayerLower,
 				},
 			}
 
@@ -560,6 +615,7 @@ func TestExecsnoop(t *testing.T) {
 				e.UID = 0
 				e.Retval = 0
 				e.MountNsID = 0
+				e.ExeInode = 0
 			}
 
 			return ExpectEntriesToMatch(output, normalize, expectedEntries...)
@@ -585,7 +641,7 @@ func TestFiletop(t *testing.T) {
 	filetopCmd := &Command{
 		Name:           "StartFiletopGadget",
 		Cmd:            fmt.Sprintf("$KUBECTL_GADGET top file -n %s", ns),
-		ExpectedRegexp: fmt.Sprintf(`%s\s+test-pod\s+test-pod\s+\d+\s+\S*\s+0\s+\d+\s+0\s+\d+\s+R\s+date`, ns),
+		ExpectedRegexp: fmt.Sprintf(`%s\s+test-pod\s+test-pod\s+\d+\s+\S*\s+0\s+\d+\s+0B\s+\S+\s+R\s+date`, ns),
 		StartAndStop:   true,
 	}
 
@@ -716,7 +772,7 @@ func TestNetworkpolicy(t *testing.T) {
 					sleep 10
 					kill $!
 					head networktrace-client.log | sort | uniq`, nsClient),
-			ExpectedRegexp: fmt.Sprintf(`{"node":".*","namespace":"%s","pod":"test-pod","type":"normal","pktType":"OUTGOING","proto":"tcp","ip":".*","port":9090,"remoteKind":"svc","podHostIP":".*","podIP":".*","podLabels":{"run":"test-pod"},"remoteServiceNamespace":"%s","remoteServiceName":"test-pod","remoteServiceLabelSelector":{"run":"test-pod"}}`, nsClient, nsServer),
+			ExpectedRegexp: fmt.Sprintf(`{"node":".*","namespace":"%s","pod":"test-pod","type":"normal","pktType":"OUTGOING","proto":"tcp","ip":".*","port":9090,"packets":\d+,"bytes":\d+,"firstSeen":\d+,"lastSeen":\d+,"remoteKind":"svc","podHostIP":".*","podIP":".*","podLabels":{"run":"test-pod"},"remoteServiceNamespace":"%s","remoteServiceName":"test-pod","remoteServiceLabelSelector":{"run":"test-pod"}}`, nsClient, nsServer),
 		},
 		{
 			// Docker bridge does not preserve source IP :-(
@@ -728,7 +784,7 @@ func TestNetworkpolicy(t *testing.T) {
 					kill $!
 					head networktrace-server.log | sort | uniq
 					kubectl get node -o jsonpath='{.items[0].status.nodeInfo.containerRuntimeVersion}'|grep -q docker && echo SKIP_TEST || true`, nsServer),
-			ExpectedRegexp: fmt.Sprintf(`SKIP_TEST|{"node":".*","namespace":"%s","pod":"test-pod","type":"normal","pktType":"HOST","proto":"tcp","ip":".*","port":9090,"remoteKind":"pod","podHostIP":".*","podIP":".*","podLabels":{"run":"test-pod"},"remotePodNamespace":"%s","remotePodName":"test-pod","remotePodLabels":{"run":"test-pod"}}`, nsServer, nsClient),
+			ExpectedRegexp: fmt.Sprintf(`SKIP_TEST|{"node":".*","namespace":"%s","pod":"test-pod","type":"normal","pktType":"HOST","proto":"tcp","ip":".*","port":9090,"packets":\d+,"bytes":\d+,"firstSeen":\d+,"lastSeen":\d+,"remoteKind":"pod","podHostIP":".*","podIP":".*","podLabels":{"run":"test-pod"},"remotePodNamespace":"%s","remotePodName":"test-pod","remotePodLabels":{"run":"test-pod"}}`, nsServer, nsClient),
 		},
 		{
 			Name: "RunNetworkPolicyReportClient",
@@ -804,6 +860,106 @@ spec:
 	RunCommands(commands, t)
 }
 
+func TestCapabilitiesAdvisor(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-capabilities-advisor")
+
+	t.Parallel()
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "touch /tmp/file; chown 1000 /tmp/file"),
+		WaitUntilTestPodReadyCommand(ns),
+		{
+			Name: "RunCapabilitiesMonitor",
+			Cmd: fmt.Sprintf(`$KUBECTL_GADGET advise capabilities monitor -n %s --output ./capabilities.log &
+					sleep 15
+					kill $!
+					grep -o '"capName":"CHOWN"' capabilities.log | head -1`, ns),
+			ExpectedRegexp: `"capName":"CHOWN"`,
+		},
+		{
+			Name: "RunCapabilitiesReport",
+			Cmd:  "$KUBECTL_GADGET advise capabilities report --input ./capabilities.log",
+			ExpectedRegexp: fmt.Sprintf(`# Pod %s/test-pod, container test-pod
+securityContext:
+  capabilities:
+    add:
+(    - [A-Z_]+
+)*    - CHOWN
+(    - [A-Z_]+
+)*    drop:
+    - ALL`, ns),
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
+func TestPodSecurityAdvisor(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-pod-security-advisor")
+
+	t.Parallel()
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "echo test > /tmp/file"),
+		WaitUntilTestPodReadyCommand(ns),
+		{
+			Name: "RunPodSecurityMonitor",
+			Cmd: fmt.Sprintf(`$KUBECTL_GADGET advise pod-security monitor -n %s --output ./pod-security.log &
+					sleep 15
+					kill $!
+					wait $!
+					grep -o '"path":"/tmp/file"' pod-security.log | head -1`, ns),
+			ExpectedRegexp: `"path":"/tmp/file"`,
+		},
+		{
+			Name: "RunPodSecurityReport",
+			Cmd:  "$KUBECTL_GADGET advise pod-security report --input ./pod-security.log",
+			ExpectedRegexp: fmt.Sprintf(`# Pod %s/test-pod, container test-pod
+(# - .*
+)*# - runAsNonRoot: not advised, processes ran as root: .*
+# - readOnlyRootFilesystem: emptyDir on /tmp, written: /tmp/file
+(# - .*
+)*securityContext:`, ns),
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
+func TestEgressAllowlistAdvisor(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-egress-allowlist-advisor")
+
+	t.Parallel()
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "nc -w 1 1.1.1.1 443"),
+		WaitUntilTestPodReadyCommand(ns),
+		{
+			Name: "RunEgressAllowlistMonitor",
+			Cmd: fmt.Sprintf(`$KUBECTL_GADGET advise egress-allowlist monitor -n %s --output ./egress-allowlist.log &
+					sleep 15
+					kill $!
+					wait $!
+					grep -o '"daddr":"1.1.1.1"' egress-allowlist.log | head -1`, ns),
+			ExpectedRegexp: `"daddr":"1.1.1.1"`,
+		},
+		{
+			Name: "RunEgressAllowlistReport",
+			Cmd:  "$KUBECTL_GADGET advise egress-allowlist report --input ./egress-allowlist.log --format list",
+			ExpectedRegexp: fmt.Sprintf(`# Pod %s/test-pod
+# Unresolved: 1.1.1.1:443`, ns),
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
 func TestOomkill(t *testing.T) {
 	ns := GenerateTestNamespaceName("test-oomkill")
 
@@ -909,6 +1065,50 @@ func TestOpensnoop(t *testing.T) {
 	RunCommands(commands, t)
 }
 
+func TestPackets(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-packets")
+
+	t.Parallel()
+
+	packetsCmd := &Command{
+		Name:         "StartPacketsGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace packets -n %s --filter 'udp dst port 9999' -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &packetsTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Interface: "lo",
+				PktType:   "OUTGOING",
+				Proto:     "udp",
+				Saddr:     "127.0.0.1",
+				Daddr:     "127.0.0.1",
+				Dport:     9999,
+				// Ethernet, IPv4 and UDP headers followed by "foo\n".
+				Len: 14 + 20 + 8 + 4,
+			}
+
+			normalize := func(e *packetsTypes.Event) {
+				e.Node = ""
+				e.Timestamp = 0
+				e.Sport = 0
+				e.Data = nil
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		packetsCmd,
+		BusyboxPodRepeatCommand(ns, "echo foo | nc -u -w 1 127.0.0.1 9999"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
 func TestProcessCollector(t *testing.T) {
 	if *k8sDistro == K8sDistroARO {
 		t.Skip("Skip running process-collector gadget on ARO: iterators are not supported on kernel 4.18.0-305.19.1.el8_4.x86_64")
@@ -957,6 +1157,26 @@ func TestProfile(t *testing.T) {
 	RunCommands(commands, t)
 }
 
+func TestProfileTcpconnectLatency(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-profile-tcpconnect-latency")
+
+	t.Parallel()
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "wget -q -O /dev/null -T 3 http://1.1.1.1"),
+		WaitUntilTestPodReadyCommand(ns),
+		{
+			Name:           "RunProfileTcpconnectLatencyGadget",
+			Cmd:            fmt.Sprintf("$KUBECTL_GADGET profile tcpconnect-latency -n %s --timeout 15", ns),
+			ExpectedRegexp: fmt.Sprintf(`Destination 1\.1\.1\.1:80\s+usecs\s+:\s+count\s+distribution[\s\S]*Pod %s/test-pod\s+usecs\s+:\s+count\s+distribution`, ns),
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
 func TestSeccompadvisor(t *testing.T) {
 	ns := GenerateTestNamespaceName("test-seccomp-advisor")
 
@@ -977,6 +1197,83 @@ func TestSeccompadvisor(t *testing.T) {
 	RunCommands(commands, t)
 }
 
+func TestSeccompadvisorAggregate(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-seccomp-advisor-aggregate")
+	// Create the profile in the test namespace so that it's deleted with it.
+	profileName := fmt.Sprintf("aggregate-%s-deployment-test-deployment-busybox", ns)
+
+	t.Parallel()
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		{
+			Name: "RunTestDeployment",
+			Cmd: fmt.Sprintf("kubectl create deployment -n %s test-deployment --image busybox --replicas 2 -- sh -c 'while true; do echo foo; sleep 1; done'"+
+				" && kubectl rollout status -n %s deployment/test-deployment --timeout 60s", ns, ns),
+		},
+		{
+			Name:           "RunSeccompAdvisorGadget",
+			Cmd:            fmt.Sprintf("id=$($KUBECTL_GADGET advise seccomp-profile start -n %s -m seccomp-profile --aggregate --profile-prefix %s/aggregate); sleep 30; $KUBECTL_GADGET advise seccomp-profile stop $id", ns, ns),
+			ExpectedRegexp: fmt.Sprintf("Successfully created seccomp profile: %s", profileName),
+		},
+		{
+			Name:           "CheckAggregatedProfile",
+			Cmd:            fmt.Sprintf(`kubectl get seccompprofile -n %s %s -o jsonpath='{.metadata.annotations.seccomp\.gadget\.kinvolk\.io/pods}'`, ns, profileName),
+			ExpectedString: "2",
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
+func TestSeccompadvisorDiff(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-seccomp-advisor-diff")
+	profile := `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["reboot"], "action": "SCMP_ACT_ALLOW"}]}`
+
+	t.Parallel()
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "echo foo"),
+		WaitUntilTestPodReadyCommand(ns),
+		{
+			Name: "RunSeccompAdvisorDiff",
+			Cmd: fmt.Sprintf("f=$(mktemp); echo '%s' > $f; id=$($KUBECTL_GADGET advise seccomp-profile start -n %s -p test-pod); sleep 30;"+
+				" $KUBECTL_GADGET advise seccomp-profile diff -n %s $id --file $f; $KUBECTL_GADGET advise seccomp-profile stop $id > /dev/null; rm -f $f", profile, ns, ns),
+			ExpectedRegexp: `(?s)Used but not allowed \(\d+\):.*  write.*Allowed but not used \(1\):\s+reboot`,
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
+func TestSeccompadvisorSyscallArgs(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-seccomp-advisor-syscall-args")
+
+	t.Parallel()
+
+	// The arguments are only restricted for the containers started after
+	// the gadget.
+	podCmd := BusyboxPodRepeatCommand(ns, "nc -w 1 127.0.0.1 1").Cmd
+	waitCmd := WaitUntilTestPodReadyCommand(ns).Cmd
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		{
+			Name: "RunSeccompAdvisorGadget",
+			Cmd: fmt.Sprintf("id=$($KUBECTL_GADGET advise seccomp-profile start -n %s -p test-pod --syscall-args socket:0)\n%s"+
+				"%s > /dev/null; sleep 30; $KUBECTL_GADGET advise seccomp-profile stop $id", ns, podCmd, waitCmd),
+			// AF_INET is 2
+			ExpectedRegexp: `"socket"\s*\],\s*"action": "SCMP_ACT_ALLOW",\s*"args": \[\s*\{\s*"index": 0,\s*"value": 2,`,
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
 func TestSigsnoop(t *testing.T) {
 	ns := GenerateTestNamespaceName("test-sigsnoop")
 
@@ -1024,7 +1321,7 @@ func TestSnisnoop(t *testing.T) {
 	snisnoopCmd := &Command{
 		Name:           "StartSnisnoopGadget",
 		Cmd:            fmt.Sprintf("$KUBECTL_GADGET trace sni -n %s", ns),
-		ExpectedRegexp: fmt.Sprintf(`%s\s+test-pod\s+kinvolk.io`, ns),
+		ExpectedRegexp: fmt.Sprintf(`%s\s+test-pod\s+kinvolk.io\s+TLS 1\.[23]\s+\S+\s+443`, ns),
 		StartAndStop:   true,
 	}
 
@@ -1040,14 +1337,6 @@ func TestSnisnoop(t *testing.T) {
 }
 
 func TestSocketCollector(t *testing.T) {
-	if *k8sDistro == K8sDistroARO {
-		t.Skip("Skip running socket-collector gadget on ARO: iterators are not supported on kernel 4.18.0-305.19.1.el8_4.x86_64")
-	}
-
-	if *k8sDistro == K8sDistroAKSUbuntu && *k8sArch == "amd64" {
-		t.Skip("Skip running socket-collector gadget on AKS Ubuntu amd64: iterators are not supported on kernel 5.4.0-1089-azure")
-	}
-
 	ns := GenerateTestNamespaceName("test-socket-collector")
 
 	t.Parallel()
@@ -1079,18 +1368,20 @@ func TestTcpconnect(t *testing.T) {
 		ExpectedOutputFn: func(output string) error {
 			expectedEntries := []*tcpconnectTypes.Event{
 				{
-					Event:     BuildBaseEvent(ns),
-					Comm:      "wget",
-					IPVersion: 4,
-					Daddr:     "1.1.1.1",
-					Dport:     80,
+					Event:      BuildBaseEvent(ns),
+					Comm:       "wget",
+					IPVersion:  4,
+					Daddr:      "1.1.1.1",
+					Dport:      80,
+					RemoteKind: "other",
 				},
 				{
-					Event:     BuildBaseEvent(ns),
-					Comm:      "wget",
-					IPVersion: 4,
-					Daddr:     "1.1.1.1",
-					Dport:     443,
+					Event:      BuildBaseEvent(ns),
+					Comm:       "wget",
+					IPVersion:  4,
+					Daddr:      "1.1.1.1",
+					Dport:      443,
+					RemoteKind: "other",
 				},
 			}
 
@@ -1116,6 +1407,105 @@ func TestTcpconnect(t *testing.T) {
 	RunCommands(commands, t)
 }
 
+func TestTcplife(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-tcplife")
+
+	t.Parallel()
+
+	tcplifeCmd := &Command{
+		Name:         "StartTcplifeGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace tcplife -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntries := []*tcplifeTypes.Event{
+				{
+					Event:      BuildBaseEvent(ns),
+					Comm:       "wget",
+					IPVersion:  4,
+					Daddr:      "1.1.1.1",
+					Dport:      80,
+					RemoteKind: "other",
+				},
+				{
+					Event:      BuildBaseEvent(ns),
+					Comm:       "wget",
+					IPVersion:  4,
+					Daddr:      "1.1.1.1",
+					Dport:      443,
+					RemoteKind: "other",
+				},
+			}
+
+			normalize := func(e *tcplifeTypes.Event) {
+				e.Node = ""
+				e.Pid = 0
+				e.Saddr = ""
+				e.Sport = 0
+				e.State = ""
+				e.Sent = 0
+				e.Received = 0
+				e.Duration = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntries...)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		tcplifeCmd,
+		BusyboxPodRepeatCommand(ns, "wget -q -O /dev/null -T 3 http://1.1.1.1"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
+func TestTcpretrans(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-tcpretrans")
+
+	t.Parallel()
+
+	tcpretransCmd := &Command{
+		Name:         "StartTcpretransGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace tcpretrans -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tcpretransTypes.Event{
+				Event:      BuildBaseEvent(ns),
+				IPVersion:  4,
+				Daddr:      "192.0.2.1",
+				Dport:      80,
+				State:      "SYN_SENT",
+				RemoteKind: "other",
+			}
+
+			normalize := func(e *tcpretransTypes.Event) {
+				e.Node = ""
+				e.Saddr = ""
+				e.Sport = 0
+				e.NetNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		tcpretransCmd,
+		// 192.0.2.1 is reserved for documentation (RFC 5737) and never
+		// answers, so the SYN packets are retransmitted.
+		BusyboxPodRepeatCommand(ns, "nc -w 3 192.0.2.1 80"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
 func TestTcptracer(t *testing.T) {
 	ns := GenerateTestNamespaceName("test-tcptracer")
 
@@ -1128,28 +1518,31 @@ func TestTcptracer(t *testing.T) {
 		ExpectedOutputFn: func(output string) error {
 			expectedEntries := []*tcpTypes.Event{
 				{
-					Event:     BuildBaseEvent(ns),
-					Comm:      "wget",
-					IPVersion: 4,
-					Daddr:     "1.1.1.1",
-					Dport:     80,
-					Operation: "connect",
+					Event:      BuildBaseEvent(ns),
+					Comm:       "wget",
+					IPVersion:  4,
+					Daddr:      "1.1.1.1",
+					Dport:      80,
+					RemoteKind: "other",
+					Operation:  "connect",
 				},
 				{
-					Event:     BuildBaseEvent(ns),
-					Comm:      "wget",
-					IPVersion: 4,
-					Daddr:     "1.1.1.1",
-					Dport:     80,
-					Operation: "close",
+					Event:      BuildBaseEvent(ns),
+					Comm:       "wget",
+					IPVersion:  4,
+					Daddr:      "1.1.1.1",
+					Dport:      80,
+					RemoteKind: "other",
+					Operation:  "close",
 				},
 				{
-					Event:     BuildBaseEvent(ns),
-					Comm:      "wget",
-					IPVersion: 4,
-					Daddr:     "1.1.1.1",
-					Dport:     443,
-					Operation: "connect",
+					Event:      BuildBaseEvent(ns),
+					Comm:       "wget",
+					IPVersion:  4,
+					Daddr:      "1.1.1.1",
+					Dport:      443,
+					RemoteKind: "other",
+					Operation:  "connect",
 				},
 			}
 
@@ -1199,6 +1592,29 @@ func TestTcptop(t *testing.T) {
 	RunCommands(commands, t)
 }
 
+func TestUdptop(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-udptop")
+
+	t.Parallel()
+
+	udptopCmd := &Command{
+		Name:           "StartUdptopGadget",
+		Cmd:            fmt.Sprintf("$KUBECTL_GADGET top udp -n %s", ns),
+		ExpectedRegexp: fmt.Sprintf(`%s\s+test-pod\s+test-pod\s+\d+\s+nc\s+4\s+\d+\s+127.0.0.1\s+9999`, ns),
+		StartAndStop:   true,
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		udptopCmd,
+		BusyboxPodRepeatCommand(ns, "echo foo | nc -u -w 1 127.0.0.1 9999"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
+
 // This test is flaky (https://github.com/kinvolk/traceloop/issues/42),
 // let's disable it until we rework this gadget
 // https://github.com/inspektor-gadget/inspektor-gadget/issues/371
@@ -0,0 +1,76 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestAdviseSeccompProfile(t *testing.T) {
+	t.Parallel()
+	prefix := "test-advise-seccomp"
+	po := fmt.Sprintf("%s-pod", prefix)
+	cn := fmt.Sprintf("%s-container", prefix)
+	ns := GenerateTestNamespaceName(fmt.Sprintf("%s-namespace", prefix))
+
+	// TODO: Handle it once we support getting K8s container name for docker
+	// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+	if *containerRuntime == ContainerRuntimeDocker {
+		t.Skip("Skip TestAdviseSeccompProfile on docker since we don't propagate the Kubernetes pod container name")
+	}
+
+	testPodYaml := fmt.Sprintf(`
+apiVersion: v1
+kind: Pod
+metadata:
+  namespace: %s
+  name: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: %s
+    image: busybox
+    command: ["/bin/sh", "-c"]
+    args:
+    - "while true; do echo foo; sleep 0.1; done"
+`, ns, po, cn)
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		{
+			Name:           "RunTestPod",
+			Cmd:            fmt.Sprintf("echo '%s' | kubectl apply -f -", testPodYaml),
+			ExpectedRegexp: fmt.Sprintf("pod/%s created", po),
+		},
+		{
+			Name:           "WaitForTestPod",
+			Cmd:            fmt.Sprintf("kubectl wait pod --for condition=ready -n %s %s", ns, po),
+			ExpectedString: fmt.Sprintf("pod/%s condition met\n", po),
+		},
+		{
+			Name: "RunAdviseSeccompProfile",
+			// The profile is generated when the command is interrupted
+			Cmd:            fmt.Sprintf("timeout --preserve-status -s INT 10 local-gadget advise seccomp-profile --runtimes=%s --containername=%s", *containerRuntime, cn),
+			ExpectedRegexp: `(?s)"defaultAction": "SCMP_ACT_ERRNO".*"write"`,
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
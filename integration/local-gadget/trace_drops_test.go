@@ -0,0 +1,70 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	dropsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/types"
+)
+
+func TestTraceDrops(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-drops")
+
+	dropsCmd := &Command{
+		Name:         "StartDropsGadget",
+		Cmd:          fmt.Sprintf("local-gadget trace drops -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &dropsTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				IPVersion: 4,
+				Proto:     "udp",
+				Saddr:     "127.0.0.1",
+				Daddr:     "127.0.0.1",
+				Dport:     9999,
+			}
+
+			normalize := func(e *dropsTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Sport = 0
+				e.NetNsID = 0
+
+				// Drop reasons are only available since Linux 5.17.
+				e.Reason = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "echo foo | nc -u -w 1 127.0.0.1 9999"),
+		WaitUntilTestPodReadyCommand(ns),
+		dropsCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	tcpretransTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpretrans/types"
+)
+
+func TestTraceTcpretrans(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-tcpretrans")
+
+	tcpretransCmd := &Command{
+		Name:         "StartTcpretransGadget",
+		Cmd:          fmt.Sprintf("local-gadget trace tcpretrans -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tcpretransTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				IPVersion: 4,
+				Daddr:     "192.0.2.1",
+				Dport:     80,
+				State:     "SYN_SENT",
+			}
+
+			normalize := func(e *tcpretransTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Saddr = ""
+				e.Sport = 0
+				e.NetNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "nc -w 3 192.0.2.1 80"),
+		WaitUntilTestPodReadyCommand(ns),
+		tcpretransCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunCommands(commands, t)
+}
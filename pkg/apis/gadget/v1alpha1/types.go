@@ -35,6 +35,9 @@ const (
 	// OperationCollect indicates capturing system state
 	// at a specific point in time
 	OperationCollect Operation = "collect"
+	// OperationDiff indicates to compare the trace with a
+	// reference e.g seccomp profile
+	OperationDiff Operation = "diff"
 )
 
 // RunMode defines running mode for the Trace
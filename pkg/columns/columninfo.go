@@ -50,6 +50,8 @@ type Column[T any] struct {
 	Description  string                // Description can hold a short description of the field that can be used to aid the user
 	Order        int                   // Order defines the default order in which columns are shown
 	Tags         []string              // Tags can be used to dynamically include or exclude columns
+	Unit         Unit                  // Unit of numeric values; used to show them in a human-readable way and to parse filter values
+	SemanticKind SemanticKind          // SemanticKind gives meaning to the value (e.g. an IP address or an errno)
 
 	fieldIndex    int          // used for the main struct
 	subFieldIndex []int        // used for embedded structs
@@ -57,6 +59,7 @@ type Column[T any] struct {
 	columnType    reflect.Type // cached type info from reflection
 	useTemplate   bool         // if a template has been set, this will be true
 	template      string       // defines the template that will be used. Non-typed templates will be applied first.
+	jsonName      string       // name of the field in the JSON representation; empty for virtual columns
 }
 
 func (ci *Column[T]) getWidthFromType() int {
@@ -207,6 +210,35 @@ func (ci *Column[T]) parseTagInfo(tagInfo []string) error {
 			if err != nil {
 				return err
 			}
+		case "unit":
+			if paramsLen == 1 {
+				return fmt.Errorf("missing unit value for field %q", ci.Name)
+			}
+			if !isInteger(ci.kind) && !isFloat(ci.kind) {
+				return fmt.Errorf("field %q is not a numeric field and thereby cannot have a unit defined", ci.Name)
+			}
+			ci.Unit, err = parseUnit(params[1])
+			if err != nil {
+				return fmt.Errorf("invalid unit value for field %q: %w", ci.Name, err)
+			}
+		case "kind":
+			if paramsLen == 1 {
+				return fmt.Errorf("missing kind value for field %q", ci.Name)
+			}
+			ci.SemanticKind, err = parseSemanticKind(params[1])
+			if err != nil {
+				return fmt.Errorf("invalid kind value for field %q: %w", ci.Name, err)
+			}
+			switch ci.SemanticKind {
+			case SemanticKindIP:
+				if ci.kind != reflect.String {
+					return fmt.Errorf("field %q is not a string field and thereby cannot be of kind %q", ci.Name, ci.SemanticKind)
+				}
+			case SemanticKindErrno, SemanticKindSignal:
+				if !isInteger(ci.kind) {
+					return fmt.Errorf("field %q is not an integer field and thereby cannot be of kind %q", ci.Name, ci.SemanticKind)
+				}
+			}
 		case "template":
 			ci.useTemplate = true
 			if paramsLen < 2 || params[1] == "" {
@@ -280,6 +312,12 @@ func (ci *Column[T]) Type() reflect.Type {
 	return ci.columnType
 }
 
+// JSONName returns the name of the field in the JSON representation of the struct (empty in case of virtual columns
+// or fields that are not serialized)
+func (ci *Column[T]) JSONName() string {
+	return ci.jsonName
+}
+
 func (ci *Column[T]) HasTag(tag string) bool {
 	for _, curTag := range ci.Tags {
 		if curTag == tag {
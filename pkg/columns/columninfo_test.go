@@ -242,6 +242,172 @@ func TestColumnsPrecision(t *testing.T) {
 	}](t, "invalid field")
 }
 
+func TestColumnsUnit(t *testing.T) {
+	type testSuccess1 struct {
+		Bytes     uint64  `column:"bytes,unit:bytes"`
+		Latency   int64   `column:"latency,unit:us"`
+		Duration  float64 `column:"duration,unit:s"`
+		Timestamp int64   `column:"timestamp,unit:timestamp"`
+	}
+
+	cols := expectColumnsSuccess[testSuccess1](t)
+	expectColumnValue(t, expectColumn(t, cols, "bytes"), "Unit", UnitBytes)
+	expectColumnValue(t, expectColumn(t, cols, "latency"), "Unit", UnitMicroseconds)
+	expectColumnValue(t, expectColumn(t, cols, "duration"), "Unit", UnitSeconds)
+	expectColumnValue(t, expectColumn(t, cols, "timestamp"), "Unit", UnitTimestamp)
+
+	expectColumnsFail[struct {
+		Field uint64 `column:"fail,unit"`
+	}](t, "missing parameter")
+	expectColumnsFail[struct {
+		Field uint64 `column:"fail,unit:foo"`
+	}](t, "invalid parameter")
+	expectColumnsFail[struct {
+		Field string `column:"fail,unit:bytes"`
+	}](t, "invalid field")
+}
+
+func TestColumnsKind(t *testing.T) {
+	type testSuccess1 struct {
+		IP     string `column:"ip,kind:ip"`
+		Errno  int32  `column:"errno,kind:errno"`
+		Signal uint32 `column:"signal,kind:signal"`
+	}
+
+	cols := expectColumnsSuccess[testSuccess1](t)
+	expectColumnValue(t, expectColumn(t, cols, "ip"), "SemanticKind", SemanticKindIP)
+	expectColumnValue(t, expectColumn(t, cols, "errno"), "SemanticKind", SemanticKindErrno)
+	expectColumnValue(t, expectColumn(t, cols, "signal"), "SemanticKind", SemanticKindSignal)
+
+	expectColumnsFail[struct {
+		Field string `column:"fail,kind"`
+	}](t, "missing parameter")
+	expectColumnsFail[struct {
+		Field string `column:"fail,kind:foo"`
+	}](t, "invalid parameter")
+	expectColumnsFail[struct {
+		Field int `column:"fail,kind:ip"`
+	}](t, "ip: invalid field")
+	expectColumnsFail[struct {
+		Field string `column:"fail,kind:errno"`
+	}](t, "errno: invalid field")
+}
+
+func TestColumnsFormatHuman(t *testing.T) {
+	type testData struct {
+		Bytes    uint64  `column:"bytes,unit:bytes"`
+		Latency  int64   `column:"latency,unit:us"`
+		Duration float64 `column:"duration,unit:ns"`
+		Errno    int32   `column:"errno,kind:errno"`
+		Signal   uint32  `column:"signal,kind:signal"`
+		IP       string  `column:"ip,kind:ip"`
+		Raw      int     `column:"raw"`
+	}
+
+	cols := expectColumnsSuccess[testData](t)
+
+	tests := []struct {
+		column   string
+		entry    *testData
+		expected string
+	}{
+		{"bytes", &testData{Bytes: 512}, "512B"},
+		{"bytes", &testData{Bytes: 1536}, "1.5KiB"},
+		{"bytes", &testData{Bytes: 10 * 1024 * 1024}, "10MiB"},
+		{"latency", &testData{Latency: 999}, "999us"},
+		{"latency", &testData{Latency: 12345}, "12.3ms"},
+		{"latency", &testData{Latency: 2000000}, "2s"},
+		{"duration", &testData{Duration: 1.5}, "1.5ns"},
+		{"errno", &testData{Errno: 2}, "ENOENT"},
+		{"errno", &testData{Errno: -13}, "-EACCES"},
+		{"errno", &testData{Errno: 0}, "0"},
+		{"errno", &testData{Errno: 10000}, "10000"},
+		{"signal", &testData{Signal: 9}, "SIGKILL"},
+	}
+
+	for _, test := range tests {
+		col := expectColumn(t, cols, test.column)
+		if !col.HasHumanFormat() {
+			t.Errorf("Expected column %q to have a human format", test.column)
+			continue
+		}
+		if res := col.FormatHuman(col.Get(test.entry)); res != test.expected {
+			t.Errorf("Expected %q for column %q, got %q", test.expected, test.column, res)
+		}
+	}
+
+	for _, name := range []string{"ip", "raw"} {
+		if expectColumn(t, cols, name).HasHumanFormat() {
+			t.Errorf("Expected column %q to not have a human format", name)
+		}
+	}
+}
+
+func TestUnitParse(t *testing.T) {
+	tests := []struct {
+		unit        Unit
+		value       string
+		expected    float64
+		expectError bool
+	}{
+		{UnitBytes, "100", 100, false},
+		{UnitBytes, "1KiB", 1024, false},
+		{UnitBytes, "1.5MiB", 1.5 * 1024 * 1024, false},
+		{UnitBytes, "2kB", 2000, false},
+		{UnitBytes, "1GB", 1000 * 1000 * 1000, false},
+		{UnitBytes, "1XB", 0, true},
+		{UnitMicroseconds, "10", 10, false},
+		{UnitMicroseconds, "10ms", 10000, false},
+		{UnitNanoseconds, "1.5us", 1500, false},
+		{UnitSeconds, "2m", 120, false},
+		{UnitMilliseconds, "foo", 0, true},
+		{UnitTimestamp, "1970-01-01T00:00:01Z", 1e9, false},
+		{UnitNone, "1ms", 0, true},
+	}
+
+	for _, test := range tests {
+		res, err := test.unit.Parse(test.value)
+		if err != nil && !test.expectError {
+			t.Errorf("Unexpected error parsing %q as %q: %v", test.value, test.unit, err)
+		}
+		if err == nil && test.expectError {
+			t.Errorf("Expected error parsing %q as %q", test.value, test.unit)
+		}
+		if res != test.expected {
+			t.Errorf("Expected %q to be parsed as %v, got %v", test.value, test.expected, res)
+		}
+	}
+}
+
+func TestSemanticKindParse(t *testing.T) {
+	tests := []struct {
+		kind        SemanticKind
+		value       string
+		expected    int64
+		expectError bool
+	}{
+		{SemanticKindErrno, "ENOENT", 2, false},
+		{SemanticKindErrno, "-eacces", -13, false},
+		{SemanticKindErrno, "5", 5, false},
+		{SemanticKindErrno, "EFOO", 0, true},
+		{SemanticKindSignal, "SIGTERM", 15, false},
+		{SemanticKindSignal, "kill", 9, false},
+	}
+
+	for _, test := range tests {
+		res, err := test.kind.Parse(test.value)
+		if err != nil && !test.expectError {
+			t.Errorf("Unexpected error parsing %q as %q: %v", test.value, test.kind, err)
+		}
+		if err == nil && test.expectError {
+			t.Errorf("Expected error parsing %q as %q", test.value, test.kind)
+		}
+		if res != test.expected {
+			t.Errorf("Expected %q to be parsed as %v, got %v", test.value, test.expected, res)
+		}
+	}
+}
+
 func TestColumnsWidth(t *testing.T) {
 	type testSuccess1 struct {
 		FieldWidth     int64 `column:"int,width:4"`
@@ -245,6 +245,16 @@ func (c *Columns[T]) iterateFields(t reflect.Type, sub []int) error {
 		// add optional description
 		column.Description = f.Tag.Get("columnDesc")
 
+		// store the name used by encoding/json
+		column.jsonName = f.Name
+		if jsonTag, ok := f.Tag.Lookup("json"); ok {
+			if jsonName := strings.Split(jsonTag, ",")[0]; jsonName == "-" {
+				column.jsonName = ""
+			} else if jsonName != "" {
+				column.jsonName = jsonName
+			}
+		}
+
 		// add optional tags
 		if tags := f.Tag.Get("columnTags"); tags != "" {
 			column.Tags = strings.Split(strings.ToLower(tags), ",")
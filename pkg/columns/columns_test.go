@@ -294,3 +294,56 @@ func TestVerifyColumnNames(t *testing.T) {
 		t.Errorf("Expected VerifyColumnNames to return 2 invalid entries")
 	}
 }
+
+func TestSchema(t *testing.T) {
+	type embeddedStruct struct {
+		Node string `json:"node,omitempty" column:"node" columnTags:"kubernetes"`
+	}
+	type testStruct struct {
+		embeddedStruct
+		Pid     uint32   `json:"pid,omitempty" column:"pid,order:100" columnDesc:"Process ID"`
+		Latency int64    `json:"latency" column:"lat,hide,unit:ns,order:200"`
+		Addr    string   `json:"-" column:"addr,kind:ip,order:300"`
+		Args    []string `column:"args,order:400"`
+	}
+	cols := expectColumnsSuccess[testStruct](t)
+	cols.MustAddColumn(Column[testStruct]{
+		Name:  "virtual",
+		Order: 500,
+		Extractor: func(*testStruct) string {
+			return ""
+		},
+	})
+
+	schema := cols.GetColumnMap().Schema()
+	expected := []ColumnSchema{
+		{Name: "node", JSONName: "node", Type: "string", Tags: []string{"kubernetes"}, Visible: true, Filterable: true, Sortable: true},
+		{Name: "pid", JSONName: "pid", Type: "uint32", Description: "Process ID", Visible: true, Filterable: true, Sortable: true},
+		{Name: "lat", JSONName: "latency", Type: "int64", Filterable: true, Sortable: true, Unit: UnitNanoseconds},
+		{Name: "addr", Type: "string", Visible: true, Filterable: true, Sortable: true, Kind: SemanticKindIP},
+		{Name: "args", JSONName: "Args", Type: "slice", Visible: true},
+		{Name: "virtual", Type: "string", Filterable: true, Sortable: true},
+	}
+	if !reflect.DeepEqual(schema.Columns, expected) {
+		t.Fatalf("Expected schema %+v, got %+v", expected, schema.Columns)
+	}
+
+	jsonSchema := schema.JSONSchema()
+	properties, ok := jsonSchema["properties"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected properties in JSON Schema")
+	}
+	if len(properties) != 4 {
+		t.Errorf("Expected 4 properties, got %d", len(properties))
+	}
+	expectedProperties := map[string]any{
+		"pid":     map[string]any{"type": "integer", "description": "Process ID"},
+		"latency": map[string]any{"type": "integer", "x-unit": UnitNanoseconds},
+		"Args":    map[string]any{"type": "array"},
+	}
+	for name, expectedProperty := range expectedProperties {
+		if !reflect.DeepEqual(properties[name], expectedProperty) {
+			t.Errorf("Expected property %q to be %+v, got %+v", name, expectedProperty, properties[name])
+		}
+	}
+}
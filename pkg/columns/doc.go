@@ -57,7 +57,10 @@ The parameter could be used for specific options, passing nil will use the defau
 	| fixed     | none                   | defines that this column will have a fixed width, even when auto-scaling is enabled                                  |
 	| group     | sum                    | defines what should happen with the field whenever entries are grouped (see grouping)                                |
 	| hide      | none                   | specifies that this column is not to be considered by default (see custom columns)                                   |
+	| kind      | ip,errno,signal        | defines the meaning of the value; errno and signal numbers are shown by name and IPs are sorted numerically          |
 	| precision | int                    | specifies the precision of floats (number of decimals)                                                               |
+	| unit      | bytes,ns,us,ms,s,      | defines the unit of a numeric value; it is shown in a human-readable way (e.g. "1.5MiB" or "12ms") and can be used   |
+	|           | timestamp              | when filtering (e.g. ">10ms"); timestamps are nanoseconds since the Unix epoch                                       |
 	| width     | int                    | defines the space allocated for the column                                                                           |
 
 # Virtual Columns or Custom Extractors
@@ -56,7 +56,11 @@ func getValueFromFilterSpec[T any](fs *FilterSpec[T], column *columns.Column[T])
 		reflect.Int64:
 		number, err := strconv.ParseInt(fs.value, 10, 64)
 		if err != nil {
-			return value, fmt.Errorf("tried to compare %q to int column %q", fs.value, column.Name)
+			humanNumber, humanErr := parseHumanValue(column, fs.value)
+			if humanErr != nil {
+				return value, fmt.Errorf("tried to compare %q to int column %q", fs.value, column.Name)
+			}
+			number = int64(humanNumber)
 		}
 		value = reflect.ValueOf(number).Convert(column.Type())
 	case reflect.Uint,
@@ -66,14 +70,22 @@ func getValueFromFilterSpec[T any](fs *FilterSpec[T], column *columns.Column[T])
 		reflect.Uint64:
 		number, err := strconv.ParseUint(fs.value, 10, 64)
 		if err != nil {
-			return value, fmt.Errorf("tried to compare %q to uint column %q", fs.value, column.Name)
+			humanNumber, humanErr := parseHumanValue(column, fs.value)
+			if humanErr != nil {
+				return value, fmt.Errorf("tried to compare %q to uint column %q", fs.value, column.Name)
+			}
+			number = uint64(humanNumber)
 		}
 		value = reflect.ValueOf(number).Convert(column.Type())
 	case reflect.Float32,
 		reflect.Float64:
 		number, err := strconv.ParseFloat(fs.value, 64)
 		if err != nil {
-			return value, fmt.Errorf("tried to compare %q to float column %q", fs.value, column.Name)
+			humanNumber, humanErr := parseHumanValue(column, fs.value)
+			if humanErr != nil {
+				return value, fmt.Errorf("tried to compare %q to float column %q", fs.value, column.Name)
+			}
+			number = float64(humanNumber)
 		}
 		value = reflect.ValueOf(number).Convert(column.Type())
 	case reflect.String:
@@ -84,6 +96,19 @@ func getValueFromFilterSpec[T any](fs *FilterSpec[T], column *columns.Column[T])
 	return value, nil
 }
 
+// parseHumanValue parses values like "10ms", "1MiB" or "ENOENT" according to the unit or kind of the column
+func parseHumanValue[T any](column *columns.Column[T], value string) (float64, error) {
+	switch column.SemanticKind {
+	case columns.SemanticKindErrno, columns.SemanticKindSignal:
+		number, err := column.SemanticKind.Parse(value)
+		return float64(number), err
+	}
+	if column.Unit == columns.UnitNone {
+		return 0, fmt.Errorf("column %q has no unit", column.Name)
+	}
+	return column.Unit.Parse(value)
+}
+
 // GetFilterFromString prepares a filter that has a Match() function that can be called on
 // entries of type *T
 func GetFilterFromString[T any](cols columns.ColumnMap[T], filter string) (*FilterSpec[T], error) {
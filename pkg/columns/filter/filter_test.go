@@ -297,3 +297,54 @@ func TestFilters(t *testing.T) {
 		}
 	})
 }
+
+func TestFiltersWithUnits(t *testing.T) {
+	type testData struct {
+		Bytes   uint64 `column:"bytes,unit:bytes"`
+		Latency int64  `column:"latency,unit:us"`
+		Errno   int32  `column:"errno,kind:errno"`
+		Raw     int64  `column:"raw"`
+	}
+
+	entries := []*testData{
+		{Bytes: 100, Latency: 500, Errno: 0},
+		{Bytes: 2048, Latency: 15000, Errno: -2},
+		{Bytes: 4 * 1024 * 1024, Latency: 2000000, Errno: -13},
+	}
+
+	filterTests := []struct {
+		filterString  string
+		expectedCount int
+		expectError   bool
+	}{
+		{filterString: "bytes:>1KiB", expectedCount: 2},
+		{filterString: "bytes:>=4MiB", expectedCount: 1},
+		{filterString: "bytes:<1kB", expectedCount: 1},
+		{filterString: "bytes:2048", expectedCount: 1},
+		{filterString: "bytes:>1XB", expectError: true},
+		{filterString: "latency:>10ms", expectedCount: 2},
+		{filterString: "latency:<1s", expectedCount: 2},
+		{filterString: "latency:>500", expectedCount: 2},
+		{filterString: "errno:-ENOENT", expectedCount: 1},
+		{filterString: "errno:!0", expectedCount: 2},
+		{filterString: "errno:-EFOO", expectError: true},
+		{filterString: "raw:>10ms", expectError: true},
+	}
+
+	cmap := columns.MustCreateColumns[testData]().GetColumnMap()
+
+	for _, filterTest := range filterTests {
+		t.Run(filterTest.filterString, func(t *testing.T) {
+			out, err := FilterEntries(cmap, entries, []string{filterTest.filterString})
+			if err != nil && !filterTest.expectError {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if err == nil && filterTest.expectError {
+				t.Errorf("Expected error")
+			}
+			if len(out) != filterTest.expectedCount {
+				t.Errorf("Expected %d entries, got %d", filterTest.expectedCount, len(out))
+			}
+		})
+	}
+}
@@ -28,6 +28,13 @@ import (
 )
 
 func (tf *TextColumnsFormatter[T]) setFormatter(column *Column[T]) {
+	if column.col.HasHumanFormat() {
+		column.formatter = func(v interface{}) string {
+			return tf.buildFixedString(column.col.FormatHuman(reflect.ValueOf(v)), column.calculatedWidth, column.col.EllipsisType, column.col.Alignment)
+		}
+		return
+	}
+
 	switch column.col.Kind() {
 	case reflect.Int,
 		reflect.Int8,
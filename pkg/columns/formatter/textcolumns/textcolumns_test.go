@@ -63,6 +63,30 @@ func TestTextColumnsFormatter_FormatEntryAndTable(t *testing.T) {
 	})
 }
 
+func TestTextColumnsFormatter_FormatEntryWithUnits(t *testing.T) {
+	type testUnitsStruct struct {
+		Bytes   uint64 `column:"bytes,width:8,align:right,unit:bytes"`
+		Latency int64  `column:"latency,width:8,align:right,unit:ns"`
+		Errno   int32  `column:"errno,width:8,kind:errno"`
+	}
+
+	formatter := NewFormatter(columns.MustCreateColumns[testUnitsStruct]().GetColumnMap(), WithAutoScale(false))
+
+	entries := []*testUnitsStruct{
+		{Bytes: 1024 * 1024, Latency: 1500000, Errno: -2},
+		{Bytes: 12, Latency: 20, Errno: 0},
+	}
+	expected := []string{
+		"    1MiB    1.5ms -ENOENT ",
+		"     12B     20ns 0       ",
+	}
+	for i, entry := range entries {
+		if res := formatter.FormatEntry(entry); res != expected[i] {
+			t.Errorf("got %q, expected %q", res, expected[i])
+		}
+	}
+}
+
 func TestTextColumnsFormatter_FormatHeader(t *testing.T) {
 	formatter := NewFormatter(testColumns)
 
@@ -0,0 +1,117 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package columns
+
+import (
+	"reflect"
+)
+
+// ColumnSchema is a serializable description of a single column
+type ColumnSchema struct {
+	Name        string       `json:"name"`
+	JSONName    string       `json:"jsonName,omitempty"`
+	Type        string       `json:"type"`
+	Description string       `json:"description,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Visible     bool         `json:"visible"`
+	Filterable  bool         `json:"filterable"`
+	Sortable    bool         `json:"sortable"`
+	Unit        Unit         `json:"unit,omitempty"`
+	Kind        SemanticKind `json:"kind,omitempty"`
+}
+
+// Schema is a serializable description of all columns of a type
+type Schema struct {
+	Columns []ColumnSchema `json:"columns"`
+}
+
+// isComparableKind returns true if values of the given kind can be used by the filter and sort packages
+func isComparableKind(kind reflect.Kind) bool {
+	return isInteger(kind) || isFloat(kind) || kind == reflect.String
+}
+
+// Schema returns the description of the columns of the map, ordered like GetOrderedColumns does
+func (c ColumnMap[T]) Schema() *Schema {
+	schema := &Schema{
+		Columns: make([]ColumnSchema, 0, len(c)),
+	}
+
+	for _, column := range c.GetOrderedColumns() {
+		schema.Columns = append(schema.Columns, ColumnSchema{
+			Name:        column.Name,
+			JSONName:    column.jsonName,
+			Type:        column.kind.String(),
+			Description: column.Description,
+			Tags:        column.Tags,
+			Visible:     column.Visible,
+			Filterable:  isComparableKind(column.kind),
+			Sortable:    isComparableKind(column.kind),
+			Unit:        column.Unit,
+			Kind:        column.SemanticKind,
+		})
+	}
+
+	return schema
+}
+
+// jsonSchemaType returns the JSON Schema type used for values of the given Go kind
+func jsonSchemaType(kindName string) string {
+	switch kindName {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "integer"
+	case "float32", "float64":
+		return "number"
+	case "bool":
+		return "boolean"
+	case "string":
+		return "string"
+	case "slice", "array":
+		return "array"
+	}
+	return "object"
+}
+
+// JSONSchema returns a JSON Schema (draft-07) describing the JSON representation of the elements; columns that are
+// not part of it (e.g. virtual columns) are skipped. Units and kinds are added using the "x-unit" and "x-kind"
+// extension keywords.
+func (s *Schema) JSONSchema() map[string]any {
+	properties := map[string]any{}
+	for _, column := range s.Columns {
+		if column.JSONName == "" {
+			continue
+		}
+
+		property := map[string]any{
+			"type": jsonSchemaType(column.Type),
+		}
+		if column.Description != "" {
+			property["description"] = column.Description
+		}
+		if column.Unit != UnitNone {
+			property["x-unit"] = column.Unit
+		}
+		if column.Kind != SemanticKindNone {
+			property["x-kind"] = column.Kind
+		}
+
+		properties[column.JSONName] = property
+	}
+
+	return map[string]any{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"type":       "object",
+		"properties": properties,
+	}
+}
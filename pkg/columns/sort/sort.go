@@ -15,8 +15,11 @@
 package sort
 
 import (
+	"bytes"
+	"net"
 	"reflect"
 	"sort"
+	"strings"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 )
@@ -117,6 +120,20 @@ func newColumnSorter[T any](array []*T, column *columns.Column[T], order columns
 			return !(column.GetRef(v1).Float() < column.GetRef(v2).Float()) != order
 		}
 	case reflect.String:
+		if column.SemanticKind == columns.SemanticKindIP {
+			cs.less = func(i, j int) bool {
+				v1 := reflect.ValueOf(array[i])
+				v2 := reflect.ValueOf(array[j])
+				if v1.IsNil() {
+					return false
+				}
+				if v2.IsNil() {
+					return true
+				}
+				return !(compareIPs(column.GetRef(v1).String(), column.GetRef(v2).String()) < 0) != order
+			}
+			break
+		}
 		cs.less = func(i, j int) bool {
 			v1 := reflect.ValueOf(array[i])
 			v2 := reflect.ValueOf(array[j])
@@ -134,6 +151,31 @@ func newColumnSorter[T any](array []*T, column *columns.Column[T], order columns
 	return cs
 }
 
+// compareIPs compares two IP addresses numerically; IPv4 addresses are sorted before IPv6 addresses and values that
+// cannot be parsed are compared as strings after all valid addresses
+func compareIPs(a, b string) int {
+	ip1, ip2 := net.ParseIP(a), net.ParseIP(b)
+	switch {
+	case ip1 == nil && ip2 == nil:
+		return strings.Compare(a, b)
+	case ip1 == nil:
+		return 1
+	case ip2 == nil:
+		return -1
+	}
+
+	ip1v4, ip2v4 := ip1.To4(), ip2.To4()
+	switch {
+	case ip1v4 != nil && ip2v4 == nil:
+		return -1
+	case ip1v4 == nil && ip2v4 != nil:
+		return 1
+	case ip1v4 != nil && ip2v4 != nil:
+		return bytes.Compare(ip1v4, ip2v4)
+	}
+	return bytes.Compare(ip1.To16(), ip2.To16())
+}
+
 func (cs *columnSorter[T]) Len() int {
 	return len(cs.array)
 }
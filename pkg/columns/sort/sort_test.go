@@ -150,3 +150,39 @@ func TestSorter(t *testing.T) {
 	// Sort nil array - should result in noop
 	SortEntries(cmap, nil, []string{""})
 }
+
+func TestSorterIP(t *testing.T) {
+	type testData struct {
+		IP     string `column:"ip,kind:ip"`
+		String string `column:"string"`
+	}
+	testEntries := []*testData{
+		{IP: "fe80::1", String: "fe80::1"},
+		{IP: "10.0.0.2", String: "10.0.0.2"},
+		{IP: "invalid", String: "invalid"},
+		{IP: "9.0.0.1", String: "9.0.0.1"},
+		{IP: "::1", String: "::1"},
+		{IP: "10.0.0.10", String: "10.0.0.10"},
+	}
+
+	cmap := columns.MustCreateColumns[testData]().GetColumnMap()
+
+	SortEntries(cmap, testEntries, []string{"ip"})
+	expected := []string{"9.0.0.1", "10.0.0.2", "10.0.0.10", "::1", "fe80::1", "invalid"}
+	for i, entry := range testEntries {
+		if entry.IP != expected[i] {
+			t.Errorf("expected %q at position %d, got %q", expected[i], i, entry.IP)
+		}
+	}
+
+	SortEntries(cmap, testEntries, []string{"-ip"})
+	if testEntries[0].IP != "invalid" || testEntries[1].IP != "fe80::1" {
+		t.Errorf("expected descending order, got %q, %q", testEntries[0].IP, testEntries[1].IP)
+	}
+
+	// Columns without kind:ip are still sorted as strings
+	SortEntries(cmap, testEntries, []string{"string"})
+	if testEntries[0].String != "10.0.0.10" {
+		t.Errorf("expected value to be 10.0.0.10, got %q", testEntries[0].String)
+	}
+}
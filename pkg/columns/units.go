@@ -0,0 +1,316 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package columns
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unit defines the unit of the (raw) numeric value of a column. It is used to show values in a human-readable way
+// and to allow values like "10ms" or "1MiB" when filtering.
+type Unit string
+
+const (
+	UnitNone         Unit = ""
+	UnitBytes        Unit = "bytes"
+	UnitNanoseconds  Unit = "ns"
+	UnitMicroseconds Unit = "us"
+	UnitMilliseconds Unit = "ms"
+	UnitSeconds      Unit = "s"
+	UnitTimestamp    Unit = "timestamp" // nanoseconds since the Unix epoch
+)
+
+// SemanticKind defines the meaning of the value of a column.
+type SemanticKind string
+
+const (
+	SemanticKindNone   SemanticKind = ""
+	SemanticKindIP     SemanticKind = "ip"     // IPv4 or IPv6 address; sorted numerically
+	SemanticKindErrno  SemanticKind = "errno"  // error number, shown by name (e.g. ENOENT)
+	SemanticKindSignal SemanticKind = "signal" // signal number, shown by name (e.g. SIGKILL)
+)
+
+// TimestampFormat is the layout used to show columns with the timestamp unit
+const TimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+func parseUnit(s string) (Unit, error) {
+	switch u := Unit(s); u {
+	case UnitBytes, UnitNanoseconds, UnitMicroseconds, UnitMilliseconds, UnitSeconds, UnitTimestamp:
+		return u, nil
+	}
+	return UnitNone, fmt.Errorf("unknown unit %q", s)
+}
+
+func parseSemanticKind(s string) (SemanticKind, error) {
+	switch k := SemanticKind(s); k {
+	case SemanticKindIP, SemanticKindErrno, SemanticKindSignal:
+		return k, nil
+	}
+	return SemanticKindNone, fmt.Errorf("unknown kind %q", s)
+}
+
+// nanoseconds returns how many nanoseconds a single unit of a duration is, or 0 if u is not a duration
+func (u Unit) nanoseconds() float64 {
+	switch u {
+	case UnitNanoseconds, UnitTimestamp:
+		return float64(time.Nanosecond)
+	case UnitMicroseconds:
+		return float64(time.Microsecond)
+	case UnitMilliseconds:
+		return float64(time.Millisecond)
+	case UnitSeconds:
+		return float64(time.Second)
+	}
+	return 0
+}
+
+// formatNumber returns the value using three significant digits, without trailing zeroes
+func formatNumber(value float64) string {
+	if math.Abs(value) >= 1000 {
+		return strconv.FormatFloat(value, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(value, 'g', 3, 64)
+}
+
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+func formatBytes(value float64) string {
+	i := 0
+	for math.Abs(value) >= 1024 && i < len(byteUnits)-1 {
+		value /= 1024
+		i++
+	}
+	return formatNumber(value) + byteUnits[i]
+}
+
+var durationUnits = []struct {
+	suffix string
+	ns     float64
+}{
+	{"s", float64(time.Second)},
+	{"ms", float64(time.Millisecond)},
+	{"us", float64(time.Microsecond)},
+	{"ns", float64(time.Nanosecond)},
+}
+
+func formatDuration(ns float64) string {
+	for _, du := range durationUnits {
+		if math.Abs(ns) >= du.ns {
+			return formatNumber(ns/du.ns) + du.suffix
+		}
+	}
+	return formatNumber(ns) + "ns"
+}
+
+// Format returns a human-readable representation of value, which is expected to be given in unit u
+func (u Unit) Format(value float64) string {
+	switch u {
+	case UnitBytes:
+		return formatBytes(value)
+	case UnitNanoseconds, UnitMicroseconds, UnitMilliseconds, UnitSeconds:
+		return formatDuration(value * u.nanoseconds())
+	case UnitTimestamp:
+		return time.Unix(0, int64(value)).Format(TimestampFormat)
+	}
+	return formatNumber(value)
+}
+
+var byteMultipliers = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1000,
+	"kb":  1000,
+	"m":   1000 * 1000,
+	"mb":  1000 * 1000,
+	"g":   1000 * 1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"t":   1000 * 1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+}
+
+func parseBytes(s string) (float64, error) {
+	idx := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	if idx == -1 {
+		idx = len(s)
+	}
+
+	number, err := strconv.ParseFloat(s[:idx], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	multiplier, ok := byteMultipliers[strings.ToLower(strings.TrimSpace(s[idx:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit in %q", s)
+	}
+
+	return number * multiplier, nil
+}
+
+// Parse parses s and returns its value in unit u. s can either be a plain number that will be interpreted as being
+// in unit u already or a value with its own unit, like "10ms" (durations, see time.ParseDuration), "1.5MiB" or "2GB"
+// (bytes) or "2006-01-02T15:04:05Z" (timestamps, RFC3339).
+func (u Unit) Parse(s string) (float64, error) {
+	if number, err := strconv.ParseFloat(s, 64); err == nil {
+		return number, nil
+	}
+
+	switch u {
+	case UnitBytes:
+		return parseBytes(s)
+	case UnitNanoseconds, UnitMicroseconds, UnitMilliseconds, UnitSeconds:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, err
+		}
+		return float64(d) / u.nanoseconds(), nil
+	case UnitTimestamp:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return 0, err
+		}
+		return float64(t.UnixNano()), nil
+	}
+
+	return 0, fmt.Errorf("invalid number %q", s)
+}
+
+func (k SemanticKind) names() map[int64]string {
+	switch k {
+	case SemanticKindErrno:
+		return errnoNames
+	case SemanticKindSignal:
+		return signalNames
+	}
+	return nil
+}
+
+// Format returns the name of the given errno or signal number; if the number is unknown, it will be returned as
+// string
+func (k SemanticKind) Format(value int64) string {
+	if value == 0 {
+		return "0"
+	}
+
+	// Kernel functions usually return negative error numbers
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	if name, ok := k.names()[value]; ok {
+		return sign + name
+	}
+	return sign + strconv.FormatInt(value, 10)
+}
+
+// Parse returns the number of the given errno or signal name; numbers are also accepted
+func (k SemanticKind) Parse(s string) (int64, error) {
+	if number, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return number, nil
+	}
+
+	sign := int64(1)
+	name := strings.ToUpper(s)
+	if strings.HasPrefix(name, "-") {
+		sign = -1
+		name = name[1:]
+	}
+	if k == SemanticKindSignal && !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	for number, curName := range k.names() {
+		if curName == name {
+			return sign * number, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown %s %q", k, s)
+}
+
+// HasHumanFormat returns true if the column has a unit or a kind that changes the way its values are shown
+func (ci *Column[T]) HasHumanFormat() bool {
+	switch ci.SemanticKind {
+	case SemanticKindErrno, SemanticKindSignal:
+		return isInteger(ci.kind)
+	}
+	return ci.Unit != UnitNone
+}
+
+// FormatHuman returns a human-readable representation of v, a value of this column, according to its unit or kind;
+// use HasHumanFormat to check whether the column needs such a treatment
+func (ci *Column[T]) FormatHuman(v reflect.Value) string {
+	switch ci.SemanticKind {
+	case SemanticKindErrno, SemanticKindSignal:
+		switch {
+		case isSigned(ci.kind):
+			return ci.SemanticKind.Format(v.Int())
+		case isUnsigned(ci.kind):
+			return ci.SemanticKind.Format(int64(v.Uint()))
+		}
+	}
+
+	switch {
+	case isSigned(ci.kind):
+		return ci.Unit.Format(float64(v.Int()))
+	case isUnsigned(ci.kind):
+		return ci.Unit.Format(float64(v.Uint()))
+	case isFloat(ci.kind):
+		return ci.Unit.Format(v.Float())
+	}
+
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+func isSigned(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUnsigned(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isInteger(kind reflect.Kind) bool {
+	return isSigned(kind) || isUnsigned(kind)
+}
+
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
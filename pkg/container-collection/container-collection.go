@@ -183,6 +183,22 @@ func (cc *ContainerCollection) LookupContainerByMntns(mntnsid uint64) *Container
 	return container
 }
 
+// LookupContainersByNetns returns a slice of containers that run in a given
+// network namespace. Or an empty slice if there are no containers running in
+// that network namespace.
+func (cc *ContainerCollection) LookupContainersByNetns(netnsid uint64) []*Container {
+	containers := []*Container{}
+
+	cc.containers.Range(func(key, value interface{}) bool {
+		c := value.(*Container)
+		if c.Netns == netnsid {
+			containers = append(containers, c)
+		}
+		return true
+	})
+	return containers
+}
+
 // LookupMntnsByPod returns the mount namespace inodes of all containers
 // belonging to the pod specified in arguments, indexed by the name of the
 // containers or an empty map if not found
@@ -311,6 +327,30 @@ func (cc *ContainerCollection) Enrich(event *eventtypes.CommonData, mountnsid ui
 	}
 }
 
+// EnrichByNetNs enriches the event with the pod running in the given network
+// namespace. The container is only set when it's the only one of the pod, and
+// nothing is set when the network namespace is shared by several pods, as it
+// happens with the host one.
+func (cc *ContainerCollection) EnrichByNetNs(event *eventtypes.CommonData, netnsid uint64) {
+	event.Node = cc.nodeName
+
+	containers := cc.LookupContainersByNetns(netnsid)
+	if len(containers) == 0 {
+		return
+	}
+	for _, c := range containers[1:] {
+		if c.Namespace != containers[0].Namespace || c.Podname != containers[0].Podname {
+			return
+		}
+	}
+
+	event.Namespace = containers[0].Namespace
+	event.Pod = containers[0].Podname
+	if len(containers) == 1 {
+		event.Container = containers[0].Name
+	}
+}
+
 // Subscribe returns the list of existing containers and registers a callback
 // for notifications about additions and deletions of containers
 func (cc *ContainerCollection) Subscribe(key interface{}, selector ContainerSelector, f FuncNotify) []*Container {
@@ -27,6 +27,7 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/containerd"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/crio"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/docker"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/podman"
 	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
 
 	ocispec "github.com/opencontainers/runtime-spec/specs-go"
@@ -36,6 +37,7 @@ var AvailableRuntimes = []string{
 	docker.Name,
 	containerd.Name,
 	crio.Name,
+	podman.Name,
 }
 
 type RuntimeConfig struct {
@@ -51,6 +53,8 @@ func NewContainerRuntimeClient(runtime *RuntimeConfig) (runtimeclient.ContainerR
 		return containerd.NewContainerdClient(runtime.SocketPath)
 	case crio.Name:
 		return crio.NewCrioClient(runtime.SocketPath)
+	case podman.Name:
+		return podman.NewPodmanClient(runtime.SocketPath)
 	default:
 		return nil, fmt.Errorf("unknown container runtime: %s (available %s)",
 			runtime, strings.Join(AvailableRuntimes, ", "))
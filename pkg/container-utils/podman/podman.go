@@ -0,0 +1,231 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+)
+
+const (
+	Name              = "podman"
+	DefaultSocketPath = "/run/podman/podman.sock"
+	DefaultTimeout    = 2 * time.Second
+
+	// The libpod API accepts any version in the path, v3.0.0 is the first
+	// one reporting the cgroup path of the containers.
+	apiPrefix = "http://d/v3.0.0/libpod"
+)
+
+// PodmanClient implements the ContainerRuntimeClient interface using the
+// libpod REST API served by the Podman system service, as Podman doesn't have
+// a long-running daemon providing the CRI.
+type PodmanClient struct {
+	client    *http.Client
+	transport *http.Transport
+}
+
+func NewPodmanClient(socketPath string) (runtimeclient.ContainerRuntimeClient, error) {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+
+	return &PodmanClient{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   DefaultTimeout,
+		},
+		transport: transport,
+	}, nil
+}
+
+// podmanContainer is an entry of the containers/json response.
+type podmanContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// podmanContainerInspect is the containers/{id}/json response.
+type podmanContainerInspect struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	State *struct {
+		Status     string `json:"Status"`
+		Pid        int    `json:"Pid"`
+		CgroupPath string `json:"CgroupPath"`
+	} `json:"State"`
+	Config *struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	Mounts []struct {
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	} `json:"Mounts"`
+}
+
+func (c *PodmanClient) get(path string, v interface{}) error {
+	resp, err := c.client.Get(apiPrefix + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: %s", path, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *PodmanClient) inspectContainer(containerID string) (*podmanContainerInspect, error) {
+	containerID, err := runtimeclient.ParseContainerID(Name, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var container podmanContainerInspect
+	if err := c.get("/containers/"+url.PathEscape(containerID)+"/json", &container); err != nil {
+		return nil, err
+	}
+
+	if container.State == nil {
+		return nil, errors.New("container state is nil")
+	}
+	if container.Config == nil {
+		return nil, errors.New("container config is nil")
+	}
+
+	return &container, nil
+}
+
+func (c *PodmanClient) GetContainers() ([]*runtimeclient.ContainerData, error) {
+	// We need to request for all containers (also non-running) because
+	// when we are enriching a container that is being created, it is not
+	// in "running" state yet.
+	var containers []podmanContainer
+	if err := c.get("/containers/json?all=true", &containers); err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	ret := make([]*runtimeclient.ContainerData, len(containers))
+
+	for i, container := range containers {
+		containerData := &runtimeclient.ContainerData{
+			ID:      container.ID,
+			State:   containerStatusStateToRuntimeClientState(container.State),
+			Runtime: Name,
+		}
+		if len(container.Names) > 0 {
+			containerData.Name = container.Names[0]
+		}
+		runtimeclient.EnrichWithK8sMetadata(containerData, container.Labels)
+
+		ret[i] = containerData
+	}
+
+	return ret, nil
+}
+
+func (c *PodmanClient) GetContainer(containerID string) (*runtimeclient.ContainerData, error) {
+	container, err := c.inspectContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return containerInspectToContainerData(container), nil
+}
+
+func (c *PodmanClient) GetContainerDetails(containerID string) (*runtimeclient.ContainerDetailsData, error) {
+	container, err := c.inspectContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if container.State.Pid == 0 {
+		return nil, errors.New("got zero pid")
+	}
+
+	containerDetailsData := runtimeclient.ContainerDetailsData{
+		ContainerData: *containerInspectToContainerData(container),
+		Pid:           container.State.Pid,
+		CgroupsPath:   container.State.CgroupPath,
+	}
+	if len(container.Mounts) > 0 {
+		containerDetailsData.Mounts = make([]runtimeclient.ContainerMountData, len(container.Mounts))
+		for i, containerMount := range container.Mounts {
+			containerDetailsData.Mounts[i] = runtimeclient.ContainerMountData{
+				Destination: containerMount.Destination,
+				Source:      containerMount.Source,
+			}
+		}
+	}
+
+	return &containerDetailsData, nil
+}
+
+func (c *PodmanClient) Close() error {
+	c.transport.CloseIdleConnections()
+	return nil
+}
+
+// Convert the state from container status to state of runtime client.
+func containerStatusStateToRuntimeClientState(containerState string) (runtimeClientState string) {
+	switch containerState {
+	case "configured", "created":
+		runtimeClientState = runtimeclient.StateCreated
+	case "running":
+		runtimeClientState = runtimeclient.StateRunning
+	case "exited", "stopped":
+		runtimeClientState = runtimeclient.StateExited
+	default:
+		runtimeClientState = runtimeclient.StateUnknown
+	}
+	return
+}
+
+func containerInspectToContainerData(container *podmanContainerInspect) *runtimeclient.ContainerData {
+	containerData := &runtimeclient.ContainerData{
+		ID:      container.ID,
+		Name:    container.Name,
+		State:   containerStatusStateToRuntimeClientState(container.State.Status),
+		Runtime: Name,
+	}
+
+	// Fill K8S information.
+	runtimeclient.EnrichWithK8sMetadata(containerData, container.Config.Labels)
+
+	return containerData
+}
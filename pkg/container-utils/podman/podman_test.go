@@ -0,0 +1,136 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podman
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+)
+
+const (
+	testContainerList = `[{
+	"Id": "4c8e6f1a2b",
+	"Names": ["web"],
+	"State": "running",
+	"Labels": {"io.kubernetes.pod.name": "web-pod", "io.kubernetes.pod.namespace": "demo"}
+}, {
+	"Id": "9d0a7b3c4e",
+	"Names": ["job"],
+	"State": "exited"
+}]`
+	testContainerInspect = `{
+	"Id": "4c8e6f1a2b",
+	"Name": "web",
+	"State": {"Status": "running", "Pid": 1234, "CgroupPath": "/machine.slice/libpod-4c8e6f1a2b.scope"},
+	"Config": {"Labels": {}},
+	"Mounts": [{"Source": "/srv/web", "Destination": "/usr/share/nginx/html"}]
+}`
+)
+
+func newTestClient(t *testing.T) runtimeclient.ContainerRuntimeClient {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3.0.0/libpod/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "true" {
+			t.Errorf("Listing containers without all=true: %s", r.URL)
+		}
+		w.Write([]byte(testContainerList))
+	})
+	mux.HandleFunc("/v3.0.0/libpod/containers/4c8e6f1a2b/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testContainerInspect))
+	})
+
+	socketPath := filepath.Join(t.TempDir(), "podman.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %s", socketPath, err)
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	client, err := NewPodmanClient(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create client: %s", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestGetContainers(t *testing.T) {
+	client := newTestClient(t)
+
+	containers, err := client.GetContainers()
+	if err != nil {
+		t.Fatalf("Failed to get containers: %s", err)
+	}
+
+	expected := []*runtimeclient.ContainerData{
+		{
+			ID:           "4c8e6f1a2b",
+			Name:         "web",
+			State:        runtimeclient.StateRunning,
+			Runtime:      Name,
+			PodName:      "web-pod",
+			PodNamespace: "demo",
+		},
+		{
+			ID:      "9d0a7b3c4e",
+			Name:    "job",
+			State:   runtimeclient.StateExited,
+			Runtime: Name,
+		},
+	}
+	if diff := cmp.Diff(expected, containers); diff != "" {
+		t.Fatalf("Unexpected containers (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetContainerDetails(t *testing.T) {
+	client := newTestClient(t)
+
+	details, err := client.GetContainerDetails("podman://4c8e6f1a2b")
+	if err != nil {
+		t.Fatalf("Failed to get container details: %s", err)
+	}
+
+	expected := &runtimeclient.ContainerDetailsData{
+		ContainerData: runtimeclient.ContainerData{
+			ID:      "4c8e6f1a2b",
+			Name:    "web",
+			State:   runtimeclient.StateRunning,
+			Runtime: Name,
+		},
+		Pid:         1234,
+		CgroupsPath: "/machine.slice/libpod-4c8e6f1a2b.scope",
+		Mounts: []runtimeclient.ContainerMountData{
+			{Source: "/srv/web", Destination: "/usr/share/nginx/html"},
+		},
+	}
+	if diff := cmp.Diff(expected, details); diff != "" {
+		t.Fatalf("Unexpected container details (-want +got):\n%s", diff)
+	}
+
+	if _, err := client.GetContainer("1f2e3d4c5b"); err == nil {
+		t.Fatal("Expected an error for an unknown container")
+	}
+}
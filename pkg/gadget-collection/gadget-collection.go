@@ -20,25 +20,31 @@ import (
 	auditseccomp "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/audit/seccomp"
 	biolatency "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/profile/block-io"
 	profile "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/profile/cpu"
+	tcpconnlat "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/profile/tcpconnect-latency"
 	processcollector "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/snapshot/process"
 	socketcollector "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/snapshot/socket"
 	biotop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/top/block-io"
 	ebpftop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/top/ebpf"
 	filetop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/top/file"
 	tcptop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/top/tcp"
+	udptop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/top/udp"
 	bindsnoop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/bind"
 	capabilities "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/capabilities"
 	dns "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/dns"
+	drops "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/drops"
 	execsnoop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/exec"
 	fsslower "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/fsslower"
 	mountsnoop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/mount"
 	networkgraph "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/network"
 	oomkill "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/oomkill"
 	opensnoop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/open"
+	packets "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/packets"
 	sigsnoop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/signal"
 	snisnoop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/sni"
 	tcptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/tcp"
 	tcpconnect "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/tcpconnect"
+	tcplife "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/tcplife"
+	tcpretrans "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/tcpretrans"
 	traceloop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/traceloop"
 )
 
@@ -50,6 +56,7 @@ func TraceFactories() map[string]gadgets.TraceFactory {
 		"biotop":            biotop.NewFactory(),
 		"capabilities":      capabilities.NewFactory(),
 		"dns":               dns.NewFactory(),
+		"drops":             drops.NewFactory(),
 		"ebpftop":           ebpftop.NewFactory(),
 		"execsnoop":         execsnoop.NewFactory(),
 		"filetop":           filetop.NewFactory(),
@@ -58,6 +65,7 @@ func TraceFactories() map[string]gadgets.TraceFactory {
 		"mountsnoop":        mountsnoop.NewFactory(),
 		"network-graph":     networkgraph.NewFactory(),
 		"oomkill":           oomkill.NewFactory(),
+		"packets":           packets.NewFactory(),
 		"process-collector": processcollector.NewFactory(),
 		"profile":           profile.NewFactory(),
 		"seccomp":           seccomp.NewFactory(),
@@ -65,9 +73,13 @@ func TraceFactories() map[string]gadgets.TraceFactory {
 		"snisnoop":          snisnoop.NewFactory(),
 		"socket-collector":  socketcollector.NewFactory(),
 		"tcpconnect":        tcpconnect.NewFactory(),
+		"tcpconnlat":        tcpconnlat.NewFactory(),
+		"tcplife":           tcplife.NewFactory(),
+		"tcpretrans":        tcpretrans.NewFactory(),
 		"tcptop":            tcptop.NewFactory(),
 		"tcptracer":         tcptracer.NewFactory(),
 		"traceloop":         traceloop.NewFactory(),
+		"udptop":            udptop.NewFactory(),
 	}
 }
 
@@ -76,11 +88,13 @@ func TraceFactoriesForLocalGadget() map[string]gadgets.TraceFactory {
 		"audit-seccomp":     auditseccomp.NewFactory(),
 		"capabilities":      capabilities.NewFactory(),
 		"dns":               dns.NewFactory(),
+		"drops":             drops.NewFactory(),
 		"ebpftop":           ebpftop.NewFactory(),
 		"network-graph":     networkgraph.NewFactory(),
 		"process-collector": processcollector.NewFactory(),
 		"socket-collector":  socketcollector.NewFactory(),
 		"seccomp":           seccomp.NewFactory(),
 		"snisnoop":          snisnoop.NewFactory(),
+		"tcpretrans":        tcpretrans.NewFactory(),
 	}
 }
@@ -0,0 +1,487 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seccomp
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	seccompprofile "sigs.k8s.io/security-profiles-operator/api/seccompprofile/v1beta1"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+)
+
+const (
+	// aggregateParam enables the generation of one SeccompProfile per
+	// workload and container name instead of one per container.
+	aggregateParam = "aggregate"
+
+	podsAnnotation              = "seccomp.gadget.kinvolk.io/pods"
+	syscallPodsAnnotation       = "seccomp.gadget.kinvolk.io/syscall-pods"
+	nodeContributionsAnnotation = "seccomp.gadget.kinvolk.io/node-contributions"
+
+	// maxNodeContributionsSize is the maximum size of the
+	// nodeContributionsAnnotation. The annotations of an object are limited
+	// to 256KiB in total and the other ones, e.g. syscallPodsAnnotation,
+	// also need some room.
+	maxNodeContributionsSize = 64 * 1024
+
+	// otherNodes is the node under which the contributions of the other
+	// nodes are merged when the nodeContributionsAnnotation becomes too
+	// big.
+	otherNodes = "*"
+)
+
+// workload identifies the containers whose syscalls are merged in the same
+// SeccompProfile: the containers with the same name in all the pods owned by
+// the same resource. Pods without owner are a workload on their own.
+type workload struct {
+	namespace string
+	kind      string
+	name      string
+	container string
+}
+
+func getWorkload(c *containercollection.Container, ownerReference *metav1.OwnerReference) workload {
+	w := workload{
+		namespace: c.Namespace,
+		kind:      "Pod",
+		name:      c.Podname,
+		container: c.Name,
+	}
+	if ownerReference != nil {
+		w.kind = ownerReference.Kind
+		w.name = ownerReference.Name
+	}
+	return w
+}
+
+func (w workload) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s", w.namespace, w.kind, w.name, w.container)
+}
+
+// getAggregatedSeccompProfileNsName computes the namespace and name of the
+// SeccompProfile of a workload. Contrary to getSeccompProfileNsName, the name
+// doesn't depend on the existing profiles so that the gadget of each node
+// updates the same resource. traceOutputName can be used to set the namespace
+// and a prefix for the name, like for the profiles of single containers.
+func getAggregatedSeccompProfileNsName(traceNs, traceOutputName string, w workload) *SeccompProfileNsName {
+	name := strings.ToLower(fmt.Sprintf("%s-%s-%s-%s", w.namespace, w.kind, w.name, w.container))
+	namespace := traceNs
+
+	if traceOutputName != "" {
+		prefix := traceOutputName
+		parts := strings.SplitN(traceOutputName, "/", 2)
+		if len(parts) == 2 {
+			namespace = parts[0]
+			prefix = parts[1]
+		}
+		name = prefix + "-" + name
+	}
+
+	return &SeccompProfileNsName{
+		namespace:    namespace,
+		name:         name,
+		generateName: false,
+	}
+}
+
+// nodeContribution contains the syscalls used by the pods of a workload
+// running on a given node.
+type nodeContribution struct {
+	// Pods is the number of pods of the workload seen on the node.
+	Pods int `json:"pods"`
+
+	// Syscalls gives, for each syscall, the number of pods that used it.
+	Syscalls map[string]int `json:"syscalls"`
+}
+
+// storedContributions is the compact form of the contributions of all the
+// nodes kept in the nodeContributionsAnnotation: the names of the syscalls are
+// stored only once and, for each node, Counts has the number of pods that
+// used each of them, as uvarints in the order of Syscalls.
+type storedContributions struct {
+	Syscalls []string                          `json:"syscalls"`
+	Nodes    map[string]storedNodeContribution `json:"nodes"`
+}
+
+type storedNodeContribution struct {
+	Pods   int    `json:"pods"`
+	Counts []byte `json:"counts"`
+}
+
+func compactContributions(contributions map[string]*nodeContribution) *storedContributions {
+	_, syscallPods := mergeContributions(contributions)
+
+	s := &storedContributions{
+		Syscalls: make([]string, 0, len(syscallPods)),
+		Nodes:    make(map[string]storedNodeContribution, len(contributions)),
+	}
+	for name := range syscallPods {
+		s.Syscalls = append(s.Syscalls, name)
+	}
+	sort.Strings(s.Syscalls)
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	for node, c := range contributions {
+		if c == nil {
+			continue
+		}
+		counts := make([]byte, 0, len(s.Syscalls))
+		for _, name := range s.Syscalls {
+			n := binary.PutUvarint(buf, uint64(c.Syscalls[name]))
+			counts = append(counts, buf[:n]...)
+		}
+		s.Nodes[node] = storedNodeContribution{Pods: c.Pods, Counts: counts}
+	}
+
+	return s
+}
+
+// encodeContributions returns the value of the nodeContributionsAnnotation.
+// If it would be bigger than maxNodeContributionsSize, the contributions of
+// all the nodes but the given one are merged under otherNodes. The syscalls
+// they used are kept but, as their own contributions can't be replaced
+// anymore, the counters of the pods running on them could be counted again
+// if they update the profile later.
+func encodeContributions(contributions map[string]*nodeContribution, node string) (string, error) {
+	b, err := json.Marshal(compactContributions(contributions))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal node contributions: %w", err)
+	}
+	if len(b) <= maxNodeContributionsSize {
+		return string(b), nil
+	}
+
+	others := make(map[string]*nodeContribution, len(contributions))
+	for name, c := range contributions {
+		if name != node {
+			others[name] = c
+		}
+	}
+	pods, syscallPods := mergeContributions(others)
+	compacted := map[string]*nodeContribution{
+		otherNodes: {Pods: pods, Syscalls: syscallPods},
+	}
+	if c, ok := contributions[node]; ok {
+		compacted[node] = c
+	}
+
+	b, err = json.Marshal(compactContributions(compacted))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal node contributions: %w", err)
+	}
+	return string(b), nil
+}
+
+// decodeContributions parses the value of the nodeContributionsAnnotation.
+func decodeContributions(annotation string) (map[string]*nodeContribution, error) {
+	s := &storedContributions{}
+	if err := json.Unmarshal([]byte(annotation), s); err != nil {
+		return nil, err
+	}
+
+	contributions := make(map[string]*nodeContribution, len(s.Nodes))
+	for node, stored := range s.Nodes {
+		c := &nodeContribution{
+			Pods:     stored.Pods,
+			Syscalls: make(map[string]int),
+		}
+		counts := stored.Counts
+		for _, name := range s.Syscalls {
+			count, n := binary.Uvarint(counts)
+			if n <= 0 {
+				return nil, fmt.Errorf("invalid counters for node %q", node)
+			}
+			counts = counts[n:]
+			if count != 0 {
+				c.Syscalls[name] = int(count)
+			}
+		}
+		if len(counts) != 0 {
+			return nil, fmt.Errorf("invalid counters for node %q", node)
+		}
+		contributions[node] = c
+	}
+
+	return contributions, nil
+}
+
+type workloadSyscalls struct {
+	ownerReference *metav1.OwnerReference
+
+	// pods has the syscalls used by each pod of the workload. Several
+	// containers of the same pod, e.g. after a restart, are merged together.
+	pods map[string][]byte
+}
+
+// aggregator merges the syscalls of the containers of the same workload seen
+// on this node.
+type aggregator struct {
+	mu        sync.Mutex
+	workloads map[workload]*workloadSyscalls
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		workloads: make(map[workload]*workloadSyscalls),
+	}
+}
+
+// add merges the syscalls used by a container of the pod podname into the
+// ones of its workload.
+func (a *aggregator) add(w workload, ownerReference *metav1.OwnerReference, podname string, syscalls []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ws, ok := a.workloads[w]
+	if !ok {
+		ws = &workloadSyscalls{
+			pods: make(map[string][]byte),
+		}
+		a.workloads[w] = ws
+	}
+	if ownerReference != nil {
+		ws.ownerReference = ownerReference
+	}
+
+	podSyscalls, ok := ws.pods[podname]
+	if !ok {
+		podSyscalls = make([]byte, len(syscalls))
+		ws.pods[podname] = podSyscalls
+	}
+	for i, val := range syscalls {
+		if val != 0 && i < len(podSyscalls) {
+			podSyscalls[i] = 1
+		}
+	}
+}
+
+// contribution returns the owner reference of the workload and the syscalls
+// used by its pods seen on this node.
+func (a *aggregator) contribution(w workload) (*metav1.OwnerReference, *nodeContribution) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c := &nodeContribution{
+		Syscalls: make(map[string]int),
+	}
+
+	ws, ok := a.workloads[w]
+	if !ok {
+		return nil, c
+	}
+
+	c.Pods = len(ws.pods)
+	for _, podSyscalls := range ws.pods {
+		for i, val := range podSyscalls {
+			if val != 0 {
+				c.Syscalls[syscallName(i)]++
+			}
+		}
+	}
+
+	return ws.ownerReference, c
+}
+
+// mergeContributions returns the total number of pods of a workload and, for
+// each syscall, the number of pods that used it, on all the nodes.
+func mergeContributions(contributions map[string]*nodeContribution) (int, map[string]int) {
+	pods := 0
+	syscallPods := make(map[string]int)
+	for _, c := range contributions {
+		if c == nil {
+			continue
+		}
+		pods += c.Pods
+		for name, count := range c.Syscalls {
+			syscallPods[name] += count
+		}
+	}
+	return pods, syscallPods
+}
+
+// generateAggregatedSeccompPolicy generates the seccomp policy of a workload
+// allowing the syscalls used by any of its pods on any node.
+func generateAggregatedSeccompPolicy(
+	trace *gadgetv1alpha1.Trace,
+	profileName *SeccompProfileNsName,
+	w workload,
+	ownerReference *metav1.OwnerReference,
+	contributions map[string]*nodeContribution,
+) (*seccompprofile.SeccompProfile, error) {
+	pods, syscallPods := mergeContributions(contributions)
+
+	names := make([]string, 0, len(syscallPods))
+	for name := range syscallPods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r := syscallNamesToSeccompPolicy(profileName, names)
+
+	syscallPodsJSON, err := json.Marshal(syscallPods)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal syscall counters: %w", err)
+	}
+	contributionsAnnotation, err := encodeContributions(contributions, trace.Spec.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	traceName := fmt.Sprintf("%s/%s", trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+	r.ObjectMeta.Annotations["seccomp.gadget.kinvolk.io/trace"] = traceName
+	r.ObjectMeta.Annotations["seccomp.gadget.kinvolk.io/namespace"] = w.namespace
+	r.ObjectMeta.Annotations["seccomp.gadget.kinvolk.io/container"] = w.container
+	if ownerReference != nil {
+		r.ObjectMeta.Annotations["seccomp.gadget.kinvolk.io/ownerReference-APIVersion"] = ownerReference.APIVersion
+		r.ObjectMeta.Annotations["seccomp.gadget.kinvolk.io/ownerReference-Kind"] = ownerReference.Kind
+		r.ObjectMeta.Annotations["seccomp.gadget.kinvolk.io/ownerReference-Name"] = ownerReference.Name
+		r.ObjectMeta.Annotations["seccomp.gadget.kinvolk.io/ownerReference-UID"] = string(ownerReference.UID)
+	} else {
+		r.ObjectMeta.Annotations["seccomp.gadget.kinvolk.io/pod"] = w.namespace + "/" + w.name
+	}
+	r.ObjectMeta.Annotations[podsAnnotation] = strconv.Itoa(pods)
+	r.ObjectMeta.Annotations[syscallPodsAnnotation] = string(syscallPodsJSON)
+	r.ObjectMeta.Annotations[nodeContributionsAnnotation] = contributionsAnnotation
+
+	for key, value := range trace.ObjectMeta.Labels {
+		r.ObjectMeta.Labels[key] = value
+	}
+
+	return r, nil
+}
+
+// updateAggregatedSeccompProfile creates or updates the SeccompProfile of a
+// workload with the syscalls seen on this node. The contributions of the
+// other nodes, stored in the profile, are kept so that the profile allows the
+// syscalls used on any of them.
+func (t *Trace) updateAggregatedSeccompProfile(trace *gadgetv1alpha1.Trace, w workload) error {
+	ownerReference, contribution := t.aggregator.contribution(w)
+	profileName := getAggregatedSeccompProfileNsName(trace.ObjectMeta.Namespace, trace.Spec.Output, w)
+
+	switch trace.Spec.OutputMode {
+	case gadgetv1alpha1.TraceOutputModeExternalResource:
+		if t.client == nil {
+			return fmt.Errorf("no Kubernetes client to write SeccompProfile %s/%s", profileName.namespace, profileName.name)
+		}
+
+		retriable := func(err error) bool {
+			// Another node created or updated the profile in the meantime
+			return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+		}
+
+		return retry.OnError(retry.DefaultRetry, retriable, func() error {
+			existing := &seccompprofile.SeccompProfile{}
+			err := t.client.Get(context.TODO(), client.ObjectKey{
+				Namespace: profileName.namespace,
+				Name:      profileName.name,
+			}, existing)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to get SeccompProfile %s/%s: %w", profileName.namespace, profileName.name, err)
+			}
+			found := err == nil
+
+			contributions := map[string]*nodeContribution{}
+			if found {
+				if s, ok := existing.ObjectMeta.Annotations[nodeContributionsAnnotation]; ok {
+					contributions, err = decodeContributions(s)
+					if err != nil {
+						return fmt.Errorf("failed to parse %s annotation of SeccompProfile %s/%s: %w",
+							nodeContributionsAnnotation, profileName.namespace, profileName.name, err)
+					}
+				}
+			}
+			contributions[trace.Spec.Node] = contribution
+
+			r, err := generateAggregatedSeccompPolicy(trace, profileName, w, ownerReference, contributions)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				return t.client.Create(context.TODO(), r)
+			}
+
+			// Keep the fields not managed by the gadget, e.g. the
+			// annotations and labels added by the user.
+			existing.Spec = r.Spec
+			if existing.ObjectMeta.Annotations == nil {
+				existing.ObjectMeta.Annotations = map[string]string{}
+			}
+			for key, value := range r.ObjectMeta.Annotations {
+				existing.ObjectMeta.Annotations[key] = value
+			}
+			if existing.ObjectMeta.Labels == nil {
+				existing.ObjectMeta.Labels = map[string]string{}
+			}
+			for key, value := range r.ObjectMeta.Labels {
+				existing.ObjectMeta.Labels[key] = value
+			}
+
+			return t.client.Update(context.TODO(), existing)
+		})
+	default:
+		return fmt.Errorf("OutputMode not supported with %s: %s", aggregateParam, trace.Spec.OutputMode)
+	}
+}
+
+// generateAggregated merges the syscalls of the running containers matching
+// the filter into the ones of their workload and updates the profiles of these
+// workloads.
+func (t *Trace) generateAggregated(trace *gadgetv1alpha1.Trace) {
+	selector := gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter)
+	containers := t.helpers.GetContainersBySelector(selector)
+
+	workloads := make(map[workload]struct{})
+	for _, container := range containers {
+		if container.Mntns == 0 {
+			continue
+		}
+
+		ownerReference := getContainerOwnerReference(container)
+		w := getWorkload(container, ownerReference)
+		t.aggregator.add(w, ownerReference, container.Podname, traceSingleton.tracer.Peek(container.Mntns))
+		workloads[w] = struct{}{}
+	}
+
+	if len(workloads) == 0 {
+		// Notify this only if the profiles were not already generated at
+		// container termination
+		if !t.policyGenerated {
+			trace.Status.OperationWarning = "No container matches the requested filter"
+		}
+		return
+	}
+
+	for w := range workloads {
+		if err := t.updateAggregatedSeccompProfile(trace, w); err != nil {
+			trace.Status.OperationError = fmt.Sprintf("Failed to update seccomp profile of %s: %s", w, err)
+			return
+		}
+	}
+}
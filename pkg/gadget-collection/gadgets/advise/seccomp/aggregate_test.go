@@ -0,0 +1,264 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seccomp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachineryruntime "k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	seccompprofile "sigs.k8s.io/security-profiles-operator/api/seccompprofile/v1beta1"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+)
+
+func syscallArr(syscalls ...int) []byte {
+	b := make([]byte, 16)
+	for _, i := range syscalls {
+		b[i] = 1
+	}
+	return b
+}
+
+func TestGetWorkload(t *testing.T) {
+	c := &containercollection.Container{
+		Namespace: "demo",
+		Podname:   "web-5d8f7b7c9-x2x4z",
+		Name:      "nginx",
+	}
+
+	w := getWorkload(c, &metav1.OwnerReference{Kind: "Deployment", Name: "web"})
+	expected := workload{namespace: "demo", kind: "Deployment", name: "web", container: "nginx"}
+	if w != expected {
+		t.Fatalf("expected workload %v, got %v", expected, w)
+	}
+
+	// Pods without owner are their own workload
+	w = getWorkload(c, nil)
+	expected = workload{namespace: "demo", kind: "Pod", name: "web-5d8f7b7c9-x2x4z", container: "nginx"}
+	if w != expected {
+		t.Fatalf("expected workload %v, got %v", expected, w)
+	}
+}
+
+func TestGetAggregatedSeccompProfileNsName(t *testing.T) {
+	w := workload{namespace: "demo", kind: "Deployment", name: "web", container: "nginx"}
+
+	tests := []struct {
+		output    string
+		namespace string
+		name      string
+	}{
+		{"", "gadget", "demo-deployment-web-nginx"},
+		{"prefix", "gadget", "prefix-demo-deployment-web-nginx"},
+		{"profiles/prefix", "profiles", "prefix-demo-deployment-web-nginx"},
+	}
+
+	for _, test := range tests {
+		p := getAggregatedSeccompProfileNsName("gadget", test.output, w)
+		if p.namespace != test.namespace || p.name != test.name || p.generateName {
+			t.Fatalf("output %q: expected %s/%s, got %s/%s (generateName: %t)",
+				test.output, test.namespace, test.name, p.namespace, p.name, p.generateName)
+		}
+	}
+}
+
+func TestAggregatorContribution(t *testing.T) {
+	a := newAggregator()
+	w := workload{namespace: "demo", kind: "Deployment", name: "web", container: "nginx"}
+	owner := &metav1.OwnerReference{Kind: "Deployment", Name: "web"}
+
+	a.add(w, owner, "web-1", syscallArr(0, 1))
+	a.add(w, owner, "web-2", syscallArr(0, 2))
+	// A restarted container of the same pod must not be counted twice
+	a.add(w, owner, "web-1", syscallArr(0, 3))
+
+	ownerReference, c := a.contribution(w)
+	if ownerReference != owner {
+		t.Fatalf("expected owner reference %v, got %v", owner, ownerReference)
+	}
+
+	expected := &nodeContribution{
+		Pods: 2,
+		Syscalls: map[string]int{
+			syscallName(0): 2,
+			syscallName(1): 1,
+			syscallName(2): 1,
+			syscallName(3): 1,
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("expected contribution %+v, got %+v", expected, c)
+	}
+
+	// Unknown workloads don't have any syscall
+	_, c = a.contribution(workload{namespace: "demo", kind: "Pod", name: "other", container: "nginx"})
+	if c.Pods != 0 || len(c.Syscalls) != 0 {
+		t.Fatalf("expected empty contribution, got %+v", c)
+	}
+}
+
+func TestUpdateAggregatedSeccompProfile(t *testing.T) {
+	scheme := apimachineryruntime.NewScheme()
+	if err := seccompprofile.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add SeccompProfile to the scheme: %s", err)
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	w := workload{namespace: "demo", kind: "Deployment", name: "web", container: "nginx"}
+	owner := &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "1234"}
+
+	// Simulate the gadget of two nodes, each one having seen some pods of
+	// the same deployment
+	for _, node := range []struct {
+		name string
+		pods map[string][]byte
+	}{
+		{"node-1", map[string][]byte{"web-1": syscallArr(0, 1), "web-2": syscallArr(0, 2)}},
+		{"node-2", map[string][]byte{"web-3": syscallArr(0, 4)}},
+	} {
+		tr := &Trace{
+			client:     cli,
+			aggregator: newAggregator(),
+		}
+		trace := &gadgetv1alpha1.Trace{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "gadget",
+				Name:      "seccomp",
+				Labels:    map[string]string{"global-trace-id": "abcd"},
+			},
+			Spec: gadgetv1alpha1.TraceSpec{
+				Node:       node.name,
+				OutputMode: gadgetv1alpha1.TraceOutputModeExternalResource,
+			},
+		}
+		for pod, syscalls := range node.pods {
+			tr.aggregator.add(w, owner, pod, syscalls)
+		}
+
+		// Updating the profile twice with the same syscalls must not
+		// change the counters
+		for i := 0; i < 2; i++ {
+			if err := tr.updateAggregatedSeccompProfile(trace, w); err != nil {
+				t.Fatalf("%s: failed to update profile: %s", node.name, err)
+			}
+		}
+	}
+
+	profile := &seccompprofile.SeccompProfile{}
+	err := cli.Get(context.TODO(), client.ObjectKey{Namespace: "gadget", Name: "demo-deployment-web-nginx"}, profile)
+	if err != nil {
+		t.Fatalf("failed to get profile: %s", err)
+	}
+
+	expectedNames := []string{syscallName(0), syscallName(1), syscallName(2), syscallName(4)}
+	sort.Strings(expectedNames)
+	if len(profile.Spec.Syscalls) != 1 || !reflect.DeepEqual(profile.Spec.Syscalls[0].Names, expectedNames) {
+		t.Fatalf("expected syscalls %v, got %+v", expectedNames, profile.Spec.Syscalls)
+	}
+
+	if pods := profile.Annotations[podsAnnotation]; pods != "3" {
+		t.Fatalf("expected 3 pods, got %q", pods)
+	}
+
+	syscallPods := map[string]int{}
+	if err := json.Unmarshal([]byte(profile.Annotations[syscallPodsAnnotation]), &syscallPods); err != nil {
+		t.Fatalf("failed to parse %s: %s", syscallPodsAnnotation, err)
+	}
+	expectedSyscallPods := map[string]int{
+		syscallName(0): 3,
+		syscallName(1): 1,
+		syscallName(2): 1,
+		syscallName(4): 1,
+	}
+	if !reflect.DeepEqual(syscallPods, expectedSyscallPods) {
+		t.Fatalf("expected syscall pods %v, got %v", expectedSyscallPods, syscallPods)
+	}
+
+	if kind := profile.Annotations["seccomp.gadget.kinvolk.io/ownerReference-Kind"]; kind != "Deployment" {
+		t.Fatalf("expected Deployment owner reference, got %q", kind)
+	}
+	if id := profile.Labels["global-trace-id"]; id != "abcd" {
+		t.Fatalf("expected trace label to be copied, got %q", id)
+	}
+}
+
+func TestEncodeContributions(t *testing.T) {
+	contributions := map[string]*nodeContribution{
+		"node-1": {Pods: 2, Syscalls: map[string]int{"read": 2, "write": 1}},
+		"node-2": {Pods: 300, Syscalls: map[string]int{"read": 300, "openat": 150}},
+	}
+
+	s, err := encodeContributions(contributions, "node-1")
+	if err != nil {
+		t.Fatalf("failed to encode contributions: %s", err)
+	}
+	decoded, err := decodeContributions(s)
+	if err != nil {
+		t.Fatalf("failed to decode contributions: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, contributions) {
+		t.Fatalf("expected contributions %+v, got %+v", contributions, decoded)
+	}
+
+	if _, err := decodeContributions(`{"syscalls":["read","write"],"nodes":{"node-1":{"pods":1,"counts":"AQ=="}}}`); err == nil {
+		t.Fatalf("expected error with missing counters")
+	}
+}
+
+func TestEncodeContributionsCompaction(t *testing.T) {
+	syscalls := map[string]int{}
+	for i := 0; i < 300; i++ {
+		syscalls[syscallName(i)] = 1
+	}
+
+	// Enough nodes to exceed the maximum size of the annotation
+	contributions := map[string]*nodeContribution{}
+	for i := 0; i < 1000; i++ {
+		contributions[fmt.Sprintf("node-%d", i)] = &nodeContribution{Pods: 1, Syscalls: syscalls}
+	}
+	contributions["node-0"] = &nodeContribution{Pods: 1, Syscalls: map[string]int{"custom": 1}}
+
+	s, err := encodeContributions(contributions, "node-0")
+	if err != nil {
+		t.Fatalf("failed to encode contributions: %s", err)
+	}
+	if len(s) > maxNodeContributionsSize {
+		t.Fatalf("annotation too big: %d bytes", len(s))
+	}
+
+	decoded, err := decodeContributions(s)
+	if err != nil {
+		t.Fatalf("failed to decode contributions: %s", err)
+	}
+	if len(decoded) != 2 || !reflect.DeepEqual(decoded["node-0"], contributions["node-0"]) {
+		t.Fatalf("unexpected contributions after compaction: %v", decoded)
+	}
+
+	// The syscalls and counters of all the nodes are kept
+	pods, syscallPods := mergeContributions(decoded)
+	expectedPods, expectedSyscallPods := mergeContributions(contributions)
+	if pods != expectedPods || !reflect.DeepEqual(syscallPods, expectedSyscallPods) {
+		t.Fatalf("expected %d pods and %v, got %d pods and %v", expectedPods, expectedSyscallPods, pods, syscallPods)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seccomp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	seccomptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+)
+
+const (
+	// syscallArgsParam enables the recording of the values of syscall
+	// arguments. It's a comma-separated list of syscall:index[:index...],
+	// or defaultSyscallArgs to record the arguments of defaultArgs.
+	syscallArgsParam   = "syscall-args"
+	defaultSyscallArgs = "default"
+
+	// maxArgRules is the maximum number of rules generated for a syscall.
+	// The arguments taking the most values are not restricted until the
+	// number of combinations fits.
+	maxArgRules = 64
+)
+
+// defaultArgs are the arguments recorded with defaultSyscallArgs. Their values
+// select the kind of operation done by the syscall, rather than being
+// pointers or sizes.
+var defaultArgs = map[string][]int{
+	"clone":       {0},    // flags
+	"ioctl":       {1},    // request
+	"personality": {0},    // persona
+	"prctl":       {0},    // option
+	"setns":       {1},    // nstype
+	"socket":      {0, 1}, // domain, type
+	"socketpair":  {0},    // domain
+	"unshare":     {0},    // flags
+}
+
+// parseSyscallArgs parses the value of syscallArgsParam into a bitmask of the
+// argument indexes to record for each syscall name.
+func parseSyscallArgs(val string) (map[string]uint8, error) {
+	syscallArgs := map[string]uint8{}
+
+	if val == defaultSyscallArgs {
+		for name, indexes := range defaultArgs {
+			for _, i := range indexes {
+				syscallArgs[name] |= 1 << i
+			}
+		}
+		return syscallArgs, nil
+	}
+
+	for _, entry := range strings.Split(val, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%q should be syscall:index[:index...]", entry)
+		}
+
+		for _, index := range parts[1:] {
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 || i >= seccomptracer.SyscallArgsCount {
+				return nil, fmt.Errorf("%q is not a valid argument index for %q, it should be between 0 and %d",
+					index, parts[0], seccomptracer.SyscallArgsCount-1)
+			}
+			syscallArgs[parts[0]] |= 1 << i
+		}
+	}
+
+	return syscallArgs, nil
+}
+
+// syscallArgConditions returns the combinations of argument values the
+// syscall was called with, each of them giving a rule allowing the syscall.
+// It returns nil when none of the arguments can be restricted.
+func syscallArgConditions(syscallArgs map[int]*seccomptracer.ArgValues) [][]specs.LinuxSeccompArg {
+	indexes := []int{}
+	for i, values := range syscallArgs {
+		if values.Overflow || len(values.Values) == 0 {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+
+	// Drop the arguments with the most values first to keep the number of
+	// combinations reasonable.
+	sort.Slice(indexes, func(a, b int) bool {
+		la, lb := len(syscallArgs[indexes[a]].Values), len(syscallArgs[indexes[b]].Values)
+		if la != lb {
+			return la < lb
+		}
+		return indexes[a] < indexes[b]
+	})
+	for len(indexes) > 0 {
+		combinations := 1
+		for _, i := range indexes {
+			combinations *= len(syscallArgs[i].Values)
+		}
+		if combinations <= maxArgRules {
+			break
+		}
+		indexes = indexes[:len(indexes)-1]
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	sort.Ints(indexes)
+
+	conditions := [][]specs.LinuxSeccompArg{{}}
+	for _, i := range indexes {
+		next := make([][]specs.LinuxSeccompArg, 0, len(conditions)*len(syscallArgs[i].Values))
+		for _, condition := range conditions {
+			for _, value := range syscallArgs[i].Values {
+				c := make([]specs.LinuxSeccompArg, len(condition), len(condition)+1)
+				copy(c, condition)
+				c = append(c, specs.LinuxSeccompArg{
+					Index: uint(i),
+					Value: value,
+					Op:    specs.OpEqualTo,
+				})
+				next = append(next, c)
+			}
+		}
+		conditions = next
+	}
+
+	return conditions
+}
+
+// syscallArgRules returns the conditions on the arguments of the syscalls of
+// v that can be restricted, by syscall number.
+func syscallArgRules(v []byte, args seccomptracer.Args) map[int][][]specs.LinuxSeccompArg {
+	rules := map[int][][]specs.LinuxSeccompArg{}
+	for syscall, syscallArgs := range args {
+		if syscall < 0 || syscall >= len(v) || v[syscall] == 0 {
+			continue
+		}
+		if conditions := syscallArgConditions(syscallArgs); conditions != nil {
+			rules[syscall] = conditions
+		}
+	}
+	return rules
+}
+
+// withoutSyscalls returns a copy of v without the syscalls having argument
+// rules.
+func withoutSyscalls(v []byte, rules map[int][][]specs.LinuxSeccompArg) []byte {
+	ret := make([]byte, len(v))
+	copy(ret, v)
+	for syscall := range rules {
+		ret[syscall] = 0
+	}
+	return ret
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seccomp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	seccomptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+)
+
+func TestParseSyscallArgs(t *testing.T) {
+	tests := []struct {
+		val      string
+		expected map[string]uint8
+		err      bool
+	}{
+		{
+			val:      "socket:0:1,clone:0",
+			expected: map[string]uint8{"socket": 0b11, "clone": 0b1},
+		},
+		{
+			val:      "socket:0, socket:2",
+			expected: map[string]uint8{"socket": 0b101},
+		},
+		{val: "socket", err: true},
+		{val: ":0", err: true},
+		{val: "socket:a", err: true},
+		{val: "socket:6", err: true},
+		{val: "socket:-1", err: true},
+	}
+
+	for _, test := range tests {
+		syscallArgs, err := parseSyscallArgs(test.val)
+		if test.err {
+			if err == nil {
+				t.Fatalf("%q: expected error", test.val)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", test.val, err)
+		}
+		if !reflect.DeepEqual(syscallArgs, test.expected) {
+			t.Fatalf("%q: expected %v, got %v", test.val, test.expected, syscallArgs)
+		}
+	}
+
+	syscallArgs, err := parseSyscallArgs(defaultSyscallArgs)
+	if err != nil {
+		t.Fatalf("%q: unexpected error: %s", defaultSyscallArgs, err)
+	}
+	if len(syscallArgs) != len(defaultArgs) || syscallArgs["socket"] != 0b11 {
+		t.Fatalf("%q: unexpected result %v", defaultSyscallArgs, syscallArgs)
+	}
+}
+
+func TestPeekArgsIncomplete(t *testing.T) {
+	tr := &Trace{
+		partialMntns: map[uint64]struct{}{42: {}},
+	}
+	if tr.argsIncomplete(42) {
+		t.Fatalf("arguments can't be incomplete without recording them")
+	}
+
+	tr.syscallArgs = map[int]uint8{1: 0b1}
+	if !tr.argsIncomplete(42) {
+		t.Fatalf("expected incomplete arguments for a mount namespace seen before the trace")
+	}
+	if tr.argsIncomplete(43) {
+		t.Fatalf("unexpected incomplete arguments for a new mount namespace")
+	}
+	if args := tr.peekArgs(42); args != nil {
+		t.Fatalf("expected no arguments, got %v", args)
+	}
+}
+
+func TestSyscallArgConditions(t *testing.T) {
+	arg := func(index uint, value uint64) specs.LinuxSeccompArg {
+		return specs.LinuxSeccompArg{Index: index, Value: value, Op: specs.OpEqualTo}
+	}
+
+	// One rule per combination of values
+	conditions := syscallArgConditions(map[int]*seccomptracer.ArgValues{
+		0: {Values: []uint64{2, 10}},
+		1: {Values: []uint64{1}},
+	})
+	expected := [][]specs.LinuxSeccompArg{
+		{arg(0, 2), arg(1, 1)},
+		{arg(0, 10), arg(1, 1)},
+	}
+	if !reflect.DeepEqual(conditions, expected) {
+		t.Fatalf("expected %v, got %v", expected, conditions)
+	}
+
+	// Arguments with too many values can't be restricted
+	conditions = syscallArgConditions(map[int]*seccomptracer.ArgValues{
+		0: {Values: []uint64{2}},
+		1: {Values: []uint64{1, 2}, Overflow: true},
+	})
+	expected = [][]specs.LinuxSeccompArg{
+		{arg(0, 2)},
+	}
+	if !reflect.DeepEqual(conditions, expected) {
+		t.Fatalf("expected %v, got %v", expected, conditions)
+	}
+
+	conditions = syscallArgConditions(map[int]*seccomptracer.ArgValues{
+		0: {Overflow: true},
+	})
+	if conditions != nil {
+		t.Fatalf("expected no conditions, got %v", conditions)
+	}
+
+	// The argument with the most values is dropped when there are too many
+	// combinations
+	many := make([]uint64, maxArgRules/2)
+	for i := range many {
+		many[i] = uint64(i)
+	}
+	conditions = syscallArgConditions(map[int]*seccomptracer.ArgValues{
+		0: {Values: []uint64{1, 2, 3}},
+		2: {Values: many},
+	})
+	expected = [][]specs.LinuxSeccompArg{
+		{arg(0, 1)},
+		{arg(0, 2)},
+		{arg(0, 3)},
+	}
+	if !reflect.DeepEqual(conditions, expected) {
+		t.Fatalf("expected %v, got %v", expected, conditions)
+	}
+}
+
+func TestSyscallArrToLinuxSeccompArgs(t *testing.T) {
+	socket, err := syscallNumber("socket")
+	if err != nil {
+		t.Fatalf("failed to get socket syscall number: %s", err)
+	}
+	v := make([]byte, socket+1)
+	v[0] = 1
+	v[socket] = 1
+
+	args := seccomptracer.Args{
+		socket: {
+			0: {Values: []uint64{2, 10}},
+		},
+		// Syscalls that weren't called are ignored
+		1: {
+			0: {Values: []uint64{1}},
+		},
+	}
+
+	s := SyscallArrToLinuxSeccomp(v, args)
+	expected := []specs.LinuxSyscall{
+		{
+			Names:  []string{syscallName(0)},
+			Action: specs.ActAllow,
+			Args:   []specs.LinuxSeccompArg{},
+		},
+		{
+			Names:  []string{"socket"},
+			Action: specs.ActAllow,
+			Args:   []specs.LinuxSeccompArg{{Index: 0, Value: 2, Op: specs.OpEqualTo}},
+		},
+		{
+			Names:  []string{"socket"},
+			Action: specs.ActAllow,
+			Args:   []specs.LinuxSeccompArg{{Index: 0, Value: 10, Op: specs.OpEqualTo}},
+		},
+	}
+	if !reflect.DeepEqual(s.Syscalls, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, s.Syscalls)
+	}
+
+	p := syscallArrToSeccompPolicy(&SeccompProfileNsName{namespace: "default", name: "test"}, v, args)
+	if len(p.Spec.Syscalls) != 3 || p.Spec.Syscalls[2].Args[0].Value != 10 {
+		t.Fatalf("unexpected profile syscalls %+v", p.Spec.Syscalls)
+	}
+}
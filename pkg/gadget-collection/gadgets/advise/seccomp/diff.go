@@ -0,0 +1,248 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seccomp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	seccompprofile "sigs.k8s.io/security-profiles-operator/api/seccompprofile/v1beta1"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
+)
+
+const (
+	// diffIDParam identifies a diff operation. It's copied in its output so
+	// that the caller can tell it apart from the output of a previous one.
+	diffIDParam = "diff-id"
+	// diffProfileParam is the namespace/name of the SeccompProfile to
+	// compare the syscalls with.
+	diffProfileParam = "diff-profile"
+	// diffProfileJSONParam is an OCI seccomp profile, in JSON, to compare
+	// the syscalls with. It's used when diffProfileParam is empty.
+	diffProfileJSONParam = "diff-profile-json"
+
+	// maxBaseProfiles is the maximum depth of the chain of base profiles
+	// followed when reading a SeccompProfile.
+	maxBaseProfiles = 8
+)
+
+// seccompRules tells whether a seccomp profile allows the syscalls.
+type seccompRules struct {
+	defaultAllowed bool
+	// allowed contains the syscalls having a rule in the profile.
+	allowed map[string]bool
+}
+
+func isAllowAction(action string) bool {
+	return action == string(specs.ActAllow) || action == string(specs.ActLog)
+}
+
+func newSeccompRules(defaultAction string) *seccompRules {
+	return &seccompRules{
+		defaultAllowed: isAllowAction(defaultAction),
+		allowed:        map[string]bool{},
+	}
+}
+
+// add adds a rule of the profile. A syscall allowed by one rule is considered
+// allowed, even if it's only for some argument values. Rules denying a syscall
+// only for some argument values are ignored.
+func (r *seccompRules) add(names []string, action string, hasArgs bool) {
+	allow := isAllowAction(action)
+	if !allow && hasArgs {
+		return
+	}
+	for _, name := range names {
+		if allow {
+			r.allowed[name] = true
+		} else if _, ok := r.allowed[name]; !ok {
+			r.allowed[name] = false
+		}
+	}
+}
+
+func (r *seccompRules) isAllowed(name string) bool {
+	if allowed, ok := r.allowed[name]; ok {
+		return allowed
+	}
+	return r.defaultAllowed
+}
+
+func linuxSeccompRules(s *specs.LinuxSeccomp) *seccompRules {
+	rules := newSeccompRules(string(s.DefaultAction))
+	for _, syscall := range s.Syscalls {
+		rules.add(syscall.Names, string(syscall.Action), len(syscall.Args) != 0)
+	}
+	return rules
+}
+
+// getSeccompProfileRules reads the rules of a SeccompProfile, including the
+// ones of its base profiles.
+func getSeccompProfileRules(cli client.Client, namespace, name string) (*seccompRules, error) {
+	var rules *seccompRules
+
+	for i := 0; name != ""; i++ {
+		if i > maxBaseProfiles {
+			return nil, fmt.Errorf("too many base profiles, the last one being %s/%s", namespace, name)
+		}
+
+		profile := &seccompprofile.SeccompProfile{}
+		err := cli.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name}, profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SeccompProfile %s/%s: %w", namespace, name, err)
+		}
+
+		// The default action of the base profiles doesn't apply.
+		if rules == nil {
+			rules = newSeccompRules(string(profile.Spec.DefaultAction))
+		}
+		for _, syscall := range profile.Spec.Syscalls {
+			rules.add(syscall.Names, string(syscall.Action), len(syscall.Args) != 0)
+		}
+
+		name = profile.Spec.BaseProfileName
+	}
+
+	return rules, nil
+}
+
+// diffSyscalls compares the syscalls of v with the rules of a profile. It
+// returns the syscalls used but not allowed, and the ones explicitly allowed
+// but not used.
+func diffSyscalls(v []byte, rules *seccompRules) ([]string, []string) {
+	usedNotAllowed := []string{}
+	allowedNotUsed := []string{}
+
+	used := map[string]struct{}{}
+	for i, val := range v {
+		if val == 0 {
+			continue
+		}
+		name := syscallName(i)
+		used[name] = struct{}{}
+		if !rules.isAllowed(name) {
+			usedNotAllowed = append(usedNotAllowed, name)
+		}
+	}
+
+	for name, allowed := range rules.allowed {
+		if _, ok := used[name]; allowed && !ok {
+			allowedNotUsed = append(allowedNotUsed, name)
+		}
+	}
+
+	sort.Strings(usedNotAllowed)
+	sort.Strings(allowedNotUsed)
+
+	return usedNotAllowed, allowedNotUsed
+}
+
+// diffRules returns the rules of the profile given in the trace parameters
+// and its name.
+func (t *Trace) diffRules(trace *gadgetv1alpha1.Trace) (*seccompRules, string, error) {
+	if profile := trace.Spec.Parameters[diffProfileParam]; profile != "" {
+		if t.client == nil {
+			return nil, "", fmt.Errorf("%s isn't supported without Kubernetes", diffProfileParam)
+		}
+
+		parts := strings.Split(profile, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, "", fmt.Errorf("%q is not valid for %q: it should be namespace/name", profile, diffProfileParam)
+		}
+
+		rules, err := getSeccompProfileRules(t.client, parts[0], parts[1])
+		if err != nil {
+			return nil, "", err
+		}
+		return rules, profile, nil
+	}
+
+	if profile := trace.Spec.Parameters[diffProfileJSONParam]; profile != "" {
+		s := &specs.LinuxSeccomp{}
+		if err := json.Unmarshal([]byte(profile), s); err != nil {
+			return nil, "", fmt.Errorf("%q is not a valid seccomp profile: %w", diffProfileJSONParam, err)
+		}
+		return linuxSeccompRules(s), "", nil
+	}
+
+	return nil, "", fmt.Errorf("one of %q or %q is needed", diffProfileParam, diffProfileJSONParam)
+}
+
+// Diff compares the syscalls used by the container selected by
+// Trace.Spec.Filter with a seccomp profile. The output is always written in
+// Trace.Status.Output, with the ID of the operation, even when the container
+// isn't on this node.
+func (t *Trace) Diff(trace *gadgetv1alpha1.Trace) {
+	if traceSingleton.tracer == nil {
+		trace.Status.OperationError = "Seccomp tracer is nil"
+		return
+	}
+
+	if !t.started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+
+	diff := &types.ProfileDiff{
+		ID:   trace.Spec.Parameters[diffIDParam],
+		Node: trace.Spec.Node,
+	}
+	if err := t.diff(trace, diff); err != nil {
+		trace.Status.OperationError = err.Error()
+		return
+	}
+	// The container lookup can fail too
+	if trace.Status.OperationError != "" {
+		return
+	}
+
+	output, err := json.Marshal(diff)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("Failed to marshal diff: %s", err)
+		return
+	}
+	trace.Status.Output = string(output)
+}
+
+func (t *Trace) diff(trace *gadgetv1alpha1.Trace, diff *types.ProfileDiff) error {
+	if t.aggregator != nil {
+		return fmt.Errorf("diff isn't supported with %s", aggregateParam)
+	}
+
+	rules, profile, err := t.diffRules(trace)
+	if err != nil {
+		return err
+	}
+
+	mntns, containerName := t.lookupContainer(trace)
+	if mntns == 0 {
+		return nil
+	}
+
+	diff.Namespace = trace.Spec.Filter.Namespace
+	diff.Pod = trace.Spec.Filter.Podname
+	diff.Container = containerName
+	diff.Profile = profile
+	diff.UsedNotAllowed, diff.AllowedNotUsed = diffSyscalls(traceSingleton.tracer.Peek(mntns), rules)
+
+	return nil
+}
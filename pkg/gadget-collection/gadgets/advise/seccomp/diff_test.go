@@ -0,0 +1,144 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seccomp
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	commonseccomp "github.com/containers/common/pkg/seccomp"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachineryruntime "k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	seccompprofile "sigs.k8s.io/security-profiles-operator/api/seccompprofile/v1beta1"
+)
+
+func TestDiffSyscalls(t *testing.T) {
+	rules := linuxSeccompRules(&specs.LinuxSeccomp{
+		DefaultAction: specs.ActErrno,
+		Syscalls: []specs.LinuxSyscall{
+			{
+				Names:  []string{syscallName(0), syscallName(1), "reboot"},
+				Action: specs.ActAllow,
+			},
+			{
+				// Allowed only for some values, still considered allowed
+				Names:  []string{syscallName(2)},
+				Action: specs.ActAllow,
+				Args:   []specs.LinuxSeccompArg{{Index: 0, Value: 1, Op: specs.OpEqualTo}},
+			},
+		},
+	})
+
+	usedNotAllowed, allowedNotUsed := diffSyscalls(syscallArr(0, 2, 3), rules)
+	if expected := []string{syscallName(3)}; !reflect.DeepEqual(usedNotAllowed, expected) {
+		t.Fatalf("expected used but not allowed %v, got %v", expected, usedNotAllowed)
+	}
+	expected := []string{"reboot", syscallName(1)}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(allowedNotUsed, expected) {
+		t.Fatalf("expected allowed but not used %v, got %v", expected, allowedNotUsed)
+	}
+}
+
+func TestDiffSyscallsDefaultAllow(t *testing.T) {
+	rules := linuxSeccompRules(&specs.LinuxSeccomp{
+		DefaultAction: specs.ActAllow,
+		Syscalls: []specs.LinuxSyscall{
+			{
+				Names:  []string{syscallName(1)},
+				Action: specs.ActErrno,
+			},
+			{
+				// Denied only for some values, ignored
+				Names:  []string{syscallName(2)},
+				Action: specs.ActErrno,
+				Args:   []specs.LinuxSeccompArg{{Index: 0, Value: 1, Op: specs.OpEqualTo}},
+			},
+		},
+	})
+
+	usedNotAllowed, allowedNotUsed := diffSyscalls(syscallArr(0, 1, 2), rules)
+	if expected := []string{syscallName(1)}; !reflect.DeepEqual(usedNotAllowed, expected) {
+		t.Fatalf("expected used but not allowed %v, got %v", expected, usedNotAllowed)
+	}
+	if len(allowedNotUsed) != 0 {
+		t.Fatalf("expected no allowed but not used syscall, got %v", allowedNotUsed)
+	}
+}
+
+func TestGetSeccompProfileRules(t *testing.T) {
+	scheme := apimachineryruntime.NewScheme()
+	if err := seccompprofile.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add SeccompProfile to the scheme: %s", err)
+	}
+
+	base := &seccompprofile.SeccompProfile{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "base"},
+		Spec: seccompprofile.SeccompProfileSpec{
+			DefaultAction: commonseccomp.ActAllow,
+			Syscalls: []*seccompprofile.Syscall{
+				{Names: []string{syscallName(1)}, Action: commonseccomp.ActAllow},
+			},
+		},
+	}
+	profile := &seccompprofile.SeccompProfile{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web"},
+		Spec: seccompprofile.SeccompProfileSpec{
+			BaseProfileName: "base",
+			DefaultAction:   commonseccomp.ActErrno,
+			Syscalls: []*seccompprofile.Syscall{
+				{Names: []string{syscallName(0)}, Action: commonseccomp.ActAllow},
+			},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(base, profile).Build()
+
+	rules, err := getSeccompProfileRules(cli, "demo", "web")
+	if err != nil {
+		t.Fatalf("failed to get rules: %s", err)
+	}
+
+	// The default action of the base profile doesn't apply
+	expected := &seccompRules{
+		defaultAllowed: false,
+		allowed: map[string]bool{
+			syscallName(0): true,
+			syscallName(1): true,
+		},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("expected rules %+v, got %+v", expected, rules)
+	}
+
+	// A profile using itself as base must not loop forever
+	loop := &seccompprofile.SeccompProfile{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "loop"},
+		Spec: seccompprofile.SeccompProfileSpec{
+			BaseProfileName: "loop",
+			DefaultAction:   commonseccomp.ActErrno,
+		},
+	}
+	cli = fake.NewClientBuilder().WithScheme(scheme).WithObjects(loop).Build()
+	if _, err := getSeccompProfileRules(cli, "demo", "loop"); err == nil {
+		t.Fatalf("expected error with a loop of base profiles")
+	}
+
+	if _, err := getSeccompProfileRules(cli, "demo", "missing"); err == nil {
+		t.Fatalf("expected error with a missing profile")
+	}
+}
@@ -49,6 +49,23 @@ type Trace struct {
 	// at pod termination so that the Generate() operation does not have
 	// to notify that it did not find a pod that matches the filter.
 	policyGenerated bool
+
+	// aggregator is used instead of generating a policy per container
+	// when the aggregate parameter is set.
+	aggregator *aggregator
+
+	// syscallArgs contains, by syscall number, a bitmask of the arguments
+	// whose values are used to restrict the syscall.
+	syscallArgs map[int]uint8
+
+	// argsSlot is where the tracer records the values of the arguments
+	// of this trace.
+	argsSlot int
+
+	// partialMntns contains the mount namespaces that already existed
+	// when the tracer started recording the arguments. Their values are
+	// incomplete and can't be used to restrict the syscalls.
+	partialMntns map[uint64]struct{}
 }
 
 type TraceFactory struct {
@@ -70,7 +87,7 @@ func NewFactory() gadgets.TraceFactory {
 }
 
 func (f *TraceFactory) Description() string {
-	return `The seccomp gadget traces system calls for each container in order to generate
+	t := `The seccomp gadget traces system calls for each container in order to generate
 seccomp policies.
 
 The seccomp policies can be generated in two ways:
@@ -111,7 +128,44 @@ SeccompProfiles will have the following annotations:
 SeccompProfiles will have the same labels as the Trace custom resource that
 generated them. They don't have meaning for the seccomp gadget. They are
 merely copied for convenience.
+
+When the %s parameter is set to true, the syscalls of the containers
+with the same name in all the pods of a workload, i.e. the highest owner
+reference of the pods, are merged in a single SeccompProfile named
+<namespace>-<kind>-<name>-<container>, prefixed by the name given in
+Trace.Spec.Output if any. The profile is updated every time a container of
+the workload terminates and on the generate operation, which doesn't need a
+pod name in this case. The profile is shared by the gadgets of all the nodes,
+each of them adding the syscalls it has seen. Only the outputMode
+ExternalResource is supported. These SeccompProfiles have the
+following annotations in addition to the trace, container and
+ownerReference ones:
+
+* seccomp.gadget.kinvolk.io/namespace: the namespace of the workload
+* seccomp.gadget.kinvolk.io/pods: the number of pods that contributed to the
+  profile
+* seccomp.gadget.kinvolk.io/syscall-pods: the number of pods that used each
+  syscall, in JSON
+* seccomp.gadget.kinvolk.io/node-contributions: the number of pods and the
+  syscalls they used per node, in a compact JSON form. When it grows over
+  64KiB, the node updating the profile merges the contributions of the other
+  nodes together and the counters become approximate.
+
+The %s parameter makes the gadget also record the values taken by some
+arguments of the syscalls, and restrict them in the generated policies with
+one rule per combination of values seen. It's a comma-separated list of
+syscall:index[:index...] entries, e.g. socket:0:1,clone:0, or %s to
+record the arguments selecting the kind of operation of clone, ioctl,
+personality, prctl, setns, socket, socketpair and unshare. Arguments that
+took more than %d different values are not restricted, neither are the ones
+that would lead to more than %d rules for a syscall. The arguments of the
+containers started before the trace are not restricted as some of their
+values could have been missed. At most %d traces can use it at the same time
+and it can't be used with the %s parameter.
 `
+	return fmt.Sprintf(t, aggregateParam, syscallArgsParam, defaultSyscallArgs,
+		seccomptracer.SyscallArgMaxValues, maxArgRules,
+		seccomptracer.SyscallArgsMaxTraces, aggregateParam)
 }
 
 func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
@@ -131,6 +185,9 @@ func deleteTrace(name string, t interface{}) {
 	if trace.started {
 		traceSingleton.mu.Lock()
 		defer traceSingleton.mu.Unlock()
+		if len(trace.syscallArgs) != 0 {
+			traceSingleton.tracer.StopRecordingArgs(trace.argsSlot)
+		}
 		traceSingleton.users--
 		if traceSingleton.users == 0 {
 			trace.helpers.Unsubscribe(genPubSubKey(name))
@@ -157,18 +214,32 @@ func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOp
 		},
 		gadgetv1alpha1.OperationGenerate: {
 			Doc: `Generate a seccomp profile for the pod specified in Trace.Spec.Filter. The
-namespace and pod name should be specified at the exclusion of other fields.`,
+namespace and pod name should be specified at the exclusion of other fields.
+When the syscalls are aggregated per workload, update the profiles of the
+workloads of all the containers matching Trace.Spec.Filter instead.`,
 			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
 				f.LookupOrCreate(name, n).(*Trace).Generate(trace)
 			},
 			Order: 2,
 		},
+		gadgetv1alpha1.OperationDiff: {
+			Doc: fmt.Sprintf(`Compare the syscalls used by the pod specified in Trace.Spec.Filter with the
+SeccompProfile named by the %s parameter (namespace/name) or with the
+OCI seccomp profile given in JSON by the %s parameter. The
+result is written in Trace.Status.Output, together with the value of the
+%s parameter to tell it apart from the result of a previous diff.`,
+				diffProfileParam, diffProfileJSONParam, diffIDParam),
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Diff(trace)
+			},
+			Order: 3,
+		},
 		gadgetv1alpha1.OperationStop: {
 			Doc: "Stop recording syscalls",
 			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
 				f.LookupOrCreate(name, n).(*Trace).Stop(trace)
 			},
-			Order: 3,
+			Order: 4,
 		},
 	}
 }
@@ -306,7 +377,7 @@ func getSeccompProfileNsName(
 
 // generateSeccompPolicy generates a seccomp policy which is ready to be
 // created.
-func generateSeccompPolicy(client client.Client, trace *gadgetv1alpha1.Trace, syscalls []byte, podname, containername, fullPodName string, ownerReference *metav1.OwnerReference) (*seccompprofile.SeccompProfile, error) {
+func generateSeccompPolicy(client client.Client, trace *gadgetv1alpha1.Trace, syscalls []byte, args seccomptracer.Args, podname, containername, fullPodName string, ownerReference *metav1.OwnerReference) (*seccompprofile.SeccompProfile, error) {
 	profileName, err := getSeccompProfileNsName(
 		client,
 		trace.ObjectMeta.Namespace,
@@ -317,7 +388,7 @@ func generateSeccompPolicy(client client.Client, trace *gadgetv1alpha1.Trace, sy
 		return nil, fmt.Errorf("failed to get the profile name: %w", err)
 	}
 
-	r := syscallArrToSeccompPolicy(profileName, syscalls)
+	r := syscallArrToSeccompPolicy(profileName, syscalls, args)
 	seccompProfileAddLabelsAndAnnotations(r, trace, fullPodName, containername, ownerReference)
 
 	return r, nil
@@ -341,16 +412,37 @@ func (t *Trace) containerTerminated(trace *gadgetv1alpha1.Trace, event container
 
 	// Get the list of syscalls from the BPF hash map
 	b := traceSingleton.tracer.Peek(event.Container.Mntns)
+	args := t.peekArgs(event.Container.Mntns)
+	if t.argsIncomplete(event.Container.Mntns) {
+		log.Warnf("Trace %s: container %s/%s/%s was started before the trace, its syscall arguments are not restricted",
+			traceName, event.Container.Namespace, event.Container.Podname, event.Container.Name)
+	}
 
-	// The container has terminated. Cleanup the BPF hash map
+	// The container has terminated. Cleanup the BPF hash maps
 	traceSingleton.tracer.Delete(event.Container.Mntns)
+	if len(t.syscallArgs) != 0 {
+		traceSingleton.tracer.DeleteArgs(t.argsSlot, event.Container.Mntns)
+	}
 
 	namespacedName := fmt.Sprintf("%s/%s", event.Container.Namespace, event.Container.Podname)
 
 	// This field was fetched when the container was created
 	ownerReference := getContainerOwnerReference(event.Container)
 
-	r, err := generateSeccompPolicy(t.client, trace, b, event.Container.Podname,
+	if t.aggregator != nil {
+		w := getWorkload(event.Container, ownerReference)
+		t.aggregator.add(w, ownerReference, event.Container.Podname, b)
+
+		log.Infof("Trace %s: updating SeccompProfile of %s with pod %s", traceName, w, namespacedName)
+		if err := t.updateAggregatedSeccompProfile(trace, w); err != nil {
+			log.Errorf("Trace %s: failed to update SeccompProfile of %s: %s", traceName, w, err)
+			return
+		}
+		t.policyGenerated = true
+		return
+	}
+
+	r, err := generateSeccompPolicy(t.client, trace, b, args, event.Container.Podname,
 		event.Container.Name, namespacedName, ownerReference)
 	if err != nil {
 		log.Errorf("Trace %s: %v", traceName, err)
@@ -381,6 +473,35 @@ func (t *Trace) containerTerminated(trace *gadgetv1alpha1.Trace, event container
 	}
 }
 
+// argsIncomplete tells whether this trace records syscall arguments but
+// started doing so after the given mount namespace was created.
+func (t *Trace) argsIncomplete(mntns uint64) bool {
+	_, ok := t.partialMntns[mntns]
+	return len(t.syscallArgs) != 0 && ok
+}
+
+// recordArgsOf makes the tracer record the syscall arguments of the container
+// for this trace. The containers started before the trace are skipped as their
+// arguments aren't used.
+func (t *Trace) recordArgsOf(c *containercollection.Container) {
+	if traceSingleton.tracer == nil || len(t.syscallArgs) == 0 || c.Mntns == 0 || t.argsIncomplete(c.Mntns) {
+		return
+	}
+	if err := traceSingleton.tracer.RecordArgsOf(t.argsSlot, c.Mntns); err != nil {
+		log.Errorf("Failed to record syscall arguments of %s/%s/%s: %s",
+			c.Namespace, c.Podname, c.Name, err)
+	}
+}
+
+// peekArgs returns the values of the syscall arguments recorded by this trace
+// for the given mount namespace. It returns nil if they are incomplete.
+func (t *Trace) peekArgs(mntns uint64) seccomptracer.Args {
+	if len(t.syscallArgs) == 0 || t.argsIncomplete(mntns) {
+		return nil
+	}
+	return traceSingleton.tracer.PeekArgs(t.argsSlot, mntns)
+}
+
 func getContainerOwnerReference(c *containercollection.Container) *metav1.OwnerReference {
 	ownerRef, err := c.GetOwnerReference()
 	// Owner reference doesn't make any sense for local-gadget, then
@@ -402,6 +523,47 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		return
 	}
 
+	t.aggregator = nil
+	if val, ok := trace.Spec.Parameters[aggregateParam]; ok {
+		aggregate, err := strconv.ParseBool(val)
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, aggregateParam)
+			return
+		}
+		if aggregate {
+			if trace.Spec.OutputMode != gadgetv1alpha1.TraceOutputModeExternalResource {
+				trace.Status.OperationError = fmt.Sprintf("OutputMode not supported with %s: %s",
+					aggregateParam, trace.Spec.OutputMode)
+				return
+			}
+			t.aggregator = newAggregator()
+		}
+	}
+
+	t.syscallArgs = nil
+	if val, ok := trace.Spec.Parameters[syscallArgsParam]; ok && val != "" {
+		if t.aggregator != nil {
+			trace.Status.OperationError = fmt.Sprintf("%s can't be used with %s", syscallArgsParam, aggregateParam)
+			return
+		}
+
+		syscallArgs, err := parseSyscallArgs(val)
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q: %s", val, syscallArgsParam, err)
+			return
+		}
+
+		t.syscallArgs = map[int]uint8{}
+		for name, args := range syscallArgs {
+			syscall, err := syscallNumber(name)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q: %s", val, syscallArgsParam, err)
+				return
+			}
+			t.syscallArgs[syscall] = args
+		}
+	}
+
 	traceSingleton.mu.Lock()
 	defer traceSingleton.mu.Unlock()
 	if traceSingleton.tracer == nil {
@@ -413,6 +575,34 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		}
 	}
 
+	selector := gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter)
+
+	t.partialMntns = nil
+	if len(t.syscallArgs) != 0 {
+		var err error
+		t.argsSlot, err = traceSingleton.tracer.RecordArgs(t.syscallArgs)
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("Failed to record syscall arguments: %s", err)
+			if traceSingleton.users == 0 {
+				traceSingleton.tracer.Close()
+				traceSingleton.tracer = nil
+			}
+			return
+		}
+
+		// The arguments are only complete for the mount namespaces
+		// created from now on. Consider both the containers and the
+		// mount namespaces seen by the tracer as the container
+		// collection could not know about the latest containers yet.
+		t.partialMntns = map[uint64]struct{}{}
+		for _, container := range t.helpers.GetContainersBySelector(selector) {
+			t.partialMntns[container.Mntns] = struct{}{}
+		}
+		for _, mntns := range traceSingleton.tracer.MountNamespaces() {
+			t.partialMntns[mntns] = struct{}{}
+		}
+	}
+
 	// 'trace' is owned by the controller and could be modified
 	// outside of the gadget control. Make a copy for the callback.
 	traceCopy := trace.DeepCopy()
@@ -425,11 +615,12 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 	// container terminates.
 	containers := t.helpers.Subscribe(
 		genPubSubKey(trace.ObjectMeta.Namespace+"/"+trace.ObjectMeta.Name),
-		*gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter),
+		*selector,
 		func(event containercollection.PubSubEvent) {
 			switch event.Type {
 			case containercollection.EventTypeAddContainer:
 				getContainerOwnerReference(event.Container)
+				t.recordArgsOf(event.Container)
 			case containercollection.EventTypeRemoveContainer:
 				t.containerTerminated(traceCopy, event)
 			}
@@ -438,6 +629,7 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 
 	for _, container := range containers {
 		getContainerOwnerReference(container)
+		t.recordArgsOf(container)
 	}
 
 	traceSingleton.users++
@@ -447,23 +639,17 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 	trace.Status.State = gadgetv1alpha1.TraceStateStarted
 }
 
-func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
-	if traceSingleton.tracer == nil {
-		log.Errorf("Seccomp tracer is nil")
-		return
-	}
-
-	if !t.started {
-		trace.Status.OperationError = "Not started"
-		return
-	}
+// lookupContainer returns the mount namespace and the name of the container
+// selected by Trace.Spec.Filter. It returns 0 and sets the trace status when
+// there isn't exactly one such container.
+func (t *Trace) lookupContainer(trace *gadgetv1alpha1.Trace) (uint64, string) {
 	if trace.Spec.Filter == nil || trace.Spec.Filter.Namespace == "" || trace.Spec.Filter.Podname == "" {
 		trace.Status.OperationError = "Missing pod"
-		return
+		return 0, ""
 	}
 	if len(trace.Spec.Filter.Labels) != 0 {
 		trace.Status.OperationError = "Seccomp gadget does not support filtering by labels"
-		return
+		return 0, ""
 	}
 
 	var mntns uint64
@@ -483,7 +669,7 @@ func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
 					trace.Spec.Filter.ContainerName,
 				)
 			}
-			return
+			return 0, ""
 		}
 		containerName = trace.Spec.Filter.ContainerName
 	} else {
@@ -499,7 +685,7 @@ func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
 					trace.Spec.Filter.Podname,
 				)
 			}
-			return
+			return 0, ""
 		}
 
 		containerList := []string{}
@@ -516,23 +702,53 @@ func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
 				trace.Spec.Filter.Podname,
 				containerList,
 			)
-			return
+			return 0, ""
 		}
 		if mntns == 0 {
 			trace.Status.OperationError = fmt.Sprintf("Pod %s/%s has unknown mntns",
 				trace.Spec.Filter.Namespace,
 				trace.Spec.Filter.Podname,
 			)
-			return
+			return 0, ""
 		}
 	}
 
+	return mntns, containerName
+}
+
+func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
+	if traceSingleton.tracer == nil {
+		log.Errorf("Seccomp tracer is nil")
+		return
+	}
+
+	if !t.started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+	// Don't leave the output of a previous diff operation if no policy is
+	// generated on this node.
+	trace.Status.Output = ""
+
+	if t.aggregator != nil {
+		t.generateAggregated(trace)
+		return
+	}
+	mntns, containerName := t.lookupContainer(trace)
+	if mntns == 0 {
+		return
+	}
+
 	// Get the list of syscalls from the BPF hash map
 	b := traceSingleton.tracer.Peek(mntns)
+	args := t.peekArgs(mntns)
+	if t.argsIncomplete(mntns) {
+		trace.Status.OperationWarning = "The container was started before the trace, its syscall arguments are not restricted"
+	}
 
 	switch trace.Spec.OutputMode {
 	case gadgetv1alpha1.TraceOutputModeStatus:
-		policy := syscallArrToLinuxSeccomp(b)
+		policy := SyscallArrToLinuxSeccomp(b, args)
 		output, err := json.MarshalIndent(policy, "", "  ")
 		if err != nil {
 			trace.Status.OperationError = fmt.Sprintf("Failed to marshal seccomp policy: %s", err)
@@ -545,7 +761,7 @@ func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
 
 		ownerReference := t.helpers.LookupOwnerReferenceByMntns(mntns)
 
-		r, err := generateSeccompPolicy(t.client, trace, b, trace.Spec.Filter.Podname, containerName, podName, ownerReference)
+		r, err := generateSeccompPolicy(t.client, trace, b, args, trace.Spec.Filter.Podname, containerName, podName, ownerReference)
 		if err != nil {
 			trace.Status.OperationError = err.Error()
 			return
@@ -574,6 +790,10 @@ func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
 
 	t.helpers.Unsubscribe(genPubSubKey(trace.ObjectMeta.Namespace + "/" + trace.ObjectMeta.Name))
 
+	if len(t.syscallArgs) != 0 {
+		traceSingleton.tracer.StopRecordingArgs(t.argsSlot)
+	}
+
 	traceSingleton.users--
 	if traceSingleton.users == 0 {
 		traceSingleton.tracer.Close()
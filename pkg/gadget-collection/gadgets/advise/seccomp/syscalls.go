@@ -27,6 +27,8 @@ import (
 	libseccomp "github.com/seccomp/libseccomp-golang"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	seccompprofile "sigs.k8s.io/security-profiles-operator/api/seccompprofile/v1beta1"
+
+	seccomptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
 )
 
 /* Function arches() under the Apache License, Version 2.0 by the containerd authors:
@@ -53,31 +55,72 @@ func arches() []specs.Arch {
 	}
 }
 
+func syscallName(i int) string {
+	call1 := libseccomp.ScmpSyscall(i)
+	name, err := call1.GetName()
+	if err != nil {
+		name = fmt.Sprintf("syscall%d", i)
+	}
+	return name
+}
+
+// syscallNumber returns the number of the syscall with the given name.
+func syscallNumber(name string) (int, error) {
+	call, err := libseccomp.GetSyscallFromName(name)
+	if err != nil {
+		return 0, fmt.Errorf("unknown syscall %q: %w", name, err)
+	}
+	return int(call), nil
+}
+
 func syscallArrToNameList(v []byte) []string {
 	names := []string{}
 	for i, val := range v {
 		if val == 0 {
 			continue
 		}
-		call1 := libseccomp.ScmpSyscall(i)
-		name, err := call1.GetName()
-		if err != nil {
-			name = fmt.Sprintf("syscall%d", i)
-		}
-		names = append(names, name)
+		names = append(names, syscallName(i))
 	}
 	sort.Strings(names)
 	return names
 }
 
-func syscallArrToLinuxSeccomp(v []byte) *specs.LinuxSeccomp {
+// sortedRuleSyscalls returns the syscalls having argument rules, sorted by
+// name.
+func sortedRuleSyscalls(rules map[int][][]specs.LinuxSeccompArg) []int {
+	syscalls := make([]int, 0, len(rules))
+	for syscall := range rules {
+		syscalls = append(syscalls, syscall)
+	}
+	sort.Slice(syscalls, func(i, j int) bool {
+		return syscallName(syscalls[i]) < syscallName(syscalls[j])
+	})
+	return syscalls
+}
+
+// SyscallArrToLinuxSeccomp returns an OCI seccomp profile, for the
+// architectures of the host, allowing the syscalls of v as returned by
+// Tracer.Peek(). The syscalls having arguments in args are only allowed with
+// the values they were called with.
+func SyscallArrToLinuxSeccomp(v []byte, args seccomptracer.Args) *specs.LinuxSeccomp {
+	rules := syscallArgRules(v, args)
+
 	syscalls := []specs.LinuxSyscall{
 		{
-			Names:  syscallArrToNameList(v),
+			Names:  syscallArrToNameList(withoutSyscalls(v, rules)),
 			Action: specs.ActAllow,
 			Args:   []specs.LinuxSeccompArg{},
 		},
 	}
+	for _, syscall := range sortedRuleSyscalls(rules) {
+		for _, conditions := range rules[syscall] {
+			syscalls = append(syscalls, specs.LinuxSyscall{
+				Names:  []string{syscallName(syscall)},
+				Action: specs.ActAllow,
+				Args:   conditions,
+			})
+		}
+	}
 
 	s := &specs.LinuxSeccomp{
 		DefaultAction: specs.ActErrno,
@@ -87,10 +130,35 @@ func syscallArrToLinuxSeccomp(v []byte) *specs.LinuxSeccomp {
 	return s
 }
 
-func syscallArrToSeccompPolicy(profileName *SeccompProfileNsName, v []byte) *seccompprofile.SeccompProfile {
+func syscallArrToSeccompPolicy(profileName *SeccompProfileNsName, v []byte, args seccomptracer.Args) *seccompprofile.SeccompProfile {
+	rules := syscallArgRules(v, args)
+
+	ret := syscallNamesToSeccompPolicy(profileName, syscallArrToNameList(withoutSyscalls(v, rules)))
+	for _, syscall := range sortedRuleSyscalls(rules) {
+		for _, conditions := range rules[syscall] {
+			profileArgs := make([]*seccompprofile.Arg, 0, len(conditions))
+			for _, c := range conditions {
+				profileArgs = append(profileArgs, &seccompprofile.Arg{
+					Index: c.Index,
+					Value: c.Value,
+					Op:    commonseccomp.OpEqualTo,
+				})
+			}
+			ret.Spec.Syscalls = append(ret.Spec.Syscalls, &seccompprofile.Syscall{
+				Names:  []string{syscallName(syscall)},
+				Action: commonseccomp.ActAllow,
+				Args:   profileArgs,
+			})
+		}
+	}
+
+	return ret
+}
+
+func syscallNamesToSeccompPolicy(profileName *SeccompProfileNsName, names []string) *seccompprofile.SeccompProfile {
 	syscalls := []*seccompprofile.Syscall{
 		{
-			Names:  syscallArrToNameList(v),
+			Names:  names,
 			Action: commonseccomp.ActAllow,
 			Args:   []*seccompprofile.Arg{},
 		},
@@ -20,14 +20,31 @@ package seccomp
 import (
 	"github.com/opencontainers/runtime-spec/specs-go"
 	seccompprofile "sigs.k8s.io/security-profiles-operator/api/seccompprofile/v1beta1"
+
+	seccomptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
 )
 
-func syscallArrToLinuxSeccomp(v []byte) *specs.LinuxSeccomp {
+func syscallName(i int) string {
+	panic("Not implemented")
+	return ""
+}
+
+func syscallNumber(name string) (int, error) {
+	panic("Not implemented")
+	return 0, nil
+}
+
+func SyscallArrToLinuxSeccomp(v []byte, args seccomptracer.Args) *specs.LinuxSeccomp {
+	panic("Not implemented")
+	return nil
+}
+
+func syscallArrToSeccompPolicy(profileName *SeccompProfileNsName, v []byte, args seccomptracer.Args) *seccompprofile.SeccompProfile {
 	panic("Not implemented")
 	return nil
 }
 
-func syscallArrToSeccompPolicy(profileName *SeccompProfileNsName, v []byte) *seccompprofile.SeccompProfile {
+func syscallNamesToSeccompPolicy(profileName *SeccompProfileNsName, names []string) *seccompprofile.SeccompProfile {
 	panic("Not implemented")
 	return nil
 }
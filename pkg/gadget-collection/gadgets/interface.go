@@ -79,6 +79,7 @@ type TraceOperation struct {
 type GadgetHelpers interface {
 	containercollection.ContainerResolver
 	gadgets.DataEnricher
+	gadgets.NetNsEnricher
 
 	PublishEvent(tracerID string, line string) error
 	TracerMountNsMap(tracerID string) (*ebpf.Map, error)
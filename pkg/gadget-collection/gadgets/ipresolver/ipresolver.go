@@ -0,0 +1,261 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ipresolver finds the Kubernetes pod, service or node an IP address
+// belongs to. Pod IPs have precedence, ignoring pods in the host network,
+// then service cluster and external IPs, then node addresses.
+//
+// The pods, services and nodes of the cluster are kept up to date with
+// informers, which are shared by all the gadgets using the resolver and
+// stopped once the last one closes it.
+package ipresolver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/k8sutil"
+)
+
+const (
+	KindPod     = "pod"
+	KindService = "svc"
+	KindNode    = "node"
+	KindOther   = "other"
+)
+
+// syncTimeout is how long NewResolver waits for the informers to get the
+// objects of the cluster. Addresses are resolved to KindOther until then.
+const syncTimeout = 10 * time.Second
+
+const ipIndex = "ip"
+
+type Resolver struct {
+	factory  informers.SharedInformerFactory
+	pods     cache.SharedIndexInformer
+	services cache.SharedIndexInformer
+	nodes    cache.SharedIndexInformer
+
+	stopCh chan struct{}
+
+	// users is protected by sharedMu
+	users int
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   *Resolver
+)
+
+// NewResolver returns the resolver shared by all the gadgets, starting its
+// informers if nobody uses it yet. Close must be called once it's not needed
+// anymore.
+func NewResolver() (*Resolver, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	if shared == nil {
+		clientset, err := k8sutil.NewClientset("")
+		if err != nil {
+			return nil, err
+		}
+
+		shared = newResolver(clientset)
+	}
+
+	shared.users++
+
+	return shared, nil
+}
+
+func normalizeIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
+
+func podIPs(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.HostNetwork {
+		return nil, nil
+	}
+	// The IP of terminated pods can be reused by new ones.
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return nil, nil
+	}
+
+	ips := make([]string, 0, len(pod.Status.PodIPs))
+	for _, ip := range pod.Status.PodIPs {
+		ips = append(ips, normalizeIP(ip.IP))
+	}
+	if len(ips) == 0 && pod.Status.PodIP != "" {
+		ips = append(ips, normalizeIP(pod.Status.PodIP))
+	}
+	return ips, nil
+}
+
+func serviceIPs(obj interface{}) ([]string, error) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return nil, nil
+	}
+
+	var ips []string
+	for _, ip := range svc.Spec.ClusterIPs {
+		if ip != corev1.ClusterIPNone {
+			ips = append(ips, normalizeIP(ip))
+		}
+	}
+	if len(ips) == 0 && svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != corev1.ClusterIPNone {
+		ips = append(ips, normalizeIP(svc.Spec.ClusterIP))
+	}
+	for _, ip := range svc.Spec.ExternalIPs {
+		ips = append(ips, normalizeIP(ip))
+	}
+	return ips, nil
+}
+
+func nodeIPs(obj interface{}) ([]string, error) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return nil, nil
+	}
+
+	var ips []string
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP || addr.Type == corev1.NodeExternalIP {
+			ips = append(ips, normalizeIP(addr.Address))
+		}
+	}
+	return ips, nil
+}
+
+func newResolver(clientset kubernetes.Interface) *Resolver {
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+
+	r := &Resolver{
+		factory:  factory,
+		pods:     factory.Core().V1().Pods().Informer(),
+		services: factory.Core().V1().Services().Informer(),
+		nodes:    factory.Core().V1().Nodes().Informer(),
+		stopCh:   make(chan struct{}),
+	}
+
+	// AddIndexers only fails when the informer was already started.
+	r.pods.AddIndexers(cache.Indexers{ipIndex: podIPs})
+	r.services.AddIndexers(cache.Indexers{ipIndex: serviceIPs})
+	r.nodes.AddIndexers(cache.Indexers{ipIndex: nodeIPs})
+
+	factory.Start(r.stopCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	if !cache.WaitForCacheSync(ctx.Done(), r.pods.HasSynced, r.services.HasSynced, r.nodes.HasSynced) {
+		log.Warnf("IP resolver: informers not synced after %s", syncTimeout)
+	}
+
+	return r
+}
+
+// Close releases the resolver. The informers are stopped once all the
+// gadgets using it closed it. It does nothing on a nil resolver, which is the
+// case of gadgets running outside of Kubernetes.
+func (r *Resolver) Close() {
+	if r == nil {
+		return
+	}
+
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	r.users--
+	if r.users > 0 {
+		return
+	}
+
+	close(r.stopCh)
+	if shared == r {
+		shared = nil
+	}
+}
+
+func byIP[T any](informer cache.SharedIndexInformer, ip string) *T {
+	objs, err := informer.GetIndexer().ByIndex(ipIndex, normalizeIP(ip))
+	if err != nil || len(objs) == 0 {
+		return nil
+	}
+
+	obj, _ := objs[0].(*T)
+	return obj
+}
+
+// PodByIP returns the pod, not using the host network, with the given IP
+// address, if any.
+func (r *Resolver) PodByIP(ip string) *corev1.Pod {
+	return byIP[corev1.Pod](r.pods, ip)
+}
+
+// ServiceByIP returns the service with the given cluster or external IP
+// address, if any.
+func (r *Resolver) ServiceByIP(ip string) *corev1.Service {
+	return byIP[corev1.Service](r.services, ip)
+}
+
+// NodeByIP returns the node with the given internal or external IP address,
+// if any.
+func (r *Resolver) NodeByIP(ip string) *corev1.Node {
+	return byIP[corev1.Node](r.nodes, ip)
+}
+
+// Pod returns the pod with the given namespace and name, if any.
+func (r *Resolver) Pod(namespace, name string) *corev1.Pod {
+	obj, exists, err := r.pods.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return nil
+	}
+
+	pod, _ := obj.(*corev1.Pod)
+	return pod
+}
+
+// Resolve returns the kind (pod, svc, node or other) and the name of the
+// Kubernetes object the given IP address belongs to. The name is
+// namespace/name for pods and services, and is empty when the kind is other.
+// Both are empty for empty and unspecified addresses.
+func (r *Resolver) Resolve(ip string) (kind, name string) {
+	if parsed := net.ParseIP(ip); ip == "" || (parsed != nil && parsed.IsUnspecified()) {
+		return "", ""
+	}
+
+	if pod := r.PodByIP(ip); pod != nil {
+		return KindPod, pod.Namespace + "/" + pod.Name
+	}
+	if svc := r.ServiceByIP(ip); svc != nil {
+		return KindService, svc.Namespace + "/" + svc.Name
+	}
+	if node := r.NodeByIP(ip); node != nil {
+		return KindNode, node.Name
+	}
+	return KindOther, ""
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolve(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web"},
+			Status: corev1.PodStatus{
+				Phase:  corev1.PodRunning,
+				PodIP:  "10.244.0.5",
+				PodIPs: []corev1.PodIP{{IP: "10.244.0.5"}, {IP: "fd00:10:244::5"}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "old"},
+			Status: corev1.PodStatus{
+				Phase:  corev1.PodSucceeded,
+				PodIP:  "10.244.0.6",
+				PodIPs: []corev1.PodIP{{IP: "10.244.0.6"}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "proxy"},
+			Spec:       corev1.PodSpec{HostNetwork: true},
+			Status: corev1.PodStatus{
+				Phase:  corev1.PodRunning,
+				PodIP:  "192.168.1.10",
+				PodIPs: []corev1.PodIP{{IP: "192.168.1.10"}},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:   "10.96.0.20",
+				ClusterIPs:  []string{"10.96.0.20"},
+				ExternalIPs: []string{"203.0.113.7"},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "headless"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:  corev1.ClusterIPNone,
+				ClusterIPs: []string{corev1.ClusterIPNone},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{
+					{Type: corev1.NodeHostName, Address: "worker-1"},
+					{Type: corev1.NodeInternalIP, Address: "192.168.1.10"},
+				},
+			},
+		},
+	)
+
+	r := newResolver(clientset)
+	defer close(r.stopCh)
+
+	tests := []struct {
+		ip   string
+		kind string
+		name string
+	}{
+		{"10.244.0.5", KindPod, "demo/web"},
+		{"fd00:10:244:0::5", KindPod, "demo/web"},
+		{"10.244.0.6", KindOther, ""},
+		{"10.96.0.20", KindService, "demo/web"},
+		{"203.0.113.7", KindService, "demo/web"},
+		{"None", KindOther, ""},
+		{"192.168.1.10", KindNode, "worker-1"},
+		{"8.8.8.8", KindOther, ""},
+		{"", "", ""},
+		{"0.0.0.0", "", ""},
+		{"::", "", ""},
+	}
+
+	for _, test := range tests {
+		kind, name := r.Resolve(test.ip)
+		if kind != test.kind || name != test.name {
+			t.Errorf("Resolve(%q) = %q, %q; want %q, %q", test.ip, kind, name, test.kind, test.name)
+		}
+	}
+
+	if pod := r.Pod("demo", "web"); pod == nil || pod.Status.PodIP != "10.244.0.5" {
+		t.Errorf("Pod(demo, web) = %v", pod)
+	}
+	if pod := r.Pod("demo", "missing"); pod != nil {
+		t.Errorf("Pod(demo, missing) = %v; want nil", pod)
+	}
+
+	// Objects created later are resolved once the informers got them.
+	_, err := clientset.CoreV1().Pods("demo").Create(context.TODO(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "new"},
+		Status: corev1.PodStatus{
+			Phase:  corev1.PodRunning,
+			PodIPs: []corev1.PodIP{{IP: "10.244.0.6"}},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("creating pod: %s", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		kind, name := r.Resolve("10.244.0.6")
+		if kind == KindPod && name == "demo/new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Resolve(10.244.0.6) = %q, %q after creating demo/new", kind, name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -17,17 +17,21 @@ package socketcollector
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 
 	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/ipresolver"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/tracer"
 	socketcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/types"
 )
 
 type Trace struct {
 	helpers gadgets.GadgetHelpers
+	client  client.Client
 }
 
 type TraceFactory struct {
@@ -39,7 +43,7 @@ func NewFactory() gadgets.TraceFactory {
 }
 
 func (f *TraceFactory) Description() string {
-	return `The socket-collector gadget gathers information about TCP and UDP sockets.`
+	return `The socket-collector gadget gathers information about TCP, UDP, UNIX and raw sockets, for both IPv4 and IPv6.`
 }
 
 func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
@@ -52,12 +56,13 @@ func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOp
 	n := func() interface{} {
 		return &Trace{
 			helpers: f.Helpers,
+			client:  f.Client,
 		}
 	}
 
 	return map[gadgetv1alpha1.Operation]gadgets.TraceOperation{
 		gadgetv1alpha1.OperationCollect: {
-			Doc: "Create a snapshot of the currently open TCP and UDP sockets. " +
+			Doc: "Create a snapshot of the currently open TCP, UDP, UNIX and raw sockets. " +
 				"Once taken, the snapshot is not updated automatically. " +
 				"However one can call the collect operation again at any time to update the snapshot.",
 			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
@@ -129,6 +134,24 @@ func (t *Trace) Collect(trace *gadgetv1alpha1.Trace) {
 		}
 	}
 
+	if t.client != nil {
+		resolver, err := ipresolver.NewResolver()
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("failed to create IP resolver: %s", err)
+			return
+		}
+		defer resolver.Close()
+
+		for i := range allSockets {
+			s := &allSockets[i]
+			// UNIX sockets don't have an IP address
+			if net.ParseIP(s.RemoteAddress) == nil {
+				continue
+			}
+			s.RemoteKind, s.RemoteName = resolver.Resolve(s.RemoteAddress)
+		}
+	}
+
 	output, err := json.MarshalIndent(allSockets, "", " ")
 	if err != nil {
 		trace.Status.OperationError = fmt.Sprintf("failed marshalling sockets: %s", err)
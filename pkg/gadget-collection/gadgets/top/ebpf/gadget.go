@@ -24,6 +24,7 @@ import (
 	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfstats"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	ebpftoptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/ebpf/tracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/ebpf/types"
 
@@ -56,10 +57,12 @@ func (f *TraceFactory) Description() string {
 The following parameters are supported:
  - %s: Output interval, in seconds. (default %d)
  - %s: Maximum rows to print. (default %d)
- - %s: The field to sort the results by (%s). (default %s)`
+ - %s: Comma-separated list of columns to sort the results by, prefix a column with - to sort it in descending order. (default %s)
+ - %s: Filters to apply to the results, separated by %s (e.g. comm:nginx). (default none)`
 	return fmt.Sprintf(t, types.IntervalParam, types.IntervalDefault,
 		types.MaxRowsParam, types.MaxRowsDefault,
-		types.SortByParam, strings.Join(types.SortBySlice, ","), types.SortByDefault)
+		types.SortByParam, strings.Join(types.SortByDefault, gadgettop.SortBySeparator),
+		types.FilterParam, gadgettop.FiltersSeparator)
 }
 
 func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
@@ -110,9 +113,11 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 	maxRows := types.MaxRowsDefault
 	intervalSeconds := types.IntervalDefault
 	sortBy := types.SortByDefault
+	var filters []string
 
 	if trace.Spec.Parameters != nil {
 		params := trace.Spec.Parameters
+		cols := types.GetColumns().GetColumnMap()
 		var err error
 
 		if val, ok := params[types.MaxRowsParam]; ok {
@@ -132,18 +137,27 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		}
 
 		if val, ok := params[types.SortByParam]; ok {
-			sortBy, err = types.ParseSortBy(val)
+			sortBy, err = gadgettop.ParseSortBy(cols, val, types.SortByAliases)
 			if err != nil {
 				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %s: %v", val, types.SortByParam, err)
 				return
 			}
 		}
+
+		if val, ok := params[types.FilterParam]; ok {
+			filters, err = gadgettop.ParseFilters(cols, val)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %s: %v", val, types.FilterParam, err)
+				return
+			}
+		}
 	}
 
 	config := &ebpftoptracer.Config{
 		MaxRows:  maxRows,
 		Interval: time.Second * time.Duration(intervalSeconds),
 		SortBy:   sortBy,
+		Filters:  filters,
 	}
 
 	eventCallback := func(ev *types.Event) {
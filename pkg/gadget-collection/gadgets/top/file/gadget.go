@@ -24,6 +24,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	filetoptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/file/tracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/file/types"
 
@@ -53,11 +54,13 @@ func (f *TraceFactory) Description() string {
 The following parameters are supported:
  - %s: Output interval, in seconds. (default %d)
  - %s: Maximum rows to print. (default %d)
- - %s: The field to sort the results by (%s). (default %s)
+ - %s: Comma-separated list of columns to sort the results by, prefix a column with - to sort it in descending order. (default %s)
+ - %s: Filters to apply to the results, separated by %s (e.g. comm:nginx). (default none)
  - %s: Show all files. (default %v, i.e. show regular files only)`
 	return fmt.Sprintf(t, types.IntervalParam, types.IntervalDefault,
 		types.MaxRowsParam, types.MaxRowsDefault,
-		types.SortByParam, strings.Join(types.SortBySlice, ","), types.SortByDefault,
+		types.SortByParam, strings.Join(types.SortByDefault, gadgettop.SortBySeparator),
+		types.FilterParam, gadgettop.FiltersSeparator,
 		types.AllFilesParam, types.AllFilesDefault)
 }
 
@@ -108,10 +111,12 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 	maxRows := types.MaxRowsDefault
 	intervalSeconds := types.IntervalDefault
 	sortBy := types.SortByDefault
+	var filters []string
 	allFiles := types.AllFilesDefault
 
 	if trace.Spec.Parameters != nil {
 		params := trace.Spec.Parameters
+		cols := types.GetColumns().GetColumnMap()
 		var err error
 
 		if val, ok := params[types.MaxRowsParam]; ok {
@@ -131,13 +136,21 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		}
 
 		if val, ok := params[types.SortByParam]; ok {
-			sortBy, err = types.ParseSortBy(val)
+			sortBy, err = gadgettop.ParseSortBy(cols, val, types.SortByAliases)
 			if err != nil {
 				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %s: %v", val, types.SortByParam, err)
 				return
 			}
 		}
 
+		if val, ok := params[types.FilterParam]; ok {
+			filters, err = gadgettop.ParseFilters(cols, val)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %s: %v", val, types.FilterParam, err)
+				return
+			}
+		}
+
 		if val, ok := params[types.AllFilesParam]; ok {
 			allFiles, err = strconv.ParseBool(val)
 			if err != nil {
@@ -158,6 +171,7 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		MaxRows:    maxRows,
 		Interval:   time.Second * time.Duration(intervalSeconds),
 		SortBy:     sortBy,
+		Filters:    filters,
 		MountnsMap: mountNsMap,
 	}
 
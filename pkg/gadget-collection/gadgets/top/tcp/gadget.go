@@ -22,8 +22,11 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/ipresolver"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	tcptoptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/tcp/tracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/tcp/types"
 
@@ -32,9 +35,12 @@ import (
 
 type Trace struct {
 	helpers gadgets.GadgetHelpers
+	client  client.Client
 
 	started bool
 	tracer  *tcptoptracer.Tracer
+
+	resolver *ipresolver.Resolver
 }
 
 type TraceFactory struct {
@@ -53,12 +59,14 @@ func (f *TraceFactory) Description() string {
 The following parameters are supported:
 - %s: Output interval, in seconds. (default %d)
 - %s: Maximum rows to print. (default %d)
-- %s: The field to sort the results by (%s). (default %s)
+- %s: Comma-separated list of columns to sort the results by, prefix a column with - to sort it in descending order. (default %s)
+- %s: Filters to apply to the results, separated by %s (e.g. comm:nginx). (default none)
 - %s: Only get events for this PID (default to all).
 - %s: Only get events for this IP version. (either 4 or 6, default to all)`
 	return fmt.Sprintf(t, types.IntervalParam, types.IntervalDefault,
 		types.MaxRowsParam, types.MaxRowsDefault,
-		types.SortByParam, strings.Join(types.SortBySlice, ","), types.SortByDefault,
+		types.SortByParam, strings.Join(types.SortByDefault, gadgettop.SortBySeparator),
+		types.FilterParam, gadgettop.FiltersSeparator,
 		types.PidParam, types.FamilyParam)
 }
 
@@ -73,12 +81,14 @@ func deleteTrace(name string, t interface{}) {
 	if trace.tracer != nil {
 		trace.tracer.Stop()
 	}
+	trace.resolver.Close()
 }
 
 func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOperation {
 	n := func() interface{} {
 		return &Trace{
 			helpers: f.Helpers,
+			client:  f.Client,
 		}
 	}
 
@@ -109,11 +119,13 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 	maxRows := types.MaxRowsDefault
 	intervalSeconds := types.IntervalDefault
 	sortBy := types.SortByDefault
+	var filters []string
 	targetPid := int32(-1)
 	targetFamily := int32(-1)
 
 	if trace.Spec.Parameters != nil {
 		params := trace.Spec.Parameters
+		cols := types.GetColumns().GetColumnMap()
 		var err error
 
 		if val, ok := params[types.MaxRowsParam]; ok {
@@ -133,13 +145,21 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		}
 
 		if val, ok := params[types.SortByParam]; ok {
-			sortBy, err = types.ParseSortBy(val)
+			sortBy, err = gadgettop.ParseSortBy(cols, val, types.SortByAliases)
 			if err != nil {
 				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.SortByParam)
 				return
 			}
 		}
 
+		if val, ok := params[types.FilterParam]; ok {
+			filters, err = gadgettop.ParseFilters(cols, val)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.FilterParam)
+				return
+			}
+		}
+
 		if val, ok := params[types.PidParam]; ok {
 			pid, err := strconv.ParseInt(val, 10, 32)
 			if err != nil {
@@ -168,12 +188,19 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		MaxRows:      maxRows,
 		Interval:     time.Second * time.Duration(intervalSeconds),
 		SortBy:       sortBy,
+		Filters:      filters,
 		MountnsMap:   mountNsMap,
 		TargetPid:    targetPid,
 		TargetFamily: targetFamily,
 	}
 
 	eventCallback := func(ev *types.Event) {
+		if t.resolver != nil {
+			for _, stats := range ev.Stats {
+				stats.RemoteKind, stats.RemoteName = t.resolver.Resolve(stats.Daddr)
+			}
+		}
+
 		r, err := json.Marshal(ev)
 		if err != nil {
 			log.Warnf("Gadget %s: Failed to marshall event: %s", trace.Spec.Gadget, err)
@@ -182,8 +209,18 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		t.helpers.PublishEvent(traceName, string(r))
 	}
 
+	if t.client != nil {
+		t.resolver, err = ipresolver.NewResolver()
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("failed to create IP resolver: %s", err)
+			return
+		}
+	}
+
 	tracer, err := tcptoptracer.NewTracer(config, t.helpers, eventCallback)
 	if err != nil {
+		t.resolver.Close()
+		t.resolver = nil
 		trace.Status.OperationError = fmt.Sprintf("failed to create tracer: %s", err)
 		return
 	}
@@ -202,6 +239,8 @@ func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
 
 	t.tracer.Stop()
 	t.tracer = nil
+	t.resolver.Close()
+	t.resolver = nil
 	t.started = false
 
 	trace.Status.State = gadgetv1alpha1.TraceStateStopped
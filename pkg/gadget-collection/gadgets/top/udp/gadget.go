@@ -0,0 +1,247 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package udptop
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/ipresolver"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	udptoptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/udp/tracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/udp/types"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+)
+
+type Trace struct {
+	helpers gadgets.GadgetHelpers
+	client  client.Client
+
+	started bool
+	tracer  *udptoptracer.Tracer
+
+	resolver *ipresolver.Resolver
+}
+
+type TraceFactory struct {
+	gadgets.BaseFactory
+}
+
+func NewFactory() gadgets.TraceFactory {
+	return &TraceFactory{
+		BaseFactory: gadgets.BaseFactory{DeleteTrace: deleteTrace},
+	}
+}
+
+func (f *TraceFactory) Description() string {
+	t := `udptop shows the UDP traffic generated by commands, with container details.
+
+The following parameters are supported:
+- %s: Output interval, in seconds. (default %d)
+- %s: Maximum rows to print. (default %d)
+- %s: Comma-separated list of columns to sort the results by, prefix a column with - to sort it in descending order. (default %s)
+- %s: Filters to apply to the results, separated by %s (e.g. comm:nginx). (default none)
+- %s: Only get events for this PID (default to all).
+- %s: Only get events for this IP version. (either 4 or 6, default to all)`
+	return fmt.Sprintf(t, types.IntervalParam, types.IntervalDefault,
+		types.MaxRowsParam, types.MaxRowsDefault,
+		types.SortByParam, strings.Join(types.SortByDefault, gadgettop.SortBySeparator),
+		types.FilterParam, gadgettop.FiltersSeparator,
+		types.PidParam, types.FamilyParam)
+}
+
+func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
+	return map[gadgetv1alpha1.TraceOutputMode]struct{}{
+		gadgetv1alpha1.TraceOutputModeStream: {},
+	}
+}
+
+func deleteTrace(name string, t interface{}) {
+	trace := t.(*Trace)
+	if trace.tracer != nil {
+		trace.tracer.Stop()
+	}
+	trace.resolver.Close()
+}
+
+func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOperation {
+	n := func() interface{} {
+		return &Trace{
+			helpers: f.Helpers,
+			client:  f.Client,
+		}
+	}
+
+	return map[gadgetv1alpha1.Operation]gadgets.TraceOperation{
+		gadgetv1alpha1.OperationStart: {
+			Doc: "Start udptop gadget",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Start(trace)
+			},
+		},
+		gadgetv1alpha1.OperationStop: {
+			Doc: "Stop udptop gadget",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Stop(trace)
+			},
+		},
+	}
+}
+
+func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
+	if t.started {
+		trace.Status.State = gadgetv1alpha1.TraceStateStarted
+		return
+	}
+
+	traceName := gadgets.TraceName(trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+
+	maxRows := types.MaxRowsDefault
+	intervalSeconds := types.IntervalDefault
+	sortBy := types.SortByDefault
+	var filters []string
+	targetPid := int32(-1)
+	targetFamily := int32(-1)
+
+	if trace.Spec.Parameters != nil {
+		params := trace.Spec.Parameters
+		cols := types.GetColumns().GetColumnMap()
+		var err error
+
+		if val, ok := params[types.MaxRowsParam]; ok {
+			maxRows, err = strconv.Atoi(val)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.MaxRowsParam)
+				return
+			}
+		}
+
+		if val, ok := params[types.IntervalParam]; ok {
+			intervalSeconds, err = strconv.Atoi(val)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.IntervalParam)
+				return
+			}
+		}
+
+		if val, ok := params[types.SortByParam]; ok {
+			sortBy, err = gadgettop.ParseSortBy(cols, val, nil)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.SortByParam)
+				return
+			}
+		}
+
+		if val, ok := params[types.FilterParam]; ok {
+			filters, err = gadgettop.ParseFilters(cols, val)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.FilterParam)
+				return
+			}
+		}
+
+		if val, ok := params[types.PidParam]; ok {
+			pid, err := strconv.ParseInt(val, 10, 32)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.PidParam)
+				return
+			}
+
+			targetPid = int32(pid)
+		}
+
+		if val, ok := params[types.FamilyParam]; ok {
+			targetFamily, err = types.ParseFilterByFamily(val)
+			if err != nil {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.FamilyParam)
+				return
+			}
+		}
+	}
+
+	mountNsMap, err := t.helpers.TracerMountNsMap(traceName)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("failed to find tracer's mount ns map: %s", err)
+		return
+	}
+	config := &udptoptracer.Config{
+		MaxRows:      maxRows,
+		Interval:     time.Second * time.Duration(intervalSeconds),
+		SortBy:       sortBy,
+		Filters:      filters,
+		MountnsMap:   mountNsMap,
+		TargetPid:    targetPid,
+		TargetFamily: targetFamily,
+	}
+
+	eventCallback := func(ev *types.Event) {
+		if t.resolver != nil {
+			for _, stats := range ev.Stats {
+				stats.RemoteKind, stats.RemoteName = t.resolver.Resolve(stats.Daddr)
+			}
+		}
+
+		r, err := json.Marshal(ev)
+		if err != nil {
+			log.Warnf("Gadget %s: Failed to marshall event: %s", trace.Spec.Gadget, err)
+			return
+		}
+		t.helpers.PublishEvent(traceName, string(r))
+	}
+
+	if t.client != nil {
+		t.resolver, err = ipresolver.NewResolver()
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("failed to create IP resolver: %s", err)
+			return
+		}
+	}
+
+	tracer, err := udptoptracer.NewTracer(config, t.helpers, eventCallback)
+	if err != nil {
+		t.resolver.Close()
+		t.resolver = nil
+		trace.Status.OperationError = fmt.Sprintf("failed to create tracer: %s", err)
+		return
+	}
+
+	t.tracer = tracer
+	t.started = true
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStarted
+}
+
+func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
+	if !t.started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+
+	t.tracer.Stop()
+	t.tracer = nil
+	t.resolver.Close()
+	t.resolver = nil
+	t.started = false
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStopped
+}
@@ -21,22 +21,28 @@ import (
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/ipresolver"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/bind/tracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/bind/types"
 	standardtracer "github.com/inspektor-gadget/inspektor-gadget/pkg/standardgadgets/trace/bind"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 
 	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
 )
 
 type Trace struct {
 	helpers gadgets.GadgetHelpers
+	client  client.Client
 
 	started bool
 	tracer  trace.Tracer
+
+	resolver *ipresolver.Resolver
 }
 
 type TraceFactory struct {
@@ -64,12 +70,14 @@ func deleteTrace(name string, t interface{}) {
 	if trace.tracer != nil {
 		trace.tracer.Stop()
 	}
+	trace.resolver.Close()
 }
 
 func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOperation {
 	n := func() interface{} {
 		return &Trace{
 			helpers: f.Helpers,
+			client:  f.Client,
 		}
 	}
 
@@ -98,6 +106,10 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 	traceName := gadgets.TraceName(trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
 
 	eventCallback := func(event types.Event) {
+		if t.resolver != nil && event.Type == eventtypes.NORMAL {
+			event.RemoteKind, event.RemoteName = t.resolver.Resolve(event.Addr)
+		}
+
 		r, err := json.Marshal(event)
 		if err != nil {
 			log.Warnf("Gadget %s: error marshalling event: %s", trace.Spec.Gadget, err)
@@ -158,6 +170,14 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		TargetPorts:  targetPorts,
 		IgnoreErrors: ignoreErrors,
 	}
+	if t.client != nil {
+		t.resolver, err = ipresolver.NewResolver()
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("failed to create IP resolver: %s", err)
+			return
+		}
+	}
+
 	t.tracer, err = tracer.NewTracer(config, t.helpers, eventCallback)
 	if err != nil {
 		trace.Status.OperationWarning = fmt.Sprint("failed to create core tracer. Falling back to standard one")
@@ -168,6 +188,8 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 
 		t.tracer, err = standardtracer.NewTracer(config, eventCallback)
 		if err != nil {
+			t.resolver.Close()
+			t.resolver = nil
 			trace.Status.OperationError = fmt.Sprintf("failed to create tracer: %s", err)
 			return
 		}
@@ -186,6 +208,8 @@ func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
 
 	t.tracer.Stop()
 	t.tracer = nil
+	t.resolver.Close()
+	t.resolver = nil
 	t.started = false
 
 	trace.Status.State = gadgetv1alpha1.TraceStateStopped
@@ -17,6 +17,7 @@ package dns
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -57,7 +58,10 @@ func NewFactory() gadgets.TraceFactory {
 }
 
 func (f *TraceFactory) Description() string {
-	return `The dns gadget traces DNS requests.`
+	return `The dns gadget traces DNS requests.
+
+The following parameters are supported:
+- responses: Also trace the responses, with the addresses of their answers (default false)`
 }
 
 func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
@@ -153,8 +157,19 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		return
 	}
 
+	config := &dnstracer.Config{}
+
 	var err error
-	t.tracer, err = dnstracer.NewTracer()
+	params := trace.Spec.Parameters
+	if val, ok := params["responses"]; ok {
+		config.CaptureResponses, err = strconv.ParseBool(val)
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("%q is not valid for responses: %s", val, err)
+			return
+		}
+	}
+
+	t.tracer, err = dnstracer.NewTracer(config)
 	if err != nil {
 		trace.Status.OperationError = fmt.Sprintf("Failed to start dns tracer: %s", err)
 		return
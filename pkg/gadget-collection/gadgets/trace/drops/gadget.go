@@ -0,0 +1,218 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drops
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/ipresolver"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/tracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/types"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type pubSubKey string
+
+type Trace struct {
+	helpers gadgets.GadgetHelpers
+	client  client.Client
+
+	started   bool
+	tracer    *tracer.Tracer
+	resolver  *ipresolver.Resolver
+	pubSubKey pubSubKey
+	netnsHost uint64
+}
+
+type TraceFactory struct {
+	gadgets.BaseFactory
+
+	netnsHost uint64
+}
+
+func NewFactory() gadgets.TraceFactory {
+	netnsHost, _ := containerutils.GetNetNs(os.Getpid())
+	return &TraceFactory{
+		BaseFactory: gadgets.BaseFactory{DeleteTrace: deleteTrace},
+		netnsHost:   netnsHost,
+	}
+}
+
+func (f *TraceFactory) Description() string {
+	return `drops traces packets dropped by the kernel and reports the reason of the drop`
+}
+
+func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
+	return map[gadgetv1alpha1.TraceOutputMode]struct{}{
+		gadgetv1alpha1.TraceOutputModeStream: {},
+	}
+}
+
+func deleteTrace(name string, t interface{}) {
+	trace := t.(*Trace)
+	if trace.started {
+		trace.helpers.Unsubscribe(trace.pubSubKey)
+	}
+	if trace.tracer != nil {
+		trace.tracer.Stop()
+	}
+	trace.resolver.Close()
+}
+
+func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOperation {
+	n := func() interface{} {
+		return &Trace{
+			helpers:   f.Helpers,
+			client:    f.Client,
+			netnsHost: f.netnsHost,
+		}
+	}
+
+	return map[gadgetv1alpha1.Operation]gadgets.TraceOperation{
+		gadgetv1alpha1.OperationStart: {
+			Doc: "Start drops gadget",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Start(trace)
+			},
+		},
+		gadgetv1alpha1.OperationStop: {
+			Doc: "Stop drops gadget",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Stop(trace)
+			},
+		},
+	}
+}
+
+func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
+	if t.started {
+		trace.Status.State = gadgetv1alpha1.TraceStateStarted
+		return
+	}
+
+	traceName := gadgets.TraceName(trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+
+	publishEvent := func(event types.Event) {
+		r, err := json.Marshal(event)
+		if err != nil {
+			log.Warnf("Gadget %s: error marshalling event: %s", trace.Spec.Gadget, err)
+			return
+		}
+		t.helpers.PublishEvent(traceName, string(r))
+	}
+
+	eventCallback := func(event types.Event) {
+		if t.resolver != nil && event.Type == eventtypes.NORMAL {
+			event.SrcKind, event.SrcName = t.resolver.Resolve(event.Saddr)
+			event.DstKind, event.DstName = t.resolver.Resolve(event.Daddr)
+		}
+		publishEvent(event)
+	}
+
+	var err error
+
+	if t.client != nil {
+		t.resolver, err = ipresolver.NewResolver()
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("failed to create IP resolver: %s", err)
+			return
+		}
+	}
+
+	config := &tracer.Config{
+		FilterByNetNs: true,
+	}
+	t.tracer, err = tracer.NewTracer(config, t.helpers, eventCallback)
+	if err != nil {
+		t.resolver.Close()
+		t.resolver = nil
+		trace.Status.OperationError = fmt.Sprintf("failed to create tracer: %s", err)
+		return
+	}
+
+	// Packets are dropped mostly in softirq context, so the events are
+	// filtered by the network namespace of the selected containers. The
+	// host network namespace is skipped as it's shared with the node.
+	attachContainerFunc := func(container *containercollection.Container) {
+		if container.Netns == t.netnsHost {
+			return
+		}
+		if err := t.tracer.AttachNetNs(container.Netns); err != nil {
+			msg := fmt.Sprintf("failed to attach tracer to %s/%s: %s", container.Namespace, container.Podname, err)
+			publishEvent(types.Base(eventtypes.Err(msg)))
+		}
+	}
+
+	detachContainerFunc := func(container *containercollection.Container) {
+		if container.Netns == t.netnsHost {
+			return
+		}
+		if err := t.tracer.DetachNetNs(container.Netns); err != nil {
+			msg := fmt.Sprintf("failed to detach tracer from %s/%s: %s", container.Namespace, container.Podname, err)
+			publishEvent(types.Base(eventtypes.Err(msg)))
+		}
+	}
+
+	containerEventCallback := func(event containercollection.PubSubEvent) {
+		switch event.Type {
+		case containercollection.EventTypeAddContainer:
+			attachContainerFunc(event.Container)
+		case containercollection.EventTypeRemoveContainer:
+			detachContainerFunc(event.Container)
+		}
+	}
+
+	t.pubSubKey = pubSubKey(fmt.Sprintf("gadget/drops/%s/%s", trace.ObjectMeta.Namespace, trace.ObjectMeta.Name))
+	existingContainers := t.helpers.Subscribe(
+		t.pubSubKey,
+		*gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter),
+		containerEventCallback,
+	)
+
+	for _, c := range existingContainers {
+		attachContainerFunc(c)
+	}
+
+	t.started = true
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStarted
+}
+
+func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
+	if !t.started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+
+	t.helpers.Unsubscribe(t.pubSubKey)
+	t.tracer.Stop()
+	t.tracer = nil
+	t.resolver.Close()
+	t.resolver = nil
+	t.started = false
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStopped
+}
@@ -17,3 +17,12 @@ package trace
 type Tracer interface {
 	Stop()
 }
+
+// NetNsTracer is implemented by the tracers whose events happen outside of
+// process context and hence are filtered by network namespace instead of
+// mount namespace.
+type NetNsTracer interface {
+	Tracer
+	AttachNetNs(netns uint64) error
+	DetachNetNs(netns uint64) error
+}
@@ -15,23 +15,17 @@
 package networkgraph
 
 import (
-	"context"
 	"strings"
 
-	log "github.com/sirupsen/logrus"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/ipresolver"
 	nettracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/tracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
-	"github.com/inspektor-gadget/inspektor-gadget/pkg/k8sutil"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 type Enricher struct {
 	withKubernetes bool
-	clientset      *kubernetes.Clientset
+	resolver       *ipresolver.Resolver
 	node           string
 }
 
@@ -43,22 +37,18 @@ func NewEnricher(withKubernetes bool, node string) (*Enricher, error) {
 		}, nil
 	}
 
-	clientset, err := k8sutil.NewClientset("")
+	resolver, err := ipresolver.NewResolver()
 	if err != nil {
 		return nil, err
 	}
 	return &Enricher{
 		withKubernetes: withKubernetes,
-		clientset:      clientset,
+		resolver:       resolver,
 		node:           node,
 	}, nil
 }
 
-func (e *Enricher) convertEvent(
-	edge nettracer.Edge,
-	pods *corev1.PodList,
-	svcs *corev1.ServiceList,
-) types.Event {
+func (e *Enricher) convertEvent(edge nettracer.Edge) types.Event {
 	var namespace, name string
 	parts := strings.Split(edge.Key, "/")
 	if len(parts) == 2 {
@@ -81,45 +71,44 @@ func (e *Enricher) convertEvent(
 		Proto:   edge.Proto,
 		IP:      edge.IP.String(),
 		Port:    edge.Port,
+
+		Packets:   edge.Packets,
+		Bytes:     edge.Bytes,
+		FirstSeen: edge.FirstSeen.UnixNano(),
+		LastSeen:  edge.LastSeen.UnixNano(),
+	}
+
+	if !e.withKubernetes {
+		return out
 	}
 
 	// Find the pod resource where the packet capture occured
-	localPodIndex := -1
-	for i, pod := range pods.Items {
-		if pod.GetNamespace() == namespace && pod.GetName() == name {
-			localPodIndex = i
-			out.PodLabels = pod.Labels
-			// Kubernetes Network Policies can't block traffic from
-			// a pod's resident node. Therefore we must not
-			// generate a network policy in that case. The advisor
-			// will use PodHostIP to detect this.
-			out.PodHostIP = pod.Status.HostIP
-			out.PodIP = pod.Status.PodIP
-			break
-		}
+	localPod := e.resolver.Pod(namespace, name)
+	if localPod != nil {
+		out.PodLabels = localPod.Labels
+		// Kubernetes Network Policies can't block traffic from
+		// a pod's resident node. Therefore we must not
+		// generate a network policy in that case. The advisor
+		// will use PodHostIP to detect this.
+		out.PodHostIP = localPod.Status.HostIP
+		out.PodIP = localPod.Status.PodIP
 	}
 
 	// Find the remote pod, if any
-	for _, pod := range pods.Items {
-		if pod.Spec.HostNetwork {
-			continue
-		}
-		if pod.Status.PodIP == edge.IP.String() {
-			out.RemoteKind = "pod"
-			out.RemotePodNamespace = pod.Namespace
-			out.RemotePodName = pod.Name
-			out.RemotePodLabels = pod.Labels
-			break
-		}
+	if pod := e.resolver.PodByIP(out.IP); pod != nil {
+		out.RemoteKind = "pod"
+		out.RemotePodNamespace = pod.Namespace
+		out.RemotePodName = pod.Name
+		out.RemotePodLabels = pod.Labels
 	}
-	if localPodIndex == -1 {
+	if localPod == nil {
 		return out
 	}
 
 	// When the pod belongs to Deployment, ReplicaSet or DaemonSet, find the
 	// shorter name without the random suffix. That will be used to
 	// generate the network policy name.
-	if pods.Items[localPodIndex].OwnerReferences != nil {
+	if localPod.OwnerReferences != nil {
 		nameItems := strings.Split(out.Pod, "-")
 		if len(nameItems) > 2 {
 			out.PodOwner = strings.Join(nameItems[:len(nameItems)-2], "-")
@@ -127,47 +116,28 @@ func (e *Enricher) convertEvent(
 	}
 
 	if out.RemoteKind == "" {
-		for _, svc := range svcs.Items {
-			if svc.Spec.ClusterIP == edge.IP.String() {
-				out.RemoteKind = "svc"
-				out.RemoteSvcNamespace = svc.Namespace
-				out.RemoteSvcName = svc.Name
-				out.RemoteSvcLabelSelector = svc.Spec.Selector
-				break
-			}
+		if svc := e.resolver.ServiceByIP(out.IP); svc != nil {
+			out.RemoteKind = "svc"
+			out.RemoteSvcNamespace = svc.Namespace
+			out.RemoteSvcName = svc.Name
+			out.RemoteSvcLabelSelector = svc.Spec.Selector
 		}
 	}
 	if out.RemoteKind == "" {
 		out.RemoteKind = "other"
-		out.RemoteOther = edge.IP.String()
+		out.RemoteOther = out.IP
 	}
 
 	return out
 }
 
 func (e *Enricher) Enrich(edges []nettracer.Edge) (out []types.Event) {
-	var err error
-	pods := &corev1.PodList{}
-	svcs := &corev1.ServiceList{}
-
-	if e.withKubernetes {
-		pods, err = e.clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			log.Errorf("%s", err)
-			return
-		}
-		svcs, err = e.clientset.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			log.Errorf("%s", err)
-			return
-		}
-	}
-
 	for _, edge := range edges {
-		out = append(out, e.convertEvent(edge, pods, svcs))
+		out = append(out, e.convertEvent(edge))
 	}
 	return out
 }
 
 func (e *Enricher) Close() {
+	e.resolver.Close()
 }
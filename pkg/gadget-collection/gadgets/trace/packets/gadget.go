@@ -0,0 +1,225 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	packetstracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/tracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Trace struct {
+	helpers gadgets.GadgetHelpers
+
+	started bool
+	tracer  *packetstracer.Tracer
+
+	netnsHost uint64
+	pubSubKey string
+}
+
+type TraceFactory struct {
+	gadgets.BaseFactory
+
+	netnsHost uint64
+}
+
+func NewFactory() gadgets.TraceFactory {
+	netnsHost, _ := containerutils.GetNetNs(os.Getpid())
+	return &TraceFactory{
+		BaseFactory: gadgets.BaseFactory{DeleteTrace: deleteTrace},
+		netnsHost:   netnsHost,
+	}
+}
+
+func (f *TraceFactory) Description() string {
+	t := `The packets gadget captures the packets of the network namespace of the selected pods.
+
+The following parameters are supported:
+- %s: Filter expression, in a subset of the tcpdump syntax. (default none)
+- %s: Number of bytes captured per packet. (default %d)`
+	return fmt.Sprintf(t, types.FilterParam, types.SnapLenParam, types.SnapLenDefault)
+}
+
+func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
+	return map[gadgetv1alpha1.TraceOutputMode]struct{}{
+		gadgetv1alpha1.TraceOutputModeStream: {},
+	}
+}
+
+func deleteTrace(name string, t interface{}) {
+	trace := t.(*Trace)
+	if trace.started {
+		trace.stop()
+	}
+}
+
+func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOperation {
+	n := func() interface{} {
+		return &Trace{
+			helpers:   f.Helpers,
+			netnsHost: f.netnsHost,
+		}
+	}
+
+	return map[gadgetv1alpha1.Operation]gadgets.TraceOperation{
+		gadgetv1alpha1.OperationStart: {
+			Doc: "Start packets gadget",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Start(trace)
+			},
+		},
+		gadgetv1alpha1.OperationStop: {
+			Doc: "Stop packets gadget",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Stop(trace)
+			},
+		},
+	}
+}
+
+func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
+	if t.started {
+		trace.Status.State = gadgetv1alpha1.TraceStateStarted
+		return
+	}
+
+	config := &packetstracer.Config{
+		SnapLen: types.SnapLenDefault,
+	}
+
+	if trace.Spec.Parameters != nil {
+		params := trace.Spec.Parameters
+
+		config.Filter = params[types.FilterParam]
+
+		if val, ok := params[types.SnapLenParam]; ok {
+			snapLen, err := strconv.ParseUint(val, 10, 32)
+			if err != nil || snapLen == 0 {
+				trace.Status.OperationError = fmt.Sprintf("%q is not valid for %q", val, types.SnapLenParam)
+				return
+			}
+			config.SnapLen = uint32(snapLen)
+		}
+	}
+
+	traceName := gadgets.TraceName(trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+
+	eventCallback := func(event *types.Event) {
+		event.Node = trace.Spec.Node
+
+		r, err := json.Marshal(event)
+		if err != nil {
+			log.Warnf("Gadget %s: error marshalling event: %s", trace.Spec.Gadget, err)
+			return
+		}
+		t.helpers.PublishEvent(traceName, string(r))
+	}
+
+	publishErr := func(msg string) {
+		event := types.Base(eventtypes.Err(msg))
+		eventCallback(&event)
+	}
+
+	var err error
+	t.tracer, err = packetstracer.NewTracer(config, eventCallback)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("failed to create tracer: %s", err)
+		return
+	}
+
+	// Containers of the same pod share the network namespace and thus the
+	// capture.
+	genKey := func(container *containercollection.Container) string {
+		return container.Namespace + "/" + container.Podname
+	}
+
+	attachContainer := func(container *containercollection.Container) {
+		if container.Netns == t.netnsHost {
+			publishErr(fmt.Sprintf("not capturing packets of %s/%s: it uses the host network namespace",
+				container.Namespace, container.Podname))
+			return
+		}
+
+		data := eventtypes.CommonData{
+			Node:      trace.Spec.Node,
+			Namespace: container.Namespace,
+			Pod:       container.Podname,
+			Container: container.Name,
+		}
+		if err := t.tracer.Attach(genKey(container), container.Pid, data); err != nil {
+			publishErr(fmt.Sprintf("failed to attach tracer to %s/%s: %s",
+				container.Namespace, container.Podname, err))
+		}
+	}
+
+	containerEventCallback := func(event containercollection.PubSubEvent) {
+		switch event.Type {
+		case containercollection.EventTypeAddContainer:
+			attachContainer(event.Container)
+		case containercollection.EventTypeRemoveContainer:
+			if event.Container.Netns == t.netnsHost {
+				return
+			}
+			if err := t.tracer.Detach(genKey(event.Container)); err != nil {
+				publishErr(fmt.Sprintf("failed to detach tracer: %s", err))
+			}
+		}
+	}
+
+	t.pubSubKey = fmt.Sprintf("gadget/packets/%s/%s", trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+	existingContainers := t.helpers.Subscribe(
+		t.pubSubKey,
+		*gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter),
+		containerEventCallback,
+	)
+
+	for _, c := range existingContainers {
+		attachContainer(c)
+	}
+
+	t.started = true
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStarted
+}
+
+func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
+	if !t.started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+
+	t.stop()
+	trace.Status.State = gadgetv1alpha1.TraceStateStopped
+}
+
+func (t *Trace) stop() {
+	t.helpers.Unsubscribe(t.pubSubKey)
+
+	t.tracer.Close()
+	t.tracer = nil
+	t.started = false
+}
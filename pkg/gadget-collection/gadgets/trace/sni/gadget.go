@@ -27,6 +27,7 @@ import (
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/ipresolver"
 	snitracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/sni/tracer"
 	types "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/sni/types"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
@@ -38,7 +39,8 @@ type Trace struct {
 
 	started bool
 
-	tracer *snitracer.Tracer
+	tracer   *snitracer.Tracer
+	resolver *ipresolver.Resolver
 
 	netnsHost uint64
 }
@@ -58,7 +60,11 @@ func NewFactory() gadgets.TraceFactory {
 }
 
 func (f *TraceFactory) Description() string {
-	return `The snisnoop gadget retrieves Server Name Indication (SNI) from TLS requests.`
+	return `The snisnoop gadget retrieves Server Name Indication (SNI) from TLS requests.
+
+Besides the server name, it reports the destination address and port, the
+Kubernetes pod or service it belongs to, the highest TLS version offered by
+the client, the ALPN protocols and the number of cipher suites.`
 }
 
 func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
@@ -73,6 +79,8 @@ func deleteTrace(name string, t interface{}) {
 		trace.helpers.Unsubscribe(genPubSubKey(name))
 		trace.tracer.Close()
 		trace.tracer = nil
+		trace.resolver.Close()
+		trace.resolver = nil
 	}
 }
 
@@ -114,8 +122,19 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 	}
 
 	var err error
+
+	if t.client != nil {
+		t.resolver, err = ipresolver.NewResolver()
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("Failed to create IP resolver: %s", err)
+			return
+		}
+	}
+
 	t.tracer, err = snitracer.NewTracer()
 	if err != nil {
+		t.resolver.Close()
+		t.resolver = nil
 		trace.Status.OperationError = fmt.Sprintf("Failed to start sni tracer: %s", err)
 		return
 	}
@@ -150,17 +169,11 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		}
 		return string(b)
 	}
-	printEvent := func(key, name string) string {
-		event := &types.Event{
-			Event: eventtypes.Event{
-				Type: eventtypes.NORMAL,
-				CommonData: eventtypes.CommonData{
-					Node: trace.Spec.Node,
-				},
-			},
-			Name: name,
-		}
+	printEvent := func(key string, event *types.Event) string {
 		fillEvent(event, key)
+		if t.resolver != nil && event.Type == eventtypes.NORMAL {
+			event.RemoteKind, event.RemoteName = t.resolver.Resolve(event.Daddr)
+		}
 
 		b, err := json.Marshal(event)
 		if err != nil {
@@ -175,7 +188,7 @@ func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
 		return func(event types.Event) {
 			t.helpers.PublishEvent(
 				traceName,
-				printEvent(key, event.Name),
+				printEvent(key, &event),
 			)
 		}
 	}
@@ -258,6 +271,8 @@ func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
 	t.helpers.Unsubscribe(genPubSubKey(trace.ObjectMeta.Namespace + "/" + trace.ObjectMeta.Name))
 	t.tracer.Close()
 	t.tracer = nil
+	t.resolver.Close()
+	t.resolver = nil
 	t.started = false
 
 	trace.Status.State = gadgetv1alpha1.TraceStateStopped
@@ -0,0 +1,212 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const verdictAllow = "Allow"
+
+// Recommendation is the security context advised for a container of a
+// workload.
+type Recommendation struct {
+	workload.ID
+
+	SecurityContext v1.SecurityContext `json:"securityContext"`
+
+	// Denied are the capabilities that were checked but never granted.
+	// They are not added because the container ran without them.
+	Denied []string `json:"denied,omitempty"`
+
+	// Privileged is set when the container runs privileged. Dropping
+	// capabilities has no effect on such containers, so the security
+	// context also disables privileged.
+	Privileged bool `json:"privileged,omitempty"`
+
+	// PrivilegedUnused is set when the container runs privileged but didn't
+	// use any capability.
+	PrivilegedUnused bool `json:"privilegedUnused,omitempty"`
+}
+
+type CapabilitiesAdvisor struct {
+	Events []types.Event
+
+	// GetPod returns the information about a pod. It can return nil when
+	// the pod is unknown, the pod is then its own workload.
+	GetPod func(namespace, name string) *workload.Pod
+
+	Recommendations []Recommendation
+}
+
+func NewAdvisor() *CapabilitiesAdvisor {
+	return &CapabilitiesAdvisor{
+		GetPod: func(namespace, name string) *workload.Pod { return nil },
+	}
+}
+
+func (a *CapabilitiesAdvisor) LoadFile(filename string) error {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return a.LoadBuffer(buf)
+}
+
+func (a *CapabilitiesAdvisor) LoadBuffer(buf []byte) error {
+	events, err := workload.LoadEvents[types.Event](buf)
+	if err != nil {
+		return err
+	}
+	a.Events = events
+	return nil
+}
+
+type containerCapabilities struct {
+	allowed    map[string]struct{}
+	denied     map[string]struct{}
+	privileged bool
+}
+
+// GenerateRecommendations aggregates the capability checks per workload and
+// container. The recommended security context drops all the capabilities and
+// only adds the ones that were granted.
+func (a *CapabilitiesAdvisor) GenerateRecommendations() {
+	getPod := workload.PodCache(a.GetPod)
+	containers := map[workload.ID]*containerCapabilities{}
+
+	for _, e := range a.Events {
+		if e.Type != eventtypes.NORMAL || e.Namespace == "" || e.Pod == "" || e.Container == "" {
+			continue
+		}
+
+		pod := getPod(e.Namespace, e.Pod)
+		key := workload.ID{
+			Namespace: e.Namespace,
+			Kind:      pod.OwnerKind,
+			Name:      pod.OwnerName,
+			Container: e.Container,
+		}
+		c, ok := containers[key]
+		if !ok {
+			c = &containerCapabilities{
+				allowed: map[string]struct{}{},
+				denied:  map[string]struct{}{},
+			}
+			containers[key] = c
+		}
+		if pod.Privileged[e.Container] {
+			c.privileged = true
+		}
+
+		if e.Verdict == verdictAllow {
+			c.allowed[e.CapName] = struct{}{}
+		} else {
+			c.denied[e.CapName] = struct{}{}
+		}
+	}
+
+	a.Recommendations = nil
+	for key, c := range containers {
+		for capName := range c.allowed {
+			delete(c.denied, capName)
+		}
+
+		capabilities := &v1.Capabilities{
+			Drop: []v1.Capability{"ALL"},
+		}
+		for _, capName := range workload.SortedKeys(c.allowed) {
+			capabilities.Add = append(capabilities.Add, v1.Capability(capName))
+		}
+
+		r := Recommendation{
+			ID: key,
+			SecurityContext: v1.SecurityContext{
+				Capabilities: capabilities,
+			},
+			Denied:           workload.SortedKeys(c.denied),
+			Privileged:       c.privileged,
+			PrivilegedUnused: c.privileged && len(c.allowed) == 0,
+		}
+		if len(r.Denied) == 0 {
+			r.Denied = nil
+		}
+		if r.Privileged {
+			privileged := false
+			r.SecurityContext.Privileged = &privileged
+		}
+
+		a.Recommendations = append(a.Recommendations, r)
+	}
+
+	sort.Slice(a.Recommendations, func(i, j int) bool {
+		return a.Recommendations[i].ID.Less(a.Recommendations[j].ID)
+	})
+}
+
+func (r *Recommendation) comments() (out string) {
+	out += r.ID.Comment()
+	if len(r.Denied) != 0 {
+		out += fmt.Sprintf("# Denied capabilities, not added: %s\n", strings.Join(r.Denied, ", "))
+	}
+	if r.PrivilegedUnused {
+		out += "# Warning: the container runs privileged but didn't use any capability\n"
+	} else if r.Privileged {
+		out += "# Warning: the container runs privileged, the capabilities it used are added instead\n"
+	}
+	return
+}
+
+// FormatRecommendations returns the security context of each container, in
+// YAML.
+func (a *CapabilitiesAdvisor) FormatRecommendations() (out string) {
+	for _, r := range a.Recommendations {
+		yamlOutput, err := k8syaml.Marshal(struct {
+			SecurityContext v1.SecurityContext `json:"securityContext"`
+		}{r.SecurityContext})
+		if err != nil {
+			out += fmt.Sprintf("# Failed to marshal security context: %s\n", err)
+			continue
+		}
+		out += "---\n" + r.comments() + string(yamlOutput)
+	}
+	return
+}
+
+// FormatPatches returns, for each workload, the kubectl command applying a
+// strategic merge patch with the security context of its containers.
+func (a *CapabilitiesAdvisor) FormatPatches() string {
+	patches := make([]workload.ContainerPatch, 0, len(a.Recommendations))
+	for _, r := range a.Recommendations {
+		patches = append(patches, workload.ContainerPatch{
+			ID:       r.ID,
+			Comments: r.comments(),
+			Fields: map[string]interface{}{
+				"securityContext": r.SecurityContext,
+			},
+		})
+	}
+	return workload.FormatPatches(patches)
+}
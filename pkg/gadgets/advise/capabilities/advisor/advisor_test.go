@@ -0,0 +1,101 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+)
+
+func TestLoad(t *testing.T) {
+	match, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inputFile := range match {
+		a := NewAdvisor()
+
+		err := a.LoadFile(inputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.GenerateRecommendations()
+		generatedOutput := a.FormatRecommendations()
+
+		goldenFile := inputFile[:len(inputFile)-len(".input")] + ".golden"
+		goldenOutputBytes, err := os.ReadFile(goldenFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		goldenOutput := string(goldenOutputBytes)
+
+		if generatedOutput != goldenOutput {
+			t.Errorf("Unexpected recommendation from %s:\n%s\nExpected:\n%s\n", inputFile, generatedOutput, goldenOutput)
+		}
+	}
+}
+
+func TestPatches(t *testing.T) {
+	a := NewAdvisor()
+	a.GetPod = func(namespace, name string) *workload.Pod {
+		switch name {
+		case "web-6d4cf56db6-abcde", "web-6d4cf56db6-fghij":
+			return &workload.Pod{
+				OwnerKind:  "Deployment",
+				OwnerName:  "web",
+				Privileged: map[string]bool{"web": true},
+			}
+		case "backup-27800000-xyz12":
+			return &workload.Pod{
+				OwnerKind:  "CronJob",
+				OwnerName:  "backup",
+				Privileged: map[string]bool{"backup": true},
+			}
+		}
+		return nil
+	}
+
+	err := a.LoadBuffer([]byte(`
+{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-abcde","container":"web","capName":"NET_BIND_SERVICE","verdict":"Allow"}
+{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-fghij","container":"web","capName":"CHOWN","verdict":"Allow"}
+{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-fghij","container":"logger","capName":"SYS_ADMIN","verdict":"Deny"}
+{"type":"normal","namespace":"demo","pod":"backup-27800000-xyz12","container":"backup","capName":"SYS_PTRACE","verdict":"Deny"}
+{"type":"normal","namespace":"demo","pod":"debug","container":"debug","capName":"NET_RAW","verdict":"Allow"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.GenerateRecommendations()
+
+	expected := `# CronJob demo/backup, container backup
+# Denied capabilities, not added: SYS_PTRACE
+# Warning: the container runs privileged but didn't use any capability
+kubectl patch -n demo cronjob backup --type strategic --patch '{"spec":{"jobTemplate":{"spec":{"template":{"spec":{"containers":[{"name":"backup","securityContext":{"capabilities":{"drop":["ALL"]},"privileged":false}}]}}}}}}'
+# Deployment demo/web, container logger
+# Denied capabilities, not added: SYS_ADMIN
+# Deployment demo/web, container web
+# Warning: the container runs privileged, the capabilities it used are added instead
+kubectl patch -n demo deployment web --type strategic --patch '{"spec":{"template":{"spec":{"containers":[{"name":"logger","securityContext":{"capabilities":{"drop":["ALL"]}}},{"name":"web","securityContext":{"capabilities":{"add":["CHOWN","NET_BIND_SERVICE"],"drop":["ALL"]},"privileged":false}}]}}}}'
+# Pod demo/debug, container debug
+# The security context of a Pod can't be patched, it needs to be recreated
+`
+	if output := a.FormatPatches(); output != expected {
+		t.Errorf("Unexpected patches:\n%s\nExpected:\n%s\n", output, expected)
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	snitypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/sni/types"
+	tcpconnecttypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpconnect/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Gadgets whose events are combined by the advisor.
+const (
+	GadgetDNS        = "dns"
+	GadgetSNI        = "sni"
+	GadgetTCPConnect = "tcpconnect"
+)
+
+// remoteKindOther is the kind of the addresses that don't belong to a
+// Kubernetes object, as given by the sni and tcpconnect gadgets.
+const remoteKindOther = "other"
+
+// Destination is an external host contacted by a workload.
+type Destination struct {
+	Host string `json:"host"`
+	Port uint16 `json:"port"`
+
+	// Evidence tells how the host name was found: "sni" for the server
+	// name of a TLS handshake and "dns" for the answer of a DNS query
+	// matching the address connected to.
+	Evidence []string `json:"evidence"`
+}
+
+// UnresolvedDestination is an external address contacted by a workload, whose
+// host name isn't known.
+type UnresolvedDestination struct {
+	IP   string `json:"ip"`
+	Port uint16 `json:"port"`
+}
+
+// Allowlist contains the external destinations contacted by a workload.
+type Allowlist struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+
+	Destinations []Destination           `json:"destinations,omitempty"`
+	Unresolved   []UnresolvedDestination `json:"unresolved,omitempty"`
+}
+
+type EgressAllowlistAdvisor struct {
+	DNSEvents        []dnstypes.Event
+	SNIEvents        []snitypes.Event
+	TCPConnectEvents []tcpconnecttypes.Event
+
+	// GetPod returns the information about a pod. It can return nil when
+	// the pod is unknown, the pod is then its own workload.
+	GetPod func(namespace, name string) *workload.Pod
+
+	Allowlists []Allowlist
+}
+
+func NewAdvisor() *EgressAllowlistAdvisor {
+	return &EgressAllowlistAdvisor{
+		GetPod: func(namespace, name string) *workload.Pod { return nil },
+	}
+}
+
+func (a *EgressAllowlistAdvisor) LoadFile(filename string) error {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return a.LoadBuffer(buf)
+}
+
+func (a *EgressAllowlistAdvisor) addRecord(record *workload.Record) error {
+	var err error
+	switch record.Gadget {
+	case GadgetDNS:
+		event := dnstypes.Event{}
+		if err = json.Unmarshal(record.Event, &event); err == nil {
+			a.DNSEvents = append(a.DNSEvents, event)
+		}
+	case GadgetSNI:
+		event := snitypes.Event{}
+		if err = json.Unmarshal(record.Event, &event); err == nil {
+			a.SNIEvents = append(a.SNIEvents, event)
+		}
+	case GadgetTCPConnect:
+		event := tcpconnecttypes.Event{}
+		if err = json.Unmarshal(record.Event, &event); err == nil {
+			a.TCPConnectEvents = append(a.TCPConnectEvents, event)
+		}
+	default:
+		err = fmt.Errorf("unknown gadget %q", record.Gadget)
+	}
+	return err
+}
+
+func (a *EgressAllowlistAdvisor) LoadBuffer(buf []byte) error {
+	a.DNSEvents = nil
+	a.SNIEvents = nil
+	a.TCPConnectEvents = nil
+
+	records, err := workload.LoadEvents[workload.Record](buf)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if err := a.addRecord(&records[i]); err != nil {
+			return fmt.Errorf("cannot parse record %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// isExternal checks if the destination of a connection is outside of the
+// cluster. When the gadget couldn't resolve the address, only the loopback
+// and unspecified addresses are known not to be external.
+func isExternal(remoteKind, ip string) bool {
+	if remoteKind != "" {
+		return remoteKind == remoteKindOther
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && !parsed.IsLoopback() && !parsed.IsUnspecified()
+}
+
+// hostName returns the name without the trailing dot of the fully qualified
+// names, or an empty string for the names of the cluster.
+func hostName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "cluster.local" || strings.HasSuffix(name, ".cluster.local") {
+		return ""
+	}
+	return name
+}
+
+type endpointKey struct {
+	ip   string
+	port uint16
+}
+
+type workloadDestinations struct {
+	// destinations contains the evidence of each host name, per port.
+	destinations map[string]map[uint16]map[string]struct{}
+	unresolved   map[endpointKey]struct{}
+}
+
+func (d *workloadDestinations) add(host string, port uint16, evidence string) {
+	if _, ok := d.destinations[host]; !ok {
+		d.destinations[host] = map[uint16]map[string]struct{}{}
+	}
+	if _, ok := d.destinations[host][port]; !ok {
+		d.destinations[host][port] = map[string]struct{}{}
+	}
+	d.destinations[host][port][evidence] = struct{}{}
+}
+
+// GenerateAllowlists lists, per workload, the external hosts and ports that
+// were contacted. The server name of the TLS handshakes is used when there is
+// one. Otherwise, the addresses connected to are matched against the answers
+// of the DNS queries of the same pod. The connections to addresses that don't
+// match any name are listed as unresolved.
+func (a *EgressAllowlistAdvisor) GenerateAllowlists() {
+	getPod := workload.PodCache(a.GetPod)
+	workloads := map[workload.ID]*workloadDestinations{}
+	getWorkload := func(namespace, name string) *workloadDestinations {
+		pod := getPod(namespace, name)
+		key := workload.ID{
+			Namespace: namespace,
+			Kind:      pod.OwnerKind,
+			Name:      pod.OwnerName,
+		}
+		w, ok := workloads[key]
+		if !ok {
+			w = &workloadDestinations{
+				destinations: map[string]map[uint16]map[string]struct{}{},
+				unresolved:   map[endpointKey]struct{}{},
+			}
+			workloads[key] = w
+		}
+		return w
+	}
+
+	// Host names of the addresses resolved by each pod
+	resolved := map[string]map[string]map[string]struct{}{}
+	for _, e := range a.DNSEvents {
+		if e.Type != eventtypes.NORMAL || e.QR != "R" || e.Namespace == "" || e.Pod == "" {
+			continue
+		}
+		host := hostName(e.DNSName)
+		if host == "" {
+			continue
+		}
+		podKey := e.Namespace + "/" + e.Pod
+		if _, ok := resolved[podKey]; !ok {
+			resolved[podKey] = map[string]map[string]struct{}{}
+		}
+		for _, addr := range e.Addresses {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				continue
+			}
+			if _, ok := resolved[podKey][ip.String()]; !ok {
+				resolved[podKey][ip.String()] = map[string]struct{}{}
+			}
+			resolved[podKey][ip.String()][host] = struct{}{}
+		}
+	}
+
+	// Server names of the TLS connections of each pod
+	serverNames := map[string]map[endpointKey]struct{}{}
+	for _, e := range a.SNIEvents {
+		if e.Type != eventtypes.NORMAL || e.Namespace == "" || e.Pod == "" {
+			continue
+		}
+		host := hostName(e.Name)
+		if host == "" || !isExternal(e.RemoteKind, e.Daddr) {
+			continue
+		}
+		getWorkload(e.Namespace, e.Pod).add(host, e.Dport, GadgetSNI)
+
+		podKey := e.Namespace + "/" + e.Pod
+		if _, ok := serverNames[podKey]; !ok {
+			serverNames[podKey] = map[endpointKey]struct{}{}
+		}
+		if ip := net.ParseIP(e.Daddr); ip != nil {
+			serverNames[podKey][endpointKey{ip: ip.String(), port: e.Dport}] = struct{}{}
+		}
+	}
+
+	for _, e := range a.TCPConnectEvents {
+		if e.Type != eventtypes.NORMAL || e.Namespace == "" || e.Pod == "" {
+			continue
+		}
+		if !isExternal(e.RemoteKind, e.Daddr) {
+			continue
+		}
+		ip := net.ParseIP(e.Daddr)
+		if ip == nil {
+			continue
+		}
+
+		podKey := e.Namespace + "/" + e.Pod
+		endpoint := endpointKey{ip: ip.String(), port: e.Dport}
+		w := getWorkload(e.Namespace, e.Pod)
+
+		// The server name of the connection is more accurate than the
+		// names resolved to its address.
+		if _, ok := serverNames[podKey][endpoint]; ok {
+			continue
+		}
+		hosts := resolved[podKey][endpoint.ip]
+		if len(hosts) == 0 {
+			w.unresolved[endpoint] = struct{}{}
+			continue
+		}
+		for host := range hosts {
+			w.add(host, e.Dport, GadgetDNS)
+		}
+	}
+
+	keys := make([]workload.ID, 0, len(workloads))
+	for key := range workloads {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Less(keys[j])
+	})
+
+	a.Allowlists = nil
+	for _, key := range keys {
+		w := workloads[key]
+		allowlist := Allowlist{
+			Namespace: key.Namespace,
+			Kind:      key.Kind,
+			Name:      key.Name,
+		}
+		for host, ports := range w.destinations {
+			for port, evidence := range ports {
+				allowlist.Destinations = append(allowlist.Destinations, Destination{
+					Host:     host,
+					Port:     port,
+					Evidence: workload.SortedKeys(evidence),
+				})
+			}
+		}
+		sort.Slice(allowlist.Destinations, func(i, j int) bool {
+			di, dj := allowlist.Destinations[i], allowlist.Destinations[j]
+			if di.Host != dj.Host {
+				return di.Host < dj.Host
+			}
+			return di.Port < dj.Port
+		})
+
+		for endpoint := range w.unresolved {
+			allowlist.Unresolved = append(allowlist.Unresolved, UnresolvedDestination{
+				IP:   endpoint.ip,
+				Port: endpoint.port,
+			})
+		}
+		sort.Slice(allowlist.Unresolved, func(i, j int) bool {
+			ui, uj := allowlist.Unresolved[i], allowlist.Unresolved[j]
+			if ui.IP != uj.IP {
+				return ui.IP < uj.IP
+			}
+			return ui.Port < uj.Port
+		})
+
+		a.Allowlists = append(a.Allowlists, allowlist)
+	}
+}
+
+// FormatAllowlists returns the allowlists in YAML.
+func (a *EgressAllowlistAdvisor) FormatAllowlists() string {
+	if len(a.Allowlists) == 0 {
+		return ""
+	}
+	yamlOutput, err := k8syaml.Marshal(a.Allowlists)
+	if err != nil {
+		return fmt.Sprintf("# Failed to marshal allowlists: %s\n", err)
+	}
+	return string(yamlOutput)
+}
+
+// FormatList returns the allowlist of each workload as host:port lines, as
+// used by most egress proxies. The unresolved destinations are commented.
+func (a *EgressAllowlistAdvisor) FormatList() (out string) {
+	for _, allowlist := range a.Allowlists {
+		out += fmt.Sprintf("# %s %s/%s\n", allowlist.Kind, allowlist.Namespace, allowlist.Name)
+		for _, d := range allowlist.Destinations {
+			out += fmt.Sprintf("%s:%d\n", d.Host, d.Port)
+		}
+		for _, u := range allowlist.Unresolved {
+			out += fmt.Sprintf("# Unresolved: %s\n", net.JoinHostPort(u.IP, fmt.Sprint(u.Port)))
+		}
+	}
+	return
+}
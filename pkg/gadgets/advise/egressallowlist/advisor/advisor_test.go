@@ -0,0 +1,93 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+)
+
+func TestLoad(t *testing.T) {
+	match, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inputFile := range match {
+		a := NewAdvisor()
+
+		err := a.LoadFile(inputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.GenerateAllowlists()
+		generatedOutput := a.FormatAllowlists()
+
+		goldenFile := inputFile[:len(inputFile)-len(".input")] + ".golden"
+		goldenOutputBytes, err := os.ReadFile(goldenFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		goldenOutput := string(goldenOutputBytes)
+
+		if generatedOutput != goldenOutput {
+			t.Errorf("Unexpected allowlists from %s:\n%s\nExpected:\n%s\n", inputFile, generatedOutput, goldenOutput)
+		}
+	}
+}
+
+func TestLoadUnknownGadget(t *testing.T) {
+	a := NewAdvisor()
+	err := a.LoadBuffer([]byte(`{"gadget":"exec","event":{"type":"normal"}}`))
+	if err == nil {
+		t.Fatalf("expected error with an unknown gadget")
+	}
+}
+
+func TestList(t *testing.T) {
+	a := NewAdvisor()
+	a.GetPod = func(namespace, name string) *workload.Pod {
+		if name != "web-6d4cf56db6-abcde" && name != "web-6d4cf56db6-fghij" {
+			return nil
+		}
+		return &workload.Pod{OwnerKind: "Deployment", OwnerName: "web"}
+	}
+
+	err := a.LoadBuffer([]byte(`[
+{"gadget":"dns","event":{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-abcde","qr":"R","qtype":"A","name":"registry.example.com.","addresses":["198.51.100.10","198.51.100.11"]}},
+{"gadget":"dns","event":{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-fghij","qr":"R","qtype":"A","name":"cdn.example.com.","addresses":["198.51.100.11"]}},
+{"gadget":"tcpconnect","event":{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-abcde","daddr":"198.51.100.10","dport":443,"remoteKind":"other"}},
+{"gadget":"tcpconnect","event":{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-fghij","daddr":"198.51.100.11","dport":443,"remoteKind":"other"}},
+{"gadget":"sni","event":{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-fghij","name":"cdn.example.com","daddr":"198.51.100.11","dport":443,"remoteKind":"other"}},
+{"gadget":"tcpconnect","event":{"type":"normal","namespace":"demo","pod":"debug","daddr":"2001:db8::1","dport":22,"remoteKind":"other"}}
+]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.GenerateAllowlists()
+
+	expected := `# Deployment demo/web
+cdn.example.com:443
+registry.example.com:443
+# Pod demo/debug
+# Unresolved: [2001:db8::1]:22
+`
+	if output := a.FormatList(); output != expected {
+		t.Errorf("Unexpected list:\n%s\nExpected:\n%s\n", output, expected)
+	}
+}
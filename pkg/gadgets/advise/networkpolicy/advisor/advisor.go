@@ -15,10 +15,9 @@
 package advisor
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"sort"
 	"strings"
@@ -29,6 +28,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	k8syaml "sigs.k8s.io/yaml"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
@@ -45,6 +46,25 @@ type NetworkPolicyAdvisor struct {
 	LabelsToIgnore map[string]struct{}
 
 	Policies []networkingv1.NetworkPolicy
+
+	// DNSEvents are the DNS queries of the pods. They are optional and only
+	// used by the output formats able to allow traffic by domain name.
+	DNSEvents []dnstypes.Event
+
+	// domainNames contains the domain names queried by the pods of each
+	// generated policy, by namespace/name of the policy.
+	domainNames map[string][]string
+
+	// ExistingPolicies are the policies validated against the recorded
+	// traffic by ValidatePolicies.
+	ExistingPolicies []networkingv1.NetworkPolicy
+
+	// GetNamespaceLabels returns the labels of a namespace, used by the
+	// namespace selectors of ExistingPolicies. When it's nil or returns
+	// nil, only the kubernetes.io/metadata.name label is known.
+	GetNamespaceLabels func(namespace string) map[string]string
+
+	Validation *Validation
 }
 
 func NewAdvisor() *NetworkPolicyAdvisor {
@@ -62,38 +82,30 @@ func (a *NetworkPolicyAdvisor) LoadFile(filename string) error {
 }
 
 func (a *NetworkPolicyAdvisor) LoadBuffer(buf []byte) error {
-	/* Try to read the file as an array */
-	events := []types.Event{}
-	err := json.Unmarshal(buf, &events)
-	if err == nil {
-		a.Events = events
-		return nil
+	events, err := workload.LoadEvents[types.Event](buf)
+	if err != nil {
+		return err
 	}
+	a.Events = events
+	return nil
+}
 
-	/* If it fails, read by line */
-	events = nil
-	line := 0
-	scanner := bufio.NewScanner(bytes.NewReader(buf))
-	for scanner.Scan() {
-		event := types.Event{}
-		text := strings.TrimSpace(scanner.Text())
-		if len(text) == 0 {
-			continue
-		}
-		line++
-		err = json.Unmarshal([]byte(text), &event)
-		if err != nil {
-			return fmt.Errorf("cannot parse line %d: %w", line, err)
-		}
-		events = append(events, event)
+// LoadDNSFile loads the DNS queries recorded by the dns gadget. See
+// DNSEvents.
+func (a *NetworkPolicyAdvisor) LoadDNSFile(filename string) error {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return err
 	}
+	return a.LoadDNSBuffer(buf)
+}
 
-	if err := scanner.Err(); err != nil {
+func (a *NetworkPolicyAdvisor) LoadDNSBuffer(buf []byte) error {
+	events, err := workload.LoadEvents[dnstypes.Event](buf)
+	if err != nil {
 		return err
 	}
-
-	a.Events = events
-
+	a.DNSEvents = events
 	return nil
 }
 
@@ -202,14 +214,19 @@ func (a *NetworkPolicyAdvisor) eventToRule(e types.Event) (ports []networkingv1.
 			}
 		}
 	} else if e.RemoteKind == "other" {
-		if e.RemoteOther == "127.0.0.1" {
+		ip := net.ParseIP(e.RemoteOther)
+		if ip.IsLoopback() {
 			// No need to generate a network policy for localhost
 			peers = []networkingv1.NetworkPolicyPeer{}
 		} else {
+			prefixLen := 32
+			if ip.To4() == nil {
+				prefixLen = 128
+			}
 			peers = []networkingv1.NetworkPolicyPeer{
 				{
 					IPBlock: &networkingv1.IPBlock{
-						CIDR: e.RemoteOther + "/32",
+						CIDR: fmt.Sprintf("%s/%d", e.RemoteOther, prefixLen),
 					},
 				},
 			}
@@ -274,7 +291,36 @@ func sortEgressRules(rules []networkingv1.NetworkPolicyEgressRule) []networkingv
 	return rules
 }
 
+// queriedDomainNames returns the domain names resolved by each pod, by
+// namespace/name of the pod. Queries for names of the cluster, including the
+// ones expanded with the search domains, are ignored.
+func (a *NetworkPolicyAdvisor) queriedDomainNames() map[string]map[string]struct{} {
+	names := map[string]map[string]struct{}{}
+	for _, e := range a.DNSEvents {
+		if e.Type != eventtypes.NORMAL || e.PktType != "OUTGOING" {
+			continue
+		}
+		if e.QType != "A" && e.QType != "AAAA" {
+			continue
+		}
+		name := strings.TrimSuffix(e.DNSName, ".")
+		if name == "" || name == "cluster.local" || strings.HasSuffix(name, ".cluster.local") {
+			continue
+		}
+
+		podKey := e.Namespace + "/" + e.Pod
+		if _, ok := names[podKey]; !ok {
+			names[podKey] = map[string]struct{}{}
+		}
+		names[podKey][name] = struct{}{}
+	}
+	return names
+}
+
 func (a *NetworkPolicyAdvisor) GeneratePolicies() {
+	queriedNames := a.queriedDomainNames()
+	a.domainNames = map[string][]string{}
+
 	eventsBySource := map[string][]types.Event{}
 	for _, e := range a.Events {
 		if e.Type != eventtypes.NORMAL {
@@ -364,6 +410,21 @@ func (a *NetworkPolicyAdvisor) GeneratePolicies() {
 			},
 		}
 		a.Policies = append(a.Policies, policy)
+
+		names := map[string]struct{}{}
+		for _, e := range events {
+			for name := range queriedNames[e.Namespace+"/"+e.Pod] {
+				names[name] = struct{}{}
+			}
+		}
+		if len(names) != 0 {
+			policyNames := make([]string, 0, len(names))
+			for name := range names {
+				policyNames = append(policyNames, name)
+			}
+			sort.Strings(policyNames)
+			a.domainNames[policy.Namespace+"/"+policy.Name] = policyNames
+		}
 	}
 
 	sort.Slice(a.Policies, func(i, j int) bool {
@@ -385,3 +446,118 @@ func (a *NetworkPolicyAdvisor) FormatPolicies() (out string) {
 	}
 	return
 }
+
+// peerRule is a rule of a generated policy with the ports of all the rules
+// sharing the same peers.
+type peerRule struct {
+	peers []networkingv1.NetworkPolicyPeer
+	ports []networkingv1.NetworkPolicyPort
+}
+
+// groupRulesByPeers merges the rules generated by eventToRule() that have the
+// same peers, keeping the order of their first appearance. Formats with rules
+// able to list several ports use it to generate fewer rules.
+func groupRulesByPeers(peersList [][]networkingv1.NetworkPolicyPeer, portsList [][]networkingv1.NetworkPolicyPort) []peerRule {
+	rules := []peerRule{}
+	index := map[string]int{}
+	for i, peers := range peersList {
+		key, _ := json.Marshal(peers)
+		j, ok := index[string(key)]
+		if !ok {
+			j = len(rules)
+			index[string(key)] = j
+			rules = append(rules, peerRule{peers: peers})
+		}
+		rules[j].ports = append(rules[j].ports, portsList[i]...)
+	}
+	return rules
+}
+
+func ingressPeerRules(rules []networkingv1.NetworkPolicyIngressRule) []peerRule {
+	peersList := make([][]networkingv1.NetworkPolicyPeer, 0, len(rules))
+	portsList := make([][]networkingv1.NetworkPolicyPort, 0, len(rules))
+	for _, r := range rules {
+		peersList = append(peersList, r.From)
+		portsList = append(portsList, r.Ports)
+	}
+	return groupRulesByPeers(peersList, portsList)
+}
+
+func egressPeerRules(rules []networkingv1.NetworkPolicyEgressRule) []peerRule {
+	peersList := make([][]networkingv1.NetworkPolicyPeer, 0, len(rules))
+	portsList := make([][]networkingv1.NetworkPolicyPort, 0, len(rules))
+	for _, r := range rules {
+		peersList = append(peersList, r.To)
+		portsList = append(portsList, r.Ports)
+	}
+	return groupRulesByPeers(peersList, portsList)
+}
+
+func portProtocol(p networkingv1.NetworkPolicyPort) v1.Protocol {
+	if p.Protocol == nil {
+		return v1.ProtocolTCP
+	}
+	return *p.Protocol
+}
+
+// portRange is a range of ports, last is equal to first for a single port.
+type portRange struct {
+	first, last int
+}
+
+// protocolPorts are the ports of a protocol allowed by a rule.
+type protocolPorts struct {
+	// all is set when the rule allows all the ports of the protocol.
+	all bool
+	// ranges are sorted, the overlapping and consecutive ones are merged.
+	ranges []portRange
+	// names are the named ports.
+	names []string
+}
+
+// groupPorts returns the sorted protocols of the ports of a rule and their
+// ports, honouring EndPort.
+func groupPorts(ports []networkingv1.NetworkPolicyPort) (protocols []string, byProtocol map[string]*protocolPorts) {
+	byProtocol = map[string]*protocolPorts{}
+	for _, p := range ports {
+		protocol := string(portProtocol(p))
+		pp, ok := byProtocol[protocol]
+		if !ok {
+			pp = &protocolPorts{}
+			byProtocol[protocol] = pp
+			protocols = append(protocols, protocol)
+		}
+		switch {
+		case p.Port == nil:
+			pp.all = true
+		case p.Port.Type == intstr.String:
+			pp.names = append(pp.names, p.Port.StrVal)
+		default:
+			r := portRange{first: int(p.Port.IntVal), last: int(p.Port.IntVal)}
+			if p.EndPort != nil && int(*p.EndPort) > r.last {
+				r.last = int(*p.EndPort)
+			}
+			pp.ranges = append(pp.ranges, r)
+		}
+	}
+	sort.Strings(protocols)
+
+	for _, pp := range byProtocol {
+		sort.Slice(pp.ranges, func(i, j int) bool {
+			return pp.ranges[i].first < pp.ranges[j].first
+		})
+		var merged []portRange
+		for _, r := range pp.ranges {
+			if n := len(merged); n != 0 && r.first <= merged[n-1].last+1 {
+				if r.last > merged[n-1].last {
+					merged[n-1].last = r.last
+				}
+				continue
+			}
+			merged = append(merged, r)
+		}
+		pp.ranges = merged
+		sort.Strings(pp.names)
+	}
+	return
+}
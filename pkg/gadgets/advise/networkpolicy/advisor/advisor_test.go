@@ -17,7 +17,12 @@ package advisor
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestLoad(t *testing.T) {
@@ -49,3 +54,133 @@ func TestLoad(t *testing.T) {
 		}
 	}
 }
+
+func TestFormats(t *testing.T) {
+	formats := map[string]func(*NetworkPolicyAdvisor) string{
+		".cilium": (*NetworkPolicyAdvisor).FormatCiliumPolicies,
+		".calico": (*NetworkPolicyAdvisor).FormatCalicoPolicies,
+	}
+
+	for ext, format := range formats {
+		match, err := filepath.Glob("testdata/*" + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, goldenFile := range match {
+			base := goldenFile[:len(goldenFile)-len(ext)]
+
+			a := NewAdvisor()
+
+			err := a.LoadFile(base + ".input")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(base + ".dns"); err == nil {
+				err = a.LoadDNSFile(base + ".dns")
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			a.GeneratePolicies()
+			generatedOuput := format(a)
+
+			goldenOutputBytes, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			goldenOutput := string(goldenOutputBytes)
+
+			if generatedOuput != goldenOutput {
+				t.Errorf("Unexpected policy from %s:\n%s\nExpected:\n%s\n", goldenFile, generatedOuput, goldenOutput)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	match, err := filepath.Glob("testdata/*.policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policiesFile := range match {
+		base := policiesFile[:len(policiesFile)-len(".policies")]
+
+		a := NewAdvisor()
+
+		err := a.LoadFile(base + ".input")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = a.LoadPoliciesFile(policiesFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.ValidatePolicies()
+		generatedOuput := a.FormatValidation()
+
+		goldenOutputBytes, err := os.ReadFile(base + ".validation")
+		if err != nil {
+			t.Fatal(err)
+		}
+		goldenOutput := string(goldenOutputBytes)
+
+		if generatedOuput != goldenOutput {
+			t.Errorf("Unexpected validation from %s:\n%s\nExpected:\n%s\n", policiesFile, generatedOuput, goldenOutput)
+		}
+	}
+}
+
+func TestLoadPoliciesUnexpectedKind(t *testing.T) {
+	a := NewAdvisor()
+	err := a.LoadPoliciesBuffer([]byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: test\n"))
+	if err == nil {
+		t.Fatal("expected an error loading a Pod as network policy")
+	}
+}
+
+func TestPortRanges(t *testing.T) {
+	udp := v1.ProtocolUDP
+	port := func(p int) *intstr.IntOrString {
+		v := intstr.FromInt(p)
+		return &v
+	}
+	endPort := func(p int32) *int32 {
+		return &p
+	}
+	ports := []networkingv1.NetworkPolicyPort{
+		{Port: port(8080), EndPort: endPort(8090)},
+		{Port: port(8085)},
+		{Port: port(8091)},
+		{Port: port(443)},
+		{Protocol: &udp, Port: port(53)},
+		{Protocol: &udp, Port: port(52)},
+	}
+
+	expectedCalico := map[string][]intstr.IntOrString{
+		"TCP": {intstr.FromInt(443), intstr.FromString("8080:8091")},
+		"UDP": {intstr.FromString("52:53")},
+	}
+	if _, calicoPorts := calicoPorts(ports); !reflect.DeepEqual(calicoPorts, expectedCalico) {
+		t.Errorf("Unexpected Calico ports: %v, expected %v", calicoPorts, expectedCalico)
+	}
+
+	// The DNS port isn't merged, it has its own rule.
+	expectedCilium := []ciliumPortRule{
+		{
+			Ports: []ciliumPortProtocol{
+				{Port: "443", Protocol: "TCP"},
+				{Port: "8080", EndPort: 8091, Protocol: "TCP"},
+				{Port: "52", Protocol: "UDP"},
+			},
+		},
+		{
+			Ports: []ciliumPortProtocol{{Port: "53", Protocol: "UDP"}},
+			Rules: &ciliumL7Rules{DNS: []ciliumFQDNSelector{{MatchPattern: "*"}}},
+		},
+	}
+	if ciliumRules := ciliumPortRules(ports, true); !reflect.DeepEqual(ciliumRules, expectedCilium) {
+		t.Errorf("Unexpected Cilium port rules: %+v, expected %+v", ciliumRules, expectedCilium)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// The types below are the subset of the NetworkPolicy and
+// GlobalNetworkPolicy of projectcalico.org/v3 used by the generated policies.
+// They avoid depending on Calico.
+
+type calicoPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        metav1.ObjectMeta `json:"metadata"`
+	Spec            calicoSpec        `json:"spec"`
+}
+
+type calicoSpec struct {
+	Selector string       `json:"selector"`
+	Types    []string     `json:"types"`
+	Ingress  []calicoRule `json:"ingress,omitempty"`
+	Egress   []calicoRule `json:"egress,omitempty"`
+}
+
+type calicoRule struct {
+	Action      string        `json:"action"`
+	Protocol    string        `json:"protocol,omitempty"`
+	Source      *calicoEntity `json:"source,omitempty"`
+	Destination *calicoEntity `json:"destination,omitempty"`
+}
+
+type calicoEntity struct {
+	Nets              []string             `json:"nets,omitempty"`
+	Selector          string               `json:"selector,omitempty"`
+	NamespaceSelector string               `json:"namespaceSelector,omitempty"`
+	Ports             []intstr.IntOrString `json:"ports,omitempty"`
+}
+
+const calicoNamespaceLabel = "projectcalico.org/namespace"
+
+// calicoDNSPolicyName is the name of the GlobalNetworkPolicy allowing the
+// pods of the generated policies to query the cluster DNS.
+const calicoDNSPolicyName = "dns-network"
+
+// calicoSelector converts the labels matched by a label selector to a Calico
+// selector expression. An empty selector matches everything.
+func calicoSelector(selector *metav1.LabelSelector) string {
+	if selector == nil || len(selector.MatchLabels) == 0 {
+		return "all()"
+	}
+	keys := make([]string, 0, len(selector.MatchLabels))
+	for k := range selector.MatchLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	terms := make([]string, 0, len(keys))
+	for _, k := range keys {
+		terms = append(terms, fmt.Sprintf("%s == '%s'", k, selector.MatchLabels[k]))
+	}
+	return strings.Join(terms, " && ")
+}
+
+// calicoPorts returns the ports by protocol. Consecutive ports are merged in
+// ranges.
+func calicoPorts(ports []networkingv1.NetworkPolicyPort) (protocols []string, portsByProtocol map[string][]intstr.IntOrString) {
+	protocols, byProtocol := groupPorts(ports)
+
+	portsByProtocol = map[string][]intstr.IntOrString{}
+	for protocol, pp := range byProtocol {
+		if pp.all {
+			// One of the rules allows all the ports
+			continue
+		}
+		for _, r := range pp.ranges {
+			if r.first == r.last {
+				portsByProtocol[protocol] = append(portsByProtocol[protocol], intstr.FromInt(r.first))
+			} else {
+				portsByProtocol[protocol] = append(portsByProtocol[protocol],
+					intstr.FromString(fmt.Sprintf("%d:%d", r.first, r.last)))
+			}
+		}
+		for _, name := range pp.names {
+			portsByProtocol[protocol] = append(portsByProtocol[protocol], intstr.FromString(name))
+		}
+	}
+	return
+}
+
+// calicoRules converts a rule with several peers to Calico rules, which
+// have a single peer and protocol each.
+func calicoRules(r peerRule, ingress bool) (rules []calicoRule) {
+	protocols, portsByProtocol := calicoPorts(r.ports)
+	for _, peer := range r.peers {
+		for _, protocol := range protocols {
+			entity := &calicoEntity{}
+			if peer.IPBlock != nil {
+				entity.Nets = []string{peer.IPBlock.CIDR}
+			} else {
+				entity.Selector = calicoSelector(peer.PodSelector)
+				if peer.NamespaceSelector != nil {
+					entity.NamespaceSelector = calicoSelector(peer.NamespaceSelector)
+				}
+			}
+
+			rule := calicoRule{
+				Action:   "Allow",
+				Protocol: protocol,
+			}
+			if ingress {
+				rule.Source = entity
+				if ports := portsByProtocol[protocol]; len(ports) != 0 {
+					rule.Destination = &calicoEntity{Ports: ports}
+				}
+			} else {
+				entity.Ports = portsByProtocol[protocol]
+				rule.Destination = entity
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return
+}
+
+// isClusterDNSRule checks if the rule only allows the DNS traffic to the
+// cluster DNS, as generated by eventToRule() for the kube-dns service.
+func isClusterDNSRule(r peerRule) bool {
+	if len(r.peers) != 1 || r.peers[0].NamespaceSelector == nil || r.peers[0].PodSelector == nil {
+		return false
+	}
+	if !reflect.DeepEqual(r.peers[0].NamespaceSelector.MatchLabels, map[string]string{"kubernetes.io/metadata.name": "kube-system"}) ||
+		!reflect.DeepEqual(r.peers[0].PodSelector.MatchLabels, map[string]string{"k8s-app": "kube-dns"}) {
+		return false
+	}
+	for _, p := range r.ports {
+		if p.Port == nil || p.Port.IntValue() != dnsPort {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatCalicoPolicies returns the generated policies as Calico
+// NetworkPolicy. The rules allowing the pods to query the cluster DNS are
+// shared by all the workloads, they are moved to a GlobalNetworkPolicy
+// selecting the pods that use it.
+func (a *NetworkPolicyAdvisor) FormatCalicoPolicies() (out string) {
+	policies := []calicoPolicy{}
+
+	var dnsSelectors []string
+	var dnsRules []calicoRule
+	for _, p := range a.Policies {
+		policy := calicoPolicy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "projectcalico.org/v3",
+				Kind:       "NetworkPolicy",
+			},
+			Metadata: metav1.ObjectMeta{
+				Name:      p.Name,
+				Namespace: p.Namespace,
+				Labels:    p.Labels,
+			},
+			Spec: calicoSpec{
+				Selector: calicoSelector(&p.Spec.PodSelector),
+			},
+		}
+		for _, t := range p.Spec.PolicyTypes {
+			policy.Spec.Types = append(policy.Spec.Types, string(t))
+		}
+
+		for _, r := range ingressPeerRules(p.Spec.Ingress) {
+			policy.Spec.Ingress = append(policy.Spec.Ingress, calicoRules(r, true)...)
+		}
+
+		usesDNS := false
+		for _, r := range egressPeerRules(p.Spec.Egress) {
+			if isClusterDNSRule(r) {
+				usesDNS = true
+				for _, rule := range calicoRules(r, false) {
+					if !containsCalicoRule(dnsRules, rule) {
+						dnsRules = append(dnsRules, rule)
+					}
+				}
+				continue
+			}
+			policy.Spec.Egress = append(policy.Spec.Egress, calicoRules(r, false)...)
+		}
+		if usesDNS {
+			selector := fmt.Sprintf("%s == '%s'", calicoNamespaceLabel, p.Namespace)
+			if len(p.Spec.PodSelector.MatchLabels) != 0 {
+				selector += " && " + calicoSelector(&p.Spec.PodSelector)
+			}
+			dnsSelectors = append(dnsSelectors, "("+selector+")")
+		}
+
+		policies = append(policies, policy)
+	}
+
+	if len(dnsSelectors) != 0 {
+		sort.Strings(dnsSelectors)
+		sort.SliceStable(dnsRules, func(i, j int) bool {
+			return dnsRules[i].Protocol < dnsRules[j].Protocol
+		})
+		policies = append(policies, calicoPolicy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "projectcalico.org/v3",
+				Kind:       "GlobalNetworkPolicy",
+			},
+			Metadata: metav1.ObjectMeta{
+				Name: calicoDNSPolicyName,
+			},
+			Spec: calicoSpec{
+				Selector: strings.Join(dnsSelectors, " || "),
+				Types:    []string{string(networkingv1.PolicyTypeEgress)},
+				Egress:   dnsRules,
+			},
+		})
+	}
+
+	for i, p := range policies {
+		yamlOutput, err := k8syaml.Marshal(p)
+		if err != nil {
+			out += fmt.Sprintf("# Failed to marshal policy %s/%s: %s\n", p.Metadata.Namespace, p.Metadata.Name, err)
+			continue
+		}
+		sep := "---\n"
+		if i == len(policies)-1 {
+			sep = ""
+		}
+		out += fmt.Sprintf("%s%s", string(yamlOutput), sep)
+	}
+	return
+}
+
+func containsCalicoRule(rules []calicoRule, rule calicoRule) bool {
+	for _, r := range rules {
+		if reflect.DeepEqual(r, rule) {
+			return true
+		}
+	}
+	return false
+}
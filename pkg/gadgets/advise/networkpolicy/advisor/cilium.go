@@ -0,0 +1,255 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"fmt"
+	"strconv"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// The types below are the subset of the CiliumNetworkPolicy of
+// cilium.io/v2 used by the generated policies. They avoid depending on
+// Cilium.
+
+type ciliumNetworkPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        metav1.ObjectMeta `json:"metadata"`
+	Spec            ciliumSpec        `json:"spec"`
+}
+
+type ciliumSpec struct {
+	EndpointSelector metav1.LabelSelector `json:"endpointSelector"`
+	Ingress          []ciliumIngressRule  `json:"ingress"`
+	Egress           []ciliumEgressRule   `json:"egress"`
+}
+
+type ciliumIngressRule struct {
+	FromEndpoints []metav1.LabelSelector `json:"fromEndpoints,omitempty"`
+	FromCIDR      []string               `json:"fromCIDR,omitempty"`
+	ToPorts       []ciliumPortRule       `json:"toPorts,omitempty"`
+}
+
+type ciliumEgressRule struct {
+	ToEndpoints []metav1.LabelSelector `json:"toEndpoints,omitempty"`
+	ToCIDR      []string               `json:"toCIDR,omitempty"`
+	ToFQDNs     []ciliumFQDNSelector   `json:"toFQDNs,omitempty"`
+	ToPorts     []ciliumPortRule       `json:"toPorts,omitempty"`
+}
+
+type ciliumPortRule struct {
+	Ports []ciliumPortProtocol `json:"ports"`
+	Rules *ciliumL7Rules       `json:"rules,omitempty"`
+}
+
+type ciliumPortProtocol struct {
+	Port     string `json:"port"`
+	EndPort  int    `json:"endPort,omitempty"`
+	Protocol string `json:"protocol"`
+}
+
+type ciliumL7Rules struct {
+	DNS []ciliumFQDNSelector `json:"dns,omitempty"`
+}
+
+type ciliumFQDNSelector struct {
+	MatchName    string `json:"matchName,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+const (
+	ciliumNamespaceLabel       = "k8s:io.kubernetes.pod.namespace"
+	ciliumNamespaceLabelPrefix = "k8s:io.cilium.k8s.namespace.labels."
+	dnsPort                    = 53
+)
+
+// ciliumEndpointSelector converts a peer selecting pods to a Cilium endpoint
+// selector. Cilium exposes the namespace and its labels as labels of the
+// endpoints.
+func ciliumEndpointSelector(peer networkingv1.NetworkPolicyPeer) metav1.LabelSelector {
+	selector := metav1.LabelSelector{MatchLabels: map[string]string{}}
+	if peer.PodSelector != nil {
+		for k, v := range peer.PodSelector.MatchLabels {
+			selector.MatchLabels[k] = v
+		}
+	}
+	if peer.NamespaceSelector != nil {
+		for k, v := range peer.NamespaceSelector.MatchLabels {
+			if k == "kubernetes.io/metadata.name" {
+				selector.MatchLabels[ciliumNamespaceLabel] = v
+			} else {
+				selector.MatchLabels[ciliumNamespaceLabelPrefix+k] = v
+			}
+		}
+	}
+	return selector
+}
+
+// ciliumPortRules converts the ports of a rule, merging consecutive ports in
+// ranges. When dns is set, the DNS port gets its own rule making Cilium
+// inspect the DNS queries, which is needed by the toFQDNs rules.
+func ciliumPortRules(ports []networkingv1.NetworkPolicyPort, dns bool) []ciliumPortRule {
+	rule := ciliumPortRule{}
+	dnsRule := ciliumPortRule{
+		Rules: &ciliumL7Rules{
+			DNS: []ciliumFQDNSelector{{MatchPattern: "*"}},
+		},
+	}
+
+	var otherPorts []networkingv1.NetworkPolicyPort
+	for _, p := range ports {
+		if dns && p.Port != nil && p.Port.Type == intstr.Int && p.Port.IntValue() == dnsPort && p.EndPort == nil {
+			dnsRule.Ports = append(dnsRule.Ports, ciliumPortProtocol{
+				Port:     p.Port.String(),
+				Protocol: string(portProtocol(p)),
+			})
+		} else {
+			otherPorts = append(otherPorts, p)
+		}
+	}
+
+	protocols, byProtocol := groupPorts(otherPorts)
+	for _, protocol := range protocols {
+		pp := byProtocol[protocol]
+		if pp.all {
+			// Port 0 matches all the ports
+			rule.Ports = append(rule.Ports, ciliumPortProtocol{Port: "0", Protocol: protocol})
+			continue
+		}
+		for _, r := range pp.ranges {
+			port := ciliumPortProtocol{
+				Port:     strconv.Itoa(r.first),
+				Protocol: protocol,
+			}
+			if r.last != r.first {
+				port.EndPort = r.last
+			}
+			rule.Ports = append(rule.Ports, port)
+		}
+		for _, name := range pp.names {
+			rule.Ports = append(rule.Ports, ciliumPortProtocol{Port: name, Protocol: protocol})
+		}
+	}
+
+	rules := []ciliumPortRule{}
+	if len(rule.Ports) != 0 {
+		rules = append(rules, rule)
+	}
+	if len(dnsRule.Ports) != 0 {
+		rules = append(rules, dnsRule)
+	}
+	return rules
+}
+
+func ciliumPolicy(p *networkingv1.NetworkPolicy, domainNames []string) ciliumNetworkPolicy {
+	policy := ciliumNetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "cilium.io/v2",
+			Kind:       "CiliumNetworkPolicy",
+		},
+		Metadata: metav1.ObjectMeta{
+			Name:      p.Name,
+			Namespace: p.Namespace,
+			Labels:    p.Labels,
+		},
+		Spec: ciliumSpec{
+			EndpointSelector: p.Spec.PodSelector,
+		},
+	}
+
+	for _, r := range ingressPeerRules(p.Spec.Ingress) {
+		rule := ciliumIngressRule{ToPorts: ciliumPortRules(r.ports, false)}
+		for _, peer := range r.peers {
+			if peer.IPBlock != nil {
+				rule.FromCIDR = append(rule.FromCIDR, peer.IPBlock.CIDR)
+			} else {
+				rule.FromEndpoints = append(rule.FromEndpoints, ciliumEndpointSelector(peer))
+			}
+		}
+		policy.Spec.Ingress = append(policy.Spec.Ingress, rule)
+	}
+
+	// The connections to the IPs outside of the cluster are also allowed
+	// by the domain names queried by the pods, the IPs behind a name can
+	// change over time.
+	fqdnPorts := []networkingv1.NetworkPolicyPort{}
+	seenFqdnPorts := map[string]struct{}{}
+	for _, r := range egressPeerRules(p.Spec.Egress) {
+		rule := ciliumEgressRule{}
+		cidrOnly := true
+		for _, peer := range r.peers {
+			if peer.IPBlock != nil {
+				rule.ToCIDR = append(rule.ToCIDR, peer.IPBlock.CIDR)
+			} else {
+				rule.ToEndpoints = append(rule.ToEndpoints, ciliumEndpointSelector(peer))
+				cidrOnly = false
+			}
+		}
+		rule.ToPorts = ciliumPortRules(r.ports, len(domainNames) != 0 && !cidrOnly)
+		policy.Spec.Egress = append(policy.Spec.Egress, rule)
+
+		if cidrOnly {
+			for _, port := range r.ports {
+				key := fmt.Sprintf("%s/%s", portProtocol(port), port.Port.String())
+				if _, ok := seenFqdnPorts[key]; !ok {
+					seenFqdnPorts[key] = struct{}{}
+					fqdnPorts = append(fqdnPorts, port)
+				}
+			}
+		}
+	}
+	if len(domainNames) != 0 && len(fqdnPorts) != 0 {
+		rule := ciliumEgressRule{ToPorts: ciliumPortRules(fqdnPorts, false)}
+		for _, name := range domainNames {
+			rule.ToFQDNs = append(rule.ToFQDNs, ciliumFQDNSelector{MatchName: name})
+		}
+		policy.Spec.Egress = append(policy.Spec.Egress, rule)
+	}
+
+	// An empty rule doesn't allow anything but enables the default deny
+	// like the policy types of the generated policies.
+	if len(policy.Spec.Ingress) == 0 {
+		policy.Spec.Ingress = []ciliumIngressRule{{}}
+	}
+	if len(policy.Spec.Egress) == 0 {
+		policy.Spec.Egress = []ciliumEgressRule{{}}
+	}
+
+	return policy
+}
+
+// FormatCiliumPolicies returns the generated policies as
+// CiliumNetworkPolicy. When DNSEvents are loaded, the connections outside of
+// the cluster are also allowed by the domain names the pods resolved.
+func (a *NetworkPolicyAdvisor) FormatCiliumPolicies() (out string) {
+	for i := range a.Policies {
+		p := &a.Policies[i]
+		yamlOutput, err := k8syaml.Marshal(ciliumPolicy(p, a.domainNames[p.Namespace+"/"+p.Name]))
+		if err != nil {
+			out += fmt.Sprintf("# Failed to marshal policy %s/%s: %s\n", p.Namespace, p.Name, err)
+			continue
+		}
+		sep := "---\n"
+		if i == len(a.Policies)-1 {
+			sep = ""
+		}
+		out += fmt.Sprintf("%s%s", string(yamlOutput), sep)
+	}
+	return
+}
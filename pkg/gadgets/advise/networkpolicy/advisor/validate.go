@@ -0,0 +1,422 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	directionIngress = "ingress"
+	directionEgress  = "egress"
+)
+
+// BlockedFlow is a recorded flow that the existing network policies would
+// block.
+type BlockedFlow struct {
+	Event types.Event
+
+	// Direction is "ingress" or "egress", from the point of view of the
+	// pod that recorded the flow.
+	Direction string
+
+	// Policies are the policies isolating the pod in that direction, none
+	// of them allows the flow.
+	Policies []string
+}
+
+// Validation is the result of the evaluation of the recorded traffic
+// against the existing network policies.
+type Validation struct {
+	Blocked []BlockedFlow
+
+	// UnusedPolicies are the policies that didn't apply to any recorded
+	// flow, as namespace/name.
+	UnusedPolicies []string
+
+	// PodsWithoutPolicy are the pods that recorded traffic but aren't
+	// selected by any policy, as namespace/name.
+	PodsWithoutPolicy []string
+}
+
+// LoadPoliciesFile loads the network policies to validate from a YAML or
+// JSON file. See LoadPoliciesBuffer.
+func (a *NetworkPolicyAdvisor) LoadPoliciesFile(filename string) error {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return a.LoadPoliciesBuffer(buf)
+}
+
+// LoadPoliciesBuffer appends the network policies of the buffer to
+// ExistingPolicies. The buffer can contain several documents, each being a
+// NetworkPolicy or a list of them, as printed by "kubectl get -o yaml".
+func (a *NetworkPolicyAdvisor) LoadPoliciesBuffer(buf []byte) error {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(buf), 4096)
+	for doc := 1; ; doc++ {
+		raw := json.RawMessage{}
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot parse document %d: %w", doc, err)
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		typeMeta := metav1.TypeMeta{}
+		if err := json.Unmarshal(raw, &typeMeta); err != nil {
+			return fmt.Errorf("cannot parse document %d: %w", doc, err)
+		}
+
+		switch typeMeta.Kind {
+		case "NetworkPolicy":
+			policy := networkingv1.NetworkPolicy{}
+			if err := json.Unmarshal(raw, &policy); err != nil {
+				return fmt.Errorf("cannot parse document %d: %w", doc, err)
+			}
+			a.ExistingPolicies = append(a.ExistingPolicies, policy)
+		case "NetworkPolicyList", "List":
+			list := networkingv1.NetworkPolicyList{}
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return fmt.Errorf("cannot parse document %d: %w", doc, err)
+			}
+			a.ExistingPolicies = append(a.ExistingPolicies, list.Items...)
+		default:
+			return fmt.Errorf("document %d: unexpected kind %q", doc, typeMeta.Kind)
+		}
+	}
+}
+
+func policyName(p *networkingv1.NetworkPolicy) string {
+	return p.Namespace + "/" + p.Name
+}
+
+// policyTypes returns the directions isolated by the policy. When the policy
+// doesn't set them, Ingress is always assumed and Egress only if the policy
+// has egress rules.
+func policyTypes(p *networkingv1.NetworkPolicy) (ingress, egress bool) {
+	if len(p.Spec.PolicyTypes) == 0 {
+		return true, len(p.Spec.Egress) != 0
+	}
+	for _, t := range p.Spec.PolicyTypes {
+		switch t {
+		case networkingv1.PolicyTypeIngress:
+			ingress = true
+		case networkingv1.PolicyTypeEgress:
+			egress = true
+		}
+	}
+	return
+}
+
+func selectorMatches(selector *metav1.LabelSelector, set map[string]string) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		// An invalid selector is rejected by the API server, it can't
+		// select anything.
+		return false
+	}
+	return s.Matches(labels.Set(set))
+}
+
+func (a *NetworkPolicyAdvisor) namespaceLabels(namespace string) map[string]string {
+	if a.GetNamespaceLabels != nil {
+		if l := a.GetNamespaceLabels(namespace); l != nil {
+			return l
+		}
+	}
+	return map[string]string{
+		"kubernetes.io/metadata.name": namespace,
+	}
+}
+
+// peerMatches checks if the remote end of the flow is selected by the peer of
+// a rule. Traffic to a service is evaluated against the pods of the service,
+// approximated by its label selector.
+func (a *NetworkPolicyAdvisor) peerMatches(peer *networkingv1.NetworkPolicyPeer, policyNamespace string, e *types.Event) bool {
+	if peer.IPBlock != nil {
+		if e.RemoteKind == "svc" {
+			return false
+		}
+		return ipBlockMatches(peer.IPBlock, e.IP)
+	}
+
+	var namespace string
+	var podLabels map[string]string
+	switch e.RemoteKind {
+	case "pod":
+		namespace, podLabels = e.RemotePodNamespace, e.RemotePodLabels
+	case "svc":
+		namespace, podLabels = e.RemoteSvcNamespace, e.RemoteSvcLabelSelector
+	default:
+		return false
+	}
+
+	if peer.NamespaceSelector == nil {
+		if namespace != policyNamespace {
+			return false
+		}
+	} else if !selectorMatches(peer.NamespaceSelector, a.namespaceLabels(namespace)) {
+		return false
+	}
+
+	return peer.PodSelector == nil || selectorMatches(peer.PodSelector, podLabels)
+}
+
+func ipBlockMatches(block *networkingv1.IPBlock, ipStr string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil || !cidr.Contains(ip) {
+		return false
+	}
+	for _, except := range block.Except {
+		_, exceptCidr, err := net.ParseCIDR(except)
+		if err == nil && exceptCidr.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// portMatches checks if the port and protocol of the flow are allowed by the
+// ports of a rule. Named ports can't be resolved from the recorded events,
+// they are considered to match.
+func portMatches(ports []networkingv1.NetworkPolicyPort, e *types.Event) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		if !strings.EqualFold(string(portProtocol(p)), e.Proto) {
+			continue
+		}
+		if p.Port == nil || p.Port.Type == intstr.String {
+			return true
+		}
+		port := int32(e.Port)
+		if p.EndPort != nil {
+			if port >= p.Port.IntVal && port <= *p.EndPort {
+				return true
+			}
+		} else if port == p.Port.IntVal {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *NetworkPolicyAdvisor) rulesAllow(p *networkingv1.NetworkPolicy, direction string, e *types.Event) bool {
+	peersAllow := func(peers []networkingv1.NetworkPolicyPeer) bool {
+		if len(peers) == 0 {
+			return true
+		}
+		for i := range peers {
+			if a.peerMatches(&peers[i], p.Namespace, e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if direction == directionIngress {
+		for _, rule := range p.Spec.Ingress {
+			if portMatches(rule.Ports, e) && peersAllow(rule.From) {
+				return true
+			}
+		}
+	} else {
+		for _, rule := range p.Spec.Egress {
+			if portMatches(rule.Ports, e) && peersAllow(rule.To) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidatePolicies evaluates the recorded flows against ExistingPolicies. A
+// flow is blocked when at least one policy isolates its pod in the direction
+// of the flow but none of them allows it.
+func (a *NetworkPolicyAdvisor) ValidatePolicies() {
+	validation := &Validation{}
+	usedPolicies := map[string]struct{}{}
+	pods := map[string]struct{}{}
+	podsWithPolicy := map[string]struct{}{}
+	flows := map[string]struct{}{}
+
+	for _, e := range a.Events {
+		if e.Type != eventtypes.NORMAL {
+			continue
+		}
+
+		var direction string
+		switch e.PktType {
+		case "HOST":
+			direction = directionIngress
+		case "OUTGOING":
+			direction = directionEgress
+		default:
+			continue
+		}
+
+		// Network policies don't apply to the traffic from the pod's node
+		// nor to localhost.
+		if e.PktType == "HOST" && e.PodHostIP == e.IP {
+			continue
+		}
+		if e.RemoteKind == "other" && net.ParseIP(e.RemoteOther).IsLoopback() {
+			continue
+		}
+
+		podKey := e.Namespace + "/" + e.Pod
+		pods[podKey] = struct{}{}
+
+		key := podKey + ":" + direction + ":" + e.Proto + ":" + a.networkPeerKey(e)
+		if _, ok := flows[key]; ok {
+			continue
+		}
+		flows[key] = struct{}{}
+
+		isolating := []string{}
+		allowed := false
+		for i := range a.ExistingPolicies {
+			p := &a.ExistingPolicies[i]
+			if p.Namespace != e.Namespace || !selectorMatches(&p.Spec.PodSelector, e.PodLabels) {
+				continue
+			}
+			podsWithPolicy[podKey] = struct{}{}
+
+			ingress, egress := policyTypes(p)
+			if (direction == directionIngress && !ingress) || (direction == directionEgress && !egress) {
+				continue
+			}
+			usedPolicies[policyName(p)] = struct{}{}
+			isolating = append(isolating, policyName(p))
+
+			if a.rulesAllow(p, direction, &e) {
+				allowed = true
+			}
+		}
+
+		if len(isolating) != 0 && !allowed {
+			sort.Strings(isolating)
+			validation.Blocked = append(validation.Blocked, BlockedFlow{
+				Event:     e,
+				Direction: direction,
+				Policies:  isolating,
+			})
+		}
+	}
+
+	for i := range a.ExistingPolicies {
+		name := policyName(&a.ExistingPolicies[i])
+		if _, ok := usedPolicies[name]; !ok {
+			validation.UnusedPolicies = append(validation.UnusedPolicies, name)
+		}
+	}
+	sort.Strings(validation.UnusedPolicies)
+
+	for pod := range pods {
+		if _, ok := podsWithPolicy[pod]; !ok {
+			validation.PodsWithoutPolicy = append(validation.PodsWithoutPolicy, pod)
+		}
+	}
+	sort.Strings(validation.PodsWithoutPolicy)
+
+	sort.SliceStable(validation.Blocked, func(i, j int) bool {
+		return validation.Blocked[i].String() < validation.Blocked[j].String()
+	})
+
+	a.Validation = validation
+}
+
+func (f *BlockedFlow) String() string {
+	e := &f.Event
+
+	var remote string
+	switch e.RemoteKind {
+	case "pod":
+		remote = fmt.Sprintf("pod %s/%s", e.RemotePodNamespace, e.RemotePodName)
+	case "svc":
+		remote = fmt.Sprintf("svc %s/%s", e.RemoteSvcNamespace, e.RemoteSvcName)
+	default:
+		remote = e.RemoteOther
+		if remote == "" {
+			remote = e.IP
+		}
+	}
+
+	arrow := "->"
+	if f.Direction == directionIngress {
+		arrow = "<-"
+	}
+
+	return fmt.Sprintf("%s/%s %s %s %s/%d", e.Namespace, e.Pod, arrow, remote, strings.ToUpper(e.Proto), e.Port)
+}
+
+// FormatValidation returns a human readable report of the validation.
+func (a *NetworkPolicyAdvisor) FormatValidation() (out string) {
+	if a.Validation == nil {
+		return
+	}
+
+	out += "Flows blocked by the existing network policies:\n"
+	for _, f := range a.Validation.Blocked {
+		out += fmt.Sprintf("  %s %s (isolated by %s)\n", f.Direction, f.String(), strings.Join(f.Policies, ", "))
+	}
+	if len(a.Validation.Blocked) == 0 {
+		out += "  none\n"
+	}
+
+	out += "Network policies not applying to any recorded traffic:\n"
+	for _, p := range a.Validation.UnusedPolicies {
+		out += fmt.Sprintf("  %s\n", p)
+	}
+	if len(a.Validation.UnusedPolicies) == 0 {
+		out += "  none\n"
+	}
+
+	out += "Pods not selected by any network policy:\n"
+	for _, p := range a.Validation.PodsWithoutPolicy {
+		out += fmt.Sprintf("  %s\n", p)
+	}
+	if len(a.Validation.PodsWithoutPolicy) == 0 {
+		out += "  none\n"
+	}
+
+	return
+}
@@ -0,0 +1,598 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	k8syaml "sigs.k8s.io/yaml"
+
+	capabilitiesadvisor "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/capabilities/advisor"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+	capabilitiestypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	exectypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	opentypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Gadgets whose events are combined by the advisor.
+const (
+	GadgetExec         = "exec"
+	GadgetOpen         = "open"
+	GadgetCapabilities = "capabilities"
+	GadgetSeccomp      = "seccomp"
+)
+
+// Flags of open(2) on Linux. They are defined here because the advisor can
+// run on other platforms.
+const (
+	oAccmode = 0x3
+	oRdonly  = 0x0
+	oWronly  = 0x1
+	oRdwr    = 0x2
+	oCreat   = 0x40
+	oTrunc   = 0x200
+	oAppend  = 0x400
+)
+
+// maxFiles is the maximum number of files given as evidence for each
+// directory.
+const maxFiles = 3
+
+// notRootFilesystem are the paths that aren't on the root filesystem of the
+// containers, in addition to the volume mounts of the pods.
+var notRootFilesystem = []string{
+	"/proc",
+	"/sys",
+	"/dev",
+	"/etc/hosts",
+	"/etc/hostname",
+	"/etc/resolv.conf",
+}
+
+// SeccompEvent contains the syscalls used by a container.
+type SeccompEvent struct {
+	eventtypes.Event
+
+	Syscalls []string `json:"syscalls"`
+}
+
+// Recommendation is the hardened security context advised for a container of a
+// workload, with the volumes needed by a read-only root filesystem.
+type Recommendation struct {
+	workload.ID
+
+	SecurityContext v1.SecurityContext `json:"securityContext"`
+	VolumeMounts    []v1.VolumeMount   `json:"volumeMounts,omitempty"`
+	Volumes         []v1.Volume        `json:"volumes,omitempty"`
+
+	// Evidence explains each recommendation, or why it isn't made.
+	Evidence []string `json:"evidence"`
+}
+
+type PodSecurityAdvisor struct {
+	ExecEvents         []exectypes.Event
+	OpenEvents         []opentypes.Event
+	CapabilitiesEvents []capabilitiestypes.Event
+	SeccompEvents      []SeccompEvent
+
+	// GetPod returns the information about a pod. It can return nil when
+	// the pod is unknown, the pod is then its own workload.
+	GetPod func(namespace, name string) *workload.Pod
+
+	Recommendations []Recommendation
+}
+
+func NewAdvisor() *PodSecurityAdvisor {
+	return &PodSecurityAdvisor{
+		GetPod: func(namespace, name string) *workload.Pod { return nil },
+	}
+}
+
+func (a *PodSecurityAdvisor) LoadFile(filename string) error {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return a.LoadBuffer(buf)
+}
+
+func (a *PodSecurityAdvisor) addRecord(record *workload.Record) error {
+	var err error
+	switch record.Gadget {
+	case GadgetExec:
+		event := exectypes.Event{}
+		if err = json.Unmarshal(record.Event, &event); err == nil {
+			a.ExecEvents = append(a.ExecEvents, event)
+		}
+	case GadgetOpen:
+		event := opentypes.Event{}
+		if err = json.Unmarshal(record.Event, &event); err == nil {
+			a.OpenEvents = append(a.OpenEvents, event)
+		}
+	case GadgetCapabilities:
+		event := capabilitiestypes.Event{}
+		if err = json.Unmarshal(record.Event, &event); err == nil {
+			a.CapabilitiesEvents = append(a.CapabilitiesEvents, event)
+		}
+	case GadgetSeccomp:
+		event := SeccompEvent{}
+		if err = json.Unmarshal(record.Event, &event); err == nil {
+			a.SeccompEvents = append(a.SeccompEvents, event)
+		}
+	default:
+		err = fmt.Errorf("unknown gadget %q", record.Gadget)
+	}
+	return err
+}
+
+func (a *PodSecurityAdvisor) LoadBuffer(buf []byte) error {
+	a.ExecEvents = nil
+	a.OpenEvents = nil
+	a.CapabilitiesEvents = nil
+	a.SeccompEvents = nil
+
+	records, err := workload.LoadEvents[workload.Record](buf)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if err := a.addRecord(&records[i]); err != nil {
+			return fmt.Errorf("cannot parse record %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+type containerActivity struct {
+	privileged bool
+	mountPaths map[string]struct{}
+
+	// uids contains the commands run by each UID.
+	uids map[uint32]map[string]struct{}
+
+	opens bool
+	// written contains the files written in the root filesystem.
+	written map[string]struct{}
+	// read contains the files read before being written, i.e. those with
+	// content coming from the image.
+	read map[string]struct{}
+
+	seccomp  bool
+	syscalls map[string]struct{}
+
+	capabilities *capabilitiesadvisor.Recommendation
+}
+
+func isWrite(flags int) bool {
+	return flags&(oWronly|oRdwr|oCreat|oTrunc|oAppend) != 0
+}
+
+func isRead(flags int) bool {
+	switch flags & oAccmode {
+	case oRdonly:
+		return true
+	case oRdwr:
+		return flags&(oCreat|oTrunc) == 0
+	}
+	return false
+}
+
+func isUnder(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/")
+}
+
+func (c *containerActivity) isRootFilesystem(p string) bool {
+	for _, dir := range notRootFilesystem {
+		if isUnder(p, dir) {
+			return false
+		}
+	}
+	for dir := range c.mountPaths {
+		if isUnder(p, dir) {
+			return false
+		}
+	}
+	return true
+}
+
+// volumeName returns the name of the emptyDir volume mounted on dir for the
+// given container. It's a valid DNS label.
+func volumeName(container, dir string) string {
+	var b strings.Builder
+	b.WriteString(container)
+	dash := false
+	for _, r := range strings.ToLower(dir) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash {
+				b.WriteByte('-')
+				dash = false
+			}
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+	}
+	name := b.String()
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// GenerateRecommendations combines the events of the gadgets per workload and
+// container to advise a hardened security context.
+func (a *PodSecurityAdvisor) GenerateRecommendations() {
+	getPod := workload.PodCache(a.GetPod)
+	containers := map[workload.ID]*containerActivity{}
+	getContainer := func(e *eventtypes.Event) *containerActivity {
+		if e.Type != eventtypes.NORMAL || e.Namespace == "" || e.Pod == "" || e.Container == "" {
+			return nil
+		}
+
+		pod := getPod(e.Namespace, e.Pod)
+		key := workload.ID{
+			Namespace: e.Namespace,
+			Kind:      pod.OwnerKind,
+			Name:      pod.OwnerName,
+			Container: e.Container,
+		}
+		c, ok := containers[key]
+		if !ok {
+			c = &containerActivity{
+				mountPaths: map[string]struct{}{},
+				uids:       map[uint32]map[string]struct{}{},
+				written:    map[string]struct{}{},
+				read:       map[string]struct{}{},
+				syscalls:   map[string]struct{}{},
+			}
+			containers[key] = c
+		}
+		if pod.Privileged[e.Container] {
+			c.privileged = true
+		}
+		for _, p := range pod.MountPaths[e.Container] {
+			c.mountPaths[p] = struct{}{}
+		}
+		return c
+	}
+
+	addUID := func(c *containerActivity, uid uint32, comm string) {
+		if c.uids[uid] == nil {
+			c.uids[uid] = map[string]struct{}{}
+		}
+		c.uids[uid][comm] = struct{}{}
+	}
+
+	for i := range a.ExecEvents {
+		e := &a.ExecEvents[i]
+		if c := getContainer(&e.Event); c != nil {
+			addUID(c, e.UID, e.Comm)
+		}
+	}
+
+	for i := range a.OpenEvents {
+		e := &a.OpenEvents[i]
+		c := getContainer(&e.Event)
+		if c == nil {
+			continue
+		}
+		addUID(c, e.UID, e.Comm)
+		c.opens = true
+		// Relative paths can't be resolved
+		if e.Err != 0 || !path.IsAbs(e.Path) {
+			continue
+		}
+		p := path.Clean(e.Path)
+		if isWrite(e.Flags) {
+			c.written[p] = struct{}{}
+		}
+		// Files written before are created by the container
+		if _, ok := c.written[p]; !ok && isRead(e.Flags) {
+			c.read[p] = struct{}{}
+		}
+	}
+
+	for i := range a.SeccompEvents {
+		e := &a.SeccompEvents[i]
+		c := getContainer(&e.Event)
+		if c == nil {
+			continue
+		}
+		c.seccomp = true
+		for _, syscall := range e.Syscalls {
+			c.syscalls[syscall] = struct{}{}
+		}
+	}
+
+	for i := range a.CapabilitiesEvents {
+		getContainer(&a.CapabilitiesEvents[i].Event)
+	}
+
+	capAdvisor := capabilitiesadvisor.NewAdvisor()
+	capAdvisor.Events = a.CapabilitiesEvents
+	capAdvisor.GetPod = getPod
+	capAdvisor.GenerateRecommendations()
+	for i := range capAdvisor.Recommendations {
+		r := &capAdvisor.Recommendations[i]
+		if c, ok := containers[r.ID]; ok {
+			c.capabilities = r
+		}
+	}
+
+	a.Recommendations = nil
+	for key, c := range containers {
+		r := Recommendation{ID: key}
+		added := c.adviseCapabilities(&r)
+		c.adviseUser(&r)
+		c.adviseRootFilesystem(&r)
+		c.adviseSeccomp(&r, added)
+		a.Recommendations = append(a.Recommendations, r)
+	}
+
+	sort.Slice(a.Recommendations, func(i, j int) bool {
+		return a.Recommendations[i].ID.Less(a.Recommendations[j].ID)
+	})
+}
+
+// adviseCapabilities drops all the capabilities but the ones that were
+// granted. It returns the added capabilities.
+func (c *containerActivity) adviseCapabilities(r *Recommendation) []string {
+	capRecommendation := c.capabilities
+	if capRecommendation == nil {
+		capRecommendation = &capabilitiesadvisor.Recommendation{
+			SecurityContext: v1.SecurityContext{
+				Capabilities: &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+			},
+			PrivilegedUnused: c.privileged,
+		}
+		if c.privileged {
+			privileged := false
+			capRecommendation.SecurityContext.Privileged = &privileged
+		}
+	}
+
+	r.SecurityContext.Capabilities = capRecommendation.SecurityContext.Capabilities
+	r.SecurityContext.Privileged = capRecommendation.SecurityContext.Privileged
+
+	added := []string{}
+	for _, capability := range r.SecurityContext.Capabilities.Add {
+		added = append(added, string(capability))
+	}
+	if len(added) == 0 {
+		r.Evidence = append(r.Evidence, "capabilities: no capability was granted")
+	} else {
+		r.Evidence = append(r.Evidence, fmt.Sprintf("capabilities: granted %s", strings.Join(added, ", ")))
+	}
+	if len(capRecommendation.Denied) != 0 {
+		r.Evidence = append(r.Evidence, fmt.Sprintf("capabilities: denied, not added: %s",
+			strings.Join(capRecommendation.Denied, ", ")))
+	}
+	if capRecommendation.PrivilegedUnused {
+		r.Evidence = append(r.Evidence, "privileged: the container runs privileged but didn't use any capability")
+	} else if c.privileged {
+		r.Evidence = append(r.Evidence, "privileged: the container runs privileged, it's kept as it uses capabilities")
+	}
+
+	return added
+}
+
+// adviseUser advises to run as non-root when no process ran as root.
+func (c *containerActivity) adviseUser(r *Recommendation) {
+	if len(c.uids) == 0 {
+		r.Evidence = append(r.Evidence, "runAsNonRoot: not advised, no process was seen")
+		return
+	}
+
+	if comms, ok := c.uids[0]; ok {
+		r.Evidence = append(r.Evidence, fmt.Sprintf("runAsNonRoot: not advised, processes ran as root: %s",
+			strings.Join(workload.SortedKeys(comms), ", ")))
+		return
+	}
+
+	uids := make([]string, 0, len(c.uids))
+	for uid := range c.uids {
+		uids = append(uids, fmt.Sprint(uid))
+	}
+	sort.Strings(uids)
+
+	runAsNonRoot := true
+	allowPrivilegeEscalation := false
+	r.SecurityContext.RunAsNonRoot = &runAsNonRoot
+	r.SecurityContext.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+	r.Evidence = append(r.Evidence, fmt.Sprintf("runAsNonRoot, allowPrivilegeEscalation: all the processes ran as UID %s",
+		strings.Join(uids, ", ")))
+}
+
+// listFiles returns the first files, with the number of the others.
+func listFiles(files []string) string {
+	if len(files) <= maxFiles {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(files[:maxFiles], ", "), len(files)-maxFiles)
+}
+
+// adviseRootFilesystem advises a read-only root filesystem, with an emptyDir
+// volume mounted on the directories of the files written. An emptyDir hides
+// the content of the image, so the advice is only given as evidence when the
+// container read image content in one of these directories.
+func (c *containerActivity) adviseRootFilesystem(r *Recommendation) {
+	if !c.opens {
+		r.Evidence = append(r.Evidence, "readOnlyRootFilesystem: not advised, no file access was seen")
+		return
+	}
+
+	// Directories of the files written, per directory
+	dirs := map[string][]string{}
+	for _, file := range workload.SortedKeys(c.written) {
+		if !c.isRootFilesystem(file) {
+			continue
+		}
+		dir := path.Dir(file)
+		if dir == "/" {
+			r.Evidence = append(r.Evidence, fmt.Sprintf("readOnlyRootFilesystem: not advised, %s was written", file))
+			return
+		}
+		dirs[dir] = append(dirs[dir], file)
+	}
+
+	// Sub-directories share the volume of their parent
+	sortedDirs := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Strings(sortedDirs)
+	volumes := []string{}
+	files := map[string][]string{}
+	for _, dir := range sortedDirs {
+		if len(volumes) != 0 && isUnder(dir, volumes[len(volumes)-1]) {
+			parent := volumes[len(volumes)-1]
+			files[parent] = append(files[parent], dirs[dir]...)
+			continue
+		}
+		volumes = append(volumes, dir)
+		files[dir] = dirs[dir]
+	}
+
+	// Image content read in the directories of the volumes
+	read := map[string][]string{}
+	for _, file := range workload.SortedKeys(c.read) {
+		for _, dir := range volumes {
+			if isUnder(file, dir) {
+				read[dir] = append(read[dir], file)
+				break
+			}
+		}
+	}
+
+	if len(read) != 0 {
+		for _, dir := range volumes {
+			if len(read[dir]) != 0 {
+				r.Evidence = append(r.Evidence, fmt.Sprintf("readOnlyRootFilesystem: not advised, an emptyDir on %s would hide the image content that was read: %s",
+					dir, listFiles(read[dir])))
+			} else {
+				r.Evidence = append(r.Evidence, fmt.Sprintf("readOnlyRootFilesystem: would need an emptyDir on %s, written: %s",
+					dir, listFiles(files[dir])))
+			}
+		}
+		return
+	}
+
+	readOnlyRootFilesystem := true
+	r.SecurityContext.ReadOnlyRootFilesystem = &readOnlyRootFilesystem
+	if len(volumes) == 0 {
+		r.Evidence = append(r.Evidence, "readOnlyRootFilesystem: no file was written in the root filesystem")
+		return
+	}
+
+	for _, dir := range volumes {
+		name := volumeName(r.Container, dir)
+		r.VolumeMounts = append(r.VolumeMounts, v1.VolumeMount{
+			Name:      name,
+			MountPath: dir,
+		})
+		r.Volumes = append(r.Volumes, v1.Volume{
+			Name: name,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		})
+		r.Evidence = append(r.Evidence, fmt.Sprintf("readOnlyRootFilesystem: emptyDir on %s, written: %s",
+			dir, listFiles(files[dir])))
+	}
+}
+
+// adviseSeccomp advises the default seccomp profile of the container runtime
+// when it allows all the syscalls used.
+func (c *containerActivity) adviseSeccomp(r *Recommendation, capabilities []string) {
+	if !c.seccomp {
+		r.Evidence = append(r.Evidence, "seccompProfile: not advised, no syscall was recorded")
+		return
+	}
+
+	allowed := runtimeDefaultSyscalls(capabilities)
+
+	notAllowed := []string{}
+	for _, syscall := range workload.SortedKeys(c.syscalls) {
+		if _, ok := allowed[syscall]; !ok {
+			notAllowed = append(notAllowed, syscall)
+		}
+	}
+	if len(notAllowed) != 0 {
+		r.Evidence = append(r.Evidence, fmt.Sprintf("seccompProfile: not advised, the default profile doesn't allow %s, use the seccomp-profile advisor instead",
+			strings.Join(notAllowed, ", ")))
+		return
+	}
+
+	r.SecurityContext.SeccompProfile = &v1.SeccompProfile{
+		Type: v1.SeccompProfileTypeRuntimeDefault,
+	}
+	r.Evidence = append(r.Evidence, fmt.Sprintf("seccompProfile: the %d syscalls used are allowed by the default profile",
+		len(c.syscalls)))
+}
+
+func (r *Recommendation) comments() (out string) {
+	out += r.ID.Comment()
+	for _, evidence := range r.Evidence {
+		out += fmt.Sprintf("# - %s\n", evidence)
+	}
+	return
+}
+
+// FormatRecommendations returns the security context of each container with
+// its volumes, in YAML.
+func (a *PodSecurityAdvisor) FormatRecommendations() (out string) {
+	for _, r := range a.Recommendations {
+		yamlOutput, err := k8syaml.Marshal(struct {
+			SecurityContext v1.SecurityContext `json:"securityContext"`
+			VolumeMounts    []v1.VolumeMount   `json:"volumeMounts,omitempty"`
+			Volumes         []v1.Volume        `json:"volumes,omitempty"`
+		}{r.SecurityContext, r.VolumeMounts, r.Volumes})
+		if err != nil {
+			out += fmt.Sprintf("# Failed to marshal security context: %s\n", err)
+			continue
+		}
+		out += "---\n" + r.comments() + string(yamlOutput)
+	}
+	return
+}
+
+// FormatPatches returns, for each workload, the kubectl command applying a
+// strategic merge patch with the security context and the volumes of its
+// containers.
+func (a *PodSecurityAdvisor) FormatPatches() string {
+	patches := make([]workload.ContainerPatch, 0, len(a.Recommendations))
+	for _, r := range a.Recommendations {
+		fields := map[string]interface{}{
+			"securityContext": r.SecurityContext,
+		}
+		if len(r.VolumeMounts) != 0 {
+			fields["volumeMounts"] = r.VolumeMounts
+		}
+		patches = append(patches, workload.ContainerPatch{
+			ID:       r.ID,
+			Comments: r.comments(),
+			Fields:   fields,
+			Volumes:  r.Volumes,
+		})
+	}
+	return workload.FormatPatches(patches)
+}
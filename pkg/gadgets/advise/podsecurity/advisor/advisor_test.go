@@ -0,0 +1,123 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/workload"
+)
+
+func TestLoad(t *testing.T) {
+	match, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inputFile := range match {
+		a := NewAdvisor()
+
+		err := a.LoadFile(inputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.GenerateRecommendations()
+		generatedOutput := a.FormatRecommendations()
+
+		goldenFile := inputFile[:len(inputFile)-len(".input")] + ".golden"
+		goldenOutputBytes, err := os.ReadFile(goldenFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		goldenOutput := string(goldenOutputBytes)
+
+		if generatedOutput != goldenOutput {
+			t.Errorf("Unexpected recommendation from %s:\n%s\nExpected:\n%s\n", inputFile, generatedOutput, goldenOutput)
+		}
+	}
+}
+
+func TestLoadUnknownGadget(t *testing.T) {
+	a := NewAdvisor()
+	err := a.LoadBuffer([]byte(`{"gadget":"dns","event":{"type":"normal"}}`))
+	if err == nil {
+		t.Fatalf("expected error with an unknown gadget")
+	}
+}
+
+func TestPatches(t *testing.T) {
+	a := NewAdvisor()
+	a.GetPod = func(namespace, name string) *workload.Pod {
+		if name != "web-6d4cf56db6-abcde" {
+			return nil
+		}
+		return &workload.Pod{
+			OwnerKind:  "Deployment",
+			OwnerName:  "web",
+			Privileged: map[string]bool{"web": true},
+			MountPaths: map[string][]string{"web": {"/data"}},
+		}
+	}
+
+	err := a.LoadBuffer([]byte(`
+{"gadget":"exec","event":{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-abcde","container":"web","uid":1000,"pcomm":"web"}}
+{"gadget":"open","event":{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-abcde","container":"web","uid":1000,"pcomm":"web","flags":577,"path":"/data/db"}}
+{"gadget":"open","event":{"type":"normal","namespace":"demo","pod":"web-6d4cf56db6-abcde","container":"web","uid":1000,"pcomm":"web","flags":577,"path":"/var/lib/web/state"}}
+{"gadget":"open","event":{"type":"normal","namespace":"demo","pod":"debug","container":"debug","pcomm":"sh","flags":577,"path":"/out.log"}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.GenerateRecommendations()
+
+	expected := `# Deployment demo/web, container web
+# - capabilities: no capability was granted
+# - privileged: the container runs privileged but didn't use any capability
+# - runAsNonRoot, allowPrivilegeEscalation: all the processes ran as UID 1000
+# - readOnlyRootFilesystem: emptyDir on /var/lib/web, written: /var/lib/web/state
+# - seccompProfile: not advised, no syscall was recorded
+kubectl patch -n demo deployment web --type strategic --patch '{"spec":{"template":{"spec":{"containers":[{"name":"web","securityContext":{"capabilities":{"drop":["ALL"]},"privileged":false,"runAsNonRoot":true,"readOnlyRootFilesystem":true,"allowPrivilegeEscalation":false},"volumeMounts":[{"name":"web-var-lib-web","mountPath":"/var/lib/web"}]}],"volumes":[{"name":"web-var-lib-web","emptyDir":{}}]}}}}'
+# Pod demo/debug, container debug
+# - capabilities: no capability was granted
+# - runAsNonRoot: not advised, processes ran as root: sh
+# - readOnlyRootFilesystem: not advised, /out.log was written
+# - seccompProfile: not advised, no syscall was recorded
+# The security context of a Pod can't be patched, it needs to be recreated
+`
+	if output := a.FormatPatches(); output != expected {
+		t.Errorf("Unexpected patches:\n%s\nExpected:\n%s\n", output, expected)
+	}
+}
+
+func TestRuntimeDefaultSyscalls(t *testing.T) {
+	allowed := runtimeDefaultSyscalls(nil)
+	for _, syscall := range []string{"read", "clone", "arch_prctl"} {
+		if _, ok := allowed[syscall]; !ok {
+			t.Errorf("expected %s to be allowed", syscall)
+		}
+	}
+	for _, syscall := range []string{"reboot", "clone3", "mount"} {
+		if _, ok := allowed[syscall]; ok {
+			t.Errorf("expected %s not to be allowed without capabilities", syscall)
+		}
+	}
+
+	allowed = runtimeDefaultSyscalls([]string{"SYS_BOOT"})
+	if _, ok := allowed["reboot"]; !ok {
+		t.Errorf("expected reboot to be allowed with SYS_BOOT")
+	}
+}
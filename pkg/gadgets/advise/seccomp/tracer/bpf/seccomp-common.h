@@ -5,4 +5,39 @@
 #define SYSCALLS_MAP_VALUE_FOOTER_SIZE	1
 #define SYSCALLS_MAP_VALUE_SIZE		(SYSCALLS_COUNT + SYSCALLS_MAP_VALUE_FOOTER_SIZE)
 
+#define SYSCALL_ARGS_COUNT		6
+
+// Maximum number of distinct values recorded for a syscall argument in a
+// mount namespace. Once exceeded, the count is set to
+// SYSCALL_ARG_MAX_VALUES + 1 and the argument can't be restricted.
+#define SYSCALL_ARG_MAX_VALUES		16
+
+// Maximum number of traces recording syscall arguments at the same time. Each
+// of them has its own slot in the configuration and its own share of the
+// values map so that a trace can't prevent the others from recording values.
+#define SYSCALL_ARGS_MAX_TRACES		4
+#define SYSCALL_ARG_VALUES_MAX_ENTRIES	65536
+#define SYSCALL_ARG_VALUES_PER_TRACE	(SYSCALL_ARG_VALUES_MAX_ENTRIES / SYSCALL_ARGS_MAX_TRACES)
+
+// syscall_args_config is the value of the syscall_args_config map: for each
+// trace slot, a bitmask of the indexes of the arguments to record.
+struct syscall_args_config {
+	__u8 args[SYSCALL_ARGS_MAX_TRACES];
+};
+
+struct syscall_arg_key {
+	__u64 mntns;
+	__u32 syscall;
+	__u16 arg;
+	__u16 trace;
+};
+
+struct syscall_arg_value_key {
+	__u64 mntns;
+	__u32 syscall;
+	__u16 arg;
+	__u16 trace;
+	__u64 value;
+};
+
 #endif
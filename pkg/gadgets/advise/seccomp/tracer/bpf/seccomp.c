@@ -14,6 +14,7 @@
 
 #define TASK_COMM_LEN 16
 #define TS_COMPAT 0x0002
+#define EEXIST 17
 
 // prctl syscall number from
 // https://github.com/seccomp/libseccomp/blob/abad8a8f41fc13efbb95fc1ccaa3e181342bade7/src/syscalls.csv#L265
@@ -57,6 +58,52 @@ struct {
 	__uint(max_entries, 1024);
 } syscalls_per_mntns SEC(".maps");
 
+// syscall_args_config contains, for each syscall, the arguments recorded by
+// each trace. It's an array so that the syscalls whose arguments aren't
+// recorded only cost an array lookup.
+struct {
+	__uint(type, BPF_MAP_TYPE_ARRAY);
+	__type(key, __u32);
+	__type(value, struct syscall_args_config);
+	__uint(max_entries, SYSCALLS_COUNT);
+} syscall_args_config SEC(".maps");
+
+// syscall_args_mntns contains, for each mount namespace, a bitmask of the
+// traces recording its syscall arguments, so that the other mount namespaces
+// don't use up the values of the traces.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, __u64);
+	__type(value, __u8);
+	__uint(max_entries, 1024);
+} syscall_args_mntns SEC(".maps");
+
+// syscall_arg_counts contains the number of distinct values recorded for each
+// argument in syscall_arg_values.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, struct syscall_arg_key);
+	__type(value, __u32);
+	__uint(max_entries, SYSCALL_ARG_VALUES_MAX_ENTRIES);
+} syscall_arg_counts SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, struct syscall_arg_value_key);
+	__type(value, __u8);
+	__uint(max_entries, SYSCALL_ARG_VALUES_MAX_ENTRIES);
+} syscall_arg_values SEC(".maps");
+
+// syscall_arg_trace_values contains the number of entries of
+// syscall_arg_values used by each trace, which can't go over
+// SYSCALL_ARG_VALUES_PER_TRACE.
+struct {
+	__uint(type, BPF_MAP_TYPE_ARRAY);
+	__type(key, __u32);
+	__type(value, __u32);
+	__uint(max_entries, SYSCALL_ARGS_MAX_TRACES);
+} syscall_arg_trace_values SEC(".maps");
+
 #ifdef __TARGET_ARCH_x86
 static __always_inline int is_x86_compat(struct task_struct *task)
 {
@@ -64,6 +111,118 @@ static __always_inline int is_x86_compat(struct task_struct *task)
 }
 #endif
 
+// Arguments of the syscall, read from the registers as done by the
+// syscall_get_arguments() function of the kernel. PT_REGS_PARM4() can't be
+// used because the 4th argument isn't passed in the same register for
+// syscalls and functions on x86.
+static __always_inline __u64 syscall_arg(struct pt_regs *regs, int i)
+{
+#if defined(bpf_target_x86)
+	switch (i) {
+	case 0: return regs->di;
+	case 1: return regs->si;
+	case 2: return regs->dx;
+	case 3: return regs->r10;
+	case 4: return regs->r8;
+	case 5: return regs->r9;
+	}
+#elif defined(bpf_target_arm64)
+	if (i >= 0 && i < SYSCALL_ARGS_COUNT)
+		return regs->regs[i];
+#endif
+	return 0;
+}
+
+static __always_inline void record_arg(__u32 *used, __u64 mntns, __u32 id,
+				       __u16 trace, __u16 arg, __u64 value)
+{
+	struct syscall_arg_key key = {
+		.mntns = mntns,
+		.syscall = id,
+		.arg = arg,
+		.trace = trace,
+	};
+	struct syscall_arg_value_key value_key = {
+		.mntns = mntns,
+		.syscall = id,
+		.arg = arg,
+		.trace = trace,
+		.value = value,
+	};
+	__u32 zero = 0, *count;
+	__u8 one = 1;
+	long ret;
+
+	if (bpf_map_lookup_elem(&syscall_arg_values, &value_key))
+		return;
+
+	count = bpf_map_lookup_elem(&syscall_arg_counts, &key);
+	if (!count) {
+		// Without any value, the argument isn't restricted.
+		if (*used >= SYSCALL_ARG_VALUES_PER_TRACE)
+			return;
+		bpf_map_update_elem(&syscall_arg_counts, &key, &zero, BPF_NOEXIST);
+		count = bpf_map_lookup_elem(&syscall_arg_counts, &key);
+		if (!count)
+			return;
+	}
+
+	if (*count > SYSCALL_ARG_MAX_VALUES)
+		return;
+	if (*count == SYSCALL_ARG_MAX_VALUES || *used >= SYSCALL_ARG_VALUES_PER_TRACE) {
+		// We can't know all the values anymore.
+		*count = SYSCALL_ARG_MAX_VALUES + 1;
+		return;
+	}
+
+	ret = bpf_map_update_elem(&syscall_arg_values, &value_key, &one, BPF_NOEXIST);
+	if (ret == -EEXIST)
+		return;
+	if (ret) {
+		*count = SYSCALL_ARG_MAX_VALUES + 1;
+		return;
+	}
+
+	__sync_fetch_and_add(count, 1);
+	__sync_fetch_and_add(used, 1);
+}
+
+static __always_inline void record_args(__u64 mntns, __u32 id, struct pt_regs *regs)
+{
+	struct syscall_args_config *config;
+	__u32 *used, slot;
+	__u8 args, *traces;
+
+	config = bpf_map_lookup_elem(&syscall_args_config, &id);
+	if (!config)
+		return;
+
+	traces = bpf_map_lookup_elem(&syscall_args_mntns, &mntns);
+	if (!traces)
+		return;
+
+#pragma unroll
+	for (__u32 trace = 0; trace < SYSCALL_ARGS_MAX_TRACES; trace++) {
+		if (!(*traces & (1 << trace)))
+			continue;
+
+		args = config->args[trace];
+		if (!args)
+			continue;
+
+		slot = trace;
+		used = bpf_map_lookup_elem(&syscall_arg_trace_values, &slot);
+		if (!used)
+			continue;
+
+#pragma unroll
+		for (int i = 0; i < SYSCALL_ARGS_COUNT; i++) {
+			if (args & (1 << i))
+				record_arg(used, mntns, id, trace, i, syscall_arg(regs, i));
+		}
+	}
+}
+
 SEC("raw_tracepoint/sys_enter")
 int ig_seccomp_e(struct bpf_raw_tracepoint_args *ctx)
 {
@@ -135,6 +294,8 @@ int ig_seccomp_e(struct bpf_raw_tracepoint_args *ctx)
 	// Record the syscall
 	syscall_bitmap[id] = 0x01;
 
+	record_args(mntns, id, &regs);
+
 	return 0;
 }
 
@@ -13,6 +13,23 @@ import (
 	"github.com/cilium/ebpf"
 )
 
+type seccompSyscallArgKey struct {
+	Mntns   uint64
+	Syscall uint32
+	Arg     uint16
+	Trace   uint16
+}
+
+type seccompSyscallArgValueKey struct {
+	Mntns   uint64
+	Syscall uint32
+	Arg     uint16
+	Trace   uint16
+	Value   uint64
+}
+
+type seccompSyscallArgsConfig struct{ Args [4]uint8 }
+
 // loadSeccomp returns the embedded CollectionSpec for seccomp.
 func loadSeccomp() (*ebpf.CollectionSpec, error) {
 	reader := bytes.NewReader(_SeccompBytes)
@@ -61,7 +78,12 @@ type seccompProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type seccompMapSpecs struct {
-	SyscallsPerMntns *ebpf.MapSpec `ebpf:"syscalls_per_mntns"`
+	SyscallArgCounts      *ebpf.MapSpec `ebpf:"syscall_arg_counts"`
+	SyscallArgTraceValues *ebpf.MapSpec `ebpf:"syscall_arg_trace_values"`
+	SyscallArgValues      *ebpf.MapSpec `ebpf:"syscall_arg_values"`
+	SyscallArgsConfig     *ebpf.MapSpec `ebpf:"syscall_args_config"`
+	SyscallArgsMntns      *ebpf.MapSpec `ebpf:"syscall_args_mntns"`
+	SyscallsPerMntns      *ebpf.MapSpec `ebpf:"syscalls_per_mntns"`
 }
 
 // seccompObjects contains all objects after they have been loaded into the kernel.
@@ -83,11 +105,21 @@ func (o *seccompObjects) Close() error {
 //
 // It can be passed to loadSeccompObjects or ebpf.CollectionSpec.LoadAndAssign.
 type seccompMaps struct {
-	SyscallsPerMntns *ebpf.Map `ebpf:"syscalls_per_mntns"`
+	SyscallArgCounts      *ebpf.Map `ebpf:"syscall_arg_counts"`
+	SyscallArgTraceValues *ebpf.Map `ebpf:"syscall_arg_trace_values"`
+	SyscallArgValues      *ebpf.Map `ebpf:"syscall_arg_values"`
+	SyscallArgsConfig     *ebpf.Map `ebpf:"syscall_args_config"`
+	SyscallArgsMntns      *ebpf.Map `ebpf:"syscall_args_mntns"`
+	SyscallsPerMntns      *ebpf.Map `ebpf:"syscalls_per_mntns"`
 }
 
 func (m *seccompMaps) Close() error {
 	return _SeccompClose(
+		m.SyscallArgCounts,
+		m.SyscallArgTraceValues,
+		m.SyscallArgValues,
+		m.SyscallArgsConfig,
+		m.SyscallArgsMntns,
 		m.SyscallsPerMntns,
 	)
 }
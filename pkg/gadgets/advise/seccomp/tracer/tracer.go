@@ -15,7 +15,10 @@
 package tracer
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
@@ -25,18 +28,61 @@ import (
 
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang seccomp ./bpf/seccomp.c -- -I./bpf/ -I../../../../${TARGET}
 
+// #include <linux/types.h>
 // #include "bpf/seccomp-common.h"
 import "C"
 
 const (
 	BPFProgName = "ig_seccomp_e"
 	BPFMapName  = "syscalls_per_mntns"
+
+	BPFArgsConfigMapName = "syscall_args_config"
+	BPFArgsMntnsMapName  = "syscall_args_mntns"
+	BPFArgCountsMapName  = "syscall_arg_counts"
+	BPFArgValuesMapName  = "syscall_arg_values"
+
+	BPFArgTraceValuesMapName = "syscall_arg_trace_values"
+
+	// SyscallArgsCount is the maximum number of arguments of a syscall.
+	SyscallArgsCount = C.SYSCALL_ARGS_COUNT
+	// SyscallArgMaxValues is the maximum number of distinct values
+	// recorded for an argument.
+	SyscallArgMaxValues = C.SYSCALL_ARG_MAX_VALUES
+	// SyscallArgsMaxTraces is the maximum number of traces recording
+	// arguments at the same time.
+	SyscallArgsMaxTraces = C.SYSCALL_ARGS_MAX_TRACES
 )
 
+// ArgValues contains the distinct values taken by a syscall argument.
+type ArgValues struct {
+	// Values is sorted in increasing order.
+	Values []uint64
+
+	// Overflow is set when the argument took more than
+	// SyscallArgMaxValues values. Values is then incomplete.
+	Overflow bool
+}
+
+// Args contains the values of the recorded arguments, by syscall number and
+// argument index.
+type Args map[int]map[int]*ArgValues
+
 type Tracer struct {
 	collection *ebpf.Collection
 	seccompMap *ebpf.Map
 
+	argsConfigMap *ebpf.Map
+	argsMntnsMap  *ebpf.Map
+	argCountsMap  *ebpf.Map
+	argValuesMap  *ebpf.Map
+
+	argTraceValuesMap *ebpf.Map
+
+	// argsSlots tells which slots of the arguments configuration are used
+	// by a trace.
+	argsMu    sync.Mutex
+	argsSlots [SyscallArgsMaxTraces]bool
+
 	// progLink links the BPF program to the tracepoint.
 	// A reference is kept so it can be closed it explicitly, otherwise
 	// the garbage collector might unlink it via the finalizer at any
@@ -56,8 +102,14 @@ func NewTracer() (*Tracer, error) {
 	}
 
 	t := &Tracer{
-		collection: coll,
-		seccompMap: coll.Maps[BPFMapName],
+		collection:    coll,
+		seccompMap:    coll.Maps[BPFMapName],
+		argsConfigMap: coll.Maps[BPFArgsConfigMapName],
+		argsMntnsMap:  coll.Maps[BPFArgsMntnsMapName],
+		argCountsMap:  coll.Maps[BPFArgCountsMapName],
+		argValuesMap:  coll.Maps[BPFArgValuesMapName],
+
+		argTraceValuesMap: coll.Maps[BPFArgTraceValuesMapName],
 	}
 
 	t.seccompMap.Update(uint64(0), [C.SYSCALLS_MAP_VALUE_SIZE]byte{}, ebpf.UpdateAny)
@@ -98,6 +150,275 @@ func (t *Tracer) Peek(mntns uint64) []byte {
 	return b[:C.SYSCALLS_COUNT]
 }
 
+// MountNamespaces returns the mount namespaces that issued syscalls since the
+// tracer was created.
+func (t *Tracer) MountNamespaces() []uint64 {
+	var mntnss []uint64
+	var mntns uint64
+	var syscalls []byte
+	iter := t.seccompMap.Iterate()
+	for iter.Next(&mntns, &syscalls) {
+		if mntns != 0 {
+			mntnss = append(mntnss, mntns)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Errorf("Error while iterating the seccomp map: %s", err)
+	}
+	return mntnss
+}
+
+// RecordArgs makes the tracer record the values of the arguments of the
+// syscalls in config, which contains a bitmask of the argument indexes by
+// syscall number. It returns the slot where the values are recorded, to be
+// passed to the other *Args methods, until StopRecordingArgs is called. Only
+// the mount namespaces added with RecordArgsOf are recorded.
+func (t *Tracer) RecordArgs(config map[int]uint8) (int, error) {
+	t.argsMu.Lock()
+	defer t.argsMu.Unlock()
+
+	slot := -1
+	for i, used := range t.argsSlots {
+		if !used {
+			slot = i
+			break
+		}
+	}
+	if slot == -1 {
+		return -1, fmt.Errorf("at most %d traces can record syscall arguments at the same time", SyscallArgsMaxTraces)
+	}
+
+	// Remove anything a previous user of the slot could have left
+	t.clearArgs(slot)
+
+	for syscall, args := range config {
+		if err := t.setArgsConfig(slot, syscall, args); err != nil {
+			t.clearArgs(slot)
+			return -1, err
+		}
+	}
+
+	t.argsSlots[slot] = true
+
+	return slot, nil
+}
+
+// StopRecordingArgs stops recording the arguments of the slot and removes
+// their values.
+func (t *Tracer) StopRecordingArgs(slot int) {
+	t.argsMu.Lock()
+	defer t.argsMu.Unlock()
+
+	t.clearArgs(slot)
+	t.argsSlots[slot] = false
+}
+
+// RecordArgsOf makes the slot record the syscall arguments of the given mount
+// namespace, until DeleteArgs is called for it.
+func (t *Tracer) RecordArgsOf(slot int, mntns uint64) error {
+	t.argsMu.Lock()
+	defer t.argsMu.Unlock()
+
+	return t.setArgsMntns(slot, mntns, true)
+}
+
+// setArgsMntns sets or clears the bit of the slot in the traces recording the
+// syscall arguments of the mount namespace.
+func (t *Tracer) setArgsMntns(slot int, mntns uint64, record bool) error {
+	var traces uint8
+	err := t.argsMntnsMap.Lookup(mntns, &traces)
+	if err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return fmt.Errorf("failed to look up traces of mount namespace %d: %w", mntns, err)
+	}
+	if record {
+		traces |= 1 << slot
+	} else {
+		traces &^= 1 << slot
+	}
+
+	if traces == 0 {
+		err = t.argsMntnsMap.Delete(mntns)
+		if err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return fmt.Errorf("failed to delete traces of mount namespace %d: %w", mntns, err)
+		}
+		return nil
+	}
+	if err := t.argsMntnsMap.Put(mntns, traces); err != nil {
+		return fmt.Errorf("failed to update traces of mount namespace %d: %w", mntns, err)
+	}
+	return nil
+}
+
+func (t *Tracer) setArgsConfig(slot, syscall int, args uint8) error {
+	var config seccompSyscallArgsConfig
+	if err := t.argsConfigMap.Lookup(uint32(syscall), &config); err != nil {
+		return fmt.Errorf("failed to look up arguments of syscall %d: %w", syscall, err)
+	}
+	if config.Args[slot] == args {
+		return nil
+	}
+	config.Args[slot] = args
+	if err := t.argsConfigMap.Put(uint32(syscall), &config); err != nil {
+		return fmt.Errorf("failed to record arguments of syscall %d: %w", syscall, err)
+	}
+	return nil
+}
+
+// clearArgs removes the configuration, the mount namespaces and the values of
+// a slot.
+func (t *Tracer) clearArgs(slot int) {
+	for syscall := 0; syscall < C.SYSCALLS_COUNT; syscall++ {
+		if err := t.setArgsConfig(slot, syscall, 0); err != nil {
+			log.Errorf("Error while clearing the syscall arguments configuration: %s", err)
+		}
+	}
+
+	var mntnss []uint64
+	var mntns uint64
+	var traces uint8
+	iter := t.argsMntnsMap.Iterate()
+	for iter.Next(&mntns, &traces) {
+		if traces&(1<<slot) != 0 {
+			mntnss = append(mntnss, mntns)
+		}
+	}
+	for _, mntns := range mntnss {
+		if err := t.setArgsMntns(slot, mntns, false); err != nil {
+			log.Errorf("Error while clearing the syscall arguments mount namespaces: %s", err)
+		}
+	}
+
+	t.deleteArgs(slot, func(uint64) bool { return true })
+
+	if err := t.argTraceValuesMap.Put(uint32(slot), uint32(0)); err != nil {
+		log.Errorf("Error while resetting the syscall argument values counter: %s", err)
+	}
+}
+
+// deleteArgs removes the values recorded in the slot for the mount
+// namespaces matching the filter and returns how many of them were removed.
+func (t *Tracer) deleteArgs(slot int, filter func(mntns uint64) bool) uint32 {
+	// Deleting the current key while iterating could make the iteration
+	// restart, so collect the keys first.
+	var countKeys []seccompSyscallArgKey
+	var countKey seccompSyscallArgKey
+	var count uint32
+	iter := t.argCountsMap.Iterate()
+	for iter.Next(&countKey, &count) {
+		if int(countKey.Trace) == slot && filter(countKey.Mntns) {
+			countKeys = append(countKeys, countKey)
+		}
+	}
+	for _, key := range countKeys {
+		t.argCountsMap.Delete(key)
+	}
+
+	var valueKeys []seccompSyscallArgValueKey
+	var valueKey seccompSyscallArgValueKey
+	var present uint8
+	iter = t.argValuesMap.Iterate()
+	for iter.Next(&valueKey, &present) {
+		if int(valueKey.Trace) == slot && filter(valueKey.Mntns) {
+			valueKeys = append(valueKeys, valueKey)
+		}
+	}
+	deleted := uint32(0)
+	for _, key := range valueKeys {
+		if err := t.argValuesMap.Delete(key); err == nil {
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// PeekArgs returns the values of the arguments recorded in the slot for the
+// given mount namespace.
+func (t *Tracer) PeekArgs(slot int, mntns uint64) Args {
+	args := Args{}
+
+	getArgValues := func(syscall uint32, arg uint16) *ArgValues {
+		if _, ok := args[int(syscall)]; !ok {
+			args[int(syscall)] = map[int]*ArgValues{}
+		}
+		values, ok := args[int(syscall)][int(arg)]
+		if !ok {
+			values = &ArgValues{}
+			args[int(syscall)][int(arg)] = values
+		}
+		return values
+	}
+
+	var countKey seccompSyscallArgKey
+	var count uint32
+	iter := t.argCountsMap.Iterate()
+	for iter.Next(&countKey, &count) {
+		if int(countKey.Trace) != slot || countKey.Mntns != mntns {
+			continue
+		}
+		if count > SyscallArgMaxValues {
+			getArgValues(countKey.Syscall, countKey.Arg).Overflow = true
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Errorf("Error while iterating the syscall argument counts: %s", err)
+	}
+
+	var valueKey seccompSyscallArgValueKey
+	var present uint8
+	iter = t.argValuesMap.Iterate()
+	for iter.Next(&valueKey, &present) {
+		if int(valueKey.Trace) != slot || valueKey.Mntns != mntns {
+			continue
+		}
+		values := getArgValues(valueKey.Syscall, valueKey.Arg)
+		values.Values = append(values.Values, valueKey.Value)
+	}
+	if err := iter.Err(); err != nil {
+		log.Errorf("Error while iterating the syscall argument values: %s", err)
+	}
+
+	for _, syscallArgs := range args {
+		for _, values := range syscallArgs {
+			sort.Slice(values.Values, func(i, j int) bool {
+				return values.Values[i] < values.Values[j]
+			})
+		}
+	}
+
+	return args
+}
+
+// DeleteArgs stops recording the arguments of the given mount namespace in the
+// slot and removes their values, giving back their room to the slot.
+func (t *Tracer) DeleteArgs(slot int, mntns uint64) {
+	t.argsMu.Lock()
+	defer t.argsMu.Unlock()
+
+	if err := t.setArgsMntns(slot, mntns, false); err != nil {
+		log.Errorf("Error while removing the syscall arguments mount namespace: %s", err)
+	}
+
+	deleted := t.deleteArgs(slot, func(m uint64) bool { return m == mntns })
+	if deleted == 0 {
+		return
+	}
+
+	// The BPF program could record values in the meantime, the counter
+	// is only an approximation to split the map between the slots.
+	var used uint32
+	if err := t.argTraceValuesMap.Lookup(uint32(slot), &used); err != nil {
+		log.Errorf("Error while looking up the syscall argument values counter: %s", err)
+		return
+	}
+	if deleted > used {
+		deleted = used
+	}
+	if err := t.argTraceValuesMap.Put(uint32(slot), used-deleted); err != nil {
+		log.Errorf("Error while updating the syscall argument values counter: %s", err)
+	}
+}
+
 func (t *Tracer) Delete(mntns uint64) {
 	t.seccompMap.Delete(mntns)
 }
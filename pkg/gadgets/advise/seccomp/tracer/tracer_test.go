@@ -0,0 +1,102 @@
+//go:build linux
+// +build linux
+
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+	utilstest "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/test"
+)
+
+func TestSeccompTracerCreate(t *testing.T) {
+	t.Parallel()
+
+	utilstest.RequireRoot(t)
+
+	tracer := createTracer(t)
+	if tracer == nil {
+		t.Fatal("Returned tracer was nil")
+	}
+}
+
+func createTracer(t *testing.T) *tracer.Tracer {
+	t.Helper()
+
+	tracer, err := tracer.NewTracer()
+	if err != nil {
+		t.Fatalf("Error creating tracer: %s", err)
+	}
+	t.Cleanup(tracer.Close)
+
+	return tracer
+}
+
+func openSocket() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return err
+	}
+	return unix.Close(fd)
+}
+
+func TestSeccompTracerRecordArgsOf(t *testing.T) {
+	t.Parallel()
+
+	utilstest.RequireRoot(t)
+
+	seccompTracer := createTracer(t)
+
+	slot, err := seccompTracer.RecordArgs(map[int]uint8{unix.SYS_SOCKET: 1 << 0})
+	if err != nil {
+		t.Fatalf("Error recording arguments: %s", err)
+	}
+	t.Cleanup(func() { seccompTracer.StopRecordingArgs(slot) })
+
+	recorded := utilstest.NewRunnerWithTest(t, nil)
+	other := utilstest.NewRunnerWithTest(t, nil)
+
+	if err := seccompTracer.RecordArgsOf(slot, recorded.Info.MountNsID); err != nil {
+		t.Fatalf("Error recording arguments of mount namespace: %s", err)
+	}
+
+	utilstest.RunWithRunner(t, recorded, openSocket)
+	utilstest.RunWithRunner(t, other, openSocket)
+
+	expected := tracer.Args{
+		unix.SYS_SOCKET: {0: {Values: []uint64{unix.AF_INET}}},
+	}
+	if args := seccompTracer.PeekArgs(slot, recorded.Info.MountNsID); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Recorded arguments: expected %v, got %v", expected, args)
+	}
+
+	// The other mount namespace mustn't use up the values of the trace.
+	if args := seccompTracer.PeekArgs(slot, other.Info.MountNsID); len(args) != 0 {
+		t.Fatalf("Arguments of another mount namespace recorded: %v", args)
+	}
+
+	// Once deleted, the mount namespace isn't recorded anymore.
+	seccompTracer.DeleteArgs(slot, recorded.Info.MountNsID)
+	utilstest.RunWithRunner(t, recorded, openSocket)
+	if args := seccompTracer.PeekArgs(slot, recorded.Info.MountNsID); len(args) != 0 {
+		t.Fatalf("Arguments of a deleted mount namespace recorded: %v", args)
+	}
+}
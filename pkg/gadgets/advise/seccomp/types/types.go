@@ -0,0 +1,38 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// ProfileDiff is the result of the comparison between the syscalls used by a
+// container and the ones allowed by a seccomp profile.
+type ProfileDiff struct {
+	// ID identifies the diff operation that produced this result.
+	ID string `json:"id"`
+
+	Node      string `json:"node,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	// Profile is the SeccompProfile or the file the syscalls were compared
+	// with.
+	Profile string `json:"profile,omitempty"`
+
+	// UsedNotAllowed are the syscalls used by the container that the profile
+	// doesn't allow.
+	UsedNotAllowed []string `json:"usedNotAllowed"`
+	// AllowedNotUsed are the syscalls explicitly allowed by the profile that
+	// the container never used.
+	AllowedNotUsed []string `json:"allowedNotUsed"`
+}
@@ -0,0 +1,229 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workload contains the helpers shared by the advisors recommending
+// changes to the containers of the workloads, e.g. their security context.
+package workload
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Pod contains the information about a pod needed by the advisors.
+type Pod struct {
+	// OwnerKind and OwnerName identify the workload of the pod, i.e. its
+	// highest owner.
+	OwnerKind string
+	OwnerName string
+
+	// Privileged contains the names of the containers running privileged.
+	Privileged map[string]bool
+
+	// MountPaths contains the paths where volumes are mounted, per
+	// container name.
+	MountPaths map[string][]string
+}
+
+// PodCache returns a function calling getPod only once per pod. Unknown pods,
+// for which getPod returns nil, are their own workload.
+func PodCache(getPod func(namespace, name string) *Pod) func(namespace, name string) *Pod {
+	pods := map[string]*Pod{}
+	return func(namespace, name string) *Pod {
+		podKey := namespace + "/" + name
+		pod, ok := pods[podKey]
+		if !ok {
+			pod = getPod(namespace, name)
+			if pod == nil {
+				pod = &Pod{OwnerKind: "Pod", OwnerName: name}
+			}
+			pods[podKey] = pod
+		}
+		return pod
+	}
+}
+
+// Record is an event of one of the gadgets, as recorded by the monitor
+// commands of the advisors combining several gadgets.
+type Record struct {
+	Gadget string          `json:"gadget"`
+	Event  json.RawMessage `json:"event"`
+}
+
+// LoadEvents reads the events recorded by a gadget, either as a JSON array or
+// as one JSON event per line.
+func LoadEvents[T any](buf []byte) ([]T, error) {
+	/* Try to read the file as an array */
+	events := []T{}
+	err := json.Unmarshal(buf, &events)
+	if err == nil {
+		return events, nil
+	}
+
+	/* If it fails, read by line */
+	events = nil
+	line := 0
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		var event T
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+		line++
+		err = json.Unmarshal([]byte(text), &event)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ID identifies the containers with the same name in all the pods of a
+// workload.
+type ID struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+}
+
+// Less orders the IDs by workload, then by container.
+func (id ID) Less(other ID) bool {
+	if id.Namespace != other.Namespace {
+		return id.Namespace < other.Namespace
+	}
+	if id.Kind != other.Kind {
+		return id.Kind < other.Kind
+	}
+	if id.Name != other.Name {
+		return id.Name < other.Name
+	}
+	return id.Container < other.Container
+}
+
+// Comment returns the comment line introducing the recommendation of the
+// container.
+func (id ID) Comment() string {
+	return fmt.Sprintf("# %s %s/%s, container %s\n", id.Kind, id.Namespace, id.Name, id.Container)
+}
+
+// SortedKeys returns the keys of m in increasing order.
+func SortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PodTemplatePath returns the path of the pod template in the workloads of the
+// given kind, nil if the kind has no pod template.
+func PodTemplatePath(kind string) []string {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "ReplicationController":
+		return []string{"spec", "template"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template"}
+	}
+	return nil
+}
+
+// ContainerPatch is the change recommended for a container of a workload.
+type ContainerPatch struct {
+	ID
+
+	// Comments are printed before the patch of the workload.
+	Comments string
+
+	// Fields are set in the container, in addition to its name.
+	Fields map[string]interface{}
+
+	// Volumes are added to the pod.
+	Volumes []v1.Volume
+}
+
+// FormatPatches returns, for each workload, the comments of its containers
+// followed by the kubectl command applying a strategic merge patch with their
+// changes. The patches of the containers of the same workload must follow
+// each other.
+func FormatPatches(patches []ContainerPatch) (out string) {
+	for i := 0; i < len(patches); {
+		first := patches[i]
+		j := i
+		for j < len(patches) &&
+			patches[j].Namespace == first.Namespace &&
+			patches[j].Kind == first.Kind &&
+			patches[j].Name == first.Name {
+			j++
+		}
+		workload := patches[i:j]
+		i = j
+
+		for _, p := range workload {
+			out += p.Comments
+		}
+
+		path := PodTemplatePath(first.Kind)
+		if path == nil {
+			out += fmt.Sprintf("# The security context of a %s can't be patched, it needs to be recreated\n", first.Kind)
+			continue
+		}
+
+		containers := []map[string]interface{}{}
+		volumes := []v1.Volume{}
+		for _, p := range workload {
+			container := map[string]interface{}{
+				"name": p.Container,
+			}
+			for k, v := range p.Fields {
+				container[k] = v
+			}
+			containers = append(containers, container)
+			volumes = append(volumes, p.Volumes...)
+		}
+		spec := map[string]interface{}{
+			"containers": containers,
+		}
+		if len(volumes) != 0 {
+			spec["volumes"] = volumes
+		}
+		var patch interface{} = map[string]interface{}{"spec": spec}
+		for k := len(path) - 1; k >= 0; k-- {
+			patch = map[string]interface{}{path[k]: patch}
+		}
+
+		jsonOutput, err := json.Marshal(patch)
+		if err != nil {
+			out += fmt.Sprintf("# Failed to marshal patch: %s\n", err)
+			continue
+		}
+		out += fmt.Sprintf("kubectl patch -n %s %s %s --type strategic --patch '%s'\n",
+			first.Namespace, strings.ToLower(first.Kind), first.Name, jsonOutput)
+	}
+	return
+}
@@ -41,3 +41,10 @@ func CloseLink(l link.Link) link.Link {
 type DataEnricher interface {
 	Enrich(event *types.CommonData, mountnsid uint64)
 }
+
+// NetNsEnricher is used to enrich events that aren't generated in the context
+// of a process, like the ones coming from the network stack, using the
+// network namespace instead of the mount namespace.
+type NetNsEnricher interface {
+	EnrichByNetNs(event *types.CommonData, netnsid uint64)
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadgets
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cilium/ebpf"
+)
+
+// NetNsFilter keeps the network namespaces a tracer reports events for in a
+// BPF hash map indexed by the network namespace inode. It's used by the
+// gadgets whose events don't happen in the context of a process and hence
+// can't be filtered by mount namespace. Several containers can share the
+// same network namespace, so each one is only removed from the map once it
+// has been removed as many times as it was added.
+type NetNsFilter struct {
+	m *ebpf.Map
+
+	mu    sync.Mutex
+	users map[uint64]int
+}
+
+func NewNetNsFilter(m *ebpf.Map) *NetNsFilter {
+	return &NetNsFilter{
+		m:     m,
+		users: make(map[uint64]int),
+	}
+}
+
+// Add starts reporting the events of the given network namespace.
+func (f *NetNsFilter) Add(netns uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.users[netns] == 0 {
+		one := uint32(1)
+		if err := f.m.Put(netns, one); err != nil {
+			return fmt.Errorf("adding network namespace %d to filter: %w", netns, err)
+		}
+	}
+	f.users[netns]++
+
+	return nil
+}
+
+// Remove stops reporting the events of the given network namespace.
+func (f *NetNsFilter) Remove(netns uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.users[netns] == 0 {
+		return fmt.Errorf("network namespace %d not in filter", netns)
+	}
+	f.users[netns]--
+	if f.users[netns] > 0 {
+		return nil
+	}
+
+	delete(f.users, netns)
+	if err := f.m.Delete(netns); err != nil {
+		return fmt.Errorf("removing network namespace %d from filter: %w", netns, err)
+	}
+
+	return nil
+}
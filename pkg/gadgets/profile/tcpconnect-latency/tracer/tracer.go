@@ -0,0 +1,187 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"encoding/json"
+	"math/bits"
+	"sort"
+	"sync"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	biolatencytypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/block-io/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/tcpconnect-latency/types"
+	tcpconnecttracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpconnect/tracer"
+	tcpconnecttypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcpconnect/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// maxSlots is the number of slots of the histograms, the same as the
+// block-io profile gadget.
+const maxSlots = 27
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type destinationKey struct {
+	daddr string
+	dport uint16
+}
+
+type podKey struct {
+	namespace string
+	pod       string
+}
+
+type histogram [maxSlots]uint64
+
+func (h *histogram) add(latency uint64) {
+	slot := 0
+	if latency > 0 {
+		slot = bits.Len64(latency) - 1
+	}
+	if slot >= maxSlots {
+		slot = maxSlots - 1
+	}
+	h[slot]++
+}
+
+func (h *histogram) data() []biolatencytypes.Data {
+	data := []biolatencytypes.Data{}
+	indexMax := 0
+	for i, val := range h {
+		if val > 0 {
+			indexMax = i
+		}
+
+		data = append(data, biolatencytypes.Data{
+			Count:         val,
+			IntervalStart: (uint64(1) << (i + 1)) >> 1,
+			IntervalEnd:   (uint64(1) << (i + 1)) - 1,
+		})
+	}
+
+	return data[:indexMax+1]
+}
+
+// Tracer aggregates the latencies reported by the tcpconnect tracer into
+// histograms per destination and per pod.
+type Tracer struct {
+	tracer *tcpconnecttracer.Tracer
+
+	mu           sync.Mutex
+	destinations map[destinationKey]*histogram
+	pods         map[podKey]*histogram
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricher) (*Tracer, error) {
+	t := &Tracer{
+		destinations: make(map[destinationKey]*histogram),
+		pods:         make(map[podKey]*histogram),
+	}
+
+	tracerConfig := &tcpconnecttracer.Config{
+		MountnsMap:       config.MountnsMap,
+		CalculateLatency: true,
+	}
+
+	var err error
+	t.tracer, err = tcpconnecttracer.NewTracer(tracerConfig, enricher, t.addEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Tracer) addEvent(event tcpconnecttypes.Event) {
+	// Errors and lost samples can't be reported in the histograms
+	if event.Type != eventtypes.NORMAL {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	dst := destinationKey{daddr: event.Daddr, dport: event.Dport}
+	h, ok := t.destinations[dst]
+	if !ok {
+		h = &histogram{}
+		t.destinations[dst] = h
+	}
+	h.add(event.Latency)
+
+	if event.Pod == "" {
+		return
+	}
+
+	pod := podKey{namespace: event.Namespace, pod: event.Pod}
+	h, ok = t.pods[pod]
+	if !ok {
+		h = &histogram{}
+		t.pods[pod] = h
+	}
+	h.add(event.Latency)
+}
+
+func (t *Tracer) Stop() (string, error) {
+	t.tracer.Stop()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := types.Report{
+		ValType:      "usecs",
+		Destinations: []types.Histogram{},
+		Pods:         []types.Histogram{},
+	}
+
+	for key, h := range t.destinations {
+		report.Destinations = append(report.Destinations, types.Histogram{
+			Daddr: key.daddr,
+			Dport: key.dport,
+			Data:  h.data(),
+		})
+	}
+	sort.Slice(report.Destinations, func(i, j int) bool {
+		a, b := report.Destinations[i], report.Destinations[j]
+		if a.Daddr != b.Daddr {
+			return a.Daddr < b.Daddr
+		}
+		return a.Dport < b.Dport
+	})
+
+	for key, h := range t.pods {
+		report.Pods = append(report.Pods, types.Histogram{
+			Namespace: key.namespace,
+			Pod:       key.pod,
+			Data:      h.data(),
+		})
+	}
+	sort.Slice(report.Pods, func(i, j int) bool {
+		a, b := report.Pods[i], report.Pods[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Pod < b.Pod
+	})
+
+	output, err := json.Marshal(report)
+
+	return string(output), err
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"reflect"
+	"testing"
+
+	biolatencytypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/block-io/types"
+)
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for _, latency := range []uint64{0, 1, 2, 3, 7, 1 << 40} {
+		h.add(latency)
+	}
+
+	data := h.data()
+	if len(data) != maxSlots {
+		t.Fatalf("expected %d slots, got %d", maxSlots, len(data))
+	}
+
+	expected := []biolatencytypes.Data{
+		{Count: 2, IntervalStart: 1, IntervalEnd: 1},
+		{Count: 2, IntervalStart: 2, IntervalEnd: 3},
+		{Count: 1, IntervalStart: 4, IntervalEnd: 7},
+	}
+	if !reflect.DeepEqual(data[:3], expected) {
+		t.Fatalf("expected %+v, got %+v", expected, data[:3])
+	}
+
+	// Values too big for the histogram are counted in the last slot
+	if data[maxSlots-1].Count != 1 {
+		t.Fatalf("expected 1 in the last slot, got %d", data[maxSlots-1].Count)
+	}
+
+	var empty histogram
+	if len(empty.data()) != 1 {
+		t.Fatalf("expected only the first slot for an empty histogram, got %+v", empty.data())
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	biolatencytypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/block-io/types"
+)
+
+// Histogram is the log2 distribution of the latency of the TCP connections
+// established by a pod or to a destination.
+type Histogram struct {
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Daddr     string `json:"daddr,omitempty"`
+	Dport     uint16 `json:"dport,omitempty"`
+
+	Data []biolatencytypes.Data `json:"data,omitempty"`
+}
+
+// Report contains the histograms of a node, grouped by destination and by pod.
+type Report struct {
+	ValType      string      `json:"valType,omitempty"`
+	Destinations []Histogram `json:"destinations,omitempty"`
+	Pods         []Histogram `json:"pods,omitempty"`
+}
@@ -11,6 +11,7 @@
 #define __GADGET_SOCKET_COMMON_H__
 
 #define AF_INET         2
+#define AF_INET6        10
 
 #define inet_daddr      sk.__sk_common.skc_daddr
 #define inet_rcv_saddr  sk.__sk_common.skc_rcv_saddr
@@ -20,14 +21,21 @@
 #define ir_num          req.__req_common.skc_num
 #define ir_rmt_addr     req.__req_common.skc_daddr
 #define ir_rmt_port     req.__req_common.skc_dport
+#define ir_v6_loc_addr  req.__req_common.skc_v6_rcv_saddr
+#define ir_v6_rmt_addr  req.__req_common.skc_v6_daddr
 
 #define sk_family       __sk_common.skc_family
 #define sk_state        __sk_common.skc_state
 #define sk_proto        __sk_common.sk_protocol
+#define sk_v6_rcv_saddr __sk_common.skc_v6_rcv_saddr
+#define sk_v6_daddr     __sk_common.skc_v6_daddr
 
 #define tw_daddr        __tw_common.skc_daddr
 #define tw_rcv_saddr    __tw_common.skc_rcv_saddr
 #define tw_dport        __tw_common.skc_dport
+#define tw_family       __tw_common.skc_family
+#define tw_v6_rcv_saddr __tw_common.skc_v6_rcv_saddr
+#define tw_v6_daddr     __tw_common.skc_v6_daddr
 
 /**
  * sock_i_ino - Returns the inode identifier associated to a socket.
@@ -75,4 +83,25 @@ static inline void socket_bpf_seq_print(struct seq_file *seq,
         bpf_ntohl(dest), bpf_ntohs(destp), state, ino);
 }
 
+/*
+ * Same as socket_bpf_seq_print() for IPv6 sockets, with "TCPv6" or "UDPv6"
+ * as protocol. Each 32-bit word of the addresses is printed in host-byte
+ * order too, so that the hexadecimal string reads as the address bytes.
+ */
+static inline void socket_bpf_seq_print6(struct seq_file *seq,
+                const char* protocol, const struct in6_addr *src,
+                const __u16 srcp, const struct in6_addr *dest,
+                const __u16 destp, const unsigned char state, long ino)
+{
+    /* bpf_seq_printf() takes at most 12 arguments, print it in two steps */
+    BPF_SEQ_PRINTF(seq, "%s %08X%08X%08X%08X %04X ", protocol,
+        bpf_ntohl(src->in6_u.u6_addr32[0]), bpf_ntohl(src->in6_u.u6_addr32[1]),
+        bpf_ntohl(src->in6_u.u6_addr32[2]), bpf_ntohl(src->in6_u.u6_addr32[3]),
+        bpf_ntohs(srcp));
+    BPF_SEQ_PRINTF(seq, "%08X%08X%08X%08X %04X %02X %lu\n",
+        bpf_ntohl(dest->in6_u.u6_addr32[0]), bpf_ntohl(dest->in6_u.u6_addr32[1]),
+        bpf_ntohl(dest->in6_u.u6_addr32[2]), bpf_ntohl(dest->in6_u.u6_addr32[3]),
+        bpf_ntohs(destp), state, ino);
+}
+
 #endif /* __GADGET_SOCKET_COMMON_H__ */
@@ -5,6 +5,7 @@
 /*
  * Inspired by the BPF selftests in the Linux tree:
  * https://github.com/torvalds/linux/blob/v5.13/tools/testing/selftests/bpf/progs/bpf_iter_tcp4.c
+ * https://github.com/torvalds/linux/blob/v5.13/tools/testing/selftests/bpf/progs/bpf_iter_tcp6.c
  */
 
 /*
@@ -20,6 +21,7 @@
 char _license[] SEC("license") = "GPL";
 
 static const char proto[] = "TCP";
+static const char proto6[] = "TCPv6";
 
 static int dump_tcp_sock(struct seq_file *seq, struct tcp_sock *tp)
 {
@@ -27,6 +29,13 @@ static int dump_tcp_sock(struct seq_file *seq, struct tcp_sock *tp)
 	const struct inet_sock *inet = &icsk->icsk_inet;
 	const struct sock *sp = &inet->sk;
 
+	if (sp->sk_family == AF_INET6) {
+		socket_bpf_seq_print6(seq, proto6, &sp->sk_v6_rcv_saddr,
+			inet->inet_sport, &sp->sk_v6_daddr,
+			inet->inet_dport, sp->sk_state, sock_i_ino(sp));
+		return 0;
+	}
+
 	socket_bpf_seq_print(seq, proto, inet->inet_rcv_saddr,
 		inet->inet_sport, inet->inet_daddr,
 		inet->inet_dport, sp->sk_state, sock_i_ino(sp));
@@ -38,6 +47,14 @@ static int dump_tw_sock(struct seq_file *seq, struct tcp_timewait_sock *ttw)
 {
 	struct inet_timewait_sock *tw = &ttw->tw_sk;
 
+	/* See below why the inode is 0 */
+	if (tw->tw_family == AF_INET6) {
+		socket_bpf_seq_print6(seq, proto6, &tw->tw_v6_rcv_saddr,
+			tw->tw_sport, &tw->tw_v6_daddr,
+			tw->tw_dport, tw->tw_substate, 0);
+		return 0;
+	}
+
 	socket_bpf_seq_print(seq, proto, tw->tw_rcv_saddr,
 		tw->tw_sport, tw->tw_daddr,
 		/*
@@ -62,6 +79,13 @@ static int dump_req_sock(struct seq_file *seq, struct tcp_request_sock *treq)
 {
 	struct inet_request_sock *irsk = &treq->req;
 
+	if (irsk->req.__req_common.skc_family == AF_INET6) {
+		socket_bpf_seq_print6(seq, proto6, &irsk->ir_v6_loc_addr,
+			irsk->ir_num, &irsk->ir_v6_rmt_addr, irsk->ir_rmt_port,
+			TCP_SYN_RECV, sock_i_ino(treq->req.req.sk));
+		return 0;
+	}
+
 	socket_bpf_seq_print(seq, proto, irsk->ir_loc_addr,
 		irsk->ir_num, irsk->ir_rmt_addr, irsk->ir_rmt_port,
 		TCP_SYN_RECV, sock_i_ino(treq->req.req.sk));
@@ -70,7 +94,7 @@ static int dump_req_sock(struct seq_file *seq, struct tcp_request_sock *treq)
 }
 
 SEC("iter/tcp")
-int ig_snap_tcp4(struct bpf_iter__tcp *ctx)
+int ig_snap_tcp(struct bpf_iter__tcp *ctx)
 {
 	struct sock_common *sk_common = ctx->sk_common;
 	struct seq_file *seq = ctx->meta->seq;
@@ -81,8 +105,7 @@ int ig_snap_tcp4(struct bpf_iter__tcp *ctx)
 	if (sk_common == (void *)0)
 		return 0;
 
-	/* Filter out IPv6 for now */
-	if (sk_common->skc_family != AF_INET)
+	if (sk_common->skc_family != AF_INET && sk_common->skc_family != AF_INET6)
 		return 0;
 
 	tp = bpf_skc_to_tcp_sock(sk_common);
@@ -5,6 +5,7 @@
 /*
  * Inspired by the BPF selftests in the Linux tree:
  * https://github.com/torvalds/linux/blob/v5.13/tools/testing/selftests/bpf/progs/bpf_iter_udp4.c
+ * https://github.com/torvalds/linux/blob/v5.13/tools/testing/selftests/bpf/progs/bpf_iter_udp6.c
  */
 
 /*
@@ -25,9 +26,10 @@
 char _license[] SEC("license") = "GPL";
 
 static const char proto[] = "UDP";
+static const char proto6[] = "UDPv6";
 
 SEC("iter/udp")
-int ig_snap_udp4(struct bpf_iter__udp *ctx)
+int ig_snap_udp(struct bpf_iter__udp *ctx)
 {
 	struct seq_file *seq = ctx->meta->seq;
 	struct udp_sock *udp_sk = ctx->udp_sk;
@@ -38,7 +40,13 @@ int ig_snap_udp4(struct bpf_iter__udp *ctx)
 
 	inet = &udp_sk->inet;
 
-	/* Filter out IPv6 for now */
+	if (inet->sk.sk_family == AF_INET6) {
+		socket_bpf_seq_print6(seq, proto6, &inet->sk.sk_v6_rcv_saddr,
+			inet->inet_sport, &inet->sk.sk_v6_daddr,
+			inet->inet_dport, inet->sk.sk_state, sock_i_ino(&inet->sk));
+		return 0;
+	}
+
 	if (inet->sk.sk_family != AF_INET)
 		return 0;
 
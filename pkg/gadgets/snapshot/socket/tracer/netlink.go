@@ -0,0 +1,193 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	socketcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/netnsenter"
+)
+
+// Kernels without BPF socket iterators can still list the sockets of a
+// network namespace using NETLINK_SOCK_DIAG, which is what ss does.
+
+const (
+	// sizeof(struct inet_diag_req_v2)
+	sizeofInetDiagReq = 56
+	// sizeof(struct inet_diag_msg)
+	sizeofInetDiagMsg = 72
+
+	// From include/net/tcp_states.h
+	tcpSynRecv    = 3
+	tcpNewSynRecv = 12
+)
+
+// inetDiagReq is the struct inet_diag_req_v2 used to dump all the sockets of
+// a family and protocol.
+type inetDiagReq struct {
+	family   uint8
+	protocol uint8
+	states   uint32
+}
+
+func (r *inetDiagReq) Len() int {
+	return sizeofInetDiagReq
+}
+
+func (r *inetDiagReq) Serialize() []byte {
+	b := make([]byte, sizeofInetDiagReq)
+	b[0] = r.family
+	b[1] = r.protocol
+	nl.NativeEndian().PutUint32(b[4:], r.states)
+	// The socket ID is ignored when dumping sockets
+	return b
+}
+
+// parseInetDiagMsg parses a struct inet_diag_msg describing an IPv4 or IPv6
+// socket.
+func parseInetDiagMsg(data []byte, protocol string) (*socketcollectortypes.Event, error) {
+	if len(data) < sizeofInetDiagMsg {
+		return nil, fmt.Errorf("short inet_diag_msg: %d bytes", len(data))
+	}
+
+	// Addresses are stored in 16 bytes, IPv4 ones only use the first 4
+	ipLen := net.IPv4len
+	if data[0] == unix.AF_INET6 {
+		ipLen = net.IPv6len
+	}
+
+	state := data[1]
+	// The BPF iterator reports request sockets as SYN_RECV, do the same
+	if state == tcpNewSynRecv {
+		state = tcpSynRecv
+	}
+	status, err := parseStatus(protocol, state)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ports and addresses are in network byte order, the rest in host one
+	return &socketcollectortypes.Event{
+		Protocol:      protocol,
+		LocalAddress:  net.IP(data[8 : 8+ipLen]).String(),
+		LocalPort:     binary.BigEndian.Uint16(data[4:6]),
+		RemoteAddress: net.IP(data[24 : 24+ipLen]).String(),
+		RemotePort:    binary.BigEndian.Uint16(data[6:8]),
+		Status:        status,
+		InodeNumber:   uint64(nl.NativeEndian().Uint32(data[68:72])),
+	}, nil
+}
+
+// dumpInetSockets returns the sockets of the given family and protocol in the
+// network namespace of the calling thread.
+func dumpInetSockets(family, ipProto uint8, protocol string) ([]socketcollectortypes.Event, error) {
+	s, err := nl.Subscribe(unix.NETLINK_SOCK_DIAG)
+	if err != nil {
+		return nil, fmt.Errorf("creating sock_diag netlink socket: %w", err)
+	}
+	defer s.Close()
+
+	req := nl.NewNetlinkRequest(nl.SOCK_DIAG_BY_FAMILY, unix.NLM_F_DUMP)
+	req.AddData(&inetDiagReq{
+		family:   family,
+		protocol: ipProto,
+		states:   ^uint32(0),
+	})
+	if err := s.Send(req); err != nil {
+		return nil, fmt.Errorf("sending sock_diag request: %w", err)
+	}
+
+	sockets := []socketcollectortypes.Event{}
+	for {
+		msgs, from, err := s.Receive()
+		if err != nil {
+			return nil, fmt.Errorf("receiving sock_diag response: %w", err)
+		}
+		if from.Pid != nl.PidKernel {
+			return nil, fmt.Errorf("wrong sender portid %d, expected %d", from.Pid, nl.PidKernel)
+		}
+		if len(msgs) == 0 {
+			return nil, errors.New("no message nor error from netlink")
+		}
+
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case unix.NLMSG_DONE:
+				return sockets, nil
+			case unix.NLMSG_ERROR:
+				errno := int32(nl.NativeEndian().Uint32(m.Data[0:4]))
+				return nil, fmt.Errorf("sock_diag request failed: %w", syscall.Errno(-errno))
+			}
+
+			socket, err := parseInetDiagMsg(m.Data, protocol)
+			if err != nil {
+				return nil, err
+			}
+			sockets = append(sockets, *socket)
+		}
+	}
+}
+
+// netlinkDumps lists the sock_diag dumps needed for each protocol.
+var netlinkDumps = []struct {
+	proto    socketcollectortypes.Proto
+	family   uint8
+	ipProto  uint8
+	protocol string
+}{
+	{socketcollectortypes.TCP4, unix.AF_INET, unix.IPPROTO_TCP, "TCP"},
+	{socketcollectortypes.TCP6, unix.AF_INET6, unix.IPPROTO_TCP, "TCPv6"},
+	{socketcollectortypes.UDP4, unix.AF_INET, unix.IPPROTO_UDP, "UDP"},
+	{socketcollectortypes.UDP6, unix.AF_INET6, unix.IPPROTO_UDP, "UDPv6"},
+}
+
+// getNetlinkSockets returns the TCP and UDP sockets of the network namespace
+// of the given process using NETLINK_SOCK_DIAG.
+func getNetlinkSockets(pid uint32, proto socketcollectortypes.Proto) ([]socketcollectortypes.Event, error) {
+	sockets := []socketcollectortypes.Event{}
+
+	err := netnsenter.NetnsEnter(int(pid), func() error {
+		for _, dump := range netlinkDumps {
+			if !proto.Includes(dump.proto) {
+				continue
+			}
+
+			dumpSockets, err := dumpInetSockets(dump.family, dump.ipProto, dump.protocol)
+			if err != nil {
+				// The family isn't supported if IPv6 is disabled
+				if dump.family == unix.AF_INET6 && errors.Is(err, syscall.ENOENT) {
+					continue
+				}
+				return err
+			}
+			sockets = append(sockets, dumpSockets...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sockets, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+
+	socketcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/types"
+)
+
+func TestParseInetDiagMsg(t *testing.T) {
+	msg := make([]byte, sizeofInetDiagMsg)
+	msg[0] = 2 // AF_INET
+	msg[1] = tcpNewSynRecv
+	msg[4], msg[5] = 0x1f, 0x90 // 8080
+	msg[6], msg[7] = 0xd4, 0x31 // 54321
+	copy(msg[8:], []byte{10, 0, 0, 1})
+	copy(msg[24:], []byte{192, 168, 1, 2})
+	nl.NativeEndian().PutUint32(msg[68:], 31337)
+
+	socket, err := parseInetDiagMsg(msg, "TCP")
+	if err != nil {
+		t.Fatalf("parsing message: %s", err)
+	}
+
+	expected := &socketcollectortypes.Event{
+		Protocol:      "TCP",
+		LocalAddress:  "10.0.0.1",
+		LocalPort:     8080,
+		RemoteAddress: "192.168.1.2",
+		RemotePort:    54321,
+		Status:        "SYN_RECV",
+		InodeNumber:   31337,
+	}
+	if !reflect.DeepEqual(socket, expected) {
+		t.Fatalf("%+v != %+v", socket, expected)
+	}
+
+	msg6 := make([]byte, sizeofInetDiagMsg)
+	msg6[0] = 10                  // AF_INET6
+	msg6[1] = 7                   // TCP_CLOSE
+	msg6[4], msg6[5] = 0x00, 0x35 // 53
+	copy(msg6[8:], []byte{0x20, 0x01, 0x0d, 0xb8, 15: 1})
+
+	socket, err = parseInetDiagMsg(msg6, "UDPv6")
+	if err != nil {
+		t.Fatalf("parsing message: %s", err)
+	}
+
+	expected = &socketcollectortypes.Event{
+		Protocol:      "UDPv6",
+		LocalAddress:  "2001:db8::1",
+		LocalPort:     53,
+		RemoteAddress: "::",
+		Status:        "INACTIVE",
+	}
+	if !reflect.DeepEqual(socket, expected) {
+		t.Fatalf("%+v != %+v", socket, expected)
+	}
+
+	if _, err := parseInetDiagMsg(msg[:10], "TCP"); err == nil {
+		t.Fatalf("expected error for short message")
+	}
+}
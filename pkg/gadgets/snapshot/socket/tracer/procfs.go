@@ -0,0 +1,396 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	socketcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/types"
+)
+
+// The BPF socket iterators only handle TCP and UDP sockets: there is no
+// iterator for raw sockets and the UNIX one needs a recent kernel (5.17).
+// The other sockets are read from the /proc/<pid>/net files instead, which
+// show the sockets of the network namespace the process belongs to.
+
+// procNetFile describes a file of /proc/<pid>/net listing sockets.
+type procNetFile struct {
+	name     string
+	protocol string
+}
+
+var procNetFiles = map[socketcollectortypes.Proto][]procNetFile{
+	socketcollectortypes.RAW:  {{"raw", "RAW"}, {"raw6", "RAWv6"}},
+	socketcollectortypes.UNIX: {{"unix", "UNIX"}},
+}
+
+// procNetPath returns the path of the given file of /proc/<pid>/net, using
+// the current process when pid is 0.
+func procNetPath(pid uint32, name string) string {
+	if pid == 0 {
+		return filepath.Join("/proc/self/net", name)
+	}
+	return filepath.Join("/proc", strconv.FormatUint(uint64(pid), 10), "net", name)
+}
+
+// parseProcNetIP parses an IPv4 or IPv6 address as printed in /proc/net/raw
+// and /proc/net/raw6, i.e. as 32-bit words in host byte order. As for the BPF
+// programs, only little endian hosts are supported.
+func parseProcNetIP(s string) (string, error) {
+	words, err := hex.DecodeString(s)
+	if err != nil || (len(words) != net.IPv4len && len(words) != net.IPv6len) {
+		return "", fmt.Errorf("invalid IP address %q", s)
+	}
+
+	ip := make(net.IP, len(words))
+	for i := 0; i < len(words); i += 4 {
+		binary.LittleEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(words[i:]))
+	}
+
+	return ip.String(), nil
+}
+
+// parseAddress parses an "address:port" pair as printed in /proc/net/raw and
+// /proc/net/raw6.
+func parseAddress(s string) (string, uint16, error) {
+	addr, portStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+
+	port, err := strconv.ParseUint(portStr, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in address %q", s)
+	}
+
+	ip, err := parseProcNetIP(addr)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return ip, uint16(port), nil
+}
+
+// parseProcNetInet parses the content of /proc/net/{tcp,udp,raw}[6].
+func parseProcNetInet(r io.Reader, protocol string) ([]socketcollectortypes.Event, error) {
+	sockets := []socketcollectortypes.Event{}
+
+	// RAW sockets use the same states as UDP ones
+	statusProto := strings.TrimSuffix(protocol, "v6")
+	if statusProto == "RAW" {
+		statusProto = "UDP"
+	}
+
+	scanner := bufio.NewScanner(r)
+	// Skip header
+	scanner.Scan()
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			return nil, fmt.Errorf("invalid %s socket line %q", protocol, scanner.Text())
+		}
+
+		localAddress, localPort, err := parseAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		remoteAddress, remotePort, err := parseAddress(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		state, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s socket state %q", protocol, fields[3])
+		}
+		status, err := parseStatus(statusProto, uint8(state))
+		if err != nil {
+			return nil, err
+		}
+
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s socket inode %q", protocol, fields[9])
+		}
+
+		sockets = append(sockets, socketcollectortypes.Event{
+			Protocol:      protocol,
+			LocalAddress:  localAddress,
+			LocalPort:     localPort,
+			RemoteAddress: remoteAddress,
+			RemotePort:    remotePort,
+			Status:        status,
+			InodeNumber:   inode,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s sockets: %w", protocol, err)
+	}
+
+	return sockets, nil
+}
+
+// From include/uapi/linux/net.h
+const (
+	unixListening     = 1 << 16 // __SO_ACCEPTCON
+	unixUnconnected   = 1
+	unixConnecting    = 2
+	unixConnected     = 3
+	unixDisconnecting = 4
+)
+
+func parseUnixStatus(flags, state uint64) string {
+	if flags&unixListening != 0 {
+		return "LISTEN"
+	}
+
+	switch state {
+	case unixUnconnected:
+		return "UNCONNECTED"
+	case unixConnecting:
+		return "CONNECTING"
+	case unixConnected:
+		return "CONNECTED"
+	case unixDisconnecting:
+		return "DISCONNECTING"
+	}
+
+	return "UNKNOWN"
+}
+
+// parseProcNetUnix parses the content of /proc/net/unix. The path of the
+// socket, if any, is used as local address.
+func parseProcNetUnix(r io.Reader) ([]socketcollectortypes.Event, error) {
+	sockets := []socketcollectortypes.Event{}
+
+	scanner := bufio.NewScanner(r)
+	// Skip header
+	scanner.Scan()
+	for scanner.Scan() {
+		// Num RefCount Protocol Flags Type St Inode Path
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("invalid UNIX socket line %q", scanner.Text())
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UNIX socket flags %q", fields[3])
+		}
+		state, err := strconv.ParseUint(fields[5], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UNIX socket state %q", fields[5])
+		}
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UNIX socket inode %q", fields[6])
+		}
+
+		path := strings.Join(fields[7:], " ")
+
+		sockets = append(sockets, socketcollectortypes.Event{
+			Protocol:     "UNIX",
+			LocalAddress: path,
+			Status:       parseUnixStatus(flags, state),
+			InodeNumber:  inode,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading UNIX sockets: %w", err)
+	}
+
+	return sockets, nil
+}
+
+// getProcNetSockets returns the sockets of the given protocol listed in the
+// /proc/<pid>/net files.
+func getProcNetSockets(pid uint32, proto socketcollectortypes.Proto) ([]socketcollectortypes.Event, error) {
+	sockets := []socketcollectortypes.Event{}
+
+	for _, file := range procNetFiles[proto] {
+		f, err := os.Open(procNetPath(pid, file.name))
+		if err != nil {
+			// The file doesn't exist if the protocol isn't supported, e.g.
+			// if IPv6 is disabled.
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("opening %s sockets file: %w", file.protocol, err)
+		}
+
+		var fileSockets []socketcollectortypes.Event
+		if proto == socketcollectortypes.UNIX {
+			fileSockets, err = parseProcNetUnix(f)
+		} else {
+			fileSockets, err = parseProcNetInet(f, file.protocol)
+		}
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		sockets = append(sockets, fileSockets...)
+	}
+
+	return sockets, nil
+}
+
+// socketOwner is a process having a socket open.
+type socketOwner struct {
+	pid  uint32
+	comm string
+	uid  uint32
+}
+
+func namespaceInode(path string) (uint64, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Ino, nil
+}
+
+// readOwner returns the command and the real UID of a process.
+func readOwner(procDir string, pid uint32) (*socketOwner, error) {
+	comm, err := os.ReadFile(filepath.Join(procDir, "comm"))
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := os.ReadFile(filepath.Join(procDir, "status"))
+	if err != nil {
+		return nil, err
+	}
+
+	owner := &socketOwner{
+		pid:  pid,
+		comm: strings.TrimSuffix(string(comm), "\n"),
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "Uid:" {
+			uid, err := strconv.ParseUint(fields[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid uid %q", fields[1])
+			}
+			owner.uid = uint32(uid)
+			break
+		}
+	}
+
+	return owner, nil
+}
+
+// getSocketOwners returns the processes in the given network namespace
+// having sockets open, indexed by the inode of the sockets. When several
+// processes share a socket, the one with the lowest PID is used.
+func getSocketOwners(netns uint64) map[uint64]*socketOwner {
+	owners := make(map[uint64]*socketOwner)
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+
+	// Entries are sorted by name, not numerically
+	for _, entry := range entries {
+		pid, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+
+		// Processes can go away at any time, just skip them
+		procDir := filepath.Join("/proc", entry.Name())
+		if ns, err := namespaceInode(filepath.Join(procDir, "ns", "net")); err != nil || ns != netns {
+			continue
+		}
+
+		fds, err := os.ReadDir(filepath.Join(procDir, "fd"))
+		if err != nil {
+			continue
+		}
+
+		var owner *socketOwner
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(procDir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+
+			if current, ok := owners[inode]; ok && current.pid < uint32(pid) {
+				continue
+			}
+
+			if owner == nil {
+				if owner, err = readOwner(procDir, uint32(pid)); err != nil {
+					break
+				}
+			}
+			owners[inode] = owner
+		}
+	}
+
+	return owners
+}
+
+// addSocketOwners fills in the network namespace of the sockets and the
+// information about the processes owning them.
+func addSocketOwners(pid uint32, sockets []socketcollectortypes.Event) error {
+	nsPath := "/proc/self/ns/net"
+	if pid != 0 {
+		nsPath = filepath.Join("/proc", strconv.FormatUint(uint64(pid), 10), "ns", "net")
+	}
+
+	netns, err := namespaceInode(nsPath)
+	if err != nil {
+		return fmt.Errorf("getting network namespace of pid %d: %w", pid, err)
+	}
+
+	owners := getSocketOwners(netns)
+	for i := range sockets {
+		sockets[i].Netns = netns
+
+		// Sockets in TIME_WAIT state don't have an inode
+		if sockets[i].InodeNumber == 0 {
+			continue
+		}
+		if owner, ok := owners[sockets[i].InodeNumber]; ok {
+			sockets[i].Pid = owner.pid
+			sockets[i].Comm = owner.comm
+			uid := owner.uid
+			sockets[i].UID = &uid
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	socketcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/types"
+)
+
+func TestParseProcNetInet(t *testing.T) {
+	content := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 31337 1 0000000000000000 100 0 0 10 0
+   1: 0000000000000000FFFF00000100007F:1F90 0000000000000000FFFF00000100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 31338 1 0000000000000000 20 4 30 10 -1
+   2: B80D0120000000000000000001000000:0035 00000000000000000000000000000000:0000 06 00000000:00000000 03:00000F0D 00000000     0        0 0 3 0000000000000000
+`
+
+	sockets, err := parseProcNetInet(strings.NewReader(content), "TCPv6")
+	if err != nil {
+		t.Fatalf("parsing sockets: %s", err)
+	}
+
+	expected := []socketcollectortypes.Event{
+		{
+			Protocol:      "TCPv6",
+			LocalAddress:  "::",
+			LocalPort:     80,
+			RemoteAddress: "::",
+			Status:        "LISTEN",
+			InodeNumber:   31337,
+		},
+		{
+			Protocol:      "TCPv6",
+			LocalAddress:  "127.0.0.1",
+			LocalPort:     8080,
+			RemoteAddress: "127.0.0.1",
+			RemotePort:    54321,
+			Status:        "ESTABLISHED",
+			InodeNumber:   31338,
+		},
+		{
+			Protocol:      "TCPv6",
+			LocalAddress:  "2001:db8::1",
+			LocalPort:     53,
+			RemoteAddress: "::",
+			Status:        "TIME_WAIT",
+		},
+	}
+	if !reflect.DeepEqual(sockets, expected) {
+		t.Fatalf("%+v != %+v", sockets, expected)
+	}
+
+	udp := `   sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  1: 00000000000000000000000000000000:14E9 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 4242 2 0000000000000000 0
+`
+	sockets, err = parseProcNetInet(strings.NewReader(udp), "UDPv6")
+	if err != nil {
+		t.Fatalf("parsing sockets: %s", err)
+	}
+	if len(sockets) != 1 || sockets[0].Status != "INACTIVE" || sockets[0].LocalPort != 5353 {
+		t.Fatalf("unexpected sockets: %+v", sockets)
+	}
+
+	raw := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  1: 0100007F:0001 0200000A:0000 07 00000000:00000000 00:00000000 00000000     0        0 5151 2 0000000000000000 0
+`
+	sockets, err = parseProcNetInet(strings.NewReader(raw), "RAW")
+	if err != nil {
+		t.Fatalf("parsing sockets: %s", err)
+	}
+	if len(sockets) != 1 || sockets[0].LocalAddress != "127.0.0.1" || sockets[0].RemoteAddress != "10.0.0.2" {
+		t.Fatalf("unexpected sockets: %+v", sockets)
+	}
+
+	if _, err := parseProcNetInet(strings.NewReader("header\n 0: foo bar\n"), "TCPv6"); err == nil {
+		t.Fatalf("expected error for invalid line")
+	}
+}
+
+func TestParseProcNetUnix(t *testing.T) {
+	content := `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 21601 /run/nginx.sock
+0000000000000000: 00000003 00000000 00000000 0001 03 21602
+0000000000000000: 00000002 00000000 00000000 0002 01  1234 @abstract name
+`
+
+	sockets, err := parseProcNetUnix(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parsing sockets: %s", err)
+	}
+
+	expected := []socketcollectortypes.Event{
+		{Protocol: "UNIX", LocalAddress: "/run/nginx.sock", Status: "LISTEN", InodeNumber: 21601},
+		{Protocol: "UNIX", Status: "CONNECTED", InodeNumber: 21602},
+		{Protocol: "UNIX", LocalAddress: "@abstract name", Status: "UNCONNECTED", InodeNumber: 1234},
+	}
+	if !reflect.DeepEqual(sockets, expected) {
+		t.Fatalf("%+v != %+v", sockets, expected)
+	}
+}
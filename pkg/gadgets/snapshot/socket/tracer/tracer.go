@@ -17,18 +17,23 @@ package tracer
 import (
 	"bufio"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 
+	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
+	log "github.com/sirupsen/logrus"
 
 	socketcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/netnsenter"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
-//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang IterTCPv4 ./bpf/tcp4-collector.c -- -I../../../../${TARGET} -Werror -O2 -g -c -x c
-//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang IterUDPv4 ./bpf/udp4-collector.c -- -I../../../../${TARGET} -Werror -O2 -g -c -x c
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang IterTCP ./bpf/tcp-collector.c -- -I../../../../${TARGET} -Werror -O2 -g -c -x c
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang IterUDP ./bpf/udp-collector.c -- -I../../../../${TARGET} -Werror -O2 -g -c -x c
 
 func parseIPv4(ipU32 uint32) string {
 	ipBytes := make([]byte, 4)
@@ -41,6 +46,17 @@ func parseIPv4(ipU32 uint32) string {
 	return ip.String()
 }
 
+// parseIterAddress parses an IPv4 or IPv6 address as printed by
+// socket_bpf_seq_print() and socket_bpf_seq_print6() in bpf/socket_common.h.
+func parseIterAddress(s string) (string, error) {
+	ip, err := hex.DecodeString(s)
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+
+	return net.IP(ip).String(), nil
+}
+
 // Format from socket_bpf_seq_print() in bpf/socket_common.h
 func parseStatus(proto string, statusUint uint8) (string, error) {
 	statusMap := [...]string{
@@ -56,7 +72,7 @@ func parseStatus(proto string, statusUint uint8) (string, error) {
 	status := statusMap[statusUint-1]
 
 	// Transform TCP status into something more suitable for UDP
-	if proto == "UDP" {
+	if proto == "UDP" || proto == "UDPv6" {
 		switch status {
 		case "ESTABLISHED":
 			status = "ACTIVE"
@@ -71,14 +87,14 @@ func parseStatus(proto string, statusUint uint8) (string, error) {
 }
 
 func getTCPIter() (*link.Iter, error) {
-	objs := IterTCPv4Objects{}
-	if err := LoadIterTCPv4Objects(&objs, nil); err != nil {
+	objs := IterTCPObjects{}
+	if err := LoadIterTCPObjects(&objs, nil); err != nil {
 		return nil, fmt.Errorf("failed to load TCP BPF objects: %w", err)
 	}
 	defer objs.Close()
 
 	it, err := link.AttachIter(link.IterOptions{
-		Program: objs.IgSnapTcp4,
+		Program: objs.IgSnapTcp,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to attach TCP BPF iterator: %w", err)
@@ -88,14 +104,14 @@ func getTCPIter() (*link.Iter, error) {
 }
 
 func getUDPIter() (*link.Iter, error) {
-	objs := IterUDPv4Objects{}
-	if err := LoadIterUDPv4Objects(&objs, nil); err != nil {
+	objs := IterUDPObjects{}
+	if err := LoadIterUDPObjects(&objs, nil); err != nil {
 		return nil, fmt.Errorf("failed to load UDP BPF objects: %w", err)
 	}
 	defer objs.Close()
 
 	it, err := link.AttachIter(link.IterOptions{
-		Program: objs.IgSnapUdp4,
+		Program: objs.IgSnapUdp,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to attach UDP BPF iterator: %w", err)
@@ -104,7 +120,67 @@ func getUDPIter() (*link.Iter, error) {
 	return it, nil
 }
 
-func RunCollector(pid uint32, podname, namespace, node string, proto socketcollectortypes.Proto) ([]socketcollectortypes.Event, error) {
+// iteratorsUnsupported is set once the kernel is known not to support the
+// BPF socket iterators. Other errors, like a lack of memory, aren't cached.
+var iteratorsUnsupported uint32
+
+// iterProtocols maps the protocols printed by the BPF iterators to the ones
+// of the sockets.
+var iterProtocols = map[string]socketcollectortypes.Proto{
+	"TCP":   socketcollectortypes.TCP4,
+	"TCPv6": socketcollectortypes.TCP6,
+	"UDP":   socketcollectortypes.UDP4,
+	"UDPv6": socketcollectortypes.UDP6,
+}
+
+// parseIterSocket parses a line printed by the BPF iterators, see
+// socket_bpf_seq_print() in bpf/socket_common.h.
+func parseIterSocket(line string) (*socketcollectortypes.Event, socketcollectortypes.Proto, error) {
+	var protocol, src, dest string
+	var destp, srcp uint16
+	var hexStatus uint8
+	var inodeNumber uint64
+
+	// IP addresses and ports are in host-byte order
+	len, err := fmt.Sscanf(line, "%s %s %04X %s %04X %02X %d",
+		&protocol, &src, &srcp, &dest, &destp, &hexStatus, &inodeNumber)
+	if err != nil || len != 7 {
+		return nil, socketcollectortypes.INVALID, fmt.Errorf("invalid socket line %q: %w", line, err)
+	}
+
+	proto, ok := iterProtocols[protocol]
+	if !ok {
+		return nil, socketcollectortypes.INVALID, fmt.Errorf("invalid protocol %q", protocol)
+	}
+
+	localAddress, err := parseIterAddress(src)
+	if err != nil {
+		return nil, socketcollectortypes.INVALID, err
+	}
+	remoteAddress, err := parseIterAddress(dest)
+	if err != nil {
+		return nil, socketcollectortypes.INVALID, err
+	}
+
+	status, err := parseStatus(protocol, hexStatus)
+	if err != nil {
+		return nil, socketcollectortypes.INVALID, err
+	}
+
+	return &socketcollectortypes.Event{
+		Protocol:      protocol,
+		LocalAddress:  localAddress,
+		LocalPort:     srcp,
+		RemoteAddress: remoteAddress,
+		RemotePort:    destp,
+		Status:        status,
+		InodeNumber:   inodeNumber,
+	}, proto, nil
+}
+
+// getIterSockets returns the TCP and UDP sockets of the network namespace of
+// the given process using the BPF socket iterators.
+func getIterSockets(pid uint32, proto socketcollectortypes.Proto) ([]socketcollectortypes.Event, error) {
 	var err error
 	var it *link.Iter
 	iters := []*link.Iter{}
@@ -115,7 +191,8 @@ func RunCollector(pid uint32, podname, namespace, node string, proto socketcolle
 		}
 	}()
 
-	if proto == socketcollectortypes.TCP || proto == socketcollectortypes.ALL {
+	// Each iterator lists the sockets of both IPv4 and IPv6
+	if proto.Includes(socketcollectortypes.TCP4) || proto.Includes(socketcollectortypes.TCP6) {
 		it, err = getTCPIter()
 		if err != nil {
 			return nil, err
@@ -123,7 +200,7 @@ func RunCollector(pid uint32, podname, namespace, node string, proto socketcolle
 		iters = append(iters, it)
 	}
 
-	if proto == socketcollectortypes.UDP || proto == socketcollectortypes.ALL {
+	if proto.Includes(socketcollectortypes.UDP4) || proto.Includes(socketcollectortypes.UDP6) {
 		it, err = getUDPIter()
 		if err != nil {
 			return nil, err
@@ -142,42 +219,14 @@ func RunCollector(pid uint32, podname, namespace, node string, proto socketcolle
 
 			scanner := bufio.NewScanner(reader)
 			for scanner.Scan() {
-				var status, proto string
-				var destp, srcp uint16
-				var dest, src uint32
-				var hexStatus uint8
-				var inodeNumber uint64
-
-				// Format from socket_bpf_seq_print() in bpf/socket_common.h
-				// IP addresses and ports are in host-byte order
-				len, err := fmt.Sscanf(scanner.Text(), "%s %08X %04X %08X %04X %02X %d",
-					&proto, &src, &srcp, &dest, &destp, &hexStatus, &inodeNumber)
-				if err != nil || len != 7 {
+				socket, socketProto, err := parseIterSocket(scanner.Text())
+				if err != nil {
 					return fmt.Errorf("failed to parse sockets information: %w", err)
 				}
 
-				status, err = parseStatus(proto, hexStatus)
-				if err != nil {
-					return err
+				if proto.Includes(socketProto) {
+					sockets = append(sockets, *socket)
 				}
-
-				sockets = append(sockets, socketcollectortypes.Event{
-					Event: eventtypes.Event{
-						Type: eventtypes.NORMAL,
-						CommonData: eventtypes.CommonData{
-							Node:      node,
-							Namespace: namespace,
-							Pod:       podname,
-						},
-					},
-					Protocol:      proto,
-					LocalAddress:  parseIPv4(src),
-					LocalPort:     srcp,
-					RemoteAddress: parseIPv4(dest),
-					RemotePort:    destp,
-					Status:        status,
-					InodeNumber:   inodeNumber,
-				})
 			}
 
 			if err := scanner.Err(); err != nil {
@@ -193,3 +242,61 @@ func RunCollector(pid uint32, podname, namespace, node string, proto socketcolle
 
 	return sockets, nil
 }
+
+func RunCollector(pid uint32, podname, namespace, node string, proto socketcollectortypes.Proto) ([]socketcollectortypes.Event, error) {
+	sockets := []socketcollectortypes.Event{}
+
+	if proto.Includes(socketcollectortypes.TCP4) || proto.Includes(socketcollectortypes.TCP6) ||
+		proto.Includes(socketcollectortypes.UDP4) || proto.Includes(socketcollectortypes.UDP6) {
+		var inetSockets []socketcollectortypes.Event
+		var err error
+
+		if atomic.LoadUint32(&iteratorsUnsupported) == 0 {
+			inetSockets, err = getIterSockets(pid, proto)
+			// The BTF of the kernel lacks the iterators or there's no BTF
+			if errors.Is(err, ebpf.ErrNotSupported) {
+				log.Warnf("BPF socket iterators not supported by the kernel, using netlink sock_diag instead: %s", err)
+				atomic.StoreUint32(&iteratorsUnsupported, 1)
+			}
+		}
+		if atomic.LoadUint32(&iteratorsUnsupported) != 0 {
+			inetSockets, err = getNetlinkSockets(pid, proto)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, inetSockets...)
+	}
+
+	for _, p := range []socketcollectortypes.Proto{
+		socketcollectortypes.UNIX,
+		socketcollectortypes.RAW,
+	} {
+		if !proto.Includes(p) {
+			continue
+		}
+
+		procSockets, err := getProcNetSockets(pid, p)
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, procSockets...)
+	}
+
+	if err := addSocketOwners(pid, sockets); err != nil {
+		return nil, err
+	}
+
+	for i := range sockets {
+		sockets[i].Event = eventtypes.Event{
+			Type: eventtypes.NORMAL,
+			CommonData: eventtypes.CommonData{
+				Node:      node,
+				Namespace: namespace,
+				Pod:       podname,
+			},
+		}
+	}
+
+	return sockets, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"reflect"
+	"testing"
+
+	socketcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/types"
+)
+
+func TestParseIterSocket(t *testing.T) {
+	tests := []struct {
+		line     string
+		proto    socketcollectortypes.Proto
+		expected *socketcollectortypes.Event
+	}{
+		{
+			line:  "TCP 0A000001 1F90 C0A80102 D431 01 31337",
+			proto: socketcollectortypes.TCP4,
+			expected: &socketcollectortypes.Event{
+				Protocol:      "TCP",
+				LocalAddress:  "10.0.0.1",
+				LocalPort:     8080,
+				RemoteAddress: "192.168.1.2",
+				RemotePort:    54321,
+				Status:        "ESTABLISHED",
+				InodeNumber:   31337,
+			},
+		},
+		{
+			line:  "UDPv6 20010DB8000000000000000000000001 0035 00000000000000000000000000000000 0000 07 4242",
+			proto: socketcollectortypes.UDP6,
+			expected: &socketcollectortypes.Event{
+				Protocol:      "UDPv6",
+				LocalAddress:  "2001:db8::1",
+				LocalPort:     53,
+				RemoteAddress: "::",
+				Status:        "INACTIVE",
+				InodeNumber:   4242,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		socket, proto, err := parseIterSocket(test.line)
+		if err != nil {
+			t.Fatalf("parsing %q: %s", test.line, err)
+		}
+		if proto != test.proto {
+			t.Fatalf("unexpected protocol for %q: %d", test.line, proto)
+		}
+		if !reflect.DeepEqual(socket, test.expected) {
+			t.Fatalf("%+v != %+v", socket, test.expected)
+		}
+	}
+
+	for _, line := range []string{
+		"SCTP 0A000001 1F90 C0A80102 D431 01 31337",
+		"TCP 0A0001 1F90 C0A80102 D431 01 31337",
+		"TCP 0A000001 1F90",
+	} {
+		if _, _, err := parseIterSocket(line); err == nil {
+			t.Fatalf("expected error for %q", line)
+		}
+	}
+}
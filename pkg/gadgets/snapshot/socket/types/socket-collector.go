@@ -26,14 +26,26 @@ type Proto int
 const (
 	INVALID Proto = iota
 	ALL
-	TCP
-	UDP
+	TCP // Both IPv4 and IPv6
+	UDP // Both IPv4 and IPv6
+	TCP4
+	UDP4
+	TCP6
+	UDP6
+	UNIX
+	RAW
 )
 
 var ProtocolsMap = map[string]Proto{
-	"all": ALL,
-	"tcp": TCP,
-	"udp": UDP,
+	"all":  ALL,
+	"tcp":  TCP,
+	"udp":  UDP,
+	"tcp4": TCP4,
+	"udp4": UDP4,
+	"tcp6": TCP6,
+	"udp6": UDP6,
+	"unix": UNIX,
+	"raw":  RAW,
 }
 
 type Event struct {
@@ -46,6 +58,18 @@ type Event struct {
 	RemotePort    uint16 `json:"remotePort"`
 	Status        string `json:"status"`
 	InodeNumber   uint64 `json:"inodeNumber"`
+
+	// Kubernetes object the remote address belongs to, if any
+	RemoteKind string `json:"remoteKind,omitempty"`
+	RemoteName string `json:"remoteName,omitempty"`
+
+	// Information about the process owning the socket, if any. UID is a
+	// pointer to tell root from an unknown owner.
+	Pid  uint32  `json:"pid,omitempty"`
+	Comm string  `json:"comm,omitempty"`
+	UID  *uint32 `json:"uid,omitempty"`
+
+	Netns uint64 `json:"netns"`
 }
 
 func ParseProtocol(protocol string) (Proto, error) {
@@ -56,6 +80,21 @@ func ParseProtocol(protocol string) (Proto, error) {
 	return INVALID, fmt.Errorf("%q is not a valid protocol value", protocol)
 }
 
+// Includes returns whether sockets of protocol q have to be collected when p
+// is requested. UNIX and raw sockets are only collected when they are
+// requested explicitly.
+func (p Proto) Includes(q Proto) bool {
+	switch p {
+	case ALL:
+		return q != UNIX && q != RAW
+	case TCP:
+		return q == TCP || q == TCP4 || q == TCP6
+	case UDP:
+		return q == UDP || q == UDP4 || q == UDP6
+	}
+	return p == q
+}
+
 func (e Event) GetBaseEvent() eventtypes.Event {
 	return e.Event
 }
@@ -0,0 +1,44 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+)
+
+func TestProtoIncludes(t *testing.T) {
+	for _, tc := range []struct {
+		requested Proto
+		collected []Proto
+		ignored   []Proto
+	}{
+		{ALL, []Proto{TCP4, TCP6, UDP4, UDP6}, []Proto{UNIX, RAW}},
+		{TCP, []Proto{TCP4, TCP6}, []Proto{UDP4, UDP6, UNIX, RAW}},
+		{UDP6, []Proto{UDP6}, []Proto{UDP4, TCP6}},
+		{UNIX, []Proto{UNIX}, []Proto{TCP4, RAW}},
+		{RAW, []Proto{RAW}, []Proto{UDP4, UNIX}},
+	} {
+		for _, q := range tc.collected {
+			if !tc.requested.Includes(q) {
+				t.Errorf("%d doesn't include %d", tc.requested, q)
+			}
+		}
+		for _, q := range tc.ignored {
+			if tc.requested.Includes(q) {
+				t.Errorf("%d includes %d", tc.requested, q)
+			}
+		}
+	}
+}
@@ -26,7 +26,9 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/block-io/types"
 
 	"github.com/cilium/ebpf"
@@ -43,12 +45,14 @@ type Config struct {
 	TargetPid  int
 	MaxRows    int
 	Interval   time.Duration
-	SortBy     types.SortBy
+	SortBy     []string
+	Filters    []string
 	MountnsMap *ebpf.Map
 }
 
 type Tracer struct {
 	config           *Config
+	colMap           columns.ColumnMap[types.Stats]
 	objs             biotopObjects
 	ioStartLink      link.Link
 	startRequestLink link.Link
@@ -63,6 +67,7 @@ func NewTracer(config *Config, enricher gadgets.DataEnricher,
 ) (*Tracer, error) {
 	t := &Tracer{
 		config:        config,
+		colMap:        types.GetColumns().GetColumnMap(),
 		enricher:      enricher,
 		eventCallback: eventCallback,
 		done:          make(chan bool),
@@ -195,8 +200,8 @@ func (t *Tracer) start() error {
 	return nil
 }
 
-func (t *Tracer) nextStats() ([]types.Stats, error) {
-	stats := []types.Stats{}
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	stats := []*types.Stats{}
 
 	var prev *C.struct_info_t = nil
 	key := C.struct_info_t{}
@@ -236,7 +241,7 @@ func (t *Tracer) nextStats() ([]types.Stats, error) {
 			return nil, err
 		}
 
-		stat := types.Stats{
+		stat := &types.Stats{
 			Write:      key.rwflag != 0,
 			Major:      int(key.major),
 			Minor:      int(key.minor),
@@ -263,8 +268,6 @@ func (t *Tracer) nextStats() ([]types.Stats, error) {
 		}
 	}
 
-	types.SortStats(stats, t.config.SortBy)
-
 	return stats, nil
 }
 
@@ -286,11 +289,8 @@ func (t *Tracer) run() {
 					return
 				}
 
-				n := len(stats)
-				if n > t.config.MaxRows {
-					n = t.config.MaxRows
-				}
-				t.eventCallback(&types.Event{Stats: stats[:n]})
+				stats = gadgettop.SelectStats(t.colMap, stats, t.config.SortBy, t.config.Filters, t.config.MaxRows)
+				t.eventCallback(&types.Event{Stats: stats})
 			}
 		}
 	}()
@@ -15,94 +15,66 @@
 package types
 
 import (
-	"fmt"
-	"sort"
-	"strings"
-
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
-type SortBy int
-
-const (
-	ALL SortBy = iota
-	IO
-	BYTES
-	TIME
-)
-
 const (
 	MaxRowsDefault  = 20
 	IntervalDefault = 1
-	SortByDefault   = ALL
 )
 
+// SortByDefault sorts by the number of operations, then by the amount of
+// bytes and then by the time spent, in descending order.
+var SortByDefault = []string{"-ios", "-bytes", "-time"}
+
+// SortByAliases maps the deprecated sort_by values to the columns they sort
+// by. They sorted in descending order.
+var SortByAliases = map[string][]string{
+	"all":   SortByDefault,
+	"io":    {"-ios"},
+	"bytes": {"-bytes"},
+	"time":  {"-time"},
+}
+
 const (
 	IntervalParam = "interval"
 	MaxRowsParam  = "max_rows"
 	SortByParam   = "sort_by"
+	FilterParam   = "filter"
 )
 
-var SortBySlice = []string{
-	"all",
-	"io",
-	"bytes",
-	"time",
-}
-
-func (s SortBy) String() string {
-	if int(s) < 0 || int(s) >= len(SortBySlice) {
-		return "INVALID"
-	}
-
-	return SortBySlice[int(s)]
-}
-
-func ParseSortBy(sortBy string) (SortBy, error) {
-	for i, v := range SortBySlice {
-		if v == sortBy {
-			return SortBy(i), nil
-		}
-	}
-	return ALL, fmt.Errorf("%q is not a valid sort by value, possible values are: %q", sortBy, strings.Join(SortBySlice, ", "))
-}
-
 // Event is the information generated by the tracer each capture
 // interval
 type Event struct {
-	Error string  `json:"error,omitempty"`
-	Stats []Stats `json:"stats,omitempty"`
+	Error string   `json:"error,omitempty"`
+	Stats []*Stats `json:"stats,omitempty"`
 }
 
 // Stats represents the operations performed on a single file
 type Stats struct {
 	eventtypes.CommonData
 
-	Write      bool   `json:"write,omitempty"`
-	Major      int    `json:"major,omitempty"`
-	Minor      int    `json:"minor,omitempty"`
-	Bytes      uint64 `json:"bytes,omitempty"`
-	MicroSecs  uint64 `json:"us,omitempty"`
-	Operations uint32 `json:"io,omitempty"`
-	MountNsID  uint64 `json:"mountnsid,omitempty"`
-	Pid        int32  `json:"pid,omitempty"`
-	Comm       string `json:"comm,omitempty"`
+	MountNsID  uint64 `json:"mountnsid,omitempty" column:"mntns,template:ns"`
+	Pid        int32  `json:"pid,omitempty" column:"pid,template:pid"`
+	Comm       string `json:"comm,omitempty" column:"comm,template:comm"`
+	Write      bool   `json:"write,omitempty" column:"r/w,width:3,fixed" columnDesc:"R: read, W: write"`
+	Major      int    `json:"major,omitempty" column:"major,width:6,align:right"`
+	Minor      int    `json:"minor,omitempty" column:"minor,width:6,align:right"`
+	Bytes      uint64 `json:"bytes,omitempty" column:"bytes,width:7,align:right,unit:bytes"`
+	MicroSecs  uint64 `json:"us,omitempty" column:"time,width:8,align:right,unit:us"`
+	Operations uint32 `json:"io,omitempty" column:"ios,width:8,align:right"`
 }
 
-func SortStats(stats []Stats, sortBy SortBy) {
-	sort.Slice(stats, func(i, j int) bool {
-		a := stats[i]
-		b := stats[j]
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
 
-		switch sortBy {
-		case IO:
-			return a.Operations > b.Operations
-		case BYTES:
-			return a.Bytes > b.Bytes
-		case TIME:
-			return a.MicroSecs > b.MicroSecs
-		default:
-			return a.Operations > b.Operations && a.Bytes > b.Bytes && a.MicroSecs > b.MicroSecs
+	cols.MustSetExtractor("r/w", func(stats *Stats) string {
+		if stats.Write {
+			return "W"
 		}
+		return "R"
 	})
+
+	return cols
 }
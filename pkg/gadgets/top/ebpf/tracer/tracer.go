@@ -25,6 +25,8 @@ import (
 	"time"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfstats"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/ebpf/piditer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/ebpf/types"
 
@@ -34,7 +36,8 @@ import (
 type Config struct {
 	MaxRows  int
 	Interval time.Duration
-	SortBy   types.SortBy
+	SortBy   []string
+	Filters  []string
 }
 
 type programStats struct {
@@ -44,6 +47,7 @@ type programStats struct {
 
 type Tracer struct {
 	config        *Config
+	colMap        columns.ColumnMap[types.Stats]
 	eventCallback func(*types.Event)
 	done          chan bool
 
@@ -58,6 +62,7 @@ func NewTracer(config *Config, eventCallback func(*types.Event),
 ) (*Tracer, error) {
 	t := &Tracer{
 		config:        config,
+		colMap:        types.GetColumns().GetColumnMap(),
 		eventCallback: eventCallback,
 		done:          make(chan bool),
 		prevStats:     make(map[string]programStats),
@@ -171,8 +176,8 @@ func getPidMapFromProcFs() (map[uint32][]*types.PidInfo, error) {
 	return pidmap, nil
 }
 
-func (t *Tracer) nextStats() ([]types.Stats, error) {
-	stats := make([]types.Stats, 0)
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	stats := make([]*types.Stats, 0)
 
 	var err error
 	var prog *ebpf.Program
@@ -270,7 +275,7 @@ func (t *Tracer) nextStats() ([]types.Stats, error) {
 			runCount: totalRunCount,
 		}
 
-		stats = append(stats, types.Stats{
+		stats = append(stats, &types.Stats{
 			ProgramID:          uint32(curID),
 			Name:               pi.Name,
 			Type:               pi.Type.String(),
@@ -315,8 +320,6 @@ func (t *Tracer) nextStats() ([]types.Stats, error) {
 		}
 	}
 
-	types.SortStats(stats, t.config.SortBy)
-
 	return stats, nil
 }
 
@@ -336,11 +339,8 @@ func (t *Tracer) run() {
 					return
 				}
 
-				n := len(stats)
-				if n > t.config.MaxRows {
-					n = t.config.MaxRows
-				}
-				t.eventCallback(&types.Event{Stats: stats[:n]})
+				stats = gadgettop.SelectStats(t.colMap, stats, t.config.SortBy, t.config.Filters, t.config.MaxRows)
+				t.eventCallback(&types.Event{Stats: stats})
 			}
 		}
 	}()
@@ -15,121 +15,105 @@
 package types
 
 import (
-	"fmt"
-	"sort"
+	"strconv"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
-type SortBy int
-
-const (
-	ALL SortBy = iota
-	RUNTIME
-	RUNCOUNT
-	PROGRAMID
-	TOTALRUNTIME
-	TOTALRUNCOUNT
-	CUMULRUNTIME
-	CUMULRUNCOUNT
-	MAPMEMORY
-	MAPCOUNT
-)
-
 const (
 	MaxRowsDefault  = 20
 	IntervalDefault = 1
-	SortByDefault   = ALL
 )
 
+// SortByDefault sorts by the runtime and then by the run count of the
+// current interval, in descending order.
+var SortByDefault = []string{"-runtime", "-runcount"}
+
+// SortByAliases maps the deprecated sort_by values to the columns they sort
+// by. They sorted in descending order.
+var SortByAliases = map[string][]string{
+	"all":           SortByDefault,
+	"runtime":       {"-runtime"},
+	"runcount":      {"-runcount"},
+	"progid":        {"-progid"},
+	"totalruntime":  {"-totalruntime"},
+	"totalruncount": {"-totalruncount"},
+	"cumulruntime":  {"-cumulruntime"},
+	"cumulruncount": {"-cumulruncount"},
+	"mapmemory":     {"-mapmemory"},
+	"mapcount":      {"-mapcount"},
+}
+
 const (
 	IntervalParam = "interval"
 	MaxRowsParam  = "max_rows"
 	SortByParam   = "sort_by"
+	FilterParam   = "filter"
 )
 
-var SortBySlice = []string{
-	"all",
-	"runtime",
-	"runcount",
-	"progid",
-	"totalruntime",
-	"totalruncount",
-	"cumulruntime",
-	"cumulruncount",
-	"mapmemory",
-	"mapcount",
-}
-
-func (s SortBy) String() string {
-	if int(s) < 0 || int(s) >= len(SortBySlice) {
-		return "INVALID"
-	}
-
-	return SortBySlice[int(s)]
-}
-
-func ParseSortBy(sortby string) (SortBy, error) {
-	for i, v := range SortBySlice {
-		if v == sortby {
-			return SortBy(i), nil
-		}
-	}
-	return ALL, fmt.Errorf("%q is not a valid sort by value", sortby)
-}
-
-func SortStats(stats []Stats, sortBy SortBy) {
-	sort.Slice(stats, func(i, j int) bool {
-		a := stats[i]
-		b := stats[j]
-
-		switch sortBy {
-		case RUNTIME:
-			return a.CurrentRuntime > b.CurrentRuntime
-		case RUNCOUNT:
-			return a.CurrentRunCount > b.CurrentRunCount
-		case TOTALRUNTIME:
-			return a.TotalRuntime > b.TotalRuntime
-		case TOTALRUNCOUNT:
-			return a.TotalRunCount > b.TotalRunCount
-		case CUMULRUNTIME:
-			return a.CumulativeRuntime > b.CumulativeRuntime
-		case CUMULRUNCOUNT:
-			return a.CumulativeRunCount > b.CumulativeRunCount
-		case PROGRAMID:
-			return a.ProgramID > b.ProgramID
-		case MAPMEMORY:
-			return a.MapMemory > b.MapMemory
-		case MAPCOUNT:
-			return a.MapCount > b.MapCount
-		default:
-			return a.CurrentRuntime > b.CurrentRuntime && a.CurrentRunCount > b.CurrentRunCount
-		}
-	})
-}
-
 type Event struct {
-	Error string  `json:"error,omitempty"`
-	Stats []Stats `json:"stats,omitempty"`
+	Error string   `json:"error,omitempty"`
+	Stats []*Stats `json:"stats,omitempty"`
 }
 
 type Stats struct {
 	eventtypes.CommonData
-	ProgramID          uint32     `json:"progid"`
+	ProgramID          uint32     `json:"progid" column:"progid,width:8"`
 	Pids               []*PidInfo `json:"pids,omitempty"`
-	Name               string     `json:"name,omitempty"`
-	Type               string     `json:"type,omitempty"`
-	CurrentRuntime     int64      `json:"currentRuntime,omitempty"`
-	CurrentRunCount    uint64     `json:"currentRunCount,omitempty"`
-	CumulativeRuntime  int64      `json:"cumulRuntime,omitempty"`
-	CumulativeRunCount uint64     `json:"cumulRunCount,omitempty"`
-	TotalRuntime       int64      `json:"totalRuntime,omitempty"`
-	TotalRunCount      uint64     `json:"totalRunCount,omitempty"`
-	MapMemory          uint64     `json:"mapMemory,omitempty"`
-	MapCount           uint32     `json:"mapCount,omitempty"`
+	Type               string     `json:"type,omitempty" column:"type,width:16"`
+	Name               string     `json:"name,omitempty" column:"name,width:16"`
+	CurrentRuntime     int64      `json:"currentRuntime,omitempty" column:"runtime,width:12,align:right,unit:ns"`
+	CurrentRunCount    uint64     `json:"currentRunCount,omitempty" column:"runcount,width:10,align:right"`
+	CumulativeRuntime  int64      `json:"cumulRuntime,omitempty" column:"cumulruntime,width:12,align:right,unit:ns,hide"`
+	CumulativeRunCount uint64     `json:"cumulRunCount,omitempty" column:"cumulruncount,width:13,align:right,hide"`
+	TotalRuntime       int64      `json:"totalRuntime,omitempty" column:"totalruntime,width:12,align:right,unit:ns,hide"`
+	TotalRunCount      uint64     `json:"totalRunCount,omitempty" column:"totalruncount,width:13,align:right,hide"`
+	MapMemory          uint64     `json:"mapMemory,omitempty" column:"mapmemory,width:14,align:right,unit:bytes"`
+	MapCount           uint32     `json:"mapCount,omitempty" column:"mapcount,width:8,align:right"`
 }
 
 type PidInfo struct {
 	Pid  uint32 `json:"pid,omitempty"`
 	Comm string `json:"comm,omitempty"`
 }
+
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
+
+	// eBPF programs aren't bound to a specific pod or container
+	for _, name := range []string{"namespace", "pod", "container"} {
+		col, _ := cols.GetColumn(name)
+		col.Visible = false
+	}
+
+	// Show the first process using the program next to its name
+	nameCol, _ := cols.GetColumn("name")
+	cols.MustAddColumn(columns.Column[Stats]{
+		Name:    "pid",
+		Width:   7,
+		Visible: true,
+		Order:   nameCol.Order + 1,
+		Extractor: func(stats *Stats) string {
+			if len(stats.Pids) == 0 {
+				return ""
+			}
+			return strconv.FormatUint(uint64(stats.Pids[0].Pid), 10)
+		},
+	})
+	cols.MustAddColumn(columns.Column[Stats]{
+		Name:     "comm",
+		MaxWidth: 16,
+		Width:    16,
+		Visible:  true,
+		Order:    nameCol.Order + 2,
+		Extractor: func(stats *Stats) string {
+			if len(stats.Pids) == 0 {
+				return ""
+			}
+			return stats.Pids[0].Comm
+		},
+	})
+
+	return cols
+}
@@ -23,7 +23,9 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/file/types"
 
 	"github.com/cilium/ebpf"
@@ -42,11 +44,13 @@ type Config struct {
 	AllFiles   bool
 	MaxRows    int
 	Interval   time.Duration
-	SortBy     types.SortBy
+	SortBy     []string
+	Filters    []string
 }
 
 type Tracer struct {
 	config        *Config
+	colMap        columns.ColumnMap[types.Stats]
 	objs          filetopObjects
 	readLink      link.Link
 	writeLink     link.Link
@@ -60,6 +64,7 @@ func NewTracer(config *Config, enricher gadgets.DataEnricher,
 ) (*Tracer, error) {
 	t := &Tracer{
 		config:        config,
+		colMap:        types.GetColumns().GetColumnMap(),
 		enricher:      enricher,
 		eventCallback: eventCallback,
 		done:          make(chan bool),
@@ -131,8 +136,8 @@ func (t *Tracer) start() error {
 	return nil
 }
 
-func (t *Tracer) nextStats() ([]types.Stats, error) {
-	stats := []types.Stats{}
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	stats := []*types.Stats{}
 
 	var prev *C.struct_file_id = nil
 	key := C.struct_file_id{}
@@ -172,7 +177,7 @@ func (t *Tracer) nextStats() ([]types.Stats, error) {
 			return nil, err
 		}
 
-		stat := types.Stats{
+		stat := &types.Stats{
 			Reads:      uint64(fileStat.reads),
 			Writes:     uint64(fileStat.writes),
 			ReadBytes:  uint64(fileStat.read_bytes),
@@ -200,8 +205,6 @@ func (t *Tracer) nextStats() ([]types.Stats, error) {
 		}
 	}
 
-	types.SortStats(stats, t.config.SortBy)
-
 	return stats, nil
 }
 
@@ -221,11 +224,8 @@ func (t *Tracer) run() {
 					return
 				}
 
-				n := len(stats)
-				if n > t.config.MaxRows {
-					n = t.config.MaxRows
-				}
-				t.eventCallback(&types.Event{Stats: stats[:n]})
+				stats = gadgettop.SelectStats(t.colMap, stats, t.config.SortBy, t.config.Filters, t.config.MaxRows)
+				t.eventCallback(&types.Event{Stats: stats})
 			}
 		}
 	}()
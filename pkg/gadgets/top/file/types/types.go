@@ -15,101 +15,67 @@
 package types
 
 import (
-	"fmt"
-	"sort"
-
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
-type SortBy int
-
-const (
-	ALL SortBy = iota
-	READS
-	WRITES
-	RBYTES
-	WBYTES
-)
-
 const (
 	MaxRowsDefault  = 20
 	IntervalDefault = 1
-	SortByDefault   = ALL
 	AllFilesDefault = false
 )
 
+// SortByDefault sorts by the number of operations and then by the amount of
+// bytes, in descending order.
+var SortByDefault = []string{"-reads", "-writes", "-rbytes", "-wbytes"}
+
+// SortByAliases maps the deprecated sort_by values to the columns they sort
+// by. They sorted in descending order.
+var SortByAliases = map[string][]string{
+	"all":    SortByDefault,
+	"reads":  {"-reads"},
+	"writes": {"-writes"},
+	"rbytes": {"-rbytes"},
+	"wbytes": {"-wbytes"},
+}
+
 const (
 	IntervalParam = "interval"
 	MaxRowsParam  = "max_rows"
 	SortByParam   = "sort_by"
+	FilterParam   = "filter"
 	AllFilesParam = "pid"
 )
 
-var SortBySlice = []string{
-	"all",
-	"reads",
-	"writes",
-	"rbytes",
-	"wbytes",
-}
-
-func (s SortBy) String() string {
-	if int(s) < 0 || int(s) >= len(SortBySlice) {
-		return "INVALID"
-	}
-
-	return SortBySlice[int(s)]
-}
-
-func ParseSortBy(sortby string) (SortBy, error) {
-	for i, v := range SortBySlice {
-		if v == sortby {
-			return SortBy(i), nil
-		}
-	}
-	return ALL, fmt.Errorf("%q is not a valid sort by value", sortby)
-}
-
 // Event is the information generated by the tracer each capture
 // interval
 type Event struct {
-	Error string  `json:"error,omitempty"`
-	Stats []Stats `json:"stats,omitempty"`
+	Error string   `json:"error,omitempty"`
+	Stats []*Stats `json:"stats,omitempty"`
 }
 
 // Stats represents the operations performed on a single file
 type Stats struct {
 	eventtypes.CommonData
 
-	Reads      uint64 `json:"reads,omitempty"`
-	Writes     uint64 `json:"writes,omitempty"`
-	ReadBytes  uint64 `json:"rbytes,omitempty"`
-	WriteBytes uint64 `json:"wbytes,omitempty"`
-	Pid        uint32 `json:"pid,omitempty"`
-	Tid        uint32 `json:"tid,omitempty"`
-	MountNsID  uint64 `json:"mountnsid,omitempty"`
-	Filename   string `json:"filename,omitempty"`
-	Comm       string `json:"comm,omitempty"`
-	FileType   byte   `json:"fileType,omitempty"`
+	MountNsID  uint64 `json:"mountnsid,omitempty" column:"mntns,template:ns"`
+	Pid        uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid        uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm       string `json:"comm,omitempty" column:"comm,template:comm"`
+	Reads      uint64 `json:"reads,omitempty" column:"reads,width:6,align:right"`
+	Writes     uint64 `json:"writes,omitempty" column:"writes,width:6,align:right"`
+	ReadBytes  uint64 `json:"rbytes,omitempty" column:"rbytes,width:7,align:right,unit:bytes"`
+	WriteBytes uint64 `json:"wbytes,omitempty" column:"wbytes,width:7,align:right,unit:bytes"`
+	FileType   byte   `json:"fileType,omitempty" column:"t,width:1,fixed" columnDesc:"R: regular file, S: socket, O: other"`
+	Filename   string `json:"filename,omitempty" column:"file,minWidth:30,width:40"`
 }
 
-func SortStats(stats []Stats, sortBy SortBy) {
-	sort.Slice(stats, func(i, j int) bool {
-		a := stats[i]
-		b := stats[j]
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
 
-		switch sortBy {
-		case READS:
-			return a.Reads > b.Reads
-		case WRITES:
-			return a.Writes > b.Writes
-		case RBYTES:
-			return a.ReadBytes > b.ReadBytes
-		case WBYTES:
-			return a.WriteBytes > b.WriteBytes
-		default:
-			return a.Reads > b.Reads && a.Writes > b.Writes &&
-				a.ReadBytes > b.ReadBytes && a.WriteBytes > b.WriteBytes
-		}
+	cols.MustSetExtractor("t", func(stats *Stats) string {
+		return string(stats.FileType)
 	})
+
+	return cols
 }
@@ -23,7 +23,9 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/tcp/types"
 
 	"github.com/cilium/ebpf"
@@ -70,11 +72,13 @@ type Config struct {
 	TargetFamily int32
 	MaxRows      int
 	Interval     time.Duration
-	SortBy       types.SortBy
+	SortBy       []string
+	Filters      []string
 }
 
 type Tracer struct {
 	config             *Config
+	colMap             columns.ColumnMap[types.Stats]
 	objs               tcptopObjects
 	tcpSendmsgLink     link.Link
 	tcpCleanupRbufLink link.Link
@@ -88,6 +92,7 @@ func NewTracer(config *Config, enricher gadgets.DataEnricher,
 ) (*Tracer, error) {
 	t := &Tracer{
 		config:        config,
+		colMap:        types.GetColumns().GetColumnMap(),
 		enricher:      enricher,
 		eventCallback: eventCallback,
 		done:          make(chan bool),
@@ -157,8 +162,8 @@ func (t *Tracer) start() error {
 	return nil
 }
 
-func (t *Tracer) nextStats() ([]types.Stats, error) {
-	stats := []types.Stats{}
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	stats := []*types.Stats{}
 
 	var prev *C.struct_ip_key_t = nil
 	key := C.struct_ip_key_t{}
@@ -201,7 +206,7 @@ func (t *Tracer) nextStats() ([]types.Stats, error) {
 		srcAddr := C.src_addr(&key)
 		dstAddr := C.dst_addr(&key)
 
-		stat := types.Stats{
+		stat := &types.Stats{
 			Saddr:     C.GoString(srcAddr),
 			Daddr:     C.GoString(dstAddr),
 			MountNsID: uint64(key.mntnsid),
@@ -232,8 +237,6 @@ func (t *Tracer) nextStats() ([]types.Stats, error) {
 		}
 	}
 
-	types.SortStats(stats, t.config.SortBy)
-
 	return stats, nil
 }
 
@@ -254,11 +257,8 @@ func (t *Tracer) run() {
 					return
 				}
 
-				n := len(stats)
-				if n > t.config.MaxRows {
-					n = t.config.MaxRows
-				}
-				t.eventCallback(&types.Event{Stats: stats[:n]})
+				stats = gadgettop.SelectStats(t.colMap, stats, t.config.SortBy, t.config.Filters, t.config.MaxRows)
+				t.eventCallback(&types.Event{Stats: stats})
 			}
 		}
 	}()
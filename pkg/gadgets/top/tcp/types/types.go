@@ -16,59 +16,38 @@ package types
 
 import (
 	"fmt"
-	"sort"
 	"syscall"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
-type SortBy int
-
-const (
-	ALL SortBy = iota
-	SENT
-	RECEIVED
-)
-
-const (
-	SortByAll      = "all"
-	SortBySent     = "sent"
-	SortByReceived = "received"
-)
-
-var SortBySlice = []string{SortByAll, SortBySent, SortByReceived}
-
 const (
 	MaxRowsDefault  = 20
 	IntervalDefault = 1
-	SortByDefault   = ALL
 )
 
+// SortByDefault sorts by the amount of bytes sent and then received, in
+// descending order.
+var SortByDefault = []string{"-sent", "-received"}
+
+// SortByAliases maps the deprecated sort_by values to the columns they sort
+// by. They sorted in descending order.
+var SortByAliases = map[string][]string{
+	"all":      SortByDefault,
+	"sent":     {"-sent"},
+	"received": {"-received"},
+}
+
 const (
 	IntervalParam = "interval"
 	MaxRowsParam  = "max_rows"
 	SortByParam   = "sort_by"
+	FilterParam   = "filter"
 	PidParam      = "pid"
 	FamilyParam   = "family"
 )
 
-func (s SortBy) String() string {
-	if int(s) < 0 || int(s) >= len(SortBySlice) {
-		return "INVALID"
-	}
-
-	return SortBySlice[int(s)]
-}
-
-func ParseSortBy(sortby string) (SortBy, error) {
-	for i, v := range SortBySlice {
-		if v == sortby {
-			return SortBy(i), nil
-		}
-	}
-	return ALL, fmt.Errorf("%q is not a valid sort by value", sortby)
-}
-
 func ParseFilterByFamily(family string) (int32, error) {
 	switch family {
 	case "4":
@@ -83,38 +62,44 @@ func ParseFilterByFamily(family string) (int32, error) {
 // Event is the information generated by the tracer each capture
 // interval
 type Event struct {
-	Error string  `json:"error,omitempty"`
-	Stats []Stats `json:"stats,omitempty"`
+	Error string   `json:"error,omitempty"`
+	Stats []*Stats `json:"stats,omitempty"`
 }
 
-// Stats represents the operations performed on a single file
+// Stats represents the operations performed on a single connection
 type Stats struct {
 	eventtypes.CommonData
 
-	Saddr     string `json:"saddr,omitempty"`
-	Daddr     string `json:"daddr,omitempty"`
-	MountNsID uint64 `json:"mountnsid,omitempty"`
-	Pid       int32  `json:"pid,omitempty"`
-	Comm      string `json:"comm,omitempty"`
-	Sport     uint16 `json:"sport,omitempty"`
-	Dport     uint16 `json:"dport,omitempty"`
-	Family    uint16 `json:"family,omitempty"`
-	Sent      uint64 `json:"sent,omitempty"`
-	Received  uint64 `json:"received,omitempty"`
+	MountNsID uint64 `json:"mountnsid,omitempty" column:"mntns,template:ns"`
+	Pid       int32  `json:"pid,omitempty" column:"pid,template:pid"`
+	Comm      string `json:"comm,omitempty" column:"comm,template:comm"`
+	Family    uint16 `json:"family,omitempty" column:"ip,width:2,fixed"`
+	Saddr     string `json:"saddr,omitempty" column:"saddr,template:ipaddr"`
+	Sport     uint16 `json:"sport,omitempty" column:"sport,template:ipport"`
+	Daddr     string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
+	Dport     uint16 `json:"dport,omitempty" column:"dport,template:ipport"`
+	Sent      uint64 `json:"sent,omitempty" column:"sent,width:7,align:right,unit:bytes"`
+	Received  uint64 `json:"received,omitempty" column:"received,width:8,align:right,unit:bytes"`
+
+	// Kubernetes object the destination address belongs to, if any
+	RemoteKind string `json:"remoteKind,omitempty" column:"remotekind,width:10,hide"`
+	RemoteName string `json:"remoteName,omitempty" column:"remotename,width:24,maxWidth:64,hide"`
 }
 
-func SortStats(stats []Stats, sortBy SortBy) {
-	sort.Slice(stats, func(i, j int) bool {
-		a := stats[i]
-		b := stats[j]
-
-		switch sortBy {
-		case SENT:
-			return a.Sent > b.Sent
-		case RECEIVED:
-			return a.Received > b.Received
-		default:
-			return a.Sent > b.Sent && a.Received > b.Received
+// afInet6 is the value of AF_INET6 on Linux, where the stats are generated.
+// syscall.AF_INET6 can't be used to show them as its value differs across
+// platforms.
+const afInet6 = 10
+
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
+
+	cols.MustSetExtractor("ip", func(stats *Stats) string {
+		if stats.Family == afInet6 {
+			return "6"
 		}
+		return "4"
 	})
+
+	return cols
 }
@@ -0,0 +1,101 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package top contains the helpers shared by the top gadgets to sort and
+// filter their stats using the columns library.
+package top
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/filter"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/sort"
+)
+
+const (
+	// SortBySeparator separates the columns of the sort_by parameter, e.g.
+	// "-wbytes,comm".
+	SortBySeparator = ","
+
+	// FiltersSeparator separates the filters of the filter parameter, e.g.
+	// "comm:nginx;rbytes:>1MiB".
+	FiltersSeparator = ";"
+)
+
+// ParseSortBy parses a list of columns separated by SortBySeparator, each of
+// them optionally prefixed with "-" to sort in descending order, and verifies
+// that all of them exist. aliases maps the deprecated sort_by values of the
+// previous versions, which only accepted a single key, to the columns they
+// sort by.
+func ParseSortBy[T any](cols columns.ColumnMap[T], sortBy string, aliases map[string][]string) ([]string, error) {
+	if sortBy == "" {
+		return nil, nil
+	}
+
+	if alias, ok := aliases[sortBy]; ok {
+		sortByList := append([]string{}, alias...)
+		log.Warnf("Sorting by %q is deprecated, use %q instead",
+			sortBy, strings.Join(sortByList, SortBySeparator))
+		return sortByList, nil
+	}
+
+	sortByList := strings.Split(sortBy, SortBySeparator)
+	for _, s := range sortByList {
+		if _, ok := cols.GetColumn(strings.TrimPrefix(s, "-")); !ok {
+			return nil, fmt.Errorf("%q is not a valid column, possible values are: %s",
+				s, strings.Join(cols.GetColumnNames(), ", "))
+		}
+	}
+
+	return sortByList, nil
+}
+
+// ParseFilters parses a list of filters (see the columns/filter package)
+// separated by FiltersSeparator and verifies that all of them are valid.
+func ParseFilters[T any](cols columns.ColumnMap[T], filters string) ([]string, error) {
+	if filters == "" {
+		return nil, nil
+	}
+
+	filterList := strings.Split(filters, FiltersSeparator)
+	for _, f := range filterList {
+		if _, err := filter.GetFilterFromString(cols, f); err != nil {
+			return nil, err
+		}
+	}
+
+	return filterList, nil
+}
+
+// SelectStats filters and sorts stats, and returns at most maxRows of them.
+// filters and sortBy are expected to be validated with ParseFilters and
+// ParseSortBy.
+func SelectStats[T any](cols columns.ColumnMap[T], stats []*T, sortBy, filters []string, maxRows int) []*T {
+	if len(filters) > 0 {
+		// Errors were already checked by ParseFilters
+		stats, _ = filter.FilterEntries(cols, stats, filters)
+	}
+
+	sort.SortEntries(cols, stats, sortBy)
+
+	if len(stats) > maxRows {
+		stats = stats[:maxRows]
+	}
+
+	return stats
+}
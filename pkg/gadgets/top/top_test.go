@@ -0,0 +1,113 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package top
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+type testStats struct {
+	Comm   string `column:"comm"`
+	Reads  uint64 `column:"reads"`
+	RBytes uint64 `column:"rbytes,unit:bytes"`
+}
+
+func TestParseSortBy(t *testing.T) {
+	cols := columns.MustCreateColumns[testStats]().GetColumnMap()
+
+	sortBy, err := ParseSortBy(cols, "-reads,comm", nil)
+	if err != nil {
+		t.Fatalf("parsing sort by: %s", err)
+	}
+	if !reflect.DeepEqual(sortBy, []string{"-reads", "comm"}) {
+		t.Fatalf("unexpected sort by: %v", sortBy)
+	}
+
+	if _, err := ParseSortBy(cols, "-reads,foo", nil); err == nil {
+		t.Fatalf("expected error for unknown column")
+	}
+}
+
+func TestParseSortByAliases(t *testing.T) {
+	cols := columns.MustCreateColumns[testStats]().GetColumnMap()
+	aliases := map[string][]string{
+		"all":   {"-reads", "-rbytes"},
+		"reads": {"-reads"},
+	}
+
+	for sortBy, expected := range map[string][]string{
+		"all":         {"-reads", "-rbytes"},
+		"reads":       {"-reads"},
+		"reads,comm":  {"reads", "comm"},
+		"-reads,comm": {"-reads", "comm"},
+	} {
+		parsed, err := ParseSortBy(cols, sortBy, aliases)
+		if err != nil {
+			t.Fatalf("parsing sort by %q: %s", sortBy, err)
+		}
+		if !reflect.DeepEqual(parsed, expected) {
+			t.Fatalf("unexpected sort by for %q: %v", sortBy, parsed)
+		}
+	}
+
+	// The alias must not be modified through the returned list
+	parsed, _ := ParseSortBy(cols, "all", aliases)
+	parsed[0] = "comm"
+	if aliases["all"][0] != "-reads" {
+		t.Fatalf("alias was modified: %v", aliases["all"])
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	cols := columns.MustCreateColumns[testStats]().GetColumnMap()
+
+	filters, err := ParseFilters(cols, "comm:cat;rbytes:>1KiB")
+	if err != nil {
+		t.Fatalf("parsing filters: %s", err)
+	}
+	if !reflect.DeepEqual(filters, []string{"comm:cat", "rbytes:>1KiB"}) {
+		t.Fatalf("unexpected filters: %v", filters)
+	}
+
+	if _, err := ParseFilters(cols, "comm:cat;foo:1"); err == nil {
+		t.Fatalf("expected error for unknown column")
+	}
+}
+
+func TestSelectStats(t *testing.T) {
+	cols := columns.MustCreateColumns[testStats]().GetColumnMap()
+
+	stats := []*testStats{
+		{Comm: "cat", Reads: 1, RBytes: 512},
+		{Comm: "cat", Reads: 3, RBytes: 4096},
+		{Comm: "ls", Reads: 5, RBytes: 8192},
+		{Comm: "cat", Reads: 2, RBytes: 2048},
+	}
+
+	selected := SelectStats(cols, stats, []string{"-reads"}, []string{"comm:cat", "rbytes:>1KiB"}, 1)
+	if len(selected) != 1 || selected[0] != stats[1] {
+		t.Fatalf("unexpected stats: %+v", selected)
+	}
+
+	// Stats are sorted in place
+	expected := []*testStats{stats[0], stats[3], stats[1], stats[2]}
+	selected = SelectStats(cols, stats, []string{"comm", "reads"}, nil, 10)
+	if !reflect.DeepEqual(selected, expected) {
+		t.Fatalf("unexpected stats: %+v", selected)
+	}
+}
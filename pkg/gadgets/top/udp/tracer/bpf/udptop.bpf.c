@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2022 The Inspektor Gadget authors
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
+
+#include "udptop.h"
+
+/* Taken from kernel include/linux/socket.h. */
+#define AF_INET		2	/* Internet IP Protocol 	*/
+#define AF_INET6	10	/* IP version 6			*/
+
+const volatile pid_t target_pid = -1;
+const volatile int target_family = -1;
+const volatile bool filter_by_mnt_ns = false;
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 10240);
+	__type(key, struct ip_key_t);
+	__type(value, struct traffic_t);
+} ip_map SEC(".maps");
+
+struct recvmsg_args {
+	struct sock *sk;
+	struct msghdr *msg;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 10240);
+	__type(key, u32);
+	__type(value, struct recvmsg_args);
+} recvmsg_args SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 1024);
+	__uint(key_size, sizeof(u64));
+	__uint(value_size, sizeof(u32));
+} mount_ns_filter SEC(".maps");
+
+static __always_inline bool is_v4_mapped(const __u32 *addr)
+{
+	return addr[0] == 0 && addr[1] == 0 && addr[2] == bpf_htonl(0x0000ffff);
+}
+
+/*
+ * Fills the remote address of the key. msg_name, when set, points to the
+ * kernel copy of the address given to sendmsg or returned by recvmsg.
+ * Otherwise the socket is connected and the address is the one of the
+ * socket. IPv4-mapped IPv6 addresses are reported as IPv4 ones. Returns
+ * false if the address couldn't be found.
+ */
+static __always_inline bool
+fill_remote(struct ip_key_t *key, struct sock *sk, struct msghdr *msg, bool ipv6)
+{
+	void *name = BPF_CORE_READ(msg, msg_name);
+	__u32 addr[4] = {};
+	__u16 family;
+
+	if (name) {
+		family = 0;
+		bpf_probe_read_kernel(&family, sizeof(family), name);
+		if (family == AF_INET) {
+			struct sockaddr_in sin = {};
+
+			bpf_probe_read_kernel(&sin, sizeof(sin), name);
+			key->family = AF_INET;
+			key->dport = bpf_ntohs(sin.sin_port);
+			__builtin_memcpy(&key->daddr, &sin.sin_addr.s_addr, 4);
+			return true;
+		}
+		if (family != AF_INET6)
+			return false;
+
+		struct sockaddr_in6 sin6 = {};
+
+		bpf_probe_read_kernel(&sin6, sizeof(sin6), name);
+		key->dport = bpf_ntohs(sin6.sin6_port);
+		__builtin_memcpy(addr, &sin6.sin6_addr, sizeof(addr));
+	} else if (ipv6) {
+		key->dport = bpf_ntohs(BPF_CORE_READ(sk, __sk_common.skc_dport));
+		BPF_CORE_READ_INTO(&addr, sk, __sk_common.skc_v6_daddr.in6_u.u6_addr32);
+	} else {
+		key->family = AF_INET;
+		key->dport = bpf_ntohs(BPF_CORE_READ(sk, __sk_common.skc_dport));
+		BPF_CORE_READ_INTO(&key->daddr, sk, __sk_common.skc_daddr);
+		return true;
+	}
+
+	if (is_v4_mapped(addr)) {
+		key->family = AF_INET;
+		__builtin_memcpy(&key->daddr, &addr[3], 4);
+	} else {
+		key->family = AF_INET6;
+		__builtin_memcpy(&key->daddr, addr, sizeof(addr));
+	}
+
+	return true;
+}
+
+static __always_inline int
+probe_ip(bool receiving, struct sock *sk, struct msghdr *msg, bool ipv6, size_t size)
+{
+	struct ip_key_t ip_key = {};
+	struct traffic_t *trafficp;
+	struct task_struct *task;
+	u64 mntns_id;
+	u32 pid;
+
+	pid = bpf_get_current_pid_tgid() >> 32;
+	if (target_pid != -1 && target_pid != pid)
+		return 0;
+
+	if (!fill_remote(&ip_key, sk, msg, ipv6))
+		return 0;
+
+	if (target_family != -1 && target_family != ip_key.family)
+		return 0;
+
+	task = (struct task_struct*) bpf_get_current_task();
+	mntns_id = (u64) BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum);
+
+	if (filter_by_mnt_ns && !bpf_map_lookup_elem(&mount_ns_filter, &mntns_id))
+		return 0;
+
+	ip_key.pid = pid;
+	bpf_get_current_comm(&ip_key.name, sizeof(ip_key.name));
+	ip_key.lport = BPF_CORE_READ(sk, __sk_common.skc_num);
+	ip_key.mntnsid = mntns_id;
+
+	trafficp = bpf_map_lookup_elem(&ip_map, &ip_key);
+	if (!trafficp) {
+		struct traffic_t zero = {};
+
+		if (receiving) {
+			zero.received = size;
+			zero.received_packets = 1;
+		} else {
+			zero.sent = size;
+			zero.sent_packets = 1;
+		}
+
+		bpf_map_update_elem(&ip_map, &ip_key, &zero, BPF_NOEXIST);
+	} else {
+		if (receiving) {
+			__atomic_add_fetch(&trafficp->received, size, __ATOMIC_RELAXED);
+			__atomic_add_fetch(&trafficp->received_packets, 1, __ATOMIC_RELAXED);
+		} else {
+			__atomic_add_fetch(&trafficp->sent, size, __ATOMIC_RELAXED);
+			__atomic_add_fetch(&trafficp->sent_packets, 1, __ATOMIC_RELAXED);
+		}
+	}
+
+	return 0;
+}
+
+SEC("kprobe/udp_sendmsg")
+int BPF_KPROBE(ig_topudp_sdmsg, struct sock *sk, struct msghdr *msg, size_t size)
+{
+	return probe_ip(false, sk, msg, false, size);
+}
+
+SEC("kprobe/udpv6_sendmsg")
+int BPF_KPROBE(ig_topudp_sdmsg6, struct sock *sk, struct msghdr *msg, size_t size)
+{
+	struct ip_key_t key = {};
+
+	/*
+	 * udpv6_sendmsg() calls udp_sendmsg() for IPv4 and IPv4-mapped
+	 * destinations, don't count them twice.
+	 */
+	if (!fill_remote(&key, sk, msg, true) || key.family == AF_INET)
+		return 0;
+
+	return probe_ip(false, sk, msg, true, size);
+}
+
+/*
+ * The size and the address of the datagram are only known when recvmsg
+ * returns, keep the arguments until then.
+ */
+static __always_inline int enter_recvmsg(struct sock *sk, struct msghdr *msg)
+{
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	struct recvmsg_args args = {
+		.sk = sk,
+		.msg = msg,
+	};
+
+	bpf_map_update_elem(&recvmsg_args, &tid, &args, BPF_ANY);
+	return 0;
+}
+
+static __always_inline int exit_recvmsg(int ret, bool ipv6)
+{
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	struct recvmsg_args *args;
+
+	args = bpf_map_lookup_elem(&recvmsg_args, &tid);
+	if (!args)
+		return 0;
+
+	if (ret > 0)
+		probe_ip(true, args->sk, args->msg, ipv6, ret);
+
+	bpf_map_delete_elem(&recvmsg_args, &tid);
+	return 0;
+}
+
+SEC("kprobe/udp_recvmsg")
+int BPF_KPROBE(ig_topudp_rcmsg_e, struct sock *sk, struct msghdr *msg)
+{
+	return enter_recvmsg(sk, msg);
+}
+
+SEC("kretprobe/udp_recvmsg")
+int BPF_KRETPROBE(ig_topudp_rcmsg_x, int ret)
+{
+	return exit_recvmsg(ret, false);
+}
+
+SEC("kprobe/udpv6_recvmsg")
+int BPF_KPROBE(ig_topudp_rcmsg6_e, struct sock *sk, struct msghdr *msg)
+{
+	return enter_recvmsg(sk, msg);
+}
+
+SEC("kretprobe/udpv6_recvmsg")
+int BPF_KRETPROBE(ig_topudp_rcmsg6_x, int ret)
+{
+	return exit_recvmsg(ret, true);
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,276 @@
+//go:build linux
+// +build linux
+
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	gadgettop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/udp/types"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+)
+
+// #include <linux/types.h>
+// #include "./bpf/udptop.h"
+// #include <arpa/inet.h>
+// #include <stdlib.h>
+//
+//static char *string_ip(const void *addr, int ip_type) {
+//	socklen_t size;
+//	char *ip;
+//
+//  // Should not occur because eBPF code already filter on this.
+//	if (ip_type != AF_INET && ip_type != AF_INET6)
+//		return NULL;
+//
+//	size = sizeof(*ip) * INET6_ADDRSTRLEN;
+//	ip = malloc(size);
+//  if (ip == NULL)
+//		return NULL;
+//
+//	inet_ntop(ip_type, addr, ip, size);
+//
+//	return ip;
+//}
+//
+// static char *dst_addr(struct ip_key_t *key) {
+// 	return string_ip(&key->daddr, key->family);
+// }
+import "C"
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -no-global-types -target $TARGET -cc clang udptop ./bpf/udptop.bpf.c -- -I./bpf/ -I../../../../${TARGET}
+
+type Config struct {
+	MountnsMap   *ebpf.Map
+	TargetPid    int32
+	TargetFamily int32
+	MaxRows      int
+	Interval     time.Duration
+	SortBy       []string
+	Filters      []string
+}
+
+type Tracer struct {
+	config        *Config
+	colMap        columns.ColumnMap[types.Stats]
+	objs          udptopObjects
+	links         []link.Link
+	enricher      gadgets.DataEnricher
+	eventCallback func(*types.Event)
+	done          chan bool
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricher,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		colMap:        types.GetColumns().GetColumnMap(),
+		enricher:      enricher,
+		eventCallback: eventCallback,
+		done:          make(chan bool),
+	}
+
+	if err := t.start(); err != nil {
+		t.Stop()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Tracer) Stop() {
+	close(t.done)
+
+	for i := range t.links {
+		t.links[i] = gadgets.CloseLink(t.links[i])
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) start() error {
+	spec, err := loadUdptop()
+	if err != nil {
+		return fmt.Errorf("failed to load ebpf program: %w", err)
+	}
+
+	mapReplacements := map[string]*ebpf.Map{}
+	filterByMntNs := false
+
+	if t.config.MountnsMap != nil {
+		filterByMntNs = true
+		mapReplacements["mount_ns_filter"] = t.config.MountnsMap
+	}
+
+	consts := map[string]interface{}{
+		"filter_by_mnt_ns": filterByMntNs,
+		"target_pid":       t.config.TargetPid,
+		"target_family":    t.config.TargetFamily,
+	}
+
+	if err := spec.RewriteConstants(consts); err != nil {
+		return fmt.Errorf("error RewriteConstants: %w", err)
+	}
+
+	opts := ebpf.CollectionOptions{
+		MapReplacements: mapReplacements,
+	}
+
+	if err := spec.LoadAndAssign(&t.objs, &opts); err != nil {
+		return fmt.Errorf("failed to load ebpf program: %w", err)
+	}
+
+	kprobes := []struct {
+		symbol string
+		prog   *ebpf.Program
+		ret    bool
+	}{
+		{"udp_sendmsg", t.objs.IgTopudpSdmsg, false},
+		{"udpv6_sendmsg", t.objs.IgTopudpSdmsg6, false},
+		{"udp_recvmsg", t.objs.IgTopudpRcmsgE, false},
+		{"udp_recvmsg", t.objs.IgTopudpRcmsgX, true},
+		{"udpv6_recvmsg", t.objs.IgTopudpRcmsg6E, false},
+		{"udpv6_recvmsg", t.objs.IgTopudpRcmsg6X, true},
+	}
+
+	for _, kp := range kprobes {
+		var l link.Link
+		if kp.ret {
+			l, err = link.Kretprobe(kp.symbol, kp.prog, nil)
+		} else {
+			l, err = link.Kprobe(kp.symbol, kp.prog, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("error opening kprobe on %s: %w", kp.symbol, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.run()
+
+	return nil
+}
+
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	stats := []*types.Stats{}
+
+	var prev *C.struct_ip_key_t = nil
+	key := C.struct_ip_key_t{}
+	ips := t.objs.IpMap
+
+	defer func() {
+		// delete elements
+		err := ips.NextKey(nil, unsafe.Pointer(&key))
+		if err != nil {
+			return
+		}
+
+		for {
+			if err := ips.Delete(key); err != nil {
+				return
+			}
+
+			prev = &key
+			if err := ips.NextKey(unsafe.Pointer(prev), unsafe.Pointer(&key)); err != nil {
+				return
+			}
+		}
+	}()
+
+	// gather elements
+	err := ips.NextKey(nil, unsafe.Pointer(&key))
+	if err != nil {
+		if errors.Is(err, ebpf.ErrKeyNotExist) {
+			return stats, nil
+		}
+		return nil, fmt.Errorf("error getting next key: %w", err)
+	}
+
+	for {
+		val := C.struct_traffic_t{}
+		if err := ips.Lookup(key, unsafe.Pointer(&val)); err != nil {
+			return nil, err
+		}
+
+		dstAddr := C.dst_addr(&key)
+
+		stat := &types.Stats{
+			Daddr:     C.GoString(dstAddr),
+			MountNsID: uint64(key.mntnsid),
+			Pid:       int32(key.pid),
+			Comm:      C.GoString(&key.name[0]),
+			Sport:     uint16(key.lport),
+			Dport:     uint16(key.dport),
+			Family:    uint16(key.family),
+			Sent:      uint64(val.sent),
+			Received:  uint64(val.received),
+			SentPkts:  uint64(val.sent_packets),
+			RecvPkts:  uint64(val.received_packets),
+		}
+
+		C.free(unsafe.Pointer(dstAddr))
+
+		if t.enricher != nil {
+			t.enricher.Enrich(&stat.CommonData, stat.MountNsID)
+		}
+
+		stats = append(stats, stat)
+
+		prev = &key
+		if err := ips.NextKey(unsafe.Pointer(prev), unsafe.Pointer(&key)); err != nil {
+			if errors.Is(err, ebpf.ErrKeyNotExist) {
+				break
+			}
+			return nil, fmt.Errorf("error getting next key: %w", err)
+		}
+	}
+
+	return stats, nil
+}
+
+func (t *Tracer) run() {
+	ticker := time.NewTicker(t.config.Interval)
+
+	go func() {
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-ticker.C:
+				stats, err := t.nextStats()
+				if err != nil {
+					t.eventCallback(&types.Event{
+						Error: err.Error(),
+					})
+					return
+				}
+
+				stats = gadgettop.SelectStats(t.colMap, stats, t.config.SortBy, t.config.Filters, t.config.MaxRows)
+				t.eventCallback(&types.Event{Stats: stats})
+			}
+		}
+	}()
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	MaxRowsDefault  = 20
+	IntervalDefault = 1
+)
+
+// SortByDefault sorts by the amount of bytes sent and then received, in
+// descending order.
+var SortByDefault = []string{"-sent", "-received"}
+
+const (
+	IntervalParam = "interval"
+	MaxRowsParam  = "max_rows"
+	SortByParam   = "sort_by"
+	FilterParam   = "filter"
+	PidParam      = "pid"
+	FamilyParam   = "family"
+)
+
+func ParseFilterByFamily(family string) (int32, error) {
+	switch family {
+	case "4":
+		return syscall.AF_INET, nil
+	case "6":
+		return syscall.AF_INET6, nil
+	default:
+		return -1, fmt.Errorf("IP version is either 4 or 6, %s was given", family)
+	}
+}
+
+// Event is the information generated by the tracer each capture
+// interval
+type Event struct {
+	Error string   `json:"error,omitempty"`
+	Stats []*Stats `json:"stats,omitempty"`
+}
+
+// Stats represents the UDP traffic exchanged by a process with a single
+// remote endpoint
+type Stats struct {
+	eventtypes.CommonData
+
+	MountNsID uint64 `json:"mountnsid,omitempty" column:"mntns,template:ns"`
+	Pid       int32  `json:"pid,omitempty" column:"pid,template:pid"`
+	Comm      string `json:"comm,omitempty" column:"comm,template:comm"`
+	Family    uint16 `json:"family,omitempty" column:"ip,width:2,fixed"`
+	Sport     uint16 `json:"sport,omitempty" column:"sport,template:ipport"`
+	Daddr     string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
+	Dport     uint16 `json:"dport,omitempty" column:"dport,template:ipport"`
+	Sent      uint64 `json:"sent,omitempty" column:"sent,width:7,align:right,unit:bytes"`
+	Received  uint64 `json:"received,omitempty" column:"received,width:8,align:right,unit:bytes"`
+	SentPkts  uint64 `json:"sentPkts,omitempty" column:"sentpkts,width:8,align:right"`
+	RecvPkts  uint64 `json:"recvPkts,omitempty" column:"recvpkts,width:8,align:right"`
+
+	// Kubernetes object the destination address belongs to, if any
+	RemoteKind string `json:"remoteKind,omitempty" column:"remotekind,width:10,hide"`
+	RemoteName string `json:"remoteName,omitempty" column:"remotename,width:24,maxWidth:64,hide"`
+}
+
+// afInet6 is the value of AF_INET6 on Linux, where the stats are generated.
+// syscall.AF_INET6 can't be used to show them as its value differs across
+// platforms.
+const afInet6 = 10
+
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
+
+	cols.MustSetExtractor("ip", func(stats *Stats) string {
+		if stats.Family == afInet6 {
+			return "6"
+		}
+		return "4"
+	})
+
+	return cols
+}
@@ -30,6 +30,11 @@ type Event struct {
 	Options   string `json:"opts,omitempty" column:"opts,width:5,fixed"`
 	Interface string `json:"if,omitempty" column:"if,width:12"`
 	MountNsID uint64 `json:"mountnsid,omitempty" column:"mntns,template:ns"`
+
+	// Kubernetes object the bound address belongs to, if any. It's the pod
+	// itself, or the node for pods using the host network.
+	RemoteKind string `json:"remoteKind,omitempty" column:"remotekind,width:10,hide"`
+	RemoteName string `json:"remoteName,omitempty" column:"remotename,width:24,maxWidth:64,hide"`
 }
 
 func GetColumns() *columns.Columns[Event] {
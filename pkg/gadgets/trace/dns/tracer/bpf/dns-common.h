@@ -5,10 +5,22 @@
 // https://datatracker.ietf.org/doc/html/rfc1034#section-3.1
 #define MAX_DNS_NAME 255
 
+// Max number of addresses reported from the answers of a response
+#define MAX_ADDR_ANSWERS 8
+
 struct event_t {
 	char name[MAX_DNS_NAME];
 	unsigned char pkt_type;
 	unsigned short qtype;
+
+	// 0 for queries, 1 for responses
+	unsigned char qr;
+
+	// Number of addresses found in the answers of a response
+	unsigned char anaddrcount;
+	// Length of each address: 4 for A records, 16 for AAAA records
+	unsigned char anaddrlen[MAX_ADDR_ANSWERS];
+	unsigned char anaddr[MAX_ADDR_ANSWERS][16];
 };
 
 #endif
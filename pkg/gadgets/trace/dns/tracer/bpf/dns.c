@@ -24,10 +24,29 @@ unsigned long long load_half(void *skb,
 unsigned long long load_word(void *skb,
 			     unsigned long long off) asm("llvm.bpf.load.word");
 
+// Max number of answers read from a response, including the ones which are
+// not addresses, like CNAME records.
+#define MAX_ANSWERS 16
+
+#define DNS_TYPE_A	1
+#define DNS_TYPE_AAAA	28
+#define DNS_CLASS_IN	1
+
+// Also capture the responses, with the addresses of their answers
+const volatile __u8 capture_responses = 0;
+
 struct {
 	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
 } events SEC(".maps");
 
+// The event is too big to index its addresses on the stack
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, struct event_t);
+} tmp_events SEC(".maps");
+
 // https://datatracker.ietf.org/doc/html/rfc1035#section-4.1.1
 union dnsflags {
 	struct {
@@ -66,25 +85,24 @@ int ig_trace_dns(struct __sk_buff *skb)
 	if (load_byte(skb, ETH_HLEN + offsetof(struct iphdr, protocol)) != IPPROTO_UDP)
 		return 0;
 
-	// Skip non DNS Query packets
 	union dnsflags flags;
 	flags.flags = load_half(skb, DNS_OFF + offsetof(struct dnshdr, flags));
 
-	// Capture questions and ignore answers
-	if (flags.qr)
+	// Capture questions and ignore answers, unless asked for
+	if (flags.qr && !capture_responses)
 		return 0;
 
 	// Skip DNS packets with more than 1 question
 	if (load_half(skb, DNS_OFF + offsetof(struct dnshdr, qdcount)) != 1)
 		return 0;
 
-	// Skip DNS packets with answers
-	if (load_half(skb, DNS_OFF + offsetof(struct dnshdr, ancount)) != 0)
-		return 0;
-
-	// Skip DNS packets with authority records
-	if (load_half(skb, DNS_OFF + offsetof(struct dnshdr, nscount)) != 0)
-		return 0;
+	// Skip DNS queries with answers or authority records
+	if (!flags.qr) {
+		if (load_half(skb, DNS_OFF + offsetof(struct dnshdr, ancount)) != 0)
+			return 0;
+		if (load_half(skb, DNS_OFF + offsetof(struct dnshdr, nscount)) != 0)
+			return 0;
+	}
 
 	// This loop iterates over the DNS labels to find the total DNS name
 	// length.
@@ -105,19 +123,65 @@ int ig_trace_dns(struct __sk_buff *skb)
 
 	__u32 len = i < MAX_DNS_NAME ? i : MAX_DNS_NAME;
 
-	struct event_t event = {0,};
+	__u32 zero = 0;
+	struct event_t *event = bpf_map_lookup_elem(&tmp_events, &zero);
+	if (!event)
+		return 0;
+	__builtin_memset(event, 0, sizeof(*event));
+
 	if (len > 0)
-		bpf_skb_load_bytes(skb, DNS_OFF + sizeof(struct dnshdr), event.name, len);
+		bpf_skb_load_bytes(skb, DNS_OFF + sizeof(struct dnshdr), event->name, len);
 
-	event.pkt_type = skb->pkt_type;
+	event->pkt_type = skb->pkt_type;
+	event->qr = flags.qr;
 
 	// Read QTYPE right after the QNAME
 	// https://datatracker.ietf.org/doc/html/rfc1035#section-4.1.2
-	event.qtype = load_half(skb, DNS_OFF + sizeof(struct dnshdr) + len + 1);
+	event->qtype = load_half(skb, DNS_OFF + sizeof(struct dnshdr) + len + 1);
+
+	if (flags.qr) {
+		// The answers follow the question: QNAME, QTYPE and QCLASS
+		// https://datatracker.ietf.org/doc/html/rfc1035#section-4.1.3
+		__u32 ancount = load_half(skb, DNS_OFF + offsetof(struct dnshdr, ancount));
+		__u32 off = DNS_OFF + sizeof(struct dnshdr) + len + 1 + 4;
+
+		for (i = 0; i < MAX_ANSWERS && i < ancount; i++) {
+			// Only compressed names are supported. They are what
+			// servers use for the answers to the question.
+			// https://datatracker.ietf.org/doc/html/rfc1035#section-4.1.4
+			if ((load_byte(skb, off) & 0xc0) != 0xc0)
+				break;
+			off += 2;
+
+			__u16 type = load_half(skb, off);
+			__u16 class = load_half(skb, off + 2);
+			__u16 rdlength = load_half(skb, off + 8);
+			__u32 rdata = off + 10;
+
+			// Move to the next answer before looking at this one, so
+			// the checks below don't make the verifier track a
+			// different offset for each kind of answer.
+			off = rdata + rdlength;
+
+			__u8 n = event->anaddrcount;
+			if (n >= MAX_ADDR_ANSWERS || class != DNS_CLASS_IN)
+				continue;
+
+			if (type == DNS_TYPE_A && rdlength == 4) {
+				bpf_skb_load_bytes(skb, rdata, event->anaddr[n], 4);
+				event->anaddrlen[n] = 4;
+				event->anaddrcount++;
+			} else if (type == DNS_TYPE_AAAA && rdlength == 16) {
+				bpf_skb_load_bytes(skb, rdata, event->anaddr[n], 16);
+				event->anaddrlen[n] = 16;
+				event->anaddrcount++;
+			}
+		}
+	}
 
 	// TODO: we should not send the event when len == 0. But the verifier
 	// won't let us.
-	bpf_perf_event_output(skb, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+	bpf_perf_event_output(skb, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
 
 	return 0;
 }
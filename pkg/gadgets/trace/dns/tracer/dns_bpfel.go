@@ -13,6 +13,16 @@ import (
 	"github.com/cilium/ebpf"
 )
 
+type dnsEventT struct {
+	Name        [255]int8
+	PktType     uint8
+	Qtype       uint16
+	Qr          uint8
+	Anaddrcount uint8
+	Anaddrlen   [8]uint8
+	Anaddr      [8][16]uint8
+}
+
 // loadDns returns the embedded CollectionSpec for dns.
 func loadDns() (*ebpf.CollectionSpec, error) {
 	reader := bytes.NewReader(_DnsBytes)
@@ -61,7 +71,8 @@ type dnsProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type dnsMapSpecs struct {
-	Events *ebpf.MapSpec `ebpf:"events"`
+	Events    *ebpf.MapSpec `ebpf:"events"`
+	TmpEvents *ebpf.MapSpec `ebpf:"tmp_events"`
 }
 
 // dnsObjects contains all objects after they have been loaded into the kernel.
@@ -83,12 +94,14 @@ func (o *dnsObjects) Close() error {
 //
 // It can be passed to loadDnsObjects or ebpf.CollectionSpec.LoadAndAssign.
 type dnsMaps struct {
-	Events *ebpf.Map `ebpf:"events"`
+	Events    *ebpf.Map `ebpf:"events"`
+	TmpEvents *ebpf.Map `ebpf:"tmp_events"`
 }
 
 func (m *dnsMaps) Close() error {
 	return _DnsClose(
 		m.Events,
+		m.TmpEvents,
 	)
 }
 
@@ -17,6 +17,7 @@ package tracer
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"syscall"
 	"unsafe"
@@ -54,21 +55,37 @@ type link struct {
 	users int
 }
 
+type Config struct {
+	// CaptureResponses also reports the responses, with the addresses of
+	// their answers, instead of only the queries.
+	CaptureResponses bool
+}
+
 type Tracer struct {
-	spec *ebpf.CollectionSpec
+	config *Config
+	spec   *ebpf.CollectionSpec
 
 	// key: namespace/podname
 	// value: Tracelet
 	attachments map[string]*link
 }
 
-func NewTracer() (*Tracer, error) {
+func NewTracer(config *Config) (*Tracer, error) {
 	spec, err := loadDns()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load asset: %w", err)
 	}
 
+	consts := map[string]interface{}{
+		"capture_responses": config.CaptureResponses,
+	}
+
+	if err := spec.RewriteConstants(consts); err != nil {
+		return nil, fmt.Errorf("error RewriteConstants: %w", err)
+	}
+
 	t := &Tracer{
+		config:      config,
 		spec:        spec,
 		attachments: make(map[string]*link),
 	}
@@ -278,6 +295,33 @@ func parseDNSEvent(rawSample []byte) (ret string, pktType string, qType string)
 	return
 }
 
+// parseDNSResponse returns whether the event is a query ("Q") or a response
+// ("R") and, for responses, the addresses of the answers.
+func parseDNSResponse(rawSample []byte) (qr string, addresses []string) {
+	dnsEvent := (*C.struct_event_t)(unsafe.Pointer(&rawSample[0]))
+	if len(rawSample) < int(unsafe.Sizeof(*dnsEvent)) {
+		return
+	}
+
+	if dnsEvent.qr == 0 {
+		return "Q", nil
+	}
+
+	count := int(dnsEvent.anaddrcount)
+	if count > C.MAX_ADDR_ANSWERS {
+		count = C.MAX_ADDR_ANSWERS
+	}
+	for i := 0; i < count; i++ {
+		length := int(dnsEvent.anaddrlen[i])
+		if length != net.IPv4len && length != net.IPv6len {
+			continue
+		}
+		addr := C.GoBytes(unsafe.Pointer(&dnsEvent.anaddr[i]), C.int(length))
+		addresses = append(addresses, net.IP(addr).String())
+	}
+	return "R", addresses
+}
+
 func (t *Tracer) listen(
 	key string,
 	rd *perf.Reader,
@@ -314,6 +358,9 @@ func (t *Tracer) listen(
 				PktType: pktType,
 				QType:   qType,
 			}
+			if t.config.CaptureResponses {
+				event.QR, event.Addresses = parseDNSResponse(record.RawSample)
+			}
 			eventCallback(event)
 		}
 	}
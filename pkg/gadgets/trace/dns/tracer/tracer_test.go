@@ -15,7 +15,9 @@
 package tracer
 
 import (
+	"reflect"
 	"testing"
+	"unsafe"
 )
 
 func TestParsing(t *testing.T) {
@@ -67,3 +69,30 @@ func TestParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestParsingResponse(t *testing.T) {
+	event := dnsEventT{
+		Qr:          1,
+		Anaddrcount: 2,
+	}
+	event.Anaddrlen[0] = 4
+	copy(event.Anaddr[0][:], []byte{93, 184, 216, 34})
+	event.Anaddrlen[1] = 16
+	copy(event.Anaddr[1][:], []byte{0x20, 0x01, 0x0d, 0xb8, 15: 1})
+
+	rawSample := unsafe.Slice((*byte)(unsafe.Pointer(&event)), unsafe.Sizeof(event))
+	qr, addresses := parseDNSResponse(rawSample)
+	if qr != "R" {
+		t.Fatalf("Failed to parse DNS response: got qr %q, expected %q", qr, "R")
+	}
+	expected := []string{"93.184.216.34", "2001:db8::1"}
+	if !reflect.DeepEqual(addresses, expected) {
+		t.Fatalf("Failed to parse DNS response: got %v, expected %v", addresses, expected)
+	}
+
+	event = dnsEventT{}
+	qr, addresses = parseDNSResponse(rawSample)
+	if qr != "Q" || addresses != nil {
+		t.Fatalf("Failed to parse DNS query: got qr %q and addresses %v", qr, addresses)
+	}
+}
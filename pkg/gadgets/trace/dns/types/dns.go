@@ -23,8 +23,16 @@ type Event struct {
 	eventtypes.Event
 
 	PktType string `json:"pktType,omitempty" column:"type,minWidth:7,maxWidth:9"`
+
+	// QR is "Q" for queries and "R" for responses
+	QR string `json:"qr,omitempty" column:"qr,width:2,fixed,hide"`
+
 	QType   string `json:"qtype,omitempty" column:"qtype,minWidth:5,maxWidth:10"`
 	DNSName string `json:"name,omitempty" column:"name,width:30"`
+
+	// Addresses are the IPv4 and IPv6 addresses of the answers of a
+	// response.
+	Addresses []string `json:"addresses,omitempty" column:"addresses,width:32,hide"`
 }
 
 func GetColumns() *columns.Columns[Event] {
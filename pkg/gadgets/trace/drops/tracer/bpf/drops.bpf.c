@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2022 The Inspektor Gadget authors */
+
+#include <vmlinux/vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_endian.h>
+#include <bpf/bpf_tracing.h>
+
+#include "drops.h"
+
+/* Define here, because there are conflicts with include files */
+#define AF_INET		2
+#define AF_INET6	10
+
+#define ETH_P_IP	0x0800
+#define ETH_P_IPV6	0x86DD
+
+#define IPPROTO_TCP	6
+#define IPPROTO_UDP	17
+
+/* Offsets of the fields used in the IP headers */
+#define IPV4_PROTO_OFF	9
+#define IPV4_SADDR_OFF	12
+#define IPV4_DADDR_OFF	16
+#define IPV6_NEXTHDR_OFF	6
+#define IPV6_SADDR_OFF	8
+#define IPV6_DADDR_OFF	24
+#define IPV6_HLEN	40
+
+const volatile bool filter_by_netns = false;
+
+/*
+ * The drop reason was added to the kfree_skb tracepoint in Linux 5.17. It's
+ * defined here to be able to check whether it exists with CO-RE.
+ */
+enum skb_drop_reason___ig {
+	SKB_DROP_REASON_NOT_SPECIFIED___ig = 2,
+};
+
+struct trace_event_raw_kfree_skb___ig {
+	enum skb_drop_reason___ig reason;
+} __attribute__((preserve_access_index));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 1024);
+	__uint(key_size, sizeof(u64));
+	__uint(value_size, sizeof(u32));
+} netns_filter SEC(".maps");
+
+static __always_inline u64 get_netns(struct sk_buff *skb, struct sock *sk)
+{
+	struct net_device *dev = BPF_CORE_READ(skb, dev);
+
+	if (dev)
+		return (u64) BPF_CORE_READ(dev, nd_net.net, ns.inum);
+	if (sk)
+		return (u64) BPF_CORE_READ(sk, __sk_common.skc_net.net, ns.inum);
+	return 0;
+}
+
+// Packets are mostly dropped in softirq context, not in the one of the
+// process owning the socket, so the events are filtered by the network
+// namespace of the device or the socket.
+SEC("tracepoint/skb/kfree_skb")
+int ig_drops(struct trace_event_raw_kfree_skb *args)
+{
+	struct trace_event_raw_kfree_skb___ig *args_reason = (void *)args;
+	struct event event = {};
+	unsigned char *head;
+	struct sk_buff *skb;
+	struct sock *sk;
+	__u16 nh, th;
+	__u16 protocol;
+	__u8 vihl;
+	u64 netns;
+
+	protocol = BPF_CORE_READ(args, protocol);
+	if (protocol != ETH_P_IP && protocol != ETH_P_IPV6)
+		return 0;
+
+	skb = BPF_CORE_READ(args, skbaddr);
+	sk = BPF_CORE_READ(skb, sk);
+
+	netns = get_netns(skb, sk);
+	if (filter_by_netns && !bpf_map_lookup_elem(&netns_filter, &netns))
+		return 0;
+
+	head = BPF_CORE_READ(skb, head);
+	nh = BPF_CORE_READ(skb, network_header);
+	th = BPF_CORE_READ(skb, transport_header);
+	// Headers not set yet
+	if (nh == (__u16)~0U)
+		return 0;
+
+	if (protocol == ETH_P_IP) {
+		event.af = AF_INET;
+		bpf_probe_read_kernel(&vihl, sizeof(vihl), head + nh);
+		bpf_probe_read_kernel(&event.proto, sizeof(event.proto),
+				      head + nh + IPV4_PROTO_OFF);
+		bpf_probe_read_kernel(&event.saddr_v4, sizeof(event.saddr_v4),
+				      head + nh + IPV4_SADDR_OFF);
+		bpf_probe_read_kernel(&event.daddr_v4, sizeof(event.daddr_v4),
+				      head + nh + IPV4_DADDR_OFF);
+		if (th == (__u16)~0U || th == nh)
+			th = nh + (vihl & 0x0f) * 4;
+	} else {
+		event.af = AF_INET6;
+		bpf_probe_read_kernel(&event.proto, sizeof(event.proto),
+				      head + nh + IPV6_NEXTHDR_OFF);
+		bpf_probe_read_kernel(&event.saddr_v6, sizeof(event.saddr_v6),
+				      head + nh + IPV6_SADDR_OFF);
+		bpf_probe_read_kernel(&event.daddr_v6, sizeof(event.daddr_v6),
+				      head + nh + IPV6_DADDR_OFF);
+		if (th == (__u16)~0U || th == nh)
+			th = nh + IPV6_HLEN;
+	}
+
+	// Source and destination ports are at the beginning of both the TCP
+	// and UDP headers.
+	if (event.proto == IPPROTO_TCP || event.proto == IPPROTO_UDP) {
+		bpf_probe_read_kernel(&event.sport, sizeof(event.sport), head + th);
+		bpf_probe_read_kernel(&event.dport, sizeof(event.dport), head + th + 2);
+		event.sport = bpf_ntohs(event.sport);
+		event.dport = bpf_ntohs(event.dport);
+	}
+
+	if (sk && event.proto == IPPROTO_TCP)
+		event.state = BPF_CORE_READ(sk, __sk_common.skc_state);
+
+	if (bpf_core_field_exists(args_reason->reason))
+		event.reason = BPF_CORE_READ(args_reason, reason);
+
+	event.netns = netns;
+
+	bpf_perf_event_output(args, &events, BPF_F_CURRENT_CPU,
+			      &event, sizeof(event));
+
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,284 @@
+//go:build linux
+// +build linux
+
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+// #include <linux/types.h>
+// #include "./bpf/drops.h"
+// #include <arpa/inet.h>
+// #include <stdlib.h>
+//
+//static char *addr_str(const void *addr, __u32 af) {
+//	size_t size = af == AF_INET ? INET_ADDRSTRLEN : INET6_ADDRSTRLEN;
+//	char *str;
+//
+//	str = malloc(size);
+//	if (!str)
+//		return NULL;
+//
+//	inet_ntop(af, addr, str, size);
+//
+//	return str;
+//}
+//
+//static char *get_src_addr(const struct event *ev) {
+//	if (ev->af == AF_INET)
+//		return addr_str(&ev->saddr_v4, ev->af);
+//	else if (ev->af == AF_INET6)
+//		return addr_str(&ev->saddr_v6, ev->af);
+//	else
+//		return NULL;
+//}
+//
+//static char *get_dst_addr(const struct event *ev) {
+//	if (ev->af == AF_INET)
+//		return addr_str(&ev->daddr_v4, ev->af);
+//	else if (ev->af == AF_INET6)
+//		return addr_str(&ev->daddr_v6, ev->af);
+//	else
+//		return NULL;
+//}
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/drops/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/tcpstates"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang drops ./bpf/drops.bpf.c -- -I./bpf/ -I../../../../${TARGET}
+
+type Config struct {
+	// FilterByNetNs only reports the packets dropped in the network
+	// namespaces added with AttachNetNs.
+	FilterByNetNs bool
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.NetNsEnricher
+	eventCallback func(types.Event)
+
+	objs        dropsObjects
+	dropsLink   link.Link
+	reader      *perf.Reader
+	netnsFilter *gadgets.NetNsFilter
+
+	// reasons are the names of the values of enum skb_drop_reason in the
+	// running kernel, they change across kernel versions.
+	reasons map[int32]string
+}
+
+func NewTracer(config *Config, enricher gadgets.NetNsEnricher,
+	eventCallback func(types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+	}
+
+	if err := t.start(); err != nil {
+		t.Stop()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Tracer) Stop() {
+	t.dropsLink = gadgets.CloseLink(t.dropsLink)
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+// AttachNetNs starts reporting the packets dropped in the given network
+// namespace.
+func (t *Tracer) AttachNetNs(netns uint64) error {
+	return t.netnsFilter.Add(netns)
+}
+
+// DetachNetNs stops reporting the packets dropped in the given network
+// namespace.
+func (t *Tracer) DetachNetNs(netns uint64) error {
+	return t.netnsFilter.Remove(netns)
+}
+
+// loadDropReasons reads the names of the drop reasons from the kernel BTF.
+func loadDropReasons() (map[int32]string, error) {
+	spec, err := btf.LoadKernelSpec()
+	if err != nil {
+		return nil, fmt.Errorf("loading kernel BTF: %w", err)
+	}
+
+	var enum *btf.Enum
+	if err := spec.TypeByName("skb_drop_reason", &enum); err != nil {
+		return nil, fmt.Errorf("looking for enum skb_drop_reason: %w", err)
+	}
+
+	reasons := make(map[int32]string, len(enum.Values))
+	for _, v := range enum.Values {
+		reasons[v.Value] = strings.TrimPrefix(v.Name, "SKB_DROP_REASON_")
+	}
+
+	return reasons, nil
+}
+
+func (t *Tracer) start() error {
+	reasons, err := loadDropReasons()
+	if err != nil {
+		// Kernels older than 5.17 don't provide the drop reason
+		log.Debugf("drop reasons not available: %s", err)
+	}
+	t.reasons = reasons
+
+	spec, err := loadDrops()
+	if err != nil {
+		return fmt.Errorf("failed to load ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"filter_by_netns": t.config.FilterByNetNs,
+	}
+
+	if err := spec.RewriteConstants(consts); err != nil {
+		return fmt.Errorf("error RewriteConstants: %w", err)
+	}
+
+	if err := spec.LoadAndAssign(&t.objs, &ebpf.CollectionOptions{}); err != nil {
+		return fmt.Errorf("failed to load ebpf program: %w", err)
+	}
+
+	t.netnsFilter = gadgets.NewNetNsFilter(t.objs.NetnsFilter)
+
+	t.dropsLink, err = link.Tracepoint("skb", "kfree_skb", t.objs.IgDrops, nil)
+	if err != nil {
+		return fmt.Errorf("error attaching tracepoint: %w", err)
+	}
+
+	reader, err := perf.NewReader(t.objs.dropsMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("error creating perf ring buffer: %w", err)
+	}
+	t.reader = reader
+
+	go t.run()
+
+	return nil
+}
+
+func protoString(proto uint8) string {
+	// proto definitions:
+	// https://www.iana.org/assignments/protocol-numbers/protocol-numbers.xhtml
+	switch proto {
+	case 1:
+		return "icmp"
+	case 6:
+		return "tcp"
+	case 17:
+		return "udp"
+	case 58:
+		return "icmp6"
+	}
+	return fmt.Sprintf("UNKNOWN#%d", proto)
+}
+
+func (t *Tracer) reasonString(reason int32) string {
+	if name, ok := t.reasons[reason]; ok {
+		return name
+	}
+	if t.reasons == nil {
+		return ""
+	}
+	return fmt.Sprintf("UNKNOWN#%d", reason)
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		eventC := (*C.struct_event)(unsafe.Pointer(&record.RawSample[0]))
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type: eventtypes.NORMAL,
+			},
+			NetNsID: uint64(eventC.netns),
+			Proto:   protoString(uint8(eventC.proto)),
+			Sport:   uint16(eventC.sport),
+			Dport:   uint16(eventC.dport),
+			Reason:  t.reasonString(int32(eventC.reason)),
+		}
+
+		if eventC.state != 0 {
+			event.State = tcpstates.StateName(uint8(eventC.state))
+		}
+
+		if eventC.af == C.AF_INET {
+			event.IPVersion = 4
+		} else if eventC.af == C.AF_INET6 {
+			event.IPVersion = 6
+		}
+
+		srcAddr := C.get_src_addr(eventC)
+		event.Saddr = C.GoString(srcAddr)
+		C.free(unsafe.Pointer(srcAddr))
+
+		dstAddr := C.get_dst_addr(eventC)
+		event.Daddr = C.GoString(dstAddr)
+		C.free(unsafe.Pointer(dstAddr))
+
+		if t.enricher != nil {
+			t.enricher.EnrichByNetNs(&event.CommonData, event.NetNsID)
+		}
+
+		t.eventCallback(event)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Event is emitted each time the kernel drops a packet. The Kubernetes
+// information of the embedded event is the one of the pod in whose network
+// namespace the packet was dropped.
+type Event struct {
+	eventtypes.Event
+
+	IPVersion int    `json:"ipversion,omitempty" column:"ip,width:2,fixed"`
+	Proto     string `json:"proto,omitempty" column:"proto,width:5,fixed"`
+	Saddr     string `json:"saddr,omitempty" column:"saddr,template:ipaddr"`
+	Sport     uint16 `json:"sport,omitempty" column:"sport,template:ipport"`
+	Daddr     string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
+	Dport     uint16 `json:"dport,omitempty" column:"dport,template:ipport"`
+	State     string `json:"state,omitempty" column:"state,width:11,maxWidth:11"`
+	Reason    string `json:"reason,omitempty" column:"reason,width:20,maxWidth:40"`
+
+	// Kubernetes objects the source and destination addresses belong to,
+	// if any
+	SrcKind string `json:"srcKind,omitempty" column:"srckind,width:5,hide"`
+	SrcName string `json:"srcName,omitempty" column:"srcname,width:24,maxWidth:64"`
+	DstKind string `json:"dstKind,omitempty" column:"dstkind,width:5,hide"`
+	DstName string `json:"dstName,omitempty" column:"dstname,width:24,maxWidth:64"`
+
+	NetNsID uint64 `json:"netnsid,omitempty" column:"netns,template:ns"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func Base(ev eventtypes.Event) Event {
+	return Event{
+		Event: ev,
+	}
+}
+
+func (e Event) GetBaseEvent() eventtypes.Event {
+	return e.Event
+}
@@ -7,6 +7,13 @@
 #endif /* __TARGET_ARCH_arm64 */
 #include "execsnoop.h"
 
+#define OVERLAYFS_SUPER_MAGIC 0x794c7630
+
+#ifndef container_of
+#define container_of(ptr, type, member) \
+	((type *)((void *)(ptr) - offsetof(type, member)))
+#endif
+
 const volatile bool ignore_failed = true;
 const volatile uid_t targ_uid = INVALID_UID;
 const volatile int max_args = DEFAULT_MAXARGS;
@@ -126,6 +133,93 @@ int ig_execve_e(struct trace_event_raw_sys_enter* ctx)
 	return 0;
 }
 
+/* upper_dentry returns the dentry of the upper layer of an overlayfs inode,
+ * NULL when the file only exists in the lower layers. struct ovl_inode is
+ * defined by the overlay module and isn't available in vmlinux.h, but its
+ * __upperdentry field directly follows the embedded VFS inode. */
+static __always_inline struct dentry *upper_dentry(struct inode *inode)
+{
+	struct dentry *upper = NULL;
+
+	bpf_probe_read_kernel(&upper, sizeof(upper),
+			      (void *)inode + bpf_core_type_size(struct inode));
+	return upper;
+}
+
+/* fill_exe sets the information about the file executed by the current
+ * task, once the exec succeeded. */
+static __always_inline void fill_exe(struct event *event)
+{
+	struct task_struct *task = (struct task_struct*)bpf_get_current_task();
+	struct file *exe_file = BPF_CORE_READ(task, mm, exe_file);
+	struct dentry *dentry, *parent;
+	struct inode *inode;
+	struct mount *mnt, *mnt_parent;
+	const unsigned char *name;
+	unsigned int size = 0;
+	char prefix[6];
+	int i, ret;
+
+	if (!exe_file)
+		return;
+
+	inode = BPF_CORE_READ(exe_file, f_inode);
+	event->exe_inode = BPF_CORE_READ(inode, i_ino);
+	if (BPF_CORE_READ(inode, __i_nlink) == 0)
+		event->exe_flags |= EXE_DELETED;
+	if (BPF_CORE_READ(inode, i_sb, s_magic) == OVERLAYFS_SUPER_MAGIC) {
+		event->exe_flags |= EXE_OVERLAY;
+		if (upper_dentry(inode))
+			event->exe_flags |= EXE_UPPER_LAYER;
+	}
+
+	dentry = BPF_CORE_READ(exe_file, f_path.dentry);
+	name = BPF_CORE_READ(dentry, d_name.name);
+	if (bpf_probe_read_kernel(prefix, sizeof(prefix), name) == 0 &&
+	    prefix[0] == 'm' && prefix[1] == 'e' && prefix[2] == 'm' &&
+	    prefix[3] == 'f' && prefix[4] == 'd' && prefix[5] == ':')
+		event->exe_flags |= EXE_MEMFD;
+
+	/* Walk the dentries up to the root, crossing the mount points like
+	 * d_path() does. bpf_d_path() can't be used from tracepoints. */
+	mnt = container_of(BPF_CORE_READ(exe_file, f_path.mnt), struct mount, mnt);
+	#pragma unroll
+	for (i = 0; i < EXEPATH_MAX_DEPTH; i++) {
+		if (dentry == BPF_CORE_READ(mnt, mnt.mnt_root)) {
+			mnt_parent = BPF_CORE_READ(mnt, mnt_parent);
+			if (mnt_parent == mnt)
+				goto out;
+			dentry = BPF_CORE_READ(mnt, mnt_mountpoint);
+			mnt = mnt_parent;
+			continue;
+		}
+
+		/* The files that aren't linked in any directory, like the
+		 * memfd files, only have their own name. */
+		parent = BPF_CORE_READ(dentry, d_parent);
+		if (dentry == parent && size != 0)
+			goto out;
+
+		if (size > LAST_EXEPATH_NAME)
+			break;
+
+		name = BPF_CORE_READ(dentry, d_name.name);
+		ret = bpf_probe_read_kernel_str(&event->exepath[size],
+						EXEPATH_NAME_SIZE, name);
+		if (ret <= 0)
+			break;
+		size += ret;
+
+		if (dentry == parent)
+			goto out;
+		dentry = parent;
+	}
+
+	event->exe_flags |= EXE_PATH_TRUNCATED;
+out:
+	event->exepath_size = size;
+}
+
 #ifdef __TARGET_ARCH_arm64
 SEC("kretprobe/do_execveat_common.isra.0")
 int BPF_KRETPROBE(ig_execveat_x)
@@ -157,6 +251,8 @@ int ig_execve_x(struct trace_event_raw_sys_exit* ctx)
 
 	event->retval = ret;
 	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+	if (ret == 0)
+		fill_exe(event);
 	size_t len = EVENT_SIZE(event);
 	if (len <= sizeof(*event))
 		bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, len);
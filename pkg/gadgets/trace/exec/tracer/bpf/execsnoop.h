@@ -11,6 +11,17 @@
 #define BASE_EVENT_SIZE (size_t)(&((struct event*)0)->args)
 #define EVENT_SIZE(e) (BASE_EVENT_SIZE + e->args_size)
 #define LAST_ARG (FULL_MAX_ARGS_ARR - ARGSIZE)
+#define EXEPATH_SIZE 1024
+#define EXEPATH_NAME_SIZE 256
+#define EXEPATH_MAX_DEPTH 32
+#define LAST_EXEPATH_NAME (EXEPATH_SIZE - EXEPATH_NAME_SIZE)
+
+/* Flags describing the executed file */
+#define EXE_OVERLAY (1 << 0)
+#define EXE_UPPER_LAYER (1 << 1)
+#define EXE_MEMFD (1 << 2)
+#define EXE_DELETED (1 << 3)
+#define EXE_PATH_TRUNCATED (1 << 4)
 
 struct event {
 	__u32 pid;
@@ -21,6 +32,12 @@ struct event {
 	int args_count;
 	unsigned int args_size;
 	char comm[TASK_COMM_LEN];
+	__u64 exe_inode;
+	__u32 exe_flags;
+	/* Names of the path components of the executed file, from the file
+	 * to the root, separated by '\0' */
+	unsigned int exepath_size;
+	char exepath[EXEPATH_SIZE];
 	char args[FULL_MAX_ARGS_ARR];
 };
 
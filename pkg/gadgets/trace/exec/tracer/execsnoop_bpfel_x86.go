@@ -14,17 +14,21 @@ import (
 )
 
 type execsnoopEvent struct {
-	Pid       uint32
-	Ppid      uint32
-	Uid       uint32
-	_         [4]byte
-	MntnsId   uint64
-	Retval    int32
-	ArgsCount int32
-	ArgsSize  uint32
-	Comm      [16]int8
-	Args      [7680]int8
-	_         [4]byte
+	Pid         uint32
+	Ppid        uint32
+	Uid         uint32
+	_           [4]byte
+	MntnsId     uint64
+	Retval      int32
+	ArgsCount   int32
+	ArgsSize    uint32
+	Comm        [16]int8
+	_           [4]byte
+	ExeInode    uint64
+	ExeFlags    uint32
+	ExepathSize uint32
+	Exepath     [1024]int8
+	Args        [7680]int8
 }
 
 // loadExecsnoop returns the embedded CollectionSpec for execsnoop.
@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
@@ -174,6 +175,8 @@ func (t *Tracer) run() {
 			}
 		}
 
+		parseExe(eventC, &event)
+
 		if t.enricher != nil {
 			t.enricher.Enrich(&event.CommonData, event.MountNsID)
 		}
@@ -181,3 +184,39 @@ func (t *Tracer) run() {
 		t.eventCallback(event)
 	}
 }
+
+// parseExe sets the information about the executed file. The eBPF program
+// gives the names of the path components from the file to the root.
+func parseExe(eventC *C.struct_event, event *types.Event) {
+	event.ExeInode = uint64(eventC.exe_inode)
+
+	flags := uint32(eventC.exe_flags)
+	if flags&C.EXE_OVERLAY != 0 {
+		if flags&C.EXE_UPPER_LAYER != 0 {
+			event.ExeLayer = types.ExeLayerUpper
+		} else {
+			event.ExeLayer = types.ExeLayerLower
+		}
+	}
+	event.ExeMemfd = flags&C.EXE_MEMFD != 0
+	event.ExeDeleted = flags&C.EXE_DELETED != 0
+
+	size := int(eventC.exepath_size)
+	if size == 0 {
+		return
+	}
+	if size > len(eventC.exepath) {
+		size = len(eventC.exepath)
+	}
+	buf := C.GoBytes(unsafe.Pointer(&eventC.exepath[0]), C.int(size))
+	names := strings.Split(strings.TrimSuffix(string(buf), "\x00"), "\x00")
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+
+	prefix := "/"
+	if flags&C.EXE_PATH_TRUNCATED != 0 {
+		prefix = ".../"
+	}
+	event.ExePath = prefix + strings.Join(names, "/")
+}
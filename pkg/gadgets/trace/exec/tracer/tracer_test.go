@@ -19,12 +19,18 @@ package tracer_test
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/sys/unix"
+
 	utilstest "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/test"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/tracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
@@ -61,6 +67,8 @@ func TestExecTracer(t *testing.T) {
 
 	const unprivilegedUID = int(1435)
 
+	catPath, catInode := exeInfo(t, "/bin/cat")
+
 	manyArgs := []string{}
 	// 19 is DEFAULT_MAXARGS - 1 (-1 because args[0] is on the first position).
 	for i := 0; i < 19; i++ {
@@ -92,6 +100,8 @@ func TestExecTracer(t *testing.T) {
 					Retval:    0,
 					Comm:      "cat",
 					Args:      []string{"/bin/cat", "/dev/null"},
+					ExePath:   catPath,
+					ExeInode:  catInode,
 				}
 			}),
 		},
@@ -123,6 +133,8 @@ func TestExecTracer(t *testing.T) {
 					Retval:    0,
 					Comm:      "cat",
 					Args:      []string{"/bin/cat", "/dev/null"},
+					ExePath:   catPath,
+					ExeInode:  catInode,
 				}
 			}),
 		},
@@ -168,6 +180,55 @@ func TestExecTracer(t *testing.T) {
 				}
 			},
 		},
+		"event_has_layer_of_overlayfs_exe": {
+			getTracerConfig: func(info *utilstest.RunnerInfo) *tracer.Config {
+				return &tracer.Config{
+					MountnsMap: utilstest.CreateMntNsFilterMap(t, info.MountNsID),
+				}
+			},
+			generateEvent: func() (int, error) {
+				return generateOverlayEvents(t)
+			},
+			validateEvent: func(t *testing.T, info *utilstest.RunnerInfo, _ int, events []types.Event) {
+				if len(events) != 2 {
+					t.Fatalf("Two events expected, %d found", len(events))
+				}
+
+				layers := map[string]string{}
+				for _, e := range events {
+					layers[filepath.Base(e.ExePath)] = e.ExeLayer
+				}
+				expected := map[string]string{
+					"image-cat":   types.ExeLayerLower,
+					"written-cat": types.ExeLayerUpper,
+				}
+				if diff := cmp.Diff(expected, layers); diff != "" {
+					t.Fatalf("Events have bad layers, diff: \n%s", diff)
+				}
+			},
+		},
+		"event_has_memfd_exe": {
+			getTracerConfig: func(info *utilstest.RunnerInfo) *tracer.Config {
+				return &tracer.Config{
+					MountnsMap: utilstest.CreateMntNsFilterMap(t, info.MountNsID),
+				}
+			},
+			generateEvent: generateMemfdEvent,
+			validateEvent: func(t *testing.T, info *utilstest.RunnerInfo, _ int, events []types.Event) {
+				if len(events) != 1 {
+					t.Fatalf("One event expected")
+				}
+
+				utilstest.Equal(t, "/memfd:exec-test", events[0].ExePath,
+					"Event has bad exe path")
+				utilstest.Equal(t, true, events[0].ExeMemfd,
+					"Event has bad memfd flag")
+				utilstest.Equal(t, true, events[0].ExeDeleted,
+					"Event has bad deleted flag")
+				utilstest.Equal(t, "", events[0].ExeLayer,
+					"Event has bad layer")
+			},
+		},
 	} {
 		test := test
 
@@ -292,3 +353,102 @@ func generateEvent() (int, error) {
 
 	return cmd.Process.Pid, nil
 }
+
+// exeInfo returns the path and the inode of an executable, as seen by the
+// kernel, i.e. with the symbolic links resolved.
+func exeInfo(t *testing.T, path string) (string, uint64) {
+	t.Helper()
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatalf("Error resolving %s: %s", path, err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		t.Fatalf("Error getting info of %s: %s", resolved, err)
+	}
+
+	return resolved, info.Sys().(*syscall.Stat_t).Ino
+}
+
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0o755)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// generateOverlayEvents executes a binary from the lower layer of an overlayfs
+// mount and one written in the mount, like in a container.
+func generateOverlayEvents(t *testing.T) (int, error) {
+	dir := t.TempDir()
+	lower := filepath.Join(dir, "lower")
+	upper := filepath.Join(dir, "upper")
+	work := filepath.Join(dir, "work")
+	merged := filepath.Join(dir, "merged")
+	for _, d := range []string{lower, upper, work, merged} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := copyFile(filepath.Join(lower, "image-cat"), "/bin/cat"); err != nil {
+		return 0, fmt.Errorf("copying cat: %w", err)
+	}
+
+	opts := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", lower, upper, work)
+	if err := unix.Mount("overlay", merged, "overlay", 0, opts); err != nil {
+		return 0, fmt.Errorf("mounting overlayfs: %w", err)
+	}
+	defer unix.Unmount(merged, unix.MNT_DETACH)
+
+	if err := copyFile(filepath.Join(merged, "written-cat"), "/bin/cat"); err != nil {
+		return 0, fmt.Errorf("copying cat: %w", err)
+	}
+
+	for _, name := range []string{"image-cat", "written-cat"} {
+		cmd := exec.Command(filepath.Join(merged, name), "/dev/null")
+		if err := cmd.Run(); err != nil {
+			return 0, fmt.Errorf("running command: %w", err)
+		}
+	}
+
+	return 0, nil
+}
+
+// generateMemfdEvent executes a binary only existing in memory.
+func generateMemfdEvent() (int, error) {
+	fd, err := unix.MemfdCreate("exec-test", unix.MFD_CLOEXEC)
+	if err != nil {
+		return 0, fmt.Errorf("creating memfd: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "memfd")
+	defer f.Close()
+
+	in, err := os.Open("/bin/cat")
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	if _, err := io.Copy(f, in); err != nil {
+		return 0, fmt.Errorf("copying cat: %w", err)
+	}
+
+	cmd := exec.Command("/proc/self/fd/3", "/dev/null")
+	cmd.ExtraFiles = []*os.File{f}
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("running command: %w", err)
+	}
+
+	return cmd.Process.Pid, nil
+}
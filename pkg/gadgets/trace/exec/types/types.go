@@ -31,8 +31,31 @@ type Event struct {
 	Args      []string `json:"args,omitempty" column:"args,width:40"`
 	UID       uint32   `json:"uid,omitempty" column:"uid,minWidth:10,hide"`
 	MountNsID uint64   `json:"mountnsid,omitempty" column:"mntns,template:ns"`
+
+	// ExePath is the path of the executed file, as resolved by the
+	// kernel. It's prefixed with ".../" when it has too many components.
+	ExePath  string `json:"exepath,omitempty" column:"exepath,width:40,hide"`
+	ExeInode uint64 `json:"exeinode,omitempty" column:"exeinode,minWidth:8,hide"`
+
+	// ExeLayer is the overlayfs layer of the executed file: "upper" when
+	// it was written after the container started, "lower" when it comes
+	// from the image. It's empty for the other filesystems.
+	ExeLayer string `json:"exelayer,omitempty" column:"exelayer,width:8,fixed,hide"`
+
+	// ExeMemfd is set when the executed file was created by
+	// memfd_create(), i.e. it only exists in memory.
+	ExeMemfd bool `json:"exememfd,omitempty" column:"exememfd,width:8,fixed,hide"`
+	// ExeDeleted is set when the executed file was removed, including
+	// the memfd files.
+	ExeDeleted bool `json:"exedeleted,omitempty" column:"exedeleted,width:10,fixed,hide"`
 }
 
+// Overlayfs layers of the executed files
+const (
+	ExeLayerUpper = "upper"
+	ExeLayerLower = "lower"
+)
+
 func GetColumns() *columns.Columns[Event] {
 	execColumns := columns.MustCreateColumns[Event]()
 
@@ -30,9 +30,9 @@ type Event struct {
 	Pid       uint32 `json:"pid,omitempty" column:"pid,template:pid"`
 	Comm      string `json:"comm,omitempty" column:"comm,template:comm"`
 	Op        string `json:"op,omitempty" column:"T,width:1,fixed"`
-	Bytes     uint64 `json:"bytes,omitempty" column:"bytes,width:10,align:right"`
-	Offset    int64  `json:"offset,omitempty" column:"offset,width:10,align:right"`
-	Latency   uint64 `json:"latency,omitempty" column:"lat,width:10,align:right"`
+	Bytes     uint64 `json:"bytes,omitempty" column:"bytes,width:10,align:right,unit:bytes"`
+	Offset    int64  `json:"offset,omitempty" column:"offset,width:10,align:right,unit:bytes"`
+	Latency   uint64 `json:"latency,omitempty" column:"lat,width:10,align:right,unit:us"`
 	File      string `json:"file,omitempty" column:"file,width:24,maxWidth:32"`
 }
 
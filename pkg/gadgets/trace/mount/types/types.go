@@ -31,7 +31,7 @@ type Event struct {
 	MountNsID uint64   `json:"mntnsid,omitempty" column:"mntns,template:ns"`
 	Operation string   `json:"operation,omitempty" column:"op,minWidth:5,maxWidth:7,hide"`
 	Retval    int      `json:"ret,omitempty" column:"ret,width:3,fixed,hide"`
-	Latency   uint64   `json:"latency,omitempty" column:"latency,minWidth:3,hide"`
+	Latency   uint64   `json:"latency,omitempty" column:"latency,minWidth:3,hide,unit:ns"`
 	Fs        string   `json:"fs,omitempty" column:"fs,minWidth:3,maxWidth:8,hide"`
 	Source    string   `json:"source,omitempty" column:"src,width:16,hide"`
 	Target    string   `json:"target,omitempty" column:"dst,width:16,hide"`
@@ -10,6 +10,7 @@
 #include <linux/if_ether.h>
 #include <linux/if_packet.h>
 #include <linux/ip.h>
+#include <linux/ipv6.h>
 #include <linux/in.h>
 #include <linux/tcp.h>
 #include <linux/udp.h>
@@ -25,6 +26,18 @@
 #define ETH_P_IP	0x0800
 #endif
 
+#ifndef ETH_P_IPV6
+#define ETH_P_IPV6	0x86DD
+#endif
+
+#ifndef AF_INET
+#define AF_INET		2
+#endif
+
+#ifndef AF_INET6
+#define AF_INET6	10
+#endif
+
 #ifndef ETH_HLEN
 #define ETH_HLEN	14
 #endif
@@ -39,6 +52,34 @@
 
 const volatile u64 container_quark = 0;
 
+static __always_inline void update_edge(struct graph_key_t *key, __u32 len)
+{
+	struct graph_value_t *value;
+	u64 now = bpf_ktime_get_ns();
+
+	value = bpf_map_lookup_elem(&graphmap, key);
+	if (!value) {
+		struct graph_value_t first = {
+			.packets	= 1,
+			.bytes		= len,
+			.first_seen	= now,
+			.last_seen	= now,
+		};
+
+		if (!bpf_map_update_elem(&graphmap, key, &first, BPF_NOEXIST))
+			return;
+
+		// Another CPU created the edge in the meantime.
+		value = bpf_map_lookup_elem(&graphmap, key);
+		if (!value)
+			return;
+	}
+
+	__sync_fetch_and_add(&value->packets, 1);
+	__sync_fetch_and_add(&value->bytes, len);
+	value->last_seen = now;
+}
+
 SEC("socket1")
 int ig_trace_net(struct __sk_buff *skb)
 {
@@ -50,68 +91,124 @@ int ig_trace_net(struct __sk_buff *skb)
 	struct ethhdr ethh;
 	if (bpf_skb_load_bytes(skb, 0, &ethh, sizeof ethh))
 		return 0;
-	if (bpf_ntohs(ethh.h_proto) != ETH_P_IP)
-		return 0;
 
 	int ip_off = ETH_HLEN;
-	// Read the IP header.
-	struct iphdr iph;
-	if (bpf_skb_load_bytes(skb, ip_off, &iph, sizeof iph))
+	int l4_off;
+	u8 saddr[16] = {};
+	u8 daddr[16] = {};
+	u16 family;
+	u8 proto;
+
+	if (bpf_ntohs(ethh.h_proto) == ETH_P_IP) {
+		// Read the IP header.
+		struct iphdr iph;
+		if (bpf_skb_load_bytes(skb, ip_off, &iph, sizeof iph))
+			return 0;
+
+		// An IPv4 header doesn't have a fixed size. The IHL field of a packet
+		// represents the size of the IP header in 32-bit words, so we need to
+		// multiply this value by 4 to get the header size in bytes.
+		__u8 ip_header_len = iph.ihl * 4;
+		l4_off = ip_off + ip_header_len;
+		family = AF_INET;
+		proto = iph.protocol;
+		__builtin_memcpy(saddr, &iph.saddr, sizeof(iph.saddr));
+		__builtin_memcpy(daddr, &iph.daddr, sizeof(iph.daddr));
+	} else if (bpf_ntohs(ethh.h_proto) == ETH_P_IPV6) {
+		// Read the IPv6 header. Packets with extension headers are
+		// skipped below as their next header isn't TCP or UDP.
+		struct ipv6hdr ip6h;
+		if (bpf_skb_load_bytes(skb, ip_off, &ip6h, sizeof ip6h))
+			return 0;
+
+		l4_off = ip_off + sizeof(ip6h);
+		family = AF_INET6;
+		proto = ip6h.nexthdr;
+		__builtin_memcpy(saddr, &ip6h.saddr, sizeof(saddr));
+		__builtin_memcpy(daddr, &ip6h.daddr, sizeof(daddr));
+	} else {
 		return 0;
+	}
 
-	// An IPv4 header doesn't have a fixed size. The IHL field of a packet
-	// represents the size of the IP header in 32-bit words, so we need to
-	// multiply this value by 4 to get the header size in bytes.
-	__u8 ip_header_len = iph.ihl * 4;
-	int l4_off = ip_off + ip_header_len;
-	u16 port;
+	// The edge is identified by the remote address and the port of the
+	// server. fwd_key is the edge of a flow initiated by the sender of the
+	// packet, rev_key the one of a flow initiated by its receiver.
+	struct graph_key_t fwd_key = {};
+	struct graph_key_t rev_key = {};
+	u16 sport, dport;
+	int syn = 0;
 
-	if (iph.protocol == IPPROTO_TCP) {
+	if (proto == IPPROTO_TCP) {
 		// Read the TCP header.
 		struct tcphdr tcph;
 		if (bpf_skb_load_bytes(skb, l4_off, &tcph, sizeof tcph))
 			return 0;
 
-		if (!tcph.syn || tcph.ack)
-			return 0;
-
-		port = tcph.dest;
-	} else if (iph.protocol == IPPROTO_UDP) {
+		syn = tcph.syn && !tcph.ack;
+		sport = tcph.source;
+		dport = tcph.dest;
+	} else if (proto == IPPROTO_UDP) {
 		// Read the UDP header.
 		struct udphdr udph;
 		if (bpf_skb_load_bytes(skb, l4_off, &udph, sizeof udph))
 			return 0;
 
-		// UDP packets don't have a TCP-SYN to identify the direction.
-		// Check usage of dynamic ports instead.
-		// https://www.iana.org/assignments/service-names-port-numbers/service-names-port-numbers.xhtml
-		// System Ports: 0-1023
-		// User Ports: 1024-49151
-		// Dynamic and/or Private Ports: 49152-65535
-		// However, Linux uses ephemeral ports: 32768-60999 (/proc/sys/net/ipv4/ip_local_port_range)
-		// And /proc/sys/net/ipv4/ip_unprivileged_port_start: 1024
-		if (bpf_htons(udph.dest) < 1024)
-			port = udph.dest;
-		else
-			return 0;
+		sport = udph.source;
+		dport = udph.dest;
 	} else {
 		// Skip packets with IP protocol other than TCP/UDP.
 		return 0;
 	}
 
-	struct graph_key_t key = {};
-	key.container_quark	= container_quark;
-	key.pkt_type		= skb->pkt_type;
-	key.proto		= iph.protocol;
-	key.port		= port;
+	fwd_key.container_quark	= container_quark;
+	fwd_key.pkt_type	= skb->pkt_type;
+	fwd_key.proto		= proto;
+	fwd_key.port		= dport;
+	fwd_key.family		= family;
+
+	rev_key.container_quark	= container_quark;
+	rev_key.pkt_type	= skb->pkt_type == PACKET_HOST ? PACKET_OUTGOING : PACKET_HOST;
+	rev_key.proto		= proto;
+	rev_key.port		= sport;
+	rev_key.family		= family;
+
 	if (skb->pkt_type == PACKET_HOST) {
-		key.ip		= iph.saddr;
+		__builtin_memcpy(fwd_key.ip, saddr, sizeof(fwd_key.ip));
+		__builtin_memcpy(rev_key.ip, saddr, sizeof(rev_key.ip));
 	} else {
-		key.ip		= iph.daddr;
+		__builtin_memcpy(fwd_key.ip, daddr, sizeof(fwd_key.ip));
+		__builtin_memcpy(rev_key.ip, daddr, sizeof(rev_key.ip));
+	}
+
+	if (proto == IPPROTO_TCP) {
+		// The SYN gives the direction of the connection, the following
+		// segments are accounted to the edge it created.
+		if (syn) {
+			u8 one = 1;
+
+			bpf_map_update_elem(&known_edges, &fwd_key, &one, BPF_ANY);
+			update_edge(&fwd_key, skb->len);
+		} else if (bpf_map_lookup_elem(&known_edges, &fwd_key)) {
+			update_edge(&fwd_key, skb->len);
+		} else if (bpf_map_lookup_elem(&known_edges, &rev_key)) {
+			update_edge(&rev_key, skb->len);
+		}
+
+		return 0;
 	}
-	u64 zero = 0;
 
-	bpf_map_update_elem(&graphmap, &key, &zero, BPF_ANY);
+	// UDP packets don't have a TCP-SYN to identify the direction.
+	// Check usage of dynamic ports instead.
+	// https://www.iana.org/assignments/service-names-port-numbers/service-names-port-numbers.xhtml
+	// System Ports: 0-1023
+	// User Ports: 1024-49151
+	// Dynamic and/or Private Ports: 49152-65535
+	// However, Linux uses ephemeral ports: 32768-60999 (/proc/sys/net/ipv4/ip_local_port_range)
+	// And /proc/sys/net/ipv4/ip_unprivileged_port_start: 1024
+	if (bpf_htons(dport) < 1024)
+		update_edge(&fwd_key, skb->len);
+	else if (bpf_htons(sport) < 1024)
+		update_edge(&rev_key, skb->len);
 
 	return 0;
 }
@@ -19,9 +19,19 @@
 struct graph_key_t {
 	u64 container_quark;
 	u32 pkt_type;
-	u32 ip;
 	u16 proto;
 	u16 port;
+	// IPv4 addresses only use the first 4 bytes
+	u8 ip[16];
+	u16 family;
+};
+
+struct graph_value_t {
+	u64 packets;
+	u64 bytes;
+	// CLOCK_MONOTONIC timestamps in nanoseconds
+	u64 first_seen;
+	u64 last_seen;
 };
 
 #endif
@@ -13,7 +13,17 @@ struct {
 	__uint(type, BPF_MAP_TYPE_HASH);
 	__uint(max_entries, MAX_ENTRIES);
 	__type(key, struct graph_key_t);
-	__type(value, u64);
+	__type(value, struct graph_value_t);
 } graphmap SEC(".maps");
 
+// known_edges contains the TCP edges whose SYN was seen. Contrary to
+// graphmap, it isn't emptied by userspace, so the packets of the following
+// segments can still be accounted to the right edge.
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct graph_key_t);
+	__type(value, u8);
+} known_edges SEC(".maps");
+
 #endif
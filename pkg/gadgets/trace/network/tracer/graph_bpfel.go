@@ -16,10 +16,18 @@ import (
 type graphGraphKeyT struct {
 	ContainerQuark uint64
 	PktType        uint32
-	Ip             uint32
 	Proto          uint16
 	Port           uint16
-	_              [4]byte
+	Ip             [16]uint8
+	Family         uint16
+	_              [6]byte
+}
+
+type graphGraphValueT struct {
+	Packets   uint64
+	Bytes     uint64
+	FirstSeen uint64
+	LastSeen  uint64
 }
 
 // loadGraph returns the embedded CollectionSpec for graph.
@@ -70,7 +78,8 @@ type graphProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type graphMapSpecs struct {
-	Graphmap *ebpf.MapSpec `ebpf:"graphmap"`
+	Graphmap   *ebpf.MapSpec `ebpf:"graphmap"`
+	KnownEdges *ebpf.MapSpec `ebpf:"known_edges"`
 }
 
 // graphObjects contains all objects after they have been loaded into the kernel.
@@ -92,12 +101,14 @@ func (o *graphObjects) Close() error {
 //
 // It can be passed to loadGraphObjects or ebpf.CollectionSpec.LoadAndAssign.
 type graphMaps struct {
-	Graphmap *ebpf.Map `ebpf:"graphmap"`
+	Graphmap   *ebpf.Map `ebpf:"graphmap"`
+	KnownEdges *ebpf.Map `ebpf:"known_edges"`
 }
 
 func (m *graphMaps) Close() error {
 	return _GraphClose(
 		m.Graphmap,
+		m.KnownEdges,
 	)
 }
 
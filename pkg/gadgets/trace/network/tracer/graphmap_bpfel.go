@@ -16,10 +16,18 @@ import (
 type graphmapGraphKeyT struct {
 	ContainerQuark uint64
 	PktType        uint32
-	Ip             uint32
 	Proto          uint16
 	Port           uint16
-	_              [4]byte
+	Ip             [16]uint8
+	Family         uint16
+	_              [6]byte
+}
+
+type graphmapGraphValueT struct {
+	Packets   uint64
+	Bytes     uint64
+	FirstSeen uint64
+	LastSeen  uint64
 }
 
 // loadGraphmap returns the embedded CollectionSpec for graphmap.
@@ -69,7 +77,8 @@ type graphmapProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type graphmapMapSpecs struct {
-	Graphmap *ebpf.MapSpec `ebpf:"graphmap"`
+	Graphmap   *ebpf.MapSpec `ebpf:"graphmap"`
+	KnownEdges *ebpf.MapSpec `ebpf:"known_edges"`
 }
 
 // graphmapObjects contains all objects after they have been loaded into the kernel.
@@ -91,12 +100,14 @@ func (o *graphmapObjects) Close() error {
 //
 // It can be passed to loadGraphmapObjects or ebpf.CollectionSpec.LoadAndAssign.
 type graphmapMaps struct {
-	Graphmap *ebpf.Map `ebpf:"graphmap"`
+	Graphmap   *ebpf.Map `ebpf:"graphmap"`
+	KnownEdges *ebpf.Map `ebpf:"known_edges"`
 }
 
 func (m *graphmapMaps) Close() error {
 	return _GraphmapClose(
 		m.Graphmap,
+		m.KnownEdges,
 	)
 }
 
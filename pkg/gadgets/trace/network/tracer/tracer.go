@@ -15,11 +15,11 @@
 package tracer
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"net"
 	"syscall"
+	"time"
 
 	"github.com/cilium/ebpf"
 	"golang.org/x/sys/unix"
@@ -31,7 +31,7 @@ import (
 
 //go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang graph ./bpf/graph.c -- $CLANG_OS_FLAGS -I./bpf/"
 
-// /* for htons() and htonl() */
+// /* for htons() */
 // #include <arpa/inet.h>
 import "C"
 
@@ -45,6 +45,13 @@ type Edge struct {
 	IP      net.IP
 	Proto   string
 	Port    int
+
+	// Packets and Bytes count the traffic seen on the edge, in both
+	// directions, since the previous call to Pop().
+	Packets   uint64
+	Bytes     uint64
+	FirstSeen time.Time
+	LastSeen  time.Time
 }
 
 type link struct {
@@ -133,7 +140,8 @@ func (t *Tracer) Attach(key string, pid uint32) (err error) {
 		&l.networkGraphObjects,
 		&ebpf.CollectionOptions{
 			MapReplacements: map[string]*ebpf.Map{
-				"graphmap": t.networkGraphMapObjects.graphmapMaps.Graphmap,
+				"graphmap":    t.networkGraphMapObjects.graphmapMaps.Graphmap,
+				"known_edges": t.networkGraphMapObjects.graphmapMaps.KnownEdges,
 			},
 		},
 	); err != nil {
@@ -202,29 +210,56 @@ func (t *Tracer) containerQuarkToKey(quark uint64) string {
 	return key
 }
 
+// monotonicToTime returns a function converting the CLOCK_MONOTONIC
+// timestamps of the eBPF program to wall clock times.
+func monotonicToTime() (func(uint64) time.Time, error) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return nil, fmt.Errorf("getting monotonic time: %w", err)
+	}
+	offset := time.Now().UnixNano() - ts.Nano()
+
+	return func(monotonic uint64) time.Time {
+		return time.Unix(0, offset+int64(monotonic))
+	}, nil
+}
+
 func (t *Tracer) Pop() ([]Edge, error) {
 	graphmap := t.networkGraphMapObjects.graphmapMaps.Graphmap
 	edges := []Edge{}
 
-	convertKeyToEdge := func(key graphmapGraphKeyT) Edge {
-		ip := make(net.IP, 4)
-		binary.BigEndian.PutUint32(ip, uint32(C.htonl(C.uint(key.Ip))))
+	toTime, err := monotonicToTime()
+	if err != nil {
+		return nil, err
+	}
+
+	convertToEdge := func(key graphmapGraphKeyT, val graphmapGraphValueT) Edge {
+		var ip net.IP
+		if key.Family == syscall.AF_INET {
+			ip = net.IP(append([]byte{}, key.Ip[:net.IPv4len]...))
+		} else {
+			ip = net.IP(append([]byte{}, key.Ip[:]...))
+		}
 		return Edge{
-			Key:     t.containerQuarkToKey(uint64(key.ContainerQuark)),
-			PktType: pktTypeString(int(key.PktType)),
-			IP:      ip,
-			Proto:   protoString(int(key.Proto)),
-			Port:    int(C.htons(C.ushort(key.Port))),
+			Key:       t.containerQuarkToKey(uint64(key.ContainerQuark)),
+			PktType:   pktTypeString(int(key.PktType)),
+			IP:        ip,
+			Proto:     protoString(int(key.Proto)),
+			Port:      int(C.htons(C.ushort(key.Port))),
+			Packets:   val.Packets,
+			Bytes:     val.Bytes,
+			FirstSeen: toTime(val.FirstSeen),
+			LastSeen:  toTime(val.LastSeen),
 		}
 	}
 
 	for {
 		nextKey := graphmapGraphKeyT{}
 		deleteKeys := make([]graphmapGraphKeyT, 256)
-		deleteValues := make([]uint64, 256)
+		deleteValues := make([]graphmapGraphValueT, 256)
 		count, err := graphmap.BatchLookupAndDelete(nil, &nextKey, deleteKeys, deleteValues, nil)
 		for i := 0; i < count; i++ {
-			edges = append(edges, convertKeyToEdge(deleteKeys[i]))
+			edges = append(edges, convertToEdge(deleteKeys[i], deleteValues[i]))
 		}
 		if errors.Is(err, ebpf.ErrKeyNotExist) {
 			return edges, nil
@@ -239,11 +274,11 @@ func (t *Tracer) Pop() ([]Edge, error) {
 	}
 
 	key := graphmapGraphKeyT{}
-	val := uint64(0)
+	val := graphmapGraphValueT{}
 	entries := graphmap.Iterate()
 
 	for entries.Next(&key, &val) {
-		edges = append(edges, convertKeyToEdge(key))
+		edges = append(edges, convertToEdge(key, val))
 
 		// Deleting an entry during the iteration causes the iteration
 		// to restart from the first key in the hash map. But in this
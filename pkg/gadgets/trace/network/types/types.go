@@ -29,6 +29,13 @@ type Event struct {
 	IP      string `json:"ip,omitempty"`
 	Port    int    `json:"port,omitempty"`
 
+	// Traffic seen on the edge, in both directions, since the previous
+	// event. The timestamps are in nanoseconds since the Unix epoch.
+	Packets   uint64 `json:"packets,omitempty"`
+	Bytes     uint64 `json:"bytes,omitempty"`
+	FirstSeen int64  `json:"firstSeen,omitempty"`
+	LastSeen  int64  `json:"lastSeen,omitempty"`
+
 	/* pod, svc or other */
 	RemoteKind string `json:"remoteKind,omitempty"`
 
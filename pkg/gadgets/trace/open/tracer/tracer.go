@@ -198,6 +198,7 @@ func (t *Tracer) run() {
 			Ret:       ret,
 			Fd:        fd,
 			Err:       errval,
+			Flags:     int(eventC.flags),
 			Path:      C.GoString(&eventC.fname[0]),
 		}
 
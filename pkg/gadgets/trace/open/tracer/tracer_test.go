@@ -100,6 +100,7 @@ func TestOpenTracer(t *testing.T) {
 					Fd:        fd,
 					Ret:       fd,
 					Err:       0,
+					Flags:     unix.O_LARGEFILE,
 					Path:      "/dev/null",
 				}
 			}),
@@ -132,6 +133,7 @@ func TestOpenTracer(t *testing.T) {
 					Fd:        fd,
 					Ret:       fd,
 					Err:       0,
+					Flags:     unix.O_LARGEFILE,
 					Path:      "/dev/null",
 				}
 			}),
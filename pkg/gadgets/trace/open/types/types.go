@@ -28,7 +28,8 @@ type Event struct {
 	Comm      string `json:"pcomm,omitempty" column:"comm,maxWidth:16"`
 	Fd        int    `json:"fd,omitempty" column:"fd,minWidth:2,width:3"`
 	Ret       int    `json:"ret,omitempty" column:"ret,width:3,fixed,hide"`
-	Err       int    `json:"err,omitempty" column:"err,width:3,fixed"`
+	Err       int    `json:"err,omitempty" column:"err,width:7,fixed,kind:errno"`
+	Flags     int    `json:"flags,omitempty" column:"flags,width:8,fixed,hide"`
 	Path      string `json:"path,omitempty" column:"path,minWidth:24,width:32"`
 }
 
@@ -0,0 +1,683 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filter compiles tcpdump-like filter expressions into classic BPF
+// programs to be attached to packet sockets. Only a subset of the pcap-filter
+// syntax is supported:
+//
+//	[ip|ip6] [src|dst|src or dst|src and dst] host ADDR
+//	[ip|ip6] [src|dst|src or dst|src and dst] net ADDR[/LEN]
+//	[ip|ip6|tcp|udp|sctp] [src|dst|src or dst|src and dst] port PORT
+//	[ip|ip6|tcp|udp|sctp] [src|dst|src or dst|src and dst] portrange PORT-PORT
+//	ip | ip6 | arp | tcp | udp | sctp | icmp | icmp6
+//	greater LEN | less LEN
+//
+// Primitives can be combined with and (&&), or (||), not (!) and
+// parentheses. As in pcap-filter, and and or have the same precedence and
+// are evaluated from left to right, and identical qualifiers can be omitted,
+// e.g. "port 80 or 443". Packets are expected to have an Ethernet header.
+package filter
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/bpf"
+)
+
+const (
+	etherTypeOff = 12
+	etherHdrLen  = 14
+
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeARP  = 0x0806
+
+	ipv4ProtoOff = etherHdrLen + 9
+	ipv4FragOff  = etherHdrLen + 6
+	ipv4SrcOff   = etherHdrLen + 12
+	ipv4DstOff   = etherHdrLen + 16
+
+	ipv6NextHdrOff = etherHdrLen + 6
+	ipv6SrcOff     = etherHdrLen + 8
+	ipv6DstOff     = etherHdrLen + 24
+	ipv6HdrLen     = 40
+
+	protoICMP   = 1
+	protoTCP    = 6
+	protoUDP    = 17
+	protoICMPv6 = 58
+	protoSCTP   = 132
+)
+
+// Compile compiles expr into a classic BPF program capturing up to snapLen
+// bytes of the packets it matches. An empty expression matches all the
+// packets.
+func Compile(expr string, snapLen uint32) ([]bpf.Instruction, error) {
+	accept := []bpf.Instruction{bpf.RetConstant{Val: snapLen}}
+	if strings.TrimSpace(expr) == "" {
+		return accept, nil
+	}
+
+	p := &parser{tokens: tokenize(expr)}
+	n, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter", p.tokens[p.pos])
+	}
+
+	g := &generator{}
+	acceptLabel, rejectLabel := g.newLabel(), g.newLabel()
+	g.emit(n, acceptLabel, rejectLabel)
+	g.mark(acceptLabel)
+	g.items = append(g.items, item{insn: accept[0]})
+	g.mark(rejectLabel)
+	g.items = append(g.items, item{insn: bpf.RetConstant{Val: 0}})
+
+	return g.resolve()
+}
+
+// Validate checks that expr can be compiled.
+func Validate(expr string) error {
+	_, err := Compile(expr, 0)
+	return err
+}
+
+// The expression is parsed into a tree of and, or and not nodes whose leaves
+// are single comparisons on the packet.
+type node interface{}
+
+type andNode struct{ l, r node }
+
+type orNode struct{ l, r node }
+
+type notNode struct{ n node }
+
+// leaf runs loads and compares the accumulator to val.
+type leaf struct {
+	loads []bpf.Instruction
+	cond  bpf.JumpTest
+	val   uint32
+}
+
+func and(nodes ...node) node {
+	n := nodes[0]
+	for _, r := range nodes[1:] {
+		n = &andNode{n, r}
+	}
+	return n
+}
+
+func or(nodes ...node) node {
+	n := nodes[0]
+	for _, r := range nodes[1:] {
+		n = &orNode{n, r}
+	}
+	return n
+}
+
+func load(off, size uint32, cond bpf.JumpTest, val uint32) node {
+	return &leaf{
+		loads: []bpf.Instruction{bpf.LoadAbsolute{Off: off, Size: int(size)}},
+		cond:  cond,
+		val:   val,
+	}
+}
+
+func tokenize(expr string) []string {
+	var tokens []string
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			flush()
+		case c == '(' || c == ')' || c == '!':
+			flush()
+			tokens = append(tokens, string(c))
+		case (c == '&' || c == '|') && i+1 < len(expr) && expr[i+1] == c:
+			flush()
+			tokens = append(tokens, expr[i:i+2])
+			i++
+		default:
+			word.WriteByte(c)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+type qualifiers struct {
+	proto string
+	dir   string
+	typ   string
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+
+	// last are the qualifiers of the last primitive, used when they are
+	// omitted.
+	last *qualifiers
+}
+
+func (p *parser) peek(offset int) string {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	tok := p.peek(0)
+	if tok != "" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseExpr() (node, error) {
+	n, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var combine func(...node) node
+		switch p.peek(0) {
+		case "and", "&&":
+			combine = and
+		case "or", "||":
+			combine = or
+		default:
+			return n, nil
+		}
+		p.next()
+
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		n = combine(n, r)
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch p.peek(0) {
+	case "not", "!":
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{n}, nil
+	case "(":
+		p.next()
+		n, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok != ")" {
+			return nil, fmt.Errorf("expected ) in filter, got %q", tok)
+		}
+		return n, nil
+	case "":
+		return nil, fmt.Errorf("unexpected end of filter")
+	default:
+		return p.parsePrimitive()
+	}
+}
+
+func isProto(tok string) bool {
+	switch tok {
+	case "ip", "ip6", "arp", "tcp", "udp", "sctp", "icmp", "icmp6":
+		return true
+	}
+	return false
+}
+
+func isType(tok string) bool {
+	switch tok {
+	case "host", "net", "port", "portrange":
+		return true
+	}
+	return false
+}
+
+func (p *parser) parsePrimitive() (node, error) {
+	switch tok := p.peek(0); tok {
+	case "greater", "less":
+		p.next()
+		val := p.next()
+		length, err := strconv.ParseUint(val, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid length %q for %s", val, tok)
+		}
+		n := &leaf{
+			loads: []bpf.Instruction{bpf.LoadExtension{Num: bpf.ExtLen}},
+			cond:  bpf.JumpGreaterOrEqual,
+			val:   uint32(length),
+		}
+		if tok == "greater" {
+			return n, nil
+		}
+		n.cond = bpf.JumpGreaterThan
+		return &notNode{n}, nil
+	}
+
+	q := qualifiers{}
+	if isProto(p.peek(0)) {
+		q.proto = p.next()
+	}
+	switch {
+	case p.peek(0) == "src" && (p.peek(1) == "or" || p.peek(1) == "and") && p.peek(2) == "dst":
+		q.dir = p.next() + " " + p.next() + " " + p.next()
+	case p.peek(0) == "src" || p.peek(0) == "dst":
+		q.dir = p.next()
+	}
+	if isType(p.peek(0)) {
+		q.typ = p.next()
+	}
+
+	if q == (qualifiers{}) {
+		// Qualifiers identical to the ones of the previous primitive
+		// can be omitted.
+		if p.last == nil {
+			return nil, fmt.Errorf("unexpected %q in filter", p.peek(0))
+		}
+		q = *p.last
+	} else if q.typ == "" {
+		if q.dir == "" {
+			return protoPrimitive(q.proto)
+		}
+		q.typ = "host"
+	}
+
+	val := p.next()
+	if val == "" {
+		return nil, fmt.Errorf("missing value for %s", q.typ)
+	}
+	p.last = &q
+
+	switch q.typ {
+	case "host":
+		return hostPrimitive(q, val)
+	case "net":
+		return netPrimitive(q, val)
+	default:
+		return portPrimitive(q, val)
+	}
+}
+
+func protoPrimitive(proto string) (node, error) {
+	switch proto {
+	case "ip":
+		return load(etherTypeOff, 2, bpf.JumpEqual, etherTypeIPv4), nil
+	case "ip6":
+		return load(etherTypeOff, 2, bpf.JumpEqual, etherTypeIPv6), nil
+	case "arp":
+		return load(etherTypeOff, 2, bpf.JumpEqual, etherTypeARP), nil
+	case "tcp":
+		return ipProto(protoTCP), nil
+	case "udp":
+		return ipProto(protoUDP), nil
+	case "sctp":
+		return ipProto(protoSCTP), nil
+	case "icmp":
+		return and(
+			load(etherTypeOff, 2, bpf.JumpEqual, etherTypeIPv4),
+			load(ipv4ProtoOff, 1, bpf.JumpEqual, protoICMP),
+		), nil
+	case "icmp6":
+		return and(
+			load(etherTypeOff, 2, bpf.JumpEqual, etherTypeIPv6),
+			load(ipv6NextHdrOff, 1, bpf.JumpEqual, protoICMPv6),
+		), nil
+	}
+	return nil, fmt.Errorf("unknown protocol %q", proto)
+}
+
+func ipProto(proto uint32) node {
+	return or(
+		and(
+			load(etherTypeOff, 2, bpf.JumpEqual, etherTypeIPv4),
+			load(ipv4ProtoOff, 1, bpf.JumpEqual, proto),
+		),
+		and(
+			load(etherTypeOff, 2, bpf.JumpEqual, etherTypeIPv6),
+			load(ipv6NextHdrOff, 1, bpf.JumpEqual, proto),
+		),
+	)
+}
+
+func direction(dir string, src, dst node) node {
+	switch dir {
+	case "src":
+		return src
+	case "dst":
+		return dst
+	case "src and dst":
+		return and(src, dst)
+	default:
+		return or(src, dst)
+	}
+}
+
+func hostPrimitive(q qualifiers, val string) (node, error) {
+	ip := net.ParseIP(val)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid host %q: only IP addresses are supported", val)
+	}
+
+	bits := net.IPv6len * 8
+	if ip.To4() != nil {
+		bits = net.IPv4len * 8
+	}
+
+	return netPrimitive(q, fmt.Sprintf("%s/%d", ip, bits))
+}
+
+func netPrimitive(q qualifiers, val string) (node, error) {
+	if !strings.Contains(val, "/") {
+		ip := net.ParseIP(val)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid network %q", val)
+		}
+		return hostPrimitive(q, val)
+	}
+
+	_, ipNet, err := net.ParseCIDR(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network %q: %w", val, err)
+	}
+
+	etherType, srcOff, dstOff := uint32(etherTypeIPv6), uint32(ipv6SrcOff), uint32(ipv6DstOff)
+	addr := ipNet.IP
+	if ip4 := addr.To4(); ip4 != nil {
+		addr = ip4
+		etherType, srcOff, dstOff = etherTypeIPv4, ipv4SrcOff, ipv4DstOff
+	}
+
+	switch q.proto {
+	case "":
+	case "ip":
+		if etherType != etherTypeIPv4 {
+			return nil, fmt.Errorf("%s is not an IPv4 address", val)
+		}
+	case "ip6":
+		if etherType != etherTypeIPv6 {
+			return nil, fmt.Errorf("%s is not an IPv6 address", val)
+		}
+	default:
+		return nil, fmt.Errorf("%s can't be used with %s", q.proto, q.typ)
+	}
+
+	compare := func(off uint32) node {
+		var words []node
+		for i := 0; i < len(addr); i += 4 {
+			mask := uint32(ipNet.Mask[i])<<24 | uint32(ipNet.Mask[i+1])<<16 |
+				uint32(ipNet.Mask[i+2])<<8 | uint32(ipNet.Mask[i+3])
+			if mask == 0 {
+				break
+			}
+			word := uint32(addr[i])<<24 | uint32(addr[i+1])<<16 |
+				uint32(addr[i+2])<<8 | uint32(addr[i+3])
+
+			loads := []bpf.Instruction{bpf.LoadAbsolute{Off: off + uint32(i), Size: 4}}
+			if mask != 0xffffffff {
+				loads = append(loads, bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: mask})
+			}
+			words = append(words, &leaf{loads: loads, cond: bpf.JumpEqual, val: word})
+		}
+		if len(words) == 0 {
+			// A /0 network matches all the addresses.
+			return nil
+		}
+		return and(words...)
+	}
+
+	family := load(etherTypeOff, 2, bpf.JumpEqual, etherType)
+	src, dst := compare(srcOff), compare(dstOff)
+	if src == nil {
+		return family, nil
+	}
+	return and(family, direction(q.dir, src, dst)), nil
+}
+
+func parsePort(val string) (uint32, error) {
+	port, err := strconv.ParseUint(val, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q: only port numbers are supported", val)
+	}
+	return uint32(port), nil
+}
+
+func portPrimitive(q qualifiers, val string) (node, error) {
+	var protos []uint32
+	switch q.proto {
+	case "tcp":
+		protos = []uint32{protoTCP}
+	case "udp":
+		protos = []uint32{protoUDP}
+	case "sctp":
+		protos = []uint32{protoSCTP}
+	case "", "ip", "ip6":
+		protos = []uint32{protoTCP, protoUDP, protoSCTP}
+	default:
+		return nil, fmt.Errorf("%s can't be used with %s", q.proto, q.typ)
+	}
+
+	low, high := uint32(0), uint32(0)
+	var err error
+	if q.typ == "port" {
+		low, err = parsePort(val)
+		if err != nil {
+			return nil, err
+		}
+		high = low
+	} else {
+		ports := strings.SplitN(val, "-", 2)
+		if len(ports) != 2 {
+			return nil, fmt.Errorf("invalid port range %q", val)
+		}
+		if low, err = parsePort(ports[0]); err != nil {
+			return nil, err
+		}
+		if high, err = parsePort(ports[1]); err != nil {
+			return nil, err
+		}
+		if low > high {
+			low, high = high, low
+		}
+	}
+
+	// ports returns the comparison of the port loaded by loadPort.
+	ports := func(loadPort []bpf.Instruction) node {
+		if low == high {
+			return &leaf{loads: loadPort, cond: bpf.JumpEqual, val: low}
+		}
+		return and(
+			&leaf{loads: loadPort, cond: bpf.JumpGreaterOrEqual, val: low},
+			&notNode{&leaf{loads: loadPort, cond: bpf.JumpGreaterThan, val: high}},
+		)
+	}
+
+	l4Proto := func(off uint32) node {
+		var nodes []node
+		for _, proto := range protos {
+			nodes = append(nodes, load(off, 1, bpf.JumpEqual, proto))
+		}
+		return or(nodes...)
+	}
+
+	// The IPv4 header has a variable length, the X register is loaded
+	// with it. Only the first fragment contains the transport header.
+	ipv4Port := func(off uint32) []bpf.Instruction {
+		return []bpf.Instruction{
+			bpf.LoadMemShift{Off: etherHdrLen},
+			bpf.LoadIndirect{Off: etherHdrLen + off, Size: 2},
+		}
+	}
+	ipv4 := and(
+		load(etherTypeOff, 2, bpf.JumpEqual, etherTypeIPv4),
+		l4Proto(ipv4ProtoOff),
+		&notNode{&leaf{
+			loads: []bpf.Instruction{bpf.LoadAbsolute{Off: ipv4FragOff, Size: 2}},
+			cond:  bpf.JumpBitsSet,
+			val:   0x1fff,
+		}},
+		direction(q.dir, ports(ipv4Port(0)), ports(ipv4Port(2))),
+	)
+
+	// IPv6 extension headers aren't supported.
+	ipv6Port := func(off uint32) []bpf.Instruction {
+		return []bpf.Instruction{
+			bpf.LoadAbsolute{Off: etherHdrLen + ipv6HdrLen + off, Size: 2},
+		}
+	}
+	ipv6 := and(
+		load(etherTypeOff, 2, bpf.JumpEqual, etherTypeIPv6),
+		l4Proto(ipv6NextHdrOff),
+		direction(q.dir, ports(ipv6Port(0)), ports(ipv6Port(2))),
+	)
+
+	switch q.proto {
+	case "ip":
+		return ipv4, nil
+	case "ip6":
+		return ipv6, nil
+	default:
+		return or(ipv4, ipv6), nil
+	}
+}
+
+type label int
+
+// item is either an instruction, a conditional jump to labels or the
+// position of a label.
+type item struct {
+	insn bpf.Instruction
+
+	jump        bool
+	cond        bpf.JumpTest
+	val         uint32
+	jumpTrue    label
+	jumpFalse   label
+	isLabel     bool
+	labelMarker label
+}
+
+type generator struct {
+	items     []item
+	numLabels int
+}
+
+func (g *generator) newLabel() label {
+	g.numLabels++
+	return label(g.numLabels)
+}
+
+func (g *generator) mark(l label) {
+	g.items = append(g.items, item{isLabel: true, labelMarker: l})
+}
+
+// emit generates the code of n, jumping to t if it matches and to f
+// otherwise. All the jumps are forward ones, as required by classic BPF.
+func (g *generator) emit(n node, t, f label) {
+	switch n := n.(type) {
+	case *andNode:
+		next := g.newLabel()
+		g.emit(n.l, next, f)
+		g.mark(next)
+		g.emit(n.r, t, f)
+	case *orNode:
+		next := g.newLabel()
+		g.emit(n.l, t, next)
+		g.mark(next)
+		g.emit(n.r, t, f)
+	case *notNode:
+		g.emit(n.n, f, t)
+	case *leaf:
+		for _, insn := range n.loads {
+			g.items = append(g.items, item{insn: insn})
+		}
+		g.items = append(g.items, item{
+			jump:      true,
+			cond:      n.cond,
+			val:       n.val,
+			jumpTrue:  t,
+			jumpFalse: f,
+		})
+	}
+}
+
+func (g *generator) resolve() ([]bpf.Instruction, error) {
+	positions := map[label]int{}
+	pos := 0
+	for _, it := range g.items {
+		if it.isLabel {
+			positions[it.labelMarker] = pos
+		} else {
+			pos++
+		}
+	}
+
+	skip := func(from int, to label) (uint8, error) {
+		offset := positions[to] - from - 1
+		if offset > 255 {
+			return 0, fmt.Errorf("filter too complex")
+		}
+		return uint8(offset), nil
+	}
+
+	insns := make([]bpf.Instruction, 0, pos)
+	for _, it := range g.items {
+		switch {
+		case it.isLabel:
+		case it.jump:
+			skipTrue, err := skip(len(insns), it.jumpTrue)
+			if err != nil {
+				return nil, err
+			}
+			skipFalse, err := skip(len(insns), it.jumpFalse)
+			if err != nil {
+				return nil, err
+			}
+			insns = append(insns, bpf.JumpIf{
+				Cond:      it.cond,
+				Val:       it.val,
+				SkipTrue:  skipTrue,
+				SkipFalse: skipFalse,
+			})
+		default:
+			insns = append(insns, it.insn)
+		}
+	}
+
+	return insns, nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"golang.org/x/net/bpf"
+)
+
+// packet builds an Ethernet frame carrying an IPv4 or IPv6 packet with a
+// TCP or UDP header and some payload.
+func packet(src, dst string, proto uint8, sport, dport uint16) []byte {
+	srcIP, dstIP := net.ParseIP(src), net.ParseIP(dst)
+	l4 := make([]byte, 20)
+	binary.BigEndian.PutUint16(l4[0:], sport)
+	binary.BigEndian.PutUint16(l4[2:], dport)
+
+	pkt := make([]byte, etherHdrLen)
+	if srcIP.To4() != nil {
+		binary.BigEndian.PutUint16(pkt[etherTypeOff:], etherTypeIPv4)
+		// IPv4 header with options, to check the variable header
+		// length is handled.
+		ip := make([]byte, 24)
+		ip[0] = 0x46
+		ip[9] = proto
+		copy(ip[12:], srcIP.To4())
+		copy(ip[16:], dstIP.To4())
+		pkt = append(pkt, ip...)
+	} else {
+		binary.BigEndian.PutUint16(pkt[etherTypeOff:], etherTypeIPv6)
+		ip := make([]byte, ipv6HdrLen)
+		ip[0] = 0x60
+		ip[6] = proto
+		copy(ip[8:], srcIP)
+		copy(ip[24:], dstIP)
+		pkt = append(pkt, ip...)
+	}
+
+	return append(append(pkt, l4...), make([]byte, 100)...)
+}
+
+func TestCompile(t *testing.T) {
+	tcp4 := packet("10.0.0.1", "192.168.1.2", protoTCP, 40000, 80)
+	udp4 := packet("10.0.0.1", "10.96.0.10", protoUDP, 40001, 53)
+	tcp6 := packet("2001:db8::1", "2001:db8:1::2", protoTCP, 443, 40002)
+	frag4 := packet("10.0.0.1", "192.168.1.2", protoTCP, 40000, 80)
+	binary.BigEndian.PutUint16(frag4[ipv4FragOff:], 10)
+	arp := make([]byte, 60)
+	binary.BigEndian.PutUint16(arp[etherTypeOff:], etherTypeARP)
+
+	packets := map[string][]byte{
+		"tcp4":  tcp4,
+		"udp4":  udp4,
+		"tcp6":  tcp6,
+		"frag4": frag4,
+		"arp":   arp,
+	}
+
+	tests := []struct {
+		filter  string
+		matches []string
+	}{
+		{"", []string{"tcp4", "udp4", "tcp6", "frag4", "arp"}},
+		{"tcp", []string{"tcp4", "tcp6", "frag4"}},
+		{"udp", []string{"udp4"}},
+		{"ip", []string{"tcp4", "udp4", "frag4"}},
+		{"ip6", []string{"tcp6"}},
+		{"arp", []string{"arp"}},
+		{"not arp", []string{"tcp4", "udp4", "tcp6", "frag4"}},
+		{"host 10.0.0.1", []string{"tcp4", "udp4", "frag4"}},
+		{"src host 10.0.0.1", []string{"tcp4", "udp4", "frag4"}},
+		{"dst host 10.0.0.1", nil},
+		{"host 2001:db8:1::2", []string{"tcp6"}},
+		{"dst host 2001:db8:1::2", []string{"tcp6"}},
+		{"src host 2001:db8:1::2", nil},
+		{"net 192.168.0.0/16", []string{"tcp4", "frag4"}},
+		{"dst net 10.96.0.0/12", []string{"udp4"}},
+		{"net 2001:db8::/32", []string{"tcp6"}},
+		{"net 2001:db8:1::/48", []string{"tcp6"}},
+		{"net 0.0.0.0/0", []string{"tcp4", "udp4", "frag4"}},
+		{"port 80", []string{"tcp4"}},
+		{"tcp port 80", []string{"tcp4"}},
+		{"udp port 80", nil},
+		{"dst port 53", []string{"udp4"}},
+		{"src port 53", nil},
+		{"port 443", []string{"tcp6"}},
+		{"ip6 port 443", []string{"tcp6"}},
+		{"ip port 443", nil},
+		{"port 80 or 53", []string{"tcp4", "udp4"}},
+		{"port 80 || port 53", []string{"tcp4", "udp4"}},
+		{"portrange 50-100", []string{"tcp4", "udp4"}},
+		{"portrange 40001-40002", []string{"udp4", "tcp6"}},
+		{"src portrange 40000-40001", []string{"tcp4", "udp4"}},
+		{"host 10.0.0.1 and not port 53", []string{"tcp4", "frag4"}},
+		{"host 10.0.0.1 && !udp", []string{"tcp4", "frag4"}},
+		{"not (udp or arp)", []string{"tcp4", "tcp6", "frag4"}},
+		// and and or have the same precedence.
+		{"tcp or udp and port 53", []string{"udp4"}},
+		{"tcp or (udp and port 53)", []string{"tcp4", "udp4", "tcp6", "frag4"}},
+		{"greater 100", []string{"tcp4", "udp4", "tcp6", "frag4"}},
+		{"less 60", []string{"arp"}},
+		{"icmp or icmp6", nil},
+	}
+
+	for _, test := range tests {
+		insns, err := Compile(test.filter, 64)
+		if err != nil {
+			t.Errorf("compiling %q: %s", test.filter, err)
+			continue
+		}
+		vm, err := bpf.NewVM(insns)
+		if err != nil {
+			t.Errorf("loading %q: %s", test.filter, err)
+			continue
+		}
+
+		expected := map[string]bool{}
+		for _, name := range test.matches {
+			expected[name] = true
+		}
+
+		for name, pkt := range packets {
+			n, err := vm.Run(pkt)
+			if err != nil {
+				t.Errorf("running %q on %s: %s", test.filter, name, err)
+				continue
+			}
+			if matched := n > 0; matched != expected[name] {
+				t.Errorf("%q on %s: got match %t, expected %t", test.filter, name, matched, expected[name])
+			}
+			if n > 64 {
+				t.Errorf("%q on %s: %d bytes captured, more than the snap length", test.filter, name, n)
+			}
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, filter := range []string{
+		"foo",
+		"host",
+		"host example.com",
+		"port http",
+		"port 70000",
+		"portrange 80",
+		"ip6 host 10.0.0.1",
+		"tcp host 10.0.0.1",
+		"icmp port 80",
+		"(tcp",
+		"tcp)",
+		"tcp and",
+		"net 10.0.0.0/33",
+		"80",
+	} {
+		if err := Validate(filter); err == nil {
+			t.Errorf("expected an error for %q", filter)
+		}
+	}
+}
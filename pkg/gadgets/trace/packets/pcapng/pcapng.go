@@ -0,0 +1,192 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pcapng writes captures in the pcapng format, as described in
+// https://www.ietf.org/archive/id/draft-tuexen-opsawg-pcapng-05.html.
+package pcapng
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	blockTypeSectionHeader     = 0x0a0d0d0a
+	blockTypeInterfaceDesc     = 0x00000001
+	blockTypeEnhancedPacket    = 0x00000006
+	byteOrderMagic             = 0x1a2b3c4d
+	optionEndOfOpt             = 0
+	optionSHBUserAppl          = 4
+	optionIFName               = 2
+	optionIFDescription        = 3
+	optionIFTsResol            = 9
+	optionEPBFlags             = 2
+	tsResolNanoseconds         = 9
+	sectionLengthNotSpecified  = 0xffffffffffffffff
+	blockHeaderAndTrailerBytes = 12
+)
+
+// LinkTypeEthernet is the link type of the interfaces with an Ethernet
+// header, see https://www.tcpdump.org/linktypes.html.
+const LinkTypeEthernet = 1
+
+// Direction is the direction of a packet, stored in the flags of the
+// enhanced packet blocks.
+type Direction uint32
+
+const (
+	DirectionUnknown Direction = iota
+	DirectionInbound
+	DirectionOutbound
+)
+
+// Interface describes an interface packets are captured on.
+type Interface struct {
+	Name        string
+	Description string
+	LinkType    uint16
+	SnapLen     uint32
+}
+
+// Packet is a packet captured on an interface added with AddInterface.
+type Packet struct {
+	Interface int
+	Timestamp time.Time
+	Direction Direction
+	Data      []byte
+
+	// Length is the length of the packet on the wire. It's the length of
+	// Data when 0.
+	Length int
+}
+
+// Writer writes a pcapng section. Each block is written with a single call
+// to Write, so the capture can be read while it's written.
+type Writer struct {
+	w          io.Writer
+	interfaces int
+}
+
+// NewWriter writes the section header block to w and returns a Writer to
+// add interfaces and packets to the section.
+func NewWriter(w io.Writer, application string) (*Writer, error) {
+	body := make([]byte, 16)
+	binary.LittleEndian.PutUint32(body[0:], byteOrderMagic)
+	binary.LittleEndian.PutUint16(body[4:], 1)
+	binary.LittleEndian.PutUint16(body[6:], 0)
+	binary.LittleEndian.PutUint64(body[8:], sectionLengthNotSpecified)
+	body = appendOptions(body, option{optionSHBUserAppl, []byte(application)})
+
+	if err := writeBlock(w, blockTypeSectionHeader, body); err != nil {
+		return nil, fmt.Errorf("writing section header: %w", err)
+	}
+
+	return &Writer{w: w}, nil
+}
+
+// AddInterface writes an interface description block and returns the
+// identifier to use in the packets captured on that interface.
+func (w *Writer) AddInterface(iface Interface) (int, error) {
+	body := make([]byte, 8)
+	binary.LittleEndian.PutUint16(body[0:], iface.LinkType)
+	binary.LittleEndian.PutUint32(body[4:], iface.SnapLen)
+
+	opts := []option{{optionIFTsResol, []byte{tsResolNanoseconds}}}
+	if iface.Name != "" {
+		opts = append(opts, option{optionIFName, []byte(iface.Name)})
+	}
+	if iface.Description != "" {
+		opts = append(opts, option{optionIFDescription, []byte(iface.Description)})
+	}
+	body = appendOptions(body, opts...)
+
+	if err := writeBlock(w.w, blockTypeInterfaceDesc, body); err != nil {
+		return 0, fmt.Errorf("writing interface description: %w", err)
+	}
+
+	id := w.interfaces
+	w.interfaces++
+	return id, nil
+}
+
+// WritePacket writes an enhanced packet block.
+func (w *Writer) WritePacket(p Packet) error {
+	if p.Interface < 0 || p.Interface >= w.interfaces {
+		return fmt.Errorf("unknown interface %d", p.Interface)
+	}
+
+	length := p.Length
+	if length == 0 {
+		length = len(p.Data)
+	}
+	ts := uint64(p.Timestamp.UnixNano())
+
+	body := make([]byte, 20, 20+len(p.Data)+16)
+	binary.LittleEndian.PutUint32(body[0:], uint32(p.Interface))
+	binary.LittleEndian.PutUint32(body[4:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(body[8:], uint32(ts))
+	binary.LittleEndian.PutUint32(body[12:], uint32(len(p.Data)))
+	binary.LittleEndian.PutUint32(body[16:], uint32(length))
+	body = append(body, p.Data...)
+	body = pad(body)
+
+	if p.Direction != DirectionUnknown {
+		flags := make([]byte, 4)
+		binary.LittleEndian.PutUint32(flags, uint32(p.Direction))
+		body = appendOptions(body, option{optionEPBFlags, flags})
+	}
+
+	if err := writeBlock(w.w, blockTypeEnhancedPacket, body); err != nil {
+		return fmt.Errorf("writing packet: %w", err)
+	}
+	return nil
+}
+
+type option struct {
+	code  uint16
+	value []byte
+}
+
+func pad(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func appendOptions(b []byte, opts ...option) []byte {
+	for _, opt := range opts {
+		hdr := make([]byte, 4)
+		binary.LittleEndian.PutUint16(hdr[0:], opt.code)
+		binary.LittleEndian.PutUint16(hdr[2:], uint16(len(opt.value)))
+		b = pad(append(append(b, hdr...), opt.value...))
+	}
+	// opt_endofopt
+	return append(b, optionEndOfOpt, 0, 0, 0)
+}
+
+func writeBlock(w io.Writer, blockType uint32, body []byte) error {
+	length := uint32(len(body) + blockHeaderAndTrailerBytes)
+
+	block := make([]byte, length)
+	binary.LittleEndian.PutUint32(block[0:], blockType)
+	binary.LittleEndian.PutUint32(block[4:], length)
+	copy(block[8:], body)
+	binary.LittleEndian.PutUint32(block[length-4:], length)
+
+	_, err := w.Write(block)
+	return err
+}
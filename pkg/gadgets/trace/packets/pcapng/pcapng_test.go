@@ -0,0 +1,152 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcapng
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type block struct {
+	blockType uint32
+	body      []byte
+}
+
+func readBlocks(t *testing.T, b []byte) []block {
+	var blocks []block
+	for len(b) > 0 {
+		if len(b) < blockHeaderAndTrailerBytes {
+			t.Fatalf("truncated block")
+		}
+		length := binary.LittleEndian.Uint32(b[4:])
+		if length%4 != 0 || int(length) > len(b) {
+			t.Fatalf("invalid block length %d", length)
+		}
+		if trailer := binary.LittleEndian.Uint32(b[length-4:]); trailer != length {
+			t.Fatalf("block length %d doesn't match trailer %d", length, trailer)
+		}
+		blocks = append(blocks, block{
+			blockType: binary.LittleEndian.Uint32(b),
+			body:      b[8 : length-4],
+		})
+		b = b[length:]
+	}
+	return blocks
+}
+
+func readOptions(t *testing.T, b []byte) map[uint16][]byte {
+	opts := map[uint16][]byte{}
+	for {
+		if len(b) < 4 {
+			t.Fatalf("options not terminated")
+		}
+		code := binary.LittleEndian.Uint16(b)
+		length := int(binary.LittleEndian.Uint16(b[2:]))
+		if code == optionEndOfOpt {
+			return opts
+		}
+		opts[code] = b[4 : 4+length]
+		b = b[4+(length+3)/4*4:]
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WritePacket(Packet{Interface: 0}); err == nil {
+		t.Fatalf("expected an error writing a packet before adding an interface")
+	}
+
+	id, err := w.AddInterface(Interface{
+		Name:        "eth0",
+		Description: "pod default/mypod",
+		LinkType:    LinkTypeEthernet,
+		SnapLen:     100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := time.Unix(1666180000, 123456789)
+	data := []byte{1, 2, 3, 4, 5}
+	if err := w.WritePacket(Packet{
+		Interface: id,
+		Timestamp: ts,
+		Direction: DirectionOutbound,
+		Data:      data,
+		Length:    1500,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := readBlocks(t, buf.Bytes())
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, expected 3", len(blocks))
+	}
+
+	shb := blocks[0]
+	if shb.blockType != blockTypeSectionHeader || binary.LittleEndian.Uint32(shb.body) != byteOrderMagic {
+		t.Fatalf("invalid section header block")
+	}
+	if appl := readOptions(t, shb.body[16:])[optionSHBUserAppl]; string(appl) != "test" {
+		t.Fatalf("got application %q, expected %q", appl, "test")
+	}
+
+	idb := blocks[1]
+	if idb.blockType != blockTypeInterfaceDesc {
+		t.Fatalf("got block type %#x, expected an interface description", idb.blockType)
+	}
+	if linkType := binary.LittleEndian.Uint16(idb.body); linkType != LinkTypeEthernet {
+		t.Fatalf("got link type %d, expected %d", linkType, LinkTypeEthernet)
+	}
+	if snapLen := binary.LittleEndian.Uint32(idb.body[4:]); snapLen != 100 {
+		t.Fatalf("got snap length %d, expected 100", snapLen)
+	}
+	expectedOpts := map[uint16][]byte{
+		optionIFTsResol:     {tsResolNanoseconds},
+		optionIFName:        []byte("eth0"),
+		optionIFDescription: []byte("pod default/mypod"),
+	}
+	if opts := readOptions(t, idb.body[8:]); !reflect.DeepEqual(opts, expectedOpts) {
+		t.Fatalf("got interface options %v, expected %v", opts, expectedOpts)
+	}
+
+	epb := blocks[2]
+	if epb.blockType != blockTypeEnhancedPacket {
+		t.Fatalf("got block type %#x, expected an enhanced packet", epb.blockType)
+	}
+	tsHigh, tsLow := binary.LittleEndian.Uint32(epb.body[4:]), binary.LittleEndian.Uint32(epb.body[8:])
+	if got := int64(tsHigh)<<32 | int64(tsLow); got != ts.UnixNano() {
+		t.Fatalf("got timestamp %d, expected %d", got, ts.UnixNano())
+	}
+	capLen, origLen := binary.LittleEndian.Uint32(epb.body[12:]), binary.LittleEndian.Uint32(epb.body[16:])
+	if capLen != uint32(len(data)) || origLen != 1500 {
+		t.Fatalf("got lengths %d/%d, expected %d/1500", capLen, origLen, len(data))
+	}
+	if got := epb.body[20 : 20+capLen]; !bytes.Equal(got, data) {
+		t.Fatalf("got data %v, expected %v", got, data)
+	}
+	flags := readOptions(t, epb.body[20+(capLen+3)/4*4:])[optionEPBFlags]
+	if len(flags) != 4 || Direction(binary.LittleEndian.Uint32(flags)) != DirectionOutbound {
+		t.Fatalf("got flags %v, expected outbound direction", flags)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/types"
+)
+
+const (
+	etherHdrLen   = 14
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeARP  = 0x0806
+	ipv6HdrLen    = 40
+)
+
+// decode fills the summary of the headers of the packet of event, as far as
+// they can be decoded from the captured bytes.
+func decode(event *types.Event) {
+	data := event.Data
+	if len(data) < etherHdrLen {
+		return
+	}
+
+	var proto uint8
+	var l4 []byte
+
+	switch binary.BigEndian.Uint16(data[12:]) {
+	case etherTypeIPv4:
+		ip := data[etherHdrLen:]
+		if len(ip) < 20 {
+			event.Proto = "ip"
+			return
+		}
+		hdrLen := int(ip[0]&0x0f) * 4
+		proto = ip[9]
+		event.Saddr = net.IP(ip[12:16]).String()
+		event.Daddr = net.IP(ip[16:20]).String()
+		// Only the first fragment contains the transport header.
+		if binary.BigEndian.Uint16(ip[6:])&0x1fff == 0 && len(ip) >= hdrLen {
+			l4 = ip[hdrLen:]
+		}
+	case etherTypeIPv6:
+		ip := data[etherHdrLen:]
+		if len(ip) < ipv6HdrLen {
+			event.Proto = "ip6"
+			return
+		}
+		proto = ip[6]
+		event.Saddr = net.IP(ip[8:24]).String()
+		event.Daddr = net.IP(ip[24:40]).String()
+		l4 = ip[ipv6HdrLen:]
+	case etherTypeARP:
+		event.Proto = "arp"
+		return
+	default:
+		return
+	}
+
+	switch proto {
+	case 1:
+		event.Proto = "icmp"
+	case 6:
+		event.Proto = "tcp"
+	case 17:
+		event.Proto = "udp"
+	case 58:
+		event.Proto = "icmp6"
+	case 132:
+		event.Proto = "sctp"
+	default:
+		event.Proto = fmt.Sprintf("#%d", proto)
+	}
+
+	switch proto {
+	case 6, 17, 132:
+		if len(l4) >= 4 {
+			event.Sport = binary.BigEndian.Uint16(l4[0:])
+			event.Dport = binary.BigEndian.Uint16(l4[2:])
+		}
+	}
+}
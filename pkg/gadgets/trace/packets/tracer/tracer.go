@@ -0,0 +1,383 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/filter"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packets/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/rawsock"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Config struct {
+	// Filter is a tcpdump-like expression, see the filter package.
+	Filter  string
+	SnapLen uint32
+}
+
+// dropsCheckInterval is how often the packets dropped by the kernel are
+// checked.
+const dropsCheckInterval = time.Second
+
+// capture is a packet socket opened in the network namespace of a pod.
+type capture struct {
+	file   *os.File
+	conn   syscall.RawConn
+	handle *netlink.Handle
+
+	// data is updated by Attach, use commonData to read it.
+	data eventtypes.CommonData
+
+	// ifaces caches the names of the interfaces by index.
+	ifaces map[int]string
+
+	// users count how many containers of the pod are traced.
+	users int
+}
+
+type Tracer struct {
+	config        *Config
+	filter        []unix.SockFilter
+	eventCallback func(*types.Event)
+
+	mu   sync.Mutex
+	wg   sync.WaitGroup
+	done chan struct{}
+
+	// key: namespace/podname
+	captures map[string]*capture
+}
+
+func NewTracer(config *Config, eventCallback func(*types.Event)) (*Tracer, error) {
+	insns, err := filter.Compile(config.Filter, config.SnapLen)
+	if err != nil {
+		return nil, fmt.Errorf("compiling filter: %w", err)
+	}
+	raw, err := bpf.Assemble(insns)
+	if err != nil {
+		return nil, fmt.Errorf("assembling filter: %w", err)
+	}
+
+	t := &Tracer{
+		config:        config,
+		eventCallback: eventCallback,
+		captures:      make(map[string]*capture),
+		done:          make(chan struct{}),
+	}
+	for _, insn := range raw {
+		t.filter = append(t.filter, unix.SockFilter{
+			Code: insn.Op,
+			Jt:   insn.Jt,
+			Jf:   insn.Jf,
+			K:    insn.K,
+		})
+	}
+
+	t.wg.Add(1)
+	go t.checkDrops()
+
+	return t, nil
+}
+
+// Attach starts capturing the packets of the network namespace of pid.
+// Containers of the same pod share the capture identified by key.
+func (t *Tracer) Attach(key string, pid uint32, data eventtypes.CommonData) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, ok := t.captures[key]; ok {
+		c.users++
+		if c.data.Container != data.Container {
+			c.data.Container = ""
+		}
+		return nil
+	}
+
+	c := &capture{
+		data:   data,
+		ifaces: make(map[int]string),
+		users:  1,
+	}
+
+	fd, err := rawsock.OpenRawSock(pid)
+	if err != nil {
+		return fmt.Errorf("opening raw socket: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			unix.Close(fd)
+			if c.handle != nil {
+				c.handle.Delete()
+			}
+		}
+	}()
+
+	prog := unix.SockFprog{
+		Len:    uint16(len(t.filter)),
+		Filter: &t.filter[0],
+	}
+	if err := unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &prog); err != nil {
+		return fmt.Errorf("attaching filter: %w", err)
+	}
+	// PACKET_AUXDATA gives the length of the packets before they are
+	// truncated to the snap length.
+	if err := unix.SetsockoptInt(fd, unix.SOL_PACKET, unix.PACKET_AUXDATA, 1); err != nil {
+		return fmt.Errorf("enabling auxiliary data: %w", err)
+	}
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_TIMESTAMPNS, 1); err != nil {
+		return fmt.Errorf("enabling timestamps: %w", err)
+	}
+
+	// The socket received all the packets until the filter was attached.
+	buf := make([]byte, 1)
+	for {
+		if _, _, err := unix.Recvfrom(fd, buf, unix.MSG_DONTWAIT); err != nil {
+			break
+		}
+	}
+
+	ns, err := netns.GetFromPid(int(pid))
+	if err != nil {
+		return fmt.Errorf("getting network namespace: %w", err)
+	}
+	c.handle, err = netlink.NewHandleAt(ns)
+	ns.Close()
+	if err != nil {
+		return fmt.Errorf("creating netlink handle: %w", err)
+	}
+
+	// The socket is non-blocking, reading from the file uses the runtime
+	// poller and closing it interrupts the reads.
+	c.file = os.NewFile(uintptr(fd), "packets")
+	c.conn, err = c.file.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("getting raw connection: %w", err)
+	}
+
+	t.captures[key] = c
+
+	t.wg.Add(1)
+	go t.read(c)
+
+	return nil
+}
+
+func (t *Tracer) read(c *capture) {
+	defer t.wg.Done()
+
+	buf := make([]byte, t.config.SnapLen)
+	oob := make([]byte, unix.CmsgSpace(int(unsafe.Sizeof(unix.TpacketAuxdata{})))+
+		unix.CmsgSpace(int(unsafe.Sizeof(unix.Timespec{}))))
+
+	for {
+		var n, oobn int
+		var from unix.Sockaddr
+		var recvErr error
+
+		err := c.conn.Read(func(fd uintptr) bool {
+			n, oobn, _, from, recvErr = unix.Recvmsg(int(fd), buf, oob, 0)
+			return !errors.Is(recvErr, unix.EAGAIN)
+		})
+		if err != nil {
+			// The capture was detached.
+			return
+		}
+		if recvErr != nil {
+			t.sendError(c, fmt.Sprintf("receiving packet: %s", recvErr))
+			return
+		}
+
+		event := &types.Event{
+			Event: eventtypes.Event{
+				Type:       eventtypes.NORMAL,
+				CommonData: t.commonData(c),
+			},
+			Timestamp: time.Now().UnixNano(),
+			Len:       uint32(n),
+			Data:      append([]byte{}, buf[:n]...),
+		}
+
+		if sll, ok := from.(*unix.SockaddrLinklayer); ok {
+			event.Interface = t.interfaceName(c, sll.Ifindex)
+			event.PktType = pktTypeString(int(sll.Pkttype))
+		}
+
+		msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+		if err == nil {
+			for _, msg := range msgs {
+				switch {
+				case msg.Header.Level == unix.SOL_SOCKET && msg.Header.Type == unix.SCM_TIMESTAMPNS &&
+					len(msg.Data) >= int(unsafe.Sizeof(unix.Timespec{})):
+					ts := (*unix.Timespec)(unsafe.Pointer(&msg.Data[0]))
+					event.Timestamp = ts.Nano()
+				case msg.Header.Level == unix.SOL_PACKET && msg.Header.Type == unix.PACKET_AUXDATA &&
+					len(msg.Data) >= int(unsafe.Sizeof(unix.TpacketAuxdata{})):
+					aux := (*unix.TpacketAuxdata)(unsafe.Pointer(&msg.Data[0]))
+					event.Len = aux.Len
+				}
+			}
+		}
+
+		decode(event)
+
+		t.eventCallback(event)
+	}
+}
+
+func (t *Tracer) commonData(c *capture) eventtypes.CommonData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return c.data
+}
+
+// checkDrops periodically reports the packets the kernel dropped because they
+// weren't read fast enough. Reading the statistics resets them.
+func (t *Tracer) checkDrops() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(dropsCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		}
+
+		type drops struct {
+			data  eventtypes.CommonData
+			count uint32
+		}
+		var dropped []drops
+
+		t.mu.Lock()
+		for _, c := range t.captures {
+			var stats *unix.TpacketStats
+			var statsErr error
+			err := c.conn.Control(func(fd uintptr) {
+				stats, statsErr = unix.GetsockoptTpacketStats(int(fd), unix.SOL_PACKET, unix.PACKET_STATISTICS)
+			})
+			if err != nil || statsErr != nil || stats.Drops == 0 {
+				continue
+			}
+			dropped = append(dropped, drops{data: c.data, count: stats.Drops})
+		}
+		t.mu.Unlock()
+
+		for _, d := range dropped {
+			event := types.Base(eventtypes.Err(fmt.Sprintf(
+				"%d packets dropped by the kernel, use a more specific filter or a lower snap length", d.count)))
+			event.CommonData = d.data
+			t.eventCallback(&event)
+		}
+	}
+}
+
+func (t *Tracer) interfaceName(c *capture, index int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if name, ok := c.ifaces[index]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("if%d", index)
+	if c.handle == nil {
+		// The capture was detached.
+		return name
+	}
+	if link, err := c.handle.LinkByIndex(index); err == nil {
+		name = link.Attrs().Name
+	}
+	c.ifaces[index] = name
+
+	return name
+}
+
+func (t *Tracer) sendError(c *capture, msg string) {
+	event := types.Base(eventtypes.Err(msg))
+	event.CommonData = t.commonData(c)
+	t.eventCallback(&event)
+}
+
+// pktTypeString returns the name of the packet types of
+// include/uapi/linux/if_packet.h.
+func pktTypeString(pktType int) string {
+	pktTypeNames := []string{
+		"HOST",
+		"BROADCAST",
+		"MULTICAST",
+		"OTHERHOST",
+		"OUTGOING",
+		"LOOPBACK",
+		"USER",
+		"KERNEL",
+	}
+	if uint(pktType) < uint(len(pktTypeNames)) {
+		return pktTypeNames[pktType]
+	}
+	return fmt.Sprintf("UNKNOWN#%d", pktType)
+}
+
+func (t *Tracer) releaseCapture(key string, c *capture) {
+	c.file.Close()
+	c.handle.Delete()
+	c.handle = nil
+	delete(t.captures, key)
+}
+
+// Detach stops the capture of key once all the containers sharing it were
+// detached.
+func (t *Tracer) Detach(key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.captures[key]
+	if !ok {
+		return fmt.Errorf("key not attached: %q", key)
+	}
+
+	c.users--
+	if c.users == 0 {
+		t.releaseCapture(key, c)
+	}
+	return nil
+}
+
+func (t *Tracer) Close() {
+	t.mu.Lock()
+	for key, c := range t.captures {
+		t.releaseCapture(key, c)
+	}
+	t.mu.Unlock()
+
+	close(t.done)
+	t.wg.Wait()
+}
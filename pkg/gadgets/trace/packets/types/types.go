@@ -0,0 +1,70 @@
+// Copyright 2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	// SnapLenDefault is the number of bytes captured per packet by default.
+	// It's lower than tcpdump's default, as each packet goes through the
+	// gadget stream, but enough for a full Ethernet frame with a VLAN tag.
+	SnapLenDefault = 1518
+)
+
+const (
+	FilterParam  = "filter"
+	SnapLenParam = "snaplen"
+)
+
+// Event is emitted for each packet captured in the network namespace of a
+// pod. Container is only set when the pod has a single container traced.
+type Event struct {
+	eventtypes.Event
+
+	// Timestamp is the capture time, in nanoseconds since the Unix epoch.
+	Timestamp int64 `json:"timestamp,omitempty"`
+
+	Interface string `json:"interface,omitempty" column:"interface,width:10"`
+	PktType   string `json:"pktType,omitempty" column:"type,width:9"`
+
+	// Summary of the packet headers, when they could be decoded
+	Proto string `json:"proto,omitempty" column:"proto,width:5"`
+	Saddr string `json:"saddr,omitempty" column:"saddr,template:ipaddr"`
+	Sport uint16 `json:"sport,omitempty" column:"sport,template:ipport"`
+	Daddr string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
+	Dport uint16 `json:"dport,omitempty" column:"dport,template:ipport"`
+
+	// Len is the length of the packet on the wire, Data only contains its
+	// first snap length bytes, starting with the Ethernet header.
+	Len  uint32 `json:"len,omitempty" column:"len,width:6,align:right"`
+	Data []byte `json:"data,omitempty"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func Base(ev eventtypes.Event) Event {
+	return Event{
+		Event: ev,
+	}
+}
+
+func (e Event) GetBaseEvent() eventtypes.Event {
+	return e.Event
+}
@@ -5,6 +5,7 @@
 #include <linux/bpf.h>
 #include <linux/if_ether.h>
 #include <linux/ip.h>
+#include <linux/ipv6.h>
 #include <linux/in.h>
 #include <linux/tcp.h>
 
@@ -13,131 +14,274 @@
 
 #include "snisnoop.h"
 
+#define AF_INET		2
+#define AF_INET6	10
+
 struct {
 	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
 } events SEC(".maps");
 
+// The event and the offsets of the extensions are kept in a map instead of
+// the stack: the verifier doesn't track the content of map values, so it
+// doesn't need to explore each combination of extensions found and of loop
+// exits separately.
+struct client_hello {
+	struct event_t event;
+	int server_name_ext_off;
+	int alpn_ext_off;
+	int supported_versions_ext_off;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, struct client_hello);
+} client_hellos SEC(".maps");
+
 
-// parse_sni() from:
+// parse_client_hello() is based on parse_sni() from:
 // https://github.com/gardener/connectivity-monitor/blob/4e924f50367c9fa02075b50b0ecd8c821b3a15f1/connectivity-exporter/packet/c/cap.c#L146-L149
 
-// Parses the provided SKB at the given offset for SNI information. If parsing
-// succeeds, the SNI information is written to the out array. Returns the
-// number of characters in the SNI field or 0 if SNI couldn't be parsed.
-static inline int parse_sni(struct __sk_buff *skb, int data_offset, char *out)
+// GREASE values (RFC 8701) are reserved values like 0x0a0a or 0x1a1a sent by
+// clients to make sure servers ignore unknown values.
+static __always_inline int is_grease(__u16 val)
+{
+  return (val & 0x0f0f) == 0x0a0a && (val >> 8) == (val & 0xff);
+}
+
+// available_len returns how many of the len bytes at off are in the skb, a
+// field can be truncated when the packet is.
+static __always_inline __u64 available_len(struct __sk_buff *skb, __u64 off, __u64 len)
 {
+  if (off >= skb->len)
+    return 0;
+  if (len > skb->len - off)
+    return skb->len - off;
+  return len;
+}
+
+// Parses the provided SKB at the given offset for a TLS ClientHello. If
+// parsing succeeds, the server name, the offered TLS version, the ALPN
+// protocols and the number of cipher suites are written to the event.
+// Returns 0 if the payload isn't a ClientHello.
+static __always_inline int parse_client_hello(struct __sk_buff *skb, int data_offset,
+					      struct client_hello *ch)
+{
+  struct event_t *event = &ch->event;
+
   // Verify TLS content type.
   __u8 content_type;
-  bpf_skb_load_bytes(skb, data_offset, &content_type, 1);
+  if (bpf_skb_load_bytes(skb, data_offset, &content_type, 1))
+    return 0;
   if (content_type != TLS_CONTENT_TYPE_HANDSHAKE)
     return 0;
 
   // Verify TLS handshake type.
   __u8 handshake_type;
-  bpf_skb_load_bytes(skb, data_offset + TLS_HANDSHAKE_TYPE_OFF, &handshake_type, 1);
+  if (bpf_skb_load_bytes(skb, data_offset + TLS_HANDSHAKE_TYPE_OFF, &handshake_type, 1))
+    return 0;
   if (handshake_type != TLS_HANDSHAKE_TYPE_CLIENT_HELLO)
     return 0;
 
+  __u16 client_version_be;
+  if (bpf_skb_load_bytes(skb, data_offset + TLS_CLIENT_VERSION_OFF, &client_version_be, 2))
+    return 0;
+  event->version = bpf_ntohs(client_version_be);
+
   int session_id_len_off = data_offset + TLS_SESSION_ID_LENGTH_OFF;
   __u8 session_id_len;
-  bpf_skb_load_bytes(skb, session_id_len_off, &session_id_len, 1);
+  if (bpf_skb_load_bytes(skb, session_id_len_off, &session_id_len, 1))
+    return 0;
 
   int cipher_suites_len_off =
       session_id_len_off + TLS_SESSION_ID_LENGTH_LEN + session_id_len;
   __u16 cipher_suites_len_be;
-  bpf_skb_load_bytes(skb, cipher_suites_len_off, &cipher_suites_len_be, 2);
+  if (bpf_skb_load_bytes(skb, cipher_suites_len_off, &cipher_suites_len_be, 2))
+    return 0;
+  __u16 cipher_suites_len = bpf_ntohs(cipher_suites_len_be);
+  // Each cipher suite takes 2 bytes.
+  event->cipher_count = cipher_suites_len / 2;
 
   int compression_methods_len_off =
-      cipher_suites_len_off + TLS_CIPHER_SUITES_LENGTH_LEN +
-      bpf_ntohs(cipher_suites_len_be);
+      cipher_suites_len_off + TLS_CIPHER_SUITES_LENGTH_LEN + cipher_suites_len;
 
   __u8 compression_methods_len;
-  bpf_skb_load_bytes(skb, compression_methods_len_off,
-      &compression_methods_len, 1);
+  if (bpf_skb_load_bytes(skb, compression_methods_len_off,
+      &compression_methods_len, 1))
+    return 1;
 
   int extensions_len_off =
       compression_methods_len_off + TLS_COMPRESSION_METHODS_LENGTH_LEN +
         compression_methods_len;
 
+  // Extensions are optional.
+  __u16 extensions_len_be;
+  if (bpf_skb_load_bytes(skb, extensions_len_off, &extensions_len_be, 2))
+    return 1;
+  __u16 extensions_len = bpf_ntohs(extensions_len_be);
+
   int extensions_off = extensions_len_off + TLS_EXTENSIONS_LENGTH_LEN;
 
-  // TODO: Ensure the cursor doesn't surpass the extensions length value?
-  __u16 cur = 0;
-  __u16 server_name_ext_off = 0;
+  __u32 cur = 0;
   for (int i = 0; i < TLS_MAX_EXTENSION_COUNT; i++) {
+    if (cur >= extensions_len)
+      break;
+
     __u16 curr_ext_type_be;
-    bpf_skb_load_bytes(skb, extensions_off + cur, &curr_ext_type_be, 2);
-    if (bpf_ntohs(curr_ext_type_be) == TLS_EXTENSION_SERVER_NAME)
-    {
-      server_name_ext_off = extensions_off + cur;
+    if (bpf_skb_load_bytes(skb, extensions_off + cur, &curr_ext_type_be, 2))
+      break;
+
+    switch (bpf_ntohs(curr_ext_type_be)) {
+    case TLS_EXTENSION_SERVER_NAME:
+      ch->server_name_ext_off = extensions_off + cur;
+      break;
+    case TLS_EXTENSION_ALPN:
+      ch->alpn_ext_off = extensions_off + cur;
+      break;
+    case TLS_EXTENSION_SUPPORTED_VERSIONS:
+      ch->supported_versions_ext_off = extensions_off + cur;
       break;
     }
+
     // Skip the extension type field to get to the extension length field.
     cur += TLS_EXTENSION_TYPE_LEN;
 
     // Read the extension length and skip the extension length field as well as
     // the rest of the extension to get to the next extension.
     __u16 len_be;
-    bpf_skb_load_bytes(skb, extensions_off + cur, &len_be, 2);
+    if (bpf_skb_load_bytes(skb, extensions_off + cur, &len_be, 2))
+      break;
     cur += TLS_EXTENSION_LENGTH_LEN + bpf_ntohs(len_be);
   }
 
-  if (server_name_ext_off == 0) // Couldn't find server name extension.
-    return 0;
+  if (ch->server_name_ext_off != 0) {
+    __u16 server_name_len_be;
+    __u64 server_name_len = 0;
+    if (!bpf_skb_load_bytes(skb, ch->server_name_ext_off + TLS_SERVER_NAME_LENGTH_OFF,
+        &server_name_len_be, 2))
+      server_name_len = bpf_ntohs(server_name_len_be);
 
-  __u16 server_name_len_be;
-  bpf_skb_load_bytes(skb, server_name_ext_off + TLS_SERVER_NAME_LENGTH_OFF,
-      &server_name_len_be, 2);
-  __u16 server_name_len = bpf_ntohs(server_name_len_be);
-  if (server_name_len == 0 || server_name_len > TLS_MAX_SERVER_NAME_LEN)
-    return 0;
+    // The server name field under the server name extension.
+    int server_name_off = ch->server_name_ext_off + TLS_SERVER_NAME_OFF;
 
-  // The server name field under the server name extension.
-  __u16 server_name_off = server_name_ext_off + TLS_SERVER_NAME_OFF;
+    // Report the part of a truncated name that is available.
+    server_name_len = available_len(skb, server_name_off, server_name_len);
 
-  // Read the server name field.
-  int counter = 0;
-  for (int i = 0; i < TLS_MAX_SERVER_NAME_LEN; i++) {
-    if (!out)
-      break;
-    if (i >= server_name_len)
-      break;
-    char b;
-    bpf_skb_load_bytes(skb, server_name_off + i, &b, 1);
-    if (b == '\0')
-      break;
-    out[i] = b;
-    counter++;
+    // Keep the last byte for the terminating NUL.
+    if (server_name_len > TLS_MAX_SERVER_NAME_LEN - 1)
+      server_name_len = TLS_MAX_SERVER_NAME_LEN - 1;
+
+    if (server_name_len > 0)
+      bpf_skb_load_bytes(skb, server_name_off, event->name, server_name_len);
+  }
+
+  if (ch->alpn_ext_off != 0) {
+    __u16 alpn_len_be;
+    __u64 alpn_len = 0;
+    if (!bpf_skb_load_bytes(skb, ch->alpn_ext_off + TLS_ALPN_LENGTH_OFF, &alpn_len_be, 2))
+      alpn_len = bpf_ntohs(alpn_len_be);
+
+    int alpn_off = ch->alpn_ext_off + TLS_ALPN_OFF;
+
+    // Protocols that don't fit entirely are dropped in user space.
+    alpn_len = available_len(skb, alpn_off, alpn_len);
+    if (alpn_len > TLS_MAX_ALPN_LEN)
+      alpn_len = TLS_MAX_ALPN_LEN;
+
+    if (alpn_len > 0 && !bpf_skb_load_bytes(skb, alpn_off, event->alpn, alpn_len))
+      event->alpn_len = alpn_len;
+  }
+
+  // TLS 1.3 clients keep 1.2 in the client version field for compatibility
+  // and offer 1.3 in the supported_versions extension.
+  if (ch->supported_versions_ext_off != 0) {
+    __u8 versions_len = 0;
+    bpf_skb_load_bytes(skb, ch->supported_versions_ext_off + TLS_SUPPORTED_VERSIONS_LENGTH_OFF,
+        &versions_len, 1);
+
+    int versions_off = ch->supported_versions_ext_off + TLS_SUPPORTED_VERSIONS_OFF;
+
+    for (int i = 0; i < TLS_MAX_SUPPORTED_VERSIONS; i++) {
+      if (i * 2 >= versions_len)
+        break;
+      __u16 version_be;
+      if (bpf_skb_load_bytes(skb, versions_off + i * 2, &version_be, 2))
+        break;
+      __u16 version = bpf_ntohs(version_be);
+      if (!is_grease(version) && version > event->version)
+        event->version = version;
+    }
   }
-  return counter;
+
+  return 1;
 }
 
 
 SEC("socket1")
 int ig_trace_sni(struct __sk_buff *skb)
 {
+	struct client_hello *ch;
+	__u32 zero = 0;
+	int tcp_off;
+
 	// Skip frames with non-IP Ethernet protocol.
 	struct ethhdr ethh;
 	if (bpf_skb_load_bytes(skb, 0, &ethh, sizeof ethh))
 		return 0;
-	if (bpf_ntohs(ethh.h_proto) != ETH_P_IP)
-		return 0;
 
 	int ip_off = ETH_HLEN;
-	// Read the IP header.
-	struct iphdr iph;
-	if (bpf_skb_load_bytes(skb, ip_off, &iph, sizeof iph))
-		return 0;
 
-	// Skip packets with IP protocol other than TCP.
-	if (iph.protocol != IPPROTO_TCP)
+	switch (bpf_ntohs(ethh.h_proto)) {
+	case ETH_P_IP: {
+		// Read the IP header.
+		struct iphdr iph;
+		if (bpf_skb_load_bytes(skb, ip_off, &iph, sizeof iph))
+			return 0;
+
+		// Skip packets with IP protocol other than TCP.
+		if (iph.protocol != IPPROTO_TCP)
+			return 0;
+
+		// An IPv4 header doesn't have a fixed size. The IHL field of a packet
+		// represents the size of the IP header in 32-bit words, so we need to
+		// multiply this value by 4 to get the header size in bytes.
+		__u8 ip_header_len = iph.ihl * 4;
+		tcp_off = ip_off + ip_header_len;
+
+		ch = bpf_map_lookup_elem(&client_hellos, &zero);
+		if (!ch)
+			return 0;
+		__builtin_memset(ch, 0, sizeof(*ch));
+
+		ch->event.af = AF_INET;
+		ch->event.daddr_v4 = iph.daddr;
+		break;
+	}
+	case ETH_P_IPV6: {
+		struct ipv6hdr ip6h;
+		if (bpf_skb_load_bytes(skb, ip_off, &ip6h, sizeof ip6h))
+			return 0;
+
+		// Extension headers aren't supported: TCP must directly follow
+		// the fixed header.
+		if (ip6h.nexthdr != IPPROTO_TCP)
+			return 0;
+
+		tcp_off = ip_off + sizeof ip6h;
+
+		ch = bpf_map_lookup_elem(&client_hellos, &zero);
+		if (!ch)
+			return 0;
+		__builtin_memset(ch, 0, sizeof(*ch));
+
+		ch->event.af = AF_INET6;
+		__builtin_memcpy(ch->event.daddr_v6, &ip6h.daddr, sizeof(ch->event.daddr_v6));
+		break;
+	}
+	default:
 		return 0;
-
-	// An IPv4 header doesn't have a fixed size. The IHL field of a packet
-	// represents the size of the IP header in 32-bit words, so we need to
-	// multiply this value by 4 to get the header size in bytes.
-	__u8 ip_header_len = iph.ihl * 4;
-	int tcp_off = ip_off + ip_header_len;
+	}
 
 	// Read the TCP header.
 	struct tcphdr tcph;
@@ -153,20 +297,12 @@ int ig_trace_sni(struct __sk_buff *skb)
 	// TLS data starts at this offset.
 	int payload_off = tcp_off + tcp_header_len;
 
-	// Parse SNI.
-	char sni[TLS_MAX_SERVER_NAME_LEN] = {};
-	int read = parse_sni(skb, payload_off, sni);
-	if (read == 0)
-		return 0;
+	ch->event.dport = bpf_ntohs(tcph.dest);
 
-	struct event_t event = {0,};
-	for (int i = 0; i < TLS_MAX_SERVER_NAME_LEN; i++) {
-		if (sni[i] == '\0')
-			break;
-		event.name[i] = sni[i];
-	}
+	if (!parse_client_hello(skb, payload_off, ch))
+		return 0;
 
-	bpf_perf_event_output(skb, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+	bpf_perf_event_output(skb, &events, BPF_F_CURRENT_CPU, &ch->event, sizeof(ch->event));
 
 	return 0;
 }
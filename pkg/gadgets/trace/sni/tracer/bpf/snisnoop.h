@@ -4,10 +4,17 @@
 #define TLS_CONTENT_TYPE_HANDSHAKE 0x16
 #define TLS_HANDSHAKE_TYPE_CLIENT_HELLO 0x1
 #define TLS_EXTENSION_SERVER_NAME 0x0
-// TODO: Figure out real max number according to RFC.
-#define TLS_MAX_EXTENSION_COUNT 20
+#define TLS_EXTENSION_ALPN 0x10
+#define TLS_EXTENSION_SUPPORTED_VERSIONS 0x2b
+// Browsers send around 20 extensions, including GREASE and padding ones.
+#define TLS_MAX_EXTENSION_COUNT 32
 // TODO: figure out the right value.
 #define TLS_MAX_SERVER_NAME_LEN 128
+// Length of the ALPN protocol list copied to the event. It's enough for
+// "h2", "http/1.1" and a few more protocols.
+#define TLS_MAX_ALPN_LEN 64
+// Number of entries of the supported_versions extension looked at.
+#define TLS_MAX_SUPPORTED_VERSIONS 8
 
 // The length of the session ID length field.
 #define TLS_SESSION_ID_LENGTH_LEN 1
@@ -29,14 +36,43 @@
 // extension.
 #define TLS_SERVER_NAME_OFF 9
 
+// The offset of the ALPN protocol list length field from the start of the
+// ALPN TLS extension.
+#define TLS_ALPN_LENGTH_OFF 4
+// The offset of the ALPN protocol list from the start of the ALPN TLS
+// extension.
+#define TLS_ALPN_OFF 6
+// The offset of the versions length field from the start of the
+// supported_versions TLS extension.
+#define TLS_SUPPORTED_VERSIONS_LENGTH_OFF 4
+// The offset of the versions from the start of the supported_versions TLS
+// extension.
+#define TLS_SUPPORTED_VERSIONS_OFF 5
+
 // The offset of the handshake type field from the start of the TLS payload.
 #define TLS_HANDSHAKE_TYPE_OFF 5
+// The offset of the client version field from the start of the TLS payload.
+#define TLS_CLIENT_VERSION_OFF 9
 // The offset of the session ID length field from the start of the TLS payload.
 #define TLS_SESSION_ID_LENGTH_OFF 43
 
 
 struct event_t {
+	union {
+		__u32 daddr_v4;
+		__u8 daddr_v6[16];
+	};
+	__u16 af; // AF_INET or AF_INET6
+	__u16 dport;
+	// Highest version offered by the client, from the supported_versions
+	// extension or the client version field.
+	__u16 version;
+	__u16 cipher_count;
+	__u16 alpn_len;
 	char name[TLS_MAX_SERVER_NAME_LEN];
+	// ALPN protocol list, in the wire format: each protocol is prefixed by
+	// its length.
+	__u8 alpn[TLS_MAX_ALPN_LEN];
 };
 
 #endif
@@ -13,6 +13,24 @@ import (
 	"github.com/cilium/ebpf"
 )
 
+type snisnoopClientHello struct {
+	Event struct {
+		DaddrV4     uint32
+		_           [12]byte
+		Af          uint16
+		Dport       uint16
+		Version     uint16
+		CipherCount uint16
+		AlpnLen     uint16
+		Name        [128]int8
+		Alpn        [64]uint8
+		_           [2]byte
+	}
+	ServerNameExtOff        int32
+	AlpnExtOff              int32
+	SupportedVersionsExtOff int32
+}
+
 // loadSnisnoop returns the embedded CollectionSpec for snisnoop.
 func loadSnisnoop() (*ebpf.CollectionSpec, error) {
 	reader := bytes.NewReader(_SnisnoopBytes)
@@ -61,7 +79,8 @@ type snisnoopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type snisnoopMapSpecs struct {
-	Events *ebpf.MapSpec `ebpf:"events"`
+	ClientHellos *ebpf.MapSpec `ebpf:"client_hellos"`
+	Events       *ebpf.MapSpec `ebpf:"events"`
 }
 
 // snisnoopObjects contains all objects after they have been loaded into the kernel.
@@ -83,11 +102,13 @@ func (o *snisnoopObjects) Close() error {
 //
 // It can be passed to loadSnisnoopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type snisnoopMaps struct {
-	Events *ebpf.Map `ebpf:"events"`
+	ClientHellos *ebpf.Map `ebpf:"client_hellos"`
+	Events       *ebpf.Map `ebpf:"events"`
 }
 
 func (m *snisnoopMaps) Close() error {
 	return _SnisnoopClose(
+		m.ClientHellos,
 		m.Events,
 	)
 }
@@ -18,8 +18,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strings"
 	"syscall"
+	"unsafe"
 
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/perf"
@@ -33,7 +33,31 @@ import (
 
 //go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang snisnoop ./bpf/snisnoop.c -- $CLANG_OS_FLAGS -I./bpf/"
 
+// #include <linux/types.h>
 // #include "bpf/snisnoop.h"
+// #include <arpa/inet.h>
+// #include <stdlib.h>
+//
+//static char *get_dst_addr(const struct event_t *ev) {
+//	size_t size = INET6_ADDRSTRLEN;
+//	const void *addr;
+//	char *str;
+//
+//	if (ev->af == AF_INET)
+//		addr = &ev->daddr_v4;
+//	else if (ev->af == AF_INET6)
+//		addr = &ev->daddr_v6;
+//	else
+//		return NULL;
+//
+//	str = malloc(size);
+//	if (!str)
+//		return NULL;
+//
+//	inet_ntop(ev->af, addr, str, size);
+//
+//	return str;
+//}
 import "C"
 
 const (
@@ -135,16 +159,77 @@ func (t *Tracer) Attach(
 	return nil
 }
 
-func parseSNIEvent(rawSample []byte) (ret string) {
-	name := make([]byte, C.TLS_MAX_SERVER_NAME_LEN)
-	copy(name, rawSample)
+// tlsVersionString returns the name of the given TLS protocol version.
+func tlsVersionString(version uint16) string {
+	switch version {
+	case 0:
+		return ""
+	case 0x0300:
+		return "SSL 3.0"
+	case 0x0301:
+		return "TLS 1.0"
+	case 0x0302:
+		return "TLS 1.1"
+	case 0x0303:
+		return "TLS 1.2"
+	case 0x0304:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04x", version)
+	}
+}
+
+// parseALPN parses an ALPN protocol list in the wire format, where each
+// protocol is prefixed by its length. A truncated last protocol is dropped.
+func parseALPN(list []byte) []string {
+	var protocols []string
+
+	for len(list) > 0 {
+		l := int(list[0])
+		if l == 0 || len(list) < 1+l {
+			break
+		}
+		protocols = append(protocols, string(list[1:1+l]))
+		list = list[1+l:]
+	}
+
+	return protocols
+}
+
+func parseSNIEvent(rawSample []byte) (*types.Event, error) {
+	if len(rawSample) < int(unsafe.Sizeof(C.struct_event_t{})) {
+		return nil, fmt.Errorf("record too short: %d bytes", len(rawSample))
+	}
+
+	eventC := (*C.struct_event_t)(unsafe.Pointer(&rawSample[0]))
+
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type: eventtypes.NORMAL,
+		},
+		Name:        C.GoString(&eventC.name[0]),
+		Dport:       uint16(eventC.dport),
+		Version:     tlsVersionString(uint16(eventC.version)),
+		CipherCount: uint16(eventC.cipher_count),
+	}
 
-	str := string(name)
-	i := strings.Index(str, "\x00")
-	if i > 0 {
-		return str[:i]
+	alpnLen := int(eventC.alpn_len)
+	if alpnLen > C.TLS_MAX_ALPN_LEN {
+		alpnLen = C.TLS_MAX_ALPN_LEN
 	}
-	return str
+	event.ALPN = parseALPN(C.GoBytes(unsafe.Pointer(&eventC.alpn[0]), C.int(alpnLen)))
+
+	if eventC.af == C.AF_INET {
+		event.IPVersion = 4
+	} else if eventC.af == C.AF_INET6 {
+		event.IPVersion = 6
+	}
+
+	dstAddr := C.get_dst_addr(eventC)
+	event.Daddr = C.GoString(dstAddr)
+	C.free(unsafe.Pointer(dstAddr))
+
+	return event, nil
 }
 
 func (t *Tracer) listen(
@@ -169,17 +254,14 @@ func (t *Tracer) listen(
 			continue
 		}
 
-		name := parseSNIEvent(record.RawSample)
-
-		if len(name) > 0 {
-			event := types.Event{
-				Event: eventtypes.Event{
-					Type: eventtypes.NORMAL,
-				},
-				Name: name,
-			}
-			eventCallback(event)
+		event, err := parseSNIEvent(record.RawSample)
+		if err != nil {
+			msg := fmt.Sprintf("Error parsing event (%s): %s", key, err)
+			eventCallback(types.Base(eventtypes.Err(msg)))
+			continue
 		}
+
+		eventCallback(*event)
 	}
 }
 
@@ -22,6 +22,19 @@ type Event struct {
 	eventtypes.Event
 
 	Name string `json:"name,omitempty"`
+
+	IPVersion int    `json:"ipversion,omitempty"`
+	Daddr     string `json:"daddr,omitempty"`
+	Dport     uint16 `json:"dport,omitempty"`
+
+	// Highest TLS version offered by the client, e.g. "TLS 1.3"
+	Version     string   `json:"version,omitempty"`
+	ALPN        []string `json:"alpn,omitempty"`
+	CipherCount uint16   `json:"cipherCount,omitempty"`
+
+	// Kubernetes object the destination address belongs to, if any
+	RemoteKind string `json:"remoteKind,omitempty"`
+	RemoteName string `json:"remoteName,omitempty"`
 }
 
 func Base(ev eventtypes.Event) Event {
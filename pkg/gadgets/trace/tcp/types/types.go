@@ -30,6 +30,11 @@ type Event struct {
 	Daddr     string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
 	Sport     uint16 `json:"sport,omitempty" column:"sport,template:ipport"`
 	Dport     uint16 `json:"dport,omitempty" column:"dport,template:ipport"`
+
+	// Kubernetes object the destination address belongs to, if any
+	RemoteKind string `json:"remoteKind,omitempty" column:"remotekind,width:10,hide"`
+	RemoteName string `json:"remoteName,omitempty" column:"remotename,width:24,maxWidth:64,hide"`
+
 	MountNsID uint64 `json:"mountnsid,omitempty" column:"mntns,template:ns"`
 }
 
@@ -17,11 +17,25 @@ const volatile uid_t filter_uid = -1;
 const volatile pid_t filter_pid = 0;
 const volatile bool do_count = 0;
 const volatile bool filter_by_mnt_ns = false;
+const volatile bool calculate_latency = false;
+const volatile __u64 targ_min_us = 0;
 
 /* Define here, because there are conflicts with include files */
 #define AF_INET		2
 #define AF_INET6	10
 
+#define TCP_SYN_SENT	2
+
+// Information of a connection being established, used to compute its
+// latency when calculate_latency is enabled.
+struct piddata {
+	char task[TASK_COMM_LEN];
+	__u64 ts;
+	__u32 pid;
+	__u32 uid;
+	__u64 mntns_id;
+};
+
 struct {
 	__uint(type, BPF_MAP_TYPE_HASH);
 	__uint(max_entries, MAX_ENTRIES);
@@ -29,6 +43,13 @@ struct {
 	__type(value, struct sock *);
 } sockets SEC(".maps");
 
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct sock *);
+	__type(value, struct piddata);
+} sockets_latency SEC(".maps");
+
 struct {
 	__uint(type, BPF_MAP_TYPE_HASH);
 	__uint(max_entries, MAX_ENTRIES);
@@ -91,6 +112,14 @@ enter_tcp_connect(struct pt_regs *ctx, struct sock *sk)
 		return 0;
 
 	bpf_map_update_elem(&sockets, &tid, &sk, 0);
+
+	if (calculate_latency) {
+		struct piddata piddata = {};
+
+		piddata.ts = bpf_ktime_get_ns();
+		bpf_map_update_elem(&sockets_latency, &sk, &piddata, 0);
+	}
+
 	return 0;
 }
 
@@ -165,6 +194,23 @@ trace_v6(struct pt_regs *ctx, pid_t pid, struct sock *sk, __u16 dport, __u64 mnt
 			      &event, sizeof(event));
 }
 
+// The event is sent later by handle_tcp_rcv_state_process() when the
+// connection is established.
+static __always_inline void
+store_latency_data(struct sock *sk, pid_t pid, __u64 mntns_id)
+{
+	struct piddata *piddata;
+
+	piddata = bpf_map_lookup_elem(&sockets_latency, &sk);
+	if (!piddata)
+		return;
+
+	piddata->pid = pid;
+	piddata->uid = bpf_get_current_uid_gid();
+	piddata->mntns_id = mntns_id;
+	bpf_get_current_comm(piddata->task, sizeof(piddata->task));
+}
+
 static __always_inline int
 exit_tcp_connect(struct pt_regs *ctx, int ret, int ip_ver)
 {
@@ -181,22 +227,24 @@ exit_tcp_connect(struct pt_regs *ctx, int ret, int ip_ver)
 	if (!skpp)
 		return 0;
 
-	if (ret)
-		goto end;
-
 	sk = *skpp;
 
+	if (ret)
+		goto cleanup;
+
 	BPF_CORE_READ_INTO(&dport, sk, __sk_common.skc_dport);
 	if (filter_port(dport))
-		goto end;
+		goto cleanup;
 
 	task = (struct task_struct*)bpf_get_current_task();
 	mntns_id = (u64) BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum);
 
 	if (filter_by_mnt_ns && !bpf_map_lookup_elem(&mount_ns_filter, &mntns_id))
-		return 0;
+		goto cleanup;
 
-	if (do_count) {
+	if (calculate_latency) {
+		store_latency_data(sk, pid, mntns_id);
+	} else if (do_count) {
 		if (ip_ver == 4)
 			count_v4(sk, dport);
 		else
@@ -208,11 +256,68 @@ exit_tcp_connect(struct pt_regs *ctx, int ret, int ip_ver)
 			trace_v6(ctx, pid, sk, dport, mntns_id);
 	}
 
+	goto end;
+
+cleanup:
+	if (calculate_latency)
+		bpf_map_delete_elem(&sockets_latency, &sk);
 end:
 	bpf_map_delete_elem(&sockets, &tid);
 	return 0;
 }
 
+static __always_inline int
+handle_tcp_rcv_state_process(void *ctx, struct sock *sk)
+{
+	struct piddata *piddata;
+	struct event event = {};
+	__u64 delta_us, ts;
+
+	if (BPF_CORE_READ(sk, __sk_common.skc_state) != TCP_SYN_SENT)
+		return 0;
+
+	piddata = bpf_map_lookup_elem(&sockets_latency, &sk);
+	if (!piddata)
+		return 0;
+
+	// The connect call hasn't returned yet or it was filtered out
+	if (!piddata->pid)
+		goto cleanup;
+
+	ts = bpf_ktime_get_ns();
+	if (ts < piddata->ts)
+		goto cleanup;
+
+	delta_us = (ts - piddata->ts) / 1000;
+	if (delta_us < targ_min_us)
+		goto cleanup;
+
+	__builtin_memcpy(&event.task, piddata->task, sizeof(event.task));
+	event.pid = piddata->pid;
+	event.uid = piddata->uid;
+	event.mntns_id = piddata->mntns_id;
+	event.ts_us = ts / 1000;
+	event.latency = delta_us;
+	event.af = BPF_CORE_READ(sk, __sk_common.skc_family);
+	BPF_CORE_READ_INTO(&event.dport, sk, __sk_common.skc_dport);
+	if (event.af == AF_INET) {
+		BPF_CORE_READ_INTO(&event.saddr_v4, sk, __sk_common.skc_rcv_saddr);
+		BPF_CORE_READ_INTO(&event.daddr_v4, sk, __sk_common.skc_daddr);
+	} else {
+		BPF_CORE_READ_INTO(&event.saddr_v6, sk,
+				   __sk_common.skc_v6_rcv_saddr.in6_u.u6_addr32);
+		BPF_CORE_READ_INTO(&event.daddr_v6, sk,
+				   __sk_common.skc_v6_daddr.in6_u.u6_addr32);
+	}
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU,
+			      &event, sizeof(event));
+
+cleanup:
+	bpf_map_delete_elem(&sockets_latency, &sk);
+	return 0;
+}
+
 SEC("kprobe/tcp_v4_connect")
 int BPF_KPROBE(ig_tcpc_v4_co_e, struct sock *sk)
 {
@@ -237,4 +342,19 @@ int BPF_KRETPROBE(ig_tcpc_v6_co_x, int ret)
 	return exit_tcp_connect(ctx, ret, 6);
 }
 
+SEC("kprobe/tcp_rcv_state_process")
+int BPF_KPROBE(ig_tcp_rsp, struct sock *sk)
+{
+	return handle_tcp_rcv_state_process(ctx, sk);
+}
+
+// tcp_v6_destroy_sock() calls tcp_v4_destroy_sock(), so this handles both
+// IPv4 and IPv6 sockets closed before being established.
+SEC("kprobe/tcp_v4_destroy_sock")
+int BPF_KPROBE(ig_tcp_destroy, struct sock *sk)
+{
+	bpf_map_delete_elem(&sockets_latency, &sk);
+	return 0;
+}
+
 char LICENSE[] SEC("license") = "GPL";
@@ -39,6 +39,7 @@ struct event {
 	__u32 uid;
 	__u16 dport;
 	__u64 mntns_id;
+	__u64 latency; // in us, only set when calculate_latency is enabled
 };
 
 #endif /* __TCPCONNECT_H */
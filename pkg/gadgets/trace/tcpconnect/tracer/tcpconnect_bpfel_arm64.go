@@ -26,6 +26,14 @@ type tcpconnectIpv6FlowKey struct {
 	Dport uint16
 }
 
+type tcpconnectPiddata struct {
+	Task    [16]int8
+	Ts      uint64
+	Pid     uint32
+	Uid     uint32
+	MntnsId uint64
+}
+
 // loadTcpconnect returns the embedded CollectionSpec for tcpconnect.
 func loadTcpconnect() (*ebpf.CollectionSpec, error) {
 	reader := bytes.NewReader(_TcpconnectBytes)
@@ -67,21 +75,24 @@ type tcpconnectSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type tcpconnectProgramSpecs struct {
-	IgTcpcV4CoE *ebpf.ProgramSpec `ebpf:"ig_tcpc_v4_co_e"`
-	IgTcpcV4CoX *ebpf.ProgramSpec `ebpf:"ig_tcpc_v4_co_x"`
-	IgTcpcV6CoE *ebpf.ProgramSpec `ebpf:"ig_tcpc_v6_co_e"`
-	IgTcpcV6CoX *ebpf.ProgramSpec `ebpf:"ig_tcpc_v6_co_x"`
+	IgTcpDestroy *ebpf.ProgramSpec `ebpf:"ig_tcp_destroy"`
+	IgTcpRsp     *ebpf.ProgramSpec `ebpf:"ig_tcp_rsp"`
+	IgTcpcV4CoE  *ebpf.ProgramSpec `ebpf:"ig_tcpc_v4_co_e"`
+	IgTcpcV4CoX  *ebpf.ProgramSpec `ebpf:"ig_tcpc_v4_co_x"`
+	IgTcpcV6CoE  *ebpf.ProgramSpec `ebpf:"ig_tcpc_v6_co_e"`
+	IgTcpcV6CoX  *ebpf.ProgramSpec `ebpf:"ig_tcpc_v6_co_x"`
 }
 
 // tcpconnectMapSpecs contains maps before they are loaded into the kernel.
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type tcpconnectMapSpecs struct {
-	Events        *ebpf.MapSpec `ebpf:"events"`
-	Ipv4Count     *ebpf.MapSpec `ebpf:"ipv4_count"`
-	Ipv6Count     *ebpf.MapSpec `ebpf:"ipv6_count"`
-	MountNsFilter *ebpf.MapSpec `ebpf:"mount_ns_filter"`
-	Sockets       *ebpf.MapSpec `ebpf:"sockets"`
+	Events         *ebpf.MapSpec `ebpf:"events"`
+	Ipv4Count      *ebpf.MapSpec `ebpf:"ipv4_count"`
+	Ipv6Count      *ebpf.MapSpec `ebpf:"ipv6_count"`
+	MountNsFilter  *ebpf.MapSpec `ebpf:"mount_ns_filter"`
+	Sockets        *ebpf.MapSpec `ebpf:"sockets"`
+	SocketsLatency *ebpf.MapSpec `ebpf:"sockets_latency"`
 }
 
 // tcpconnectObjects contains all objects after they have been loaded into the kernel.
@@ -103,11 +114,12 @@ func (o *tcpconnectObjects) Close() error {
 //
 // It can be passed to loadTcpconnectObjects or ebpf.CollectionSpec.LoadAndAssign.
 type tcpconnectMaps struct {
-	Events        *ebpf.Map `ebpf:"events"`
-	Ipv4Count     *ebpf.Map `ebpf:"ipv4_count"`
-	Ipv6Count     *ebpf.Map `ebpf:"ipv6_count"`
-	MountNsFilter *ebpf.Map `ebpf:"mount_ns_filter"`
-	Sockets       *ebpf.Map `ebpf:"sockets"`
+	Events         *ebpf.Map `ebpf:"events"`
+	Ipv4Count      *ebpf.Map `ebpf:"ipv4_count"`
+	Ipv6Count      *ebpf.Map `ebpf:"ipv6_count"`
+	MountNsFilter  *ebpf.Map `ebpf:"mount_ns_filter"`
+	Sockets        *ebpf.Map `ebpf:"sockets"`
+	SocketsLatency *ebpf.Map `ebpf:"sockets_latency"`
 }
 
 func (m *tcpconnectMaps) Close() error {
@@ -117,6 +129,7 @@ func (m *tcpconnectMaps) Close() error {
 		m.Ipv6Count,
 		m.MountNsFilter,
 		m.Sockets,
+		m.SocketsLatency,
 	)
 }
 
@@ -124,14 +137,18 @@ func (m *tcpconnectMaps) Close() error {
 //
 // It can be passed to loadTcpconnectObjects or ebpf.CollectionSpec.LoadAndAssign.
 type tcpconnectPrograms struct {
-	IgTcpcV4CoE *ebpf.Program `ebpf:"ig_tcpc_v4_co_e"`
-	IgTcpcV4CoX *ebpf.Program `ebpf:"ig_tcpc_v4_co_x"`
-	IgTcpcV6CoE *ebpf.Program `ebpf:"ig_tcpc_v6_co_e"`
-	IgTcpcV6CoX *ebpf.Program `ebpf:"ig_tcpc_v6_co_x"`
+	IgTcpDestroy *ebpf.Program `ebpf:"ig_tcp_destroy"`
+	IgTcpRsp     *ebpf.Program `ebpf:"ig_tcp_rsp"`
+	IgTcpcV4CoE  *ebpf.Program `ebpf:"ig_tcpc_v4_co_e"`
+	IgTcpcV4CoX  *ebpf.Program `ebpf:"ig_tcpc_v4_co_x"`
+	IgTcpcV6CoE  *ebpf.Program `ebpf:"ig_tcpc_v6_co_e"`
+	IgTcpcV6CoX  *ebpf.Program `ebpf:"ig_tcpc_v6_co_x"`
 }
 
 func (p *tcpconnectPrograms) Close() error {
 	return _TcpconnectClose(
+		p.IgTcpDestroy,
+		p.IgTcpRsp,
 		p.IgTcpcV4CoE,
 		p.IgTcpcV4CoX,
 		p.IgTcpcV6CoE,
@@ -58,6 +58,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
@@ -72,6 +73,14 @@ import (
 
 type Config struct {
 	MountnsMap *ebpf.Map
+
+	// CalculateLatency reports the connections once they are established,
+	// together with the time elapsed since the connect call, instead of
+	// when connect returns.
+	CalculateLatency bool
+	// MinLatency only reports the connections whose latency is greater
+	// than this value. Only used with CalculateLatency.
+	MinLatency time.Duration
 }
 
 type Tracer struct {
@@ -84,6 +93,8 @@ type Tracer struct {
 	v4ExitLink  link.Link
 	v6EnterLink link.Link
 	v6ExitLink  link.Link
+	rcvLink     link.Link
+	destroyLink link.Link
 	reader      *perf.Reader
 }
 
@@ -109,6 +120,12 @@ func (t *Tracer) Stop() {
 	t.v4ExitLink = gadgets.CloseLink(t.v4ExitLink)
 	t.v6EnterLink = gadgets.CloseLink(t.v6EnterLink)
 	t.v6ExitLink = gadgets.CloseLink(t.v6ExitLink)
+	t.rcvLink = gadgets.CloseLink(t.rcvLink)
+	t.destroyLink = gadgets.CloseLink(t.destroyLink)
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
 
 	t.objs.Close()
 }
@@ -129,7 +146,9 @@ func (t *Tracer) start() error {
 	}
 
 	consts := map[string]interface{}{
-		"filter_by_mnt_ns": filterByMntNs,
+		"filter_by_mnt_ns":  filterByMntNs,
+		"calculate_latency": t.config.CalculateLatency,
+		"targ_min_us":       uint64(t.config.MinLatency.Microseconds()),
 	}
 
 	if err := spec.RewriteConstants(consts); err != nil {
@@ -164,6 +183,18 @@ func (t *Tracer) start() error {
 		return fmt.Errorf("error attaching program: %w", err)
 	}
 
+	if t.config.CalculateLatency {
+		t.rcvLink, err = link.Kprobe("tcp_rcv_state_process", t.objs.IgTcpRsp, nil)
+		if err != nil {
+			return fmt.Errorf("error attaching program: %w", err)
+		}
+
+		t.destroyLink, err = link.Kprobe("tcp_v4_destroy_sock", t.objs.IgTcpDestroy, nil)
+		if err != nil {
+			return fmt.Errorf("error attaching program: %w", err)
+		}
+	}
+
 	reader, err := perf.NewReader(t.objs.tcpconnectMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
 	if err != nil {
 		return fmt.Errorf("error creating perf ring buffer: %w", err)
@@ -206,6 +237,7 @@ func (t *Tracer) run() {
 			UID:       uint32(eventC.uid),
 			Comm:      C.GoString(&eventC.task[0]),
 			Dport:     uint16(C.htons(eventC.dport)),
+			Latency:   uint64(eventC.latency),
 		}
 
 		if eventC.af == C.AF_INET {
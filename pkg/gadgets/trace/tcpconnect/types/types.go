@@ -29,6 +29,12 @@ type Event struct {
 	Saddr     string `json:"saddr,omitempty" column:"saddr,template:ipaddr"`
 	Daddr     string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
 	Dport     uint16 `json:"dport,omitempty" column:"dport,template:ipport"`
+
+	// Kubernetes object the destination address belongs to, if any
+	RemoteKind string `json:"remoteKind,omitempty" column:"remotekind,width:10,hide"`
+	RemoteName string `json:"remoteName,omitempty" column:"remotename,width:24,maxWidth:64,hide"`
+
+	Latency   uint64 `json:"latency,omitempty" column:"latency,width:8,align:right,unit:us,hide"`
 	MountNsID uint64 `json:"mountnsid,omitempty" column:"mntns,template:ns"`
 }
 
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2022 Hengqi Chen
+//
+// Based on tcplife(8) from BCC by Brendan Gregg
+#include <vmlinux/vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+
+#include "tcplife.h"
+
+/* Define here, because there are conflicts with include files */
+#define AF_INET		2
+#define AF_INET6	10
+
+#define IPPROTO_TCP	6
+
+/* From include/net/tcp_states.h */
+#define TCP_SYN_SENT	2
+#define TCP_FIN_WAIT1	4
+#define TCP_CLOSE	7
+#define TCP_LAST_ACK	9
+
+const volatile bool filter_by_mnt_ns = false;
+
+/* Time at which each socket entered its first state */
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct sock *);
+	__type(value, __u64);
+} birth SEC(".maps");
+
+/*
+ * Process that owns each socket. The last state change usually happens in
+ * softirq context, so it's recorded while the process is still the current
+ * task: on connect() and on close().
+ */
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct sock *);
+	__type(value, struct ident);
+} idents SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 1024);
+	__uint(key_size, sizeof(u64));
+	__uint(value_size, sizeof(u32));
+} mount_ns_filter SEC(".maps");
+
+static __always_inline void fill_ident(struct ident *ident)
+{
+	struct task_struct *task;
+
+	task = (struct task_struct*)bpf_get_current_task();
+	ident->mntns_id = (u64) BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum);
+	ident->pid = bpf_get_current_pid_tgid() >> 32;
+	ident->uid = bpf_get_current_uid_gid();
+	bpf_get_current_comm(ident->task, sizeof(ident->task));
+}
+
+SEC("tracepoint/sock/inet_sock_set_state")
+int ig_tcplife(struct trace_event_raw_inet_sock_set_state *args)
+{
+	struct ident ident = {};
+	struct event event = {};
+	struct ident *identp;
+	struct tcp_sock *tp;
+	int oldstate, newstate;
+	struct sock *sk;
+	__u64 *start;
+	__u16 family;
+	__u64 ts;
+
+	if (BPF_CORE_READ(args, protocol) != IPPROTO_TCP)
+		return 0;
+
+	family = BPF_CORE_READ(args, family);
+	if (family != AF_INET && family != AF_INET6)
+		return 0;
+
+	sk = (struct sock *)BPF_CORE_READ(args, skaddr);
+	oldstate = BPF_CORE_READ(args, oldstate);
+	newstate = BPF_CORE_READ(args, newstate);
+
+	if (newstate < TCP_FIN_WAIT1) {
+		ts = bpf_ktime_get_ns();
+		bpf_map_update_elem(&birth, &sk, &ts, BPF_NOEXIST);
+	}
+
+	if (newstate == TCP_SYN_SENT || newstate == TCP_FIN_WAIT1 ||
+	    newstate == TCP_LAST_ACK) {
+		fill_ident(&ident);
+		bpf_map_update_elem(&idents, &sk, &ident, BPF_ANY);
+	}
+
+	if (newstate != TCP_CLOSE)
+		return 0;
+
+	start = bpf_map_lookup_elem(&birth, &sk);
+	if (!start)
+		goto cleanup;
+
+	identp = bpf_map_lookup_elem(&idents, &sk);
+	if (identp)
+		ident = *identp;
+	else
+		fill_ident(&ident);
+
+	if (filter_by_mnt_ns &&
+	    !bpf_map_lookup_elem(&mount_ns_filter, &ident.mntns_id))
+		goto cleanup;
+
+	ts = bpf_ktime_get_ns();
+	event.span_us = (ts - *start) / 1000;
+
+	tp = (struct tcp_sock *)sk;
+	event.rx_b = BPF_CORE_READ(tp, bytes_received);
+	event.tx_b = BPF_CORE_READ(tp, bytes_acked);
+
+	event.af = family;
+	event.state = oldstate;
+	event.sport = BPF_CORE_READ(args, sport);
+	event.dport = BPF_CORE_READ(args, dport);
+	if (family == AF_INET) {
+		BPF_CORE_READ_INTO(&event.saddr_v4, args, saddr);
+		BPF_CORE_READ_INTO(&event.daddr_v4, args, daddr);
+	} else {
+		BPF_CORE_READ_INTO(&event.saddr_v6, args, saddr_v6);
+		BPF_CORE_READ_INTO(&event.daddr_v6, args, daddr_v6);
+	}
+
+	event.mntns_id = ident.mntns_id;
+	event.pid = ident.pid;
+	event.uid = ident.uid;
+	__builtin_memcpy(event.task, ident.task, sizeof(event.task));
+
+	bpf_perf_event_output(args, &events, BPF_F_CURRENT_CPU,
+			      &event, sizeof(event));
+
+cleanup:
+	bpf_map_delete_elem(&birth, &sk);
+	bpf_map_delete_elem(&idents, &sk);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
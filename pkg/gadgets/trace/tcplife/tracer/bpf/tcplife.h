@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2022 Hengqi Chen
+#ifndef __TCPLIFE_H
+#define __TCPLIFE_H
+
+/* The maximum number of items in maps */
+#define MAX_ENTRIES 10240
+
+#define TASK_COMM_LEN 16
+
+struct ident {
+	__u64 mntns_id;
+	__u32 pid;
+	__u32 uid;
+	char task[TASK_COMM_LEN];
+};
+
+struct event {
+	union {
+		__u32 saddr_v4;
+		__u8 saddr_v6[16];
+	};
+	union {
+		__u32 daddr_v4;
+		__u8 daddr_v6[16];
+	};
+	char task[TASK_COMM_LEN];
+	__u64 span_us;
+	__u64 rx_b;
+	__u64 tx_b;
+	__u64 mntns_id;
+	__u32 pid;
+	__u32 uid;
+	__u16 sport;
+	__u16 dport;
+	__u16 af; // AF_INET or AF_INET6
+	__u8 state; // state of the connection before closing
+};
+
+#endif /* __TCPLIFE_H */
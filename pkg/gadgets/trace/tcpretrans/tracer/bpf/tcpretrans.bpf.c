@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2022 The Inspektor Gadget authors */
+
+#include <vmlinux/vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+
+#include "tcpretrans.h"
+
+/* Define here, because there are conflicts with include files */
+#define AF_INET		2
+#define AF_INET6	10
+
+const volatile bool filter_by_netns = false;
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 1024);
+	__uint(key_size, sizeof(u64));
+	__uint(value_size, sizeof(u32));
+} netns_filter SEC(".maps");
+
+/*
+ * Linux 6.14 gave tcp_retransmit_skb its own event class, which renamed the
+ * type of the tracepoint context from trace_event_raw_tcp_event_sk_skb. The
+ * fields read here are the same in both.
+ */
+struct trace_event_raw_tcp_retransmit_skb___x {
+	const void *skaddr;
+	int state;
+	__u16 sport;
+	__u16 dport;
+} __attribute__((preserve_access_index));
+
+#define ctx_field(ctx, field)							\
+	(bpf_core_type_exists(struct trace_event_raw_tcp_event_sk_skb) ?	\
+	 BPF_CORE_READ((struct trace_event_raw_tcp_event_sk_skb *)(ctx), field) : \
+	 BPF_CORE_READ((struct trace_event_raw_tcp_retransmit_skb___x *)(ctx), field))
+
+// Retransmissions happen from the TCP timers, not in the context of the
+// process owning the socket, so the events are filtered by the network
+// namespace of the socket.
+SEC("tracepoint/tcp/tcp_retransmit_skb")
+int ig_tcpretrans(void *args)
+{
+	struct event event = {};
+	const struct sock *sk;
+	__u16 family;
+	u64 netns;
+
+	sk = ctx_field(args, skaddr);
+	family = BPF_CORE_READ(sk, __sk_common.skc_family);
+	if (family != AF_INET && family != AF_INET6)
+		return 0;
+
+	netns = (u64) BPF_CORE_READ(sk, __sk_common.skc_net.net, ns.inum);
+
+	if (filter_by_netns && !bpf_map_lookup_elem(&netns_filter, &netns))
+		return 0;
+
+	event.netns = netns;
+	event.af = family;
+	event.state = ctx_field(args, state);
+	event.sport = ctx_field(args, sport);
+	event.dport = ctx_field(args, dport);
+	if (family == AF_INET) {
+		BPF_CORE_READ_INTO(&event.saddr_v4, sk, __sk_common.skc_rcv_saddr);
+		BPF_CORE_READ_INTO(&event.daddr_v4, sk, __sk_common.skc_daddr);
+	} else {
+		BPF_CORE_READ_INTO(&event.saddr_v6, sk,
+				   __sk_common.skc_v6_rcv_saddr.in6_u.u6_addr32);
+		BPF_CORE_READ_INTO(&event.daddr_v6, sk,
+				   __sk_common.skc_v6_daddr.in6_u.u6_addr32);
+	}
+
+	bpf_perf_event_output(args, &events, BPF_F_CURRENT_CPU,
+			      &event, sizeof(event));
+
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
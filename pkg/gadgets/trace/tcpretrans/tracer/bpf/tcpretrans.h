@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2022 The Inspektor Gadget authors */
+#ifndef __TCPRETRANS_H
+#define __TCPRETRANS_H
+
+struct event {
+	union {
+		__u32 saddr_v4;
+		__u8 saddr_v6[16];
+	};
+	union {
+		__u32 daddr_v4;
+		__u8 daddr_v6[16];
+	};
+	__u64 netns;
+	__u16 af; // AF_INET or AF_INET6
+	__u16 sport;
+	__u16 dport;
+	__u8 state;
+};
+
+#endif /* __TCPRETRANS_H */
@@ -428,7 +428,7 @@ func TestDNS(t *testing.T) {
 		},
 	}
 
-	if event != expectedEvent {
+	if !reflect.DeepEqual(event, expectedEvent) {
 		t.Fatalf("Received: %v, Expected: %v", event, expectedEvent)
 	}
 
@@ -453,7 +453,7 @@ func TestDNS(t *testing.T) {
 		QType:   "A",
 	}
 
-	if event != expectedEvent {
+	if !reflect.DeepEqual(event, expectedEvent) {
 		t.Fatalf("Received: %v, Expected: %v", event, expectedEvent)
 	}
 
@@ -476,7 +476,7 @@ func TestDNS(t *testing.T) {
 		},
 	}
 
-	if event != expectedEvent {
+	if !reflect.DeepEqual(event, expectedEvent) {
 		t.Fatalf("Received: %v, Expected: %v", event, expectedEvent)
 	}
 
@@ -576,6 +576,12 @@ func TestNetworkGraph(t *testing.T) {
 		Port:    443,
 	}
 
+	// The counters depend on the traffic, only check they are set.
+	if event.Packets == 0 || event.Bytes == 0 || event.FirstSeen == 0 || event.LastSeen < event.FirstSeen {
+		t.Fatalf("Unexpected counters in %+v", event)
+	}
+	event.Packets, event.Bytes, event.FirstSeen, event.LastSeen = 0, 0, 0, 0
+
 	if !reflect.DeepEqual(event, expectedEvent) {
 		t.Fatalf("Received: %+v, Expected: %+v", event, expectedEvent)
 	}
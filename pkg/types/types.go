@@ -34,7 +34,7 @@ func init() {
 	// For IPs (IPv4+IPv6):
 	// Min: XXX.XXX.XXX.XXX (IPv4) = 15
 	// Max: 0000:0000:0000:0000:0000:ffff:XXX.XXX.XXX.XXX (IPv4-mapped IPv6 address) = 45
-	columns.MustRegisterTemplate("ipaddr", "minWidth:15,maxWidth:45")
+	columns.MustRegisterTemplate("ipaddr", "minWidth:15,maxWidth:45,kind:ip")
 	columns.MustRegisterTemplate("ipport", "minWidth:type")
 }
 